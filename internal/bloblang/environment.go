@@ -77,6 +77,20 @@ func (e *Environment) NewMapping(blobl string) (*mapping.Executor, error) {
 	return exec, nil
 }
 
+// LintMapping parses a Bloblang mapping using the Environment and performs a
+// static analysis of it, returning any likely problems that were found. An
+// optional JSON Schema describing the input documents of the mapping can be
+// provided in order to infer the types of referenced fields.
+//
+// When a parsing error occurs the error will be the type *parser.Error.
+func (e *Environment) LintMapping(blobl string, inputSchema any) ([]parser.Lint, error) {
+	lints, err := parser.LintMapping(e.pCtx, blobl, inputSchema)
+	if err != nil {
+		return nil, err
+	}
+	return lints, nil
+}
+
 // Deactivated returns a version of the environment where constructors are
 // disabled for all functions and methods, allowing mappings to be parsed and
 // validated but not executed.
@@ -132,6 +146,15 @@ func (e *Environment) WithImporterRelativeToFile(filePath string) *Environment {
 	return &env
 }
 
+// WithImportLockRelativeToFile returns a new environment where the lock file of
+// remote imports is searched for from the directory of the provided file path,
+// such as a config file, and its parent directories.
+func (e *Environment) WithImportLockRelativeToFile(filePath string) *Environment {
+	env := *e
+	env.pCtx = env.pCtx.WithImportLockRelativeToFile(filePath)
+	return &env
+}
+
 // WithDisabledImports returns a version of the environment where imports within
 // mappings are disabled entirely. This prevents mappings from accessing files
 // from the host disk.
@@ -150,6 +173,23 @@ func (e *Environment) WithCustomImporter(fn func(name string) ([]byte, error)) *
 	return &env
 }
 
+// WithProfiler returns a version of the environment where the statements,
+// function calls and method calls of parsed mappings record their execution
+// costs to a profiler.
+func (e *Environment) WithProfiler(p *parser.Profiler) *Environment {
+	env := *e
+	env.pCtx = env.pCtx.WithProfiler(p)
+	return &env
+}
+
+// WithCoverage returns a version of the environment where the branches of
+// parsed mappings record whether they were executed to a coverage tracker.
+func (e *Environment) WithCoverage(c *parser.Coverage) *Environment {
+	env := *e
+	env.pCtx = env.pCtx.WithCoverage(c)
+	return &env
+}
+
 // WithoutMethods returns a copy of the environment but with a variadic list of
 // method names removed. Instantiation of these removed methods within a mapping
 // will cause errors at parse time.
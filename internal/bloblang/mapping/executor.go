@@ -57,6 +57,22 @@ func NewStatement(input []rune, assignment Assignment, query query.Function) Sta
 	}
 }
 
+// Input returns the parsed expression that created the statement, which may be
+// nil.
+func (s Statement) Input() []rune {
+	return s.input
+}
+
+// Assignment returns the assignment of the statement.
+func (s Statement) Assignment() Assignment {
+	return s.assignment
+}
+
+// Query returns the query function of the statement.
+func (s Statement) Query() query.Function {
+	return s.query
+}
+
 //------------------------------------------------------------------------------
 
 // Executor is a parsed bloblang mapping that can be executed on a Benthos
@@ -16,6 +16,14 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+	userFns      *userFunctionSet
+
+	profiler      *Profiler
+	coverage      *Coverage
+	instrumentSrc *instrumentSource
+
+	linter             *linter
+	lintUnknownContext bool
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -62,6 +70,15 @@ func (pCtx Context) HasNamedContext(name string) bool {
 	return false
 }
 
+// withUserFunctions returns a Context with an empty set of user defined
+// functions, unless it already has one.
+func (pCtx Context) withUserFunctions() Context {
+	if pCtx.userFns == nil {
+		pCtx.userFns = &userFunctionSet{fns: map[string]*userFunction{}}
+	}
+	return pCtx
+}
+
 // InitFunction attempts to initialise a function from the available
 // constructors of the parser context.
 func (pCtx Context) InitFunction(name string, args *query.ParsedParams) (query.Function, error) {
@@ -81,6 +98,22 @@ func (pCtx Context) WithImporter(importer Importer) Context {
 	return pCtx
 }
 
+// WithImportLockRelativeToFile returns a Context where the lock file of remote
+// imports is searched for from the directory of the provided file path, and
+// its parent directories, rather than the directory that relative imports are
+// made from. This has no effect on custom importers.
+func (pCtx Context) WithImportLockRelativeToFile(pathStr string) Context {
+	if i, ok := pCtx.importer.(*osImporter); ok {
+		newI := *i
+		newI.lockDir = filepath.Dir(pathStr)
+		if !filepath.IsAbs(newI.lockDir) && i.relativePath != "" {
+			newI.lockDir = filepath.Join(i.relativePath, newI.lockDir)
+		}
+		pCtx.importer = &newI
+	}
+	return pCtx
+}
+
 // WithImporterRelativeToFile returns a Context where any relative imports will
 // be made from the directory of the provided file path. The provided path can
 // itself be relative (to the current importer directory) or absolute.
@@ -140,6 +173,10 @@ type Importer interface {
 
 type osImporter struct {
 	relativePath string
+
+	// lockDir is the directory from which the lock file of remote imports is
+	// searched for, when empty the relative path is used instead.
+	lockDir string
 }
 
 func newOSImporter() Importer {
@@ -150,6 +187,14 @@ func newOSImporter() Importer {
 }
 
 func (i *osImporter) Import(pathStr string) ([]byte, error) {
+	if IsRemoteImport(pathStr) {
+		remote, err := i.remoteImports()
+		if err != nil {
+			return nil, err
+		}
+		return remote.Import(pathStr)
+	}
+
 	if !filepath.IsAbs(pathStr) {
 		pathStr = filepath.Join(i.relativePath, pathStr)
 	}
@@ -162,9 +207,22 @@ func (i *osImporter) Import(pathStr string) ([]byte, error) {
 }
 
 func (i *osImporter) RelativeToFile(filePath string) Importer {
+	if IsRemoteImport(filePath) {
+		remote, err := i.remoteImports()
+		if err != nil {
+			return &failedImporter{err: err}
+		}
+		return newRemoteImporterRelativeTo("", remote, filePath)
+	}
+
+	// The lock file of remote imports is searched for from the directory of
+	// the importing file.
+	newI := *i
+	newI.lockDir = ""
+
 	dir := filepath.Dir(filePath)
 	if dir == "" || dir == "." {
-		return i
+		return &newI
 	}
 
 	pathStr := filepath.Dir(filePath)
@@ -172,11 +230,18 @@ func (i *osImporter) RelativeToFile(filePath string) Importer {
 		pathStr = filepath.Join(i.relativePath, pathStr)
 	}
 
-	newI := *i
 	newI.relativePath = pathStr
 	return &newI
 }
 
+func (i *osImporter) remoteImports() (*RemoteImports, error) {
+	dir := i.lockDir
+	if dir == "" {
+		dir = i.relativePath
+	}
+	return remoteImportsFromDir(dir)
+}
+
 //------------------------------------------------------------------------------
 
 type customImporter struct {
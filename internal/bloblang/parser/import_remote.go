@@ -0,0 +1,398 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ImportLockFileName is the default name of a file containing the pinned
+// content hashes of remote imports.
+const ImportLockFileName = "blobl.lock"
+
+const importHashPrefix = "sha256:"
+
+// maxRemoteImportBytes is the maximum size of a remote import.
+const maxRemoteImportBytes = 10 * 1024 * 1024
+
+// IsRemoteImport returns true if an import path refers to a remote mapping
+// that must be fetched over HTTP, from a git repository or from an OCI
+// artifact.
+func IsRemoteImport(pathStr string) bool {
+	return strings.HasPrefix(pathStr, "http://") ||
+		strings.HasPrefix(pathStr, "https://") ||
+		isGitImport(pathStr) ||
+		isOCIImport(pathStr)
+}
+
+// ImportLock describes the pinned content hashes of remote imports, keyed by
+// the path of the import.
+type ImportLock struct {
+	Imports map[string]string `json:"imports"`
+}
+
+// ReadImportLock attempts to read an import lock file from a path. If the file
+// does not exist an empty lock is returned.
+func ReadImportLock(path string) (*ImportLock, error) {
+	lock := &ImportLock{Imports: map[string]string{}}
+
+	lockBytes, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return lock, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(lockBytes, lock); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %v: %w", path, err)
+	}
+	if lock.Imports == nil {
+		lock.Imports = map[string]string{}
+	}
+	return lock, nil
+}
+
+// WriteFile writes the import lock to a path.
+func (l *ImportLock) WriteFile(path string) error {
+	lockBytes, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(lockBytes, '\n'), 0o644)
+}
+
+// FindImportLock searches for an import lock file within a directory and each
+// of its parent directories, and reads the first one found. If no lock file
+// exists an empty lock is returned.
+func FindImportLock(dir string) (*ImportLock, error) {
+	if dir == "" {
+		var err error
+		if dir, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	if !filepath.IsAbs(dir) {
+		var err error
+		if dir, err = filepath.Abs(dir); err != nil {
+			return nil, err
+		}
+	}
+	for {
+		lockPath := filepath.Join(dir, ImportLockFileName)
+		if _, err := os.Stat(lockPath); err == nil {
+			return ReadImportLock(lockPath)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return &ImportLock{Imports: map[string]string{}}, nil
+		}
+		dir = parent
+	}
+}
+
+// URLs returns a sorted slice of the import URLs within the lock.
+func (l *ImportLock) URLs() []string {
+	urls := make([]string, 0, len(l.Imports))
+	for k := range l.Imports {
+		urls = append(urls, k)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+//------------------------------------------------------------------------------
+
+// RemoteImports fetches mappings imported from HTTP URLs, git repositories and
+// OCI artifacts. Every remote import must be pinned to a content hash, either
+// with a `#sha256=<hex>` fragment or with an entry in an import lock. Fetched mappings are cached on the local
+// filesystem by their content hash, which means pinned imports can be resolved
+// without network access once they've been fetched.
+type RemoteImports struct {
+	client   *http.Client
+	cacheDir string
+
+	mut           sync.Mutex
+	lock          *ImportLock
+	allowUnpinned bool
+}
+
+// NewRemoteImports creates a remote import resolver that caches fetched
+// mappings within the provided directory and checks content hashes against a
+// lock. If allowUnpinned is true then imports that aren't pinned are fetched
+// and their hashes are added to the lock, otherwise they are rejected.
+func NewRemoteImports(cacheDir string, lock *ImportLock, allowUnpinned bool) *RemoteImports {
+	if lock == nil {
+		lock = &ImportLock{Imports: map[string]string{}}
+	}
+	return &RemoteImports{
+		client:        &http.Client{Timeout: time.Second * 30},
+		cacheDir:      cacheDir,
+		lock:          lock,
+		allowUnpinned: allowUnpinned,
+	}
+}
+
+// Lock returns the import lock used by the resolver.
+func (r *RemoteImports) Lock() *ImportLock {
+	return r.lock
+}
+
+// DefaultImportCacheDir returns the directory used for caching remote imports
+// when one is not explicitly provided.
+func DefaultImportCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "benthos", "bloblang")
+}
+
+// remoteImportsFromDir creates a remote import resolver with the default cache
+// directory and a lock found from the provided directory.
+func remoteImportsFromDir(dir string) (*RemoteImports, error) {
+	lock, err := FindImportLock(dir)
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteImports(DefaultImportCacheDir(), lock, false), nil
+}
+
+func splitImportPin(pathStr string) (string, string, error) {
+	if _, err := url.Parse(pathStr); err != nil {
+		return "", "", fmt.Errorf("failed to parse import URL: %w", err)
+	}
+
+	var pin string
+	if i := strings.Index(pathStr, "#"); i >= 0 {
+		fragment := pathStr[i+1:]
+		pathStr = pathStr[:i]
+		if !strings.HasPrefix(fragment, "sha256=") {
+			return "", "", fmt.Errorf("unrecognised import URL fragment '%v', expected sha256=<hex>", fragment)
+		}
+		pin = importHashPrefix + strings.ToLower(strings.TrimPrefix(fragment, "sha256="))
+	}
+	return pathStr, pin, nil
+}
+
+// readLimited reads at most maxRemoteImportBytes from a reader, and returns an
+// error if the content exceeds it.
+func readLimited(r io.Reader, name string) ([]byte, error) {
+	b, err := io.ReadAll(io.LimitReader(r, maxRemoteImportBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(b) > maxRemoteImportBytes {
+		return nil, fmt.Errorf("import %v exceeds the maximum size of %v bytes", name, maxRemoteImportBytes)
+	}
+	return b, nil
+}
+
+func hashImport(b []byte) string {
+	sum := sha256.Sum256(b)
+	return importHashPrefix + hex.EncodeToString(sum[:])
+}
+
+func (r *RemoteImports) cachePath(hash string) string {
+	return filepath.Join(r.cacheDir, strings.TrimPrefix(hash, importHashPrefix)+".blobl")
+}
+
+func (r *RemoteImports) readCache(hash string) ([]byte, bool) {
+	if r.cacheDir == "" {
+		return nil, false
+	}
+	b, err := os.ReadFile(r.cachePath(hash))
+	if err != nil || hashImport(b) != hash {
+		return nil, false
+	}
+	return b, true
+}
+
+func (r *RemoteImports) writeCache(hash string, b []byte) {
+	if r.cacheDir == "" {
+		return
+	}
+	if err := os.MkdirAll(r.cacheDir, 0o755); err != nil {
+		return
+	}
+	_ = os.WriteFile(r.cachePath(hash), b, 0o644)
+}
+
+func (r *RemoteImports) fetch(u string) ([]byte, error) {
+	switch {
+	case isGitImport(u):
+		return r.fetchGit(u)
+	case isOCIImport(u):
+		return r.fetchOCI(u)
+	}
+	return r.fetchHTTP(u)
+}
+
+func (r *RemoteImports) fetchHTTP(u string) ([]byte, error) {
+	res, err := r.client.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status code from %v: %v", u, res.StatusCode)
+	}
+	return readLimited(res.Body, u)
+}
+
+// Import a remote mapping from a URL, git repository or OCI artifact. The path
+// may contain a fragment of the form `#sha256=<hex>` that pins the content hash
+// of the mapping.
+func (r *RemoteImports) Import(pathStr string) ([]byte, error) {
+	u, pin, err := splitImportPin(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	lockHash, locked := r.lock.Imports[u]
+	if pin != "" && locked && pin != lockHash {
+		return nil, fmt.Errorf("import %v is pinned to %v but the lock file contains %v", u, pin, lockHash)
+	}
+	if pin == "" {
+		pin = lockHash
+	}
+
+	if pin != "" {
+		if b, exists := r.readCache(pin); exists {
+			return b, nil
+		}
+	} else if !r.allowUnpinned {
+		return nil, fmt.Errorf("remote import %v must be pinned, either add a #sha256=<hex> fragment to the URL or add it to a %v file with the command `benthos blobl lock`", u, ImportLockFileName)
+	}
+
+	b, err := r.fetch(u)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := hashImport(b)
+	if pin != "" && pin != hash {
+		return nil, fmt.Errorf("content of import %v does not match pinned hash %v, got %v", u, pin, hash)
+	}
+
+	r.writeCache(hash, b)
+	r.lock.Imports[u] = hash
+	return b, nil
+}
+
+//------------------------------------------------------------------------------
+
+// resolveRemoteImport resolves an import path relative to the path of a remote
+// mapping. Relative imports from mappings within git repositories and OCI
+// artifacts refer to files of the same repository ref or artifact.
+func resolveRemoteImport(base, pathStr string) (string, error) {
+	if IsRemoteImport(pathStr) {
+		return pathStr, nil
+	}
+	switch {
+	case isGitImport(base):
+		return resolveGitImport(base, pathStr)
+	case isOCIImport(base):
+		return resolveOCIImport(base, pathStr)
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse import path: %w", err)
+	}
+	rel, err := url.Parse(pathStr)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse import path: %w", err)
+	}
+	return baseURL.ResolveReference(rel).String(), nil
+}
+
+// remoteImporter resolves imports made from within a remote mapping, where
+// relative paths are resolved from the path of the importing mapping.
+type remoteImporter struct {
+	base   string
+	remote *RemoteImports
+}
+
+func (i *remoteImporter) Import(pathStr string) ([]byte, error) {
+	pathStr, err := resolveRemoteImport(i.base, pathStr)
+	if err != nil {
+		return nil, err
+	}
+	return i.remote.Import(pathStr)
+}
+
+func (i *remoteImporter) RelativeToFile(filePath string) Importer {
+	return newRemoteImporterRelativeTo(i.base, i.remote, filePath)
+}
+
+func newRemoteImporterRelativeTo(base string, remote *RemoteImports, filePath string) Importer {
+	pathStr := filePath
+	if base != "" {
+		var err error
+		if pathStr, err = resolveRemoteImport(base, filePath); err != nil {
+			return &failedImporter{err: err}
+		}
+	}
+	if i := strings.Index(pathStr, "#"); i >= 0 {
+		pathStr = pathStr[:i]
+	}
+	return &remoteImporter{base: pathStr, remote: remote}
+}
+
+// failedImporter returns an error for all imports, and is used when an importer
+// cannot be created relative to a file.
+type failedImporter struct {
+	err error
+}
+
+func (i *failedImporter) Import(string) ([]byte, error) {
+	return nil, i.err
+}
+
+func (i *failedImporter) RelativeToFile(string) Importer {
+	return i
+}
+
+// WithRemoteImports returns a version of the parser context where remote
+// imports are resolved with the provided remote import resolver.
+func (pCtx Context) WithRemoteImports(r *RemoteImports) Context {
+	nextCtx := pCtx
+	nextCtx.importer = &remoteAwareImporter{local: pCtx.importer, remote: r}
+	return nextCtx
+}
+
+// remoteAwareImporter resolves remote imports with a resolver and all other
+// imports with a local importer.
+type remoteAwareImporter struct {
+	local  Importer
+	remote *RemoteImports
+}
+
+func (i *remoteAwareImporter) Import(pathStr string) ([]byte, error) {
+	if IsRemoteImport(pathStr) {
+		return i.remote.Import(pathStr)
+	}
+	return i.local.Import(pathStr)
+}
+
+func (i *remoteAwareImporter) RelativeToFile(filePath string) Importer {
+	if IsRemoteImport(filePath) {
+		return newRemoteImporterRelativeTo("", i.remote, filePath)
+	}
+	return &remoteAwareImporter{local: i.local.RelativeToFile(filePath), remote: i.remote}
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+)
+
+const gitImportPrefix = "git+"
+
+// isGitImport returns true if an import path refers to a file within a git
+// repository, which takes the form
+// `git+<scheme>://<repository>//<file path>?ref=<ref>`.
+func isGitImport(pathStr string) bool {
+	return strings.HasPrefix(pathStr, gitImportPrefix+"https://") ||
+		strings.HasPrefix(pathStr, gitImportPrefix+"http://") ||
+		strings.HasPrefix(pathStr, gitImportPrefix+"ssh://") ||
+		strings.HasPrefix(pathStr, gitImportPrefix+"file://")
+}
+
+type gitImport struct {
+	repo     string
+	filePath string
+	ref      string
+}
+
+func parseGitImport(pathStr string) (gitImport, error) {
+	u, err := url.Parse(strings.TrimPrefix(pathStr, gitImportPrefix))
+	if err != nil {
+		return gitImport{}, fmt.Errorf("failed to parse git import: %w", err)
+	}
+
+	g := gitImport{ref: u.Query().Get("ref")}
+	if g.ref == "" {
+		g.ref = "HEAD"
+	}
+	if strings.HasPrefix(g.ref, "-") {
+		return gitImport{}, fmt.Errorf("invalid git ref: %v", g.ref)
+	}
+
+	i := strings.Index(u.Path, "//")
+	if i < 0 || i+2 >= len(u.Path) {
+		return gitImport{}, fmt.Errorf("git import %v must specify a file path within the repository separated by a double slash, e.g. git+https://example.com/repo.git//path/to/file.blobl", pathStr)
+	}
+	g.filePath = u.Path[i+2:]
+
+	u.Path = u.Path[:i]
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	g.repo = u.String()
+	return g, nil
+}
+
+func (g gitImport) String() string {
+	return fmt.Sprintf("%v%v//%v?ref=%v", gitImportPrefix, g.repo, g.filePath, url.QueryEscape(g.ref))
+}
+
+func resolveGitImport(base, pathStr string) (string, error) {
+	g, err := parseGitImport(base)
+	if err != nil {
+		return "", err
+	}
+	g.filePath = strings.TrimPrefix(path.Join(path.Dir(g.filePath), pathStr), "/")
+	return g.String(), nil
+}
+
+// fetchGit obtains a file from a git repository at a given ref by performing a
+// shallow fetch of the ref into a temporary repository, which requires the git
+// command to be installed.
+func (r *RemoteImports) fetchGit(pathStr string) ([]byte, error) {
+	g, err := parseGitImport(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "benthos_blobl_git")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	ctx, done := context.WithTimeout(context.Background(), r.client.Timeout)
+	defer done()
+
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", tmpDir}, args...)...)
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			if errors.Is(err, exec.ErrNotFound) {
+				return nil, errors.New("the git command is required for git imports but could not be found")
+			}
+			return nil, fmt.Errorf("git %v: %w: %s", args[0], err, bytes.TrimSpace(stderr.Bytes()))
+		}
+		return stdout.Bytes(), nil
+	}
+
+	if _, err := git("init", "-q"); err != nil {
+		return nil, err
+	}
+	if _, err := git("fetch", "-q", "--depth", "1", "--", g.repo, g.ref); err != nil {
+		return nil, fmt.Errorf("failed to fetch ref %v of %v: %w", g.ref, g.repo, err)
+	}
+
+	// The file is streamed from git show in order to stop reading, and kill
+	// the command, as soon as the content exceeds the maximum import size.
+	cmd := exec.CommandContext(ctx, "git", "-C", tmpDir, "show", "FETCH_HEAD:"+g.filePath)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to read %v from ref %v of %v: %w", g.filePath, g.ref, g.repo, err)
+	}
+
+	b, err := readLimited(stdout, pathStr)
+	if err != nil {
+		done()
+		_ = cmd.Wait()
+		return nil, err
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("failed to read %v from ref %v of %v: git show: %w: %s", g.filePath, g.ref, g.repo, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	return b, nil
+}
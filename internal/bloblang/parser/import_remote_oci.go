@@ -0,0 +1,304 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const (
+	ociImportPrefix          = "oci://"
+	ociPlainHTTPImportPrefix = "oci+http://"
+
+	ociTitleAnnotation = "org.opencontainers.image.title"
+)
+
+// isOCIImport returns true if an import path refers to a file within an OCI
+// artifact, which takes the form `oci://<registry>/<repository>:<tag>//<file>`
+// or `oci://<registry>/<repository>@<digest>//<file>`. The scheme
+// `oci+http://` can be used for registries that are served over plain HTTP.
+func isOCIImport(pathStr string) bool {
+	return strings.HasPrefix(pathStr, ociImportPrefix) || strings.HasPrefix(pathStr, ociPlainHTTPImportPrefix)
+}
+
+type ociImport struct {
+	scheme    string
+	registry  string
+	repo      string
+	reference string
+	digestRef bool
+	filePath  string
+}
+
+func parseOCIImport(pathStr string) (ociImport, error) {
+	var o ociImport
+	rest := pathStr
+	if strings.HasPrefix(rest, ociPlainHTTPImportPrefix) {
+		o.scheme, rest = "http", strings.TrimPrefix(rest, ociPlainHTTPImportPrefix)
+	} else {
+		o.scheme, rest = "https", strings.TrimPrefix(rest, ociImportPrefix)
+	}
+
+	if i := strings.Index(rest, "//"); i >= 0 {
+		o.filePath = rest[i+2:]
+		rest = rest[:i]
+	}
+
+	i := strings.Index(rest, "/")
+	if i <= 0 {
+		return ociImport{}, fmt.Errorf("OCI import %v must specify a registry and repository", pathStr)
+	}
+	o.registry, rest = rest[:i], rest[i+1:]
+
+	if i := strings.Index(rest, "@"); i >= 0 {
+		o.repo, o.reference, o.digestRef = rest[:i], rest[i+1:], true
+		if !strings.HasPrefix(o.reference, "sha256:") {
+			return ociImport{}, fmt.Errorf("OCI import %v has an unsupported digest, expected sha256:<hex>", pathStr)
+		}
+	} else if i := strings.LastIndex(rest, ":"); i >= 0 {
+		o.repo, o.reference = rest[:i], rest[i+1:]
+	} else {
+		return ociImport{}, fmt.Errorf("OCI import %v must specify a tag or digest", pathStr)
+	}
+	if o.repo == "" || o.reference == "" {
+		return ociImport{}, fmt.Errorf("OCI import %v must specify a repository and a tag or digest", pathStr)
+	}
+	return o, nil
+}
+
+func (o ociImport) String() string {
+	prefix := ociImportPrefix
+	if o.scheme == "http" {
+		prefix = ociPlainHTTPImportPrefix
+	}
+	sep := ":"
+	if o.digestRef {
+		sep = "@"
+	}
+	str := prefix + o.registry + "/" + o.repo + sep + o.reference
+	if o.filePath != "" {
+		str += "//" + o.filePath
+	}
+	return str
+}
+
+func resolveOCIImport(base, pathStr string) (string, error) {
+	o, err := parseOCIImport(base)
+	if err != nil {
+		return "", err
+	}
+	o.filePath = strings.TrimPrefix(path.Join(path.Dir(o.filePath), pathStr), "/")
+	return o.String(), nil
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// selectLayer returns the layer of an artifact that contains a file, where
+// files are identified by their title annotation. When no file is specified
+// the artifact must contain exactly one layer.
+func (m ociManifest) selectLayer(filePath string) (ociDescriptor, error) {
+	if filePath == "" {
+		if len(m.Layers) != 1 {
+			return ociDescriptor{}, fmt.Errorf("artifact contains %v layers, a file must be specified", len(m.Layers))
+		}
+		return m.Layers[0], nil
+	}
+	for _, l := range m.Layers {
+		if l.Annotations[ociTitleAnnotation] == filePath {
+			return l, nil
+		}
+	}
+	return ociDescriptor{}, fmt.Errorf("artifact does not contain a layer titled %v", filePath)
+}
+
+// fetchOCI obtains a file from an OCI artifact by reading the manifest of the
+// artifact and then the blob of the layer containing the file. Registries
+// that require a token are supported when they allow anonymous pulls.
+func (r *RemoteImports) fetchOCI(pathStr string) ([]byte, error) {
+	o, err := parseOCIImport(pathStr)
+	if err != nil {
+		return nil, err
+	}
+
+	baseURL := fmt.Sprintf("%v://%v/v2/%v", o.scheme, o.registry, o.repo)
+
+	var token string
+	manifestBytes, err := r.ociGet(baseURL+"/manifests/"+o.reference, &token,
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.v2+json",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest of %v: %w", pathStr, err)
+	}
+	if o.digestRef {
+		if err := checkOCIDigest(manifestBytes, o.reference); err != nil {
+			return nil, fmt.Errorf("manifest of %v: %w", pathStr, err)
+		}
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest of %v: %w", pathStr, err)
+	}
+
+	layer, err := manifest.selectLayer(o.filePath)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %w", pathStr, err)
+	}
+	if layer.Size > maxRemoteImportBytes {
+		return nil, fmt.Errorf("import %v exceeds the maximum size of %v bytes", pathStr, maxRemoteImportBytes)
+	}
+
+	b, err := r.ociGet(baseURL+"/blobs/"+layer.Digest, &token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch layer of %v: %w", pathStr, err)
+	}
+	if err := checkOCIDigest(b, layer.Digest); err != nil {
+		return nil, fmt.Errorf("layer of %v: %w", pathStr, err)
+	}
+	return b, nil
+}
+
+func checkOCIDigest(b []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %v", digest)
+	}
+	sum := sha256.Sum256(b)
+	if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+		return fmt.Errorf("content does not match digest %v, got %v", digest, actual)
+	}
+	return nil
+}
+
+// ociGet performs a GET request against a registry, when the registry responds
+// with a bearer token challenge an anonymous token is obtained and the request
+// is retried. The token is stored for subsequent requests.
+func (r *RemoteImports) ociGet(u string, token *string, accept ...string) ([]byte, error) {
+	do := func() (*http.Response, error) {
+		req, err := http.NewRequest(http.MethodGet, u, http.NoBody)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range accept {
+			req.Header.Add("Accept", a)
+		}
+		if *token != "" {
+			req.Header.Set("Authorization", "Bearer "+*token)
+		}
+		return r.client.Do(req)
+	}
+
+	res, err := do()
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized && *token == "" {
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+		if *token, err = r.ociToken(challenge); err != nil {
+			return nil, err
+		}
+		if res, err = do(); err != nil {
+			return nil, err
+		}
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status code from %v: %v", u, res.StatusCode)
+	}
+	return readLimited(res.Body, u)
+}
+
+func (r *RemoteImports) ociToken(challenge string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", errors.New("registry requires authentication that is not supported, only anonymous bearer tokens can be obtained")
+	}
+
+	params := parseOCIChallengeParams(challenge[len("bearer "):])
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("registry token challenge has an invalid realm: %v", params["realm"])
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if v := params[k]; v != "" {
+			q.Set(k, v)
+		}
+	}
+	realm.RawQuery = q.Encode()
+
+	res, err := r.client.Get(realm.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to obtain registry token: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("failed to obtain registry token: unexpected status code %v", res.StatusCode)
+	}
+
+	b, err := readLimited(res.Body, realm.String())
+	if err != nil {
+		return "", err
+	}
+	var tokenRes struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.Unmarshal(b, &tokenRes); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if tokenRes.Token != "" {
+		return tokenRes.Token, nil
+	}
+	if tokenRes.AccessToken != "" {
+		return tokenRes.AccessToken, nil
+	}
+	return "", errors.New("registry token response did not contain a token")
+}
+
+// parseOCIChallengeParams parses the comma separated key value parameters of a
+// WWW-Authenticate challenge, where values may be quoted and contain commas.
+func parseOCIChallengeParams(str string) map[string]string {
+	params := map[string]string{}
+	for {
+		str = strings.TrimLeft(str, " ,")
+		i := strings.Index(str, "=")
+		if i <= 0 {
+			return params
+		}
+		key := strings.ToLower(strings.TrimSpace(str[:i]))
+		str = str[i+1:]
+
+		var value string
+		if strings.HasPrefix(str, `"`) {
+			end := strings.Index(str[1:], `"`)
+			if end < 0 {
+				value, str = str[1:], ""
+			} else {
+				value, str = str[1:end+1], str[end+2:]
+			}
+		} else if end := strings.Index(str, ","); end >= 0 {
+			value, str = str[:end], str[end:]
+		} else {
+			value, str = str, ""
+		}
+		params[key] = value
+	}
+}
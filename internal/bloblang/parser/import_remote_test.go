@@ -0,0 +1,369 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func remoteImportsServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		content, exists := files[r.URL.Path]
+		if !exists {
+			http.Error(w, "nope", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(content))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRemoteImportPinned(t *testing.T) {
+	libContent := `map upper_foo { root.foo = this.foo.uppercase() }`
+	srv := remoteImportsServer(t, map[string]string{
+		"/lib.blobl": libContent,
+	})
+
+	cacheDir := t.TempDir()
+	pin := hashImport([]byte(libContent))[len(importHashPrefix):]
+
+	mapping := `import "` + srv.URL + `/lib.blobl#sha256=` + pin + `"
+root = this.apply("upper_foo")`
+
+	pCtx := GlobalContext().WithRemoteImports(NewRemoteImports(cacheDir, nil, false))
+	exec, perr := ParseMapping(pCtx, mapping)
+	require.Nil(t, perr)
+
+	res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"foo":"hello"}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"HELLO"}`, string(res.AsBytes()))
+
+	// Once cached the import is resolved without the server.
+	srv.Close()
+
+	pCtx = GlobalContext().WithRemoteImports(NewRemoteImports(cacheDir, nil, false))
+	_, perr = ParseMapping(pCtx, mapping)
+	require.Nil(t, perr)
+}
+
+func TestRemoteImportHashMismatch(t *testing.T) {
+	srv := remoteImportsServer(t, map[string]string{
+		"/lib.blobl": `map foo { root = "foo" }`,
+	})
+
+	mapping := `import "` + srv.URL + `/lib.blobl#sha256=deadbeef"
+root = this.apply("foo")`
+
+	pCtx := GlobalContext().WithRemoteImports(NewRemoteImports(t.TempDir(), nil, false))
+	_, perr := ParseMapping(pCtx, mapping)
+	require.NotNil(t, perr)
+	assert.Contains(t, perr.Error(), "does not match pinned hash sha256:deadbeef")
+}
+
+func TestRemoteImportUnpinned(t *testing.T) {
+	srv := remoteImportsServer(t, map[string]string{
+		"/lib.blobl":    `import "./nested.blobl"`,
+		"/nested.blobl": `map foo { root = "foo" }`,
+	})
+
+	mapping := `import "` + srv.URL + `/lib.blobl"
+root = this.apply("foo")`
+
+	_, perr := ParseMapping(GlobalContext().WithRemoteImports(NewRemoteImports(t.TempDir(), nil, false)), mapping)
+	require.NotNil(t, perr)
+	assert.Contains(t, perr.Error(), "must be pinned")
+
+	remote := NewRemoteImports(t.TempDir(), nil, true)
+	_, perr = ParseMapping(GlobalContext().WithRemoteImports(remote), mapping)
+	require.Nil(t, perr)
+
+	assert.Equal(t, []string{
+		srv.URL + "/lib.blobl",
+		srv.URL + "/nested.blobl",
+	}, remote.Lock().URLs())
+	assert.Equal(t, hashImport([]byte(`map foo { root = "foo" }`)), remote.Lock().Imports[srv.URL+"/nested.blobl"])
+}
+
+func TestRemoteImportLockFile(t *testing.T) {
+	srv := remoteImportsServer(t, map[string]string{
+		"/lib.blobl": `map foo { root = "foo" }`,
+	})
+
+	lockPath := filepath.Join(t.TempDir(), ImportLockFileName)
+
+	lock, err := ReadImportLock(lockPath)
+	require.NoError(t, err)
+	assert.Empty(t, lock.Imports)
+
+	mapping := `import "` + srv.URL + `/lib.blobl"
+root = this.apply("foo")`
+
+	remote := NewRemoteImports(t.TempDir(), lock, true)
+	_, perr := ParseMapping(GlobalContext().WithRemoteImports(remote), mapping)
+	require.Nil(t, perr)
+	require.NoError(t, lock.WriteFile(lockPath))
+
+	lock, err = ReadImportLock(lockPath)
+	require.NoError(t, err)
+
+	_, perr = ParseMapping(GlobalContext().WithRemoteImports(NewRemoteImports(t.TempDir(), lock, false)), mapping)
+	require.Nil(t, perr)
+
+	lock.Imports[srv.URL+"/lib.blobl"] = "sha256:nope"
+	_, perr = ParseMapping(GlobalContext().WithRemoteImports(NewRemoteImports(t.TempDir(), lock, false)), mapping)
+	require.NotNil(t, perr)
+	assert.Contains(t, perr.Error(), "does not match pinned hash sha256:nope")
+}
+
+func TestRemoteImportSizeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bytes.Repeat([]byte("#"), maxRemoteImportBytes+1))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := NewRemoteImports(t.TempDir(), nil, true).Import(srv.URL + "/lib.blobl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum size")
+}
+
+func TestRemoteImportLockRelativeToFile(t *testing.T) {
+	libContent := `map foo { root = "foo" }`
+	srv := remoteImportsServer(t, map[string]string{
+		"/lib.blobl": libContent,
+	})
+
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	projectDir := t.TempDir()
+	mappingDir := filepath.Join(projectDir, "mappings")
+	require.NoError(t, os.MkdirAll(mappingDir, 0o755))
+
+	mappingPath := filepath.Join(mappingDir, "foo.blobl")
+	require.NoError(t, os.WriteFile(mappingPath, []byte(`import "`+srv.URL+`/lib.blobl"
+root = this.apply("foo")`), 0o644))
+
+	mapping := `import "` + mappingPath + `"`
+
+	// Without a lock file the import is unpinned.
+	_, perr := ParseMapping(GlobalContext(), mapping)
+	require.NotNil(t, perr)
+	assert.Contains(t, perr.Error(), "must be pinned")
+
+	// A lock within a parent directory of the importing mapping file is found
+	// regardless of the working directory, and changes are picked up.
+	lock := &ImportLock{Imports: map[string]string{
+		srv.URL + "/lib.blobl": hashImport([]byte(libContent)),
+	}}
+	require.NoError(t, lock.WriteFile(filepath.Join(projectDir, ImportLockFileName)))
+
+	_, perr = ParseMapping(GlobalContext(), mapping)
+	require.Nil(t, perr)
+
+	// A lock relative to a config file is used for mappings within the config.
+	configPath := filepath.Join(projectDir, "config.yaml")
+	_, perr = ParseMapping(GlobalContext().WithImportLockRelativeToFile(configPath), `import "`+srv.URL+`/lib.blobl"
+root = this.apply("foo")`)
+	require.Nil(t, perr)
+
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, ImportLockFileName), []byte(`not json`), 0o644))
+	_, perr = ParseMapping(GlobalContext(), mapping)
+	require.NotNil(t, perr)
+	assert.Contains(t, perr.Error(), "failed to parse lock file")
+}
+
+func TestRemoteImportLockErrorRelativeToFile(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ImportLockFileName), []byte(`not json`), 0o644))
+
+	importer := (&osImporter{relativePath: dir}).RelativeToFile("https://example.com/foo.blobl")
+	_, err := importer.Import("./bar.blobl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse lock file")
+}
+
+func TestResolveRemoteImport(t *testing.T) {
+	tests := []struct {
+		base, path, expected string
+	}{
+		{
+			base:     "https://example.com/a/b.blobl",
+			path:     "./c.blobl",
+			expected: "https://example.com/a/c.blobl",
+		},
+		{
+			base:     "git+https://example.com/repo.git//a/b.blobl?ref=v1.0.0",
+			path:     "../c.blobl",
+			expected: "git+https://example.com/repo.git//c.blobl?ref=v1.0.0",
+		},
+		{
+			base:     "oci://ghcr.io/foo/maps:v1//a/b.blobl",
+			path:     "./c.blobl",
+			expected: "oci://ghcr.io/foo/maps:v1//a/c.blobl",
+		},
+		{
+			base:     "oci://localhost:5000/foo/maps@sha256:abcd//b.blobl",
+			path:     "c.blobl",
+			expected: "oci://localhost:5000/foo/maps@sha256:abcd//c.blobl",
+		},
+		{
+			base:     "oci://ghcr.io/foo/maps:v1//b.blobl",
+			path:     "https://example.com/c.blobl",
+			expected: "https://example.com/c.blobl",
+		},
+	}
+
+	for _, test := range tests {
+		res, err := resolveRemoteImport(test.base, test.path)
+		require.NoError(t, err, test.base)
+		assert.Equal(t, test.expected, res, test.base)
+	}
+}
+
+func TestRemoteImportGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	repoDir := t.TempDir()
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", repoDir}, args...)...)
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+	}
+
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "maps"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "maps", "lib.blobl"), []byte(`import "./nested.blobl"`), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "maps", "nested.blobl"), []byte(`map foo { root = "v1" }`), 0o644))
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "v1")
+	git("tag", "v1.0.0")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "maps", "nested.blobl"), []byte(`map foo { root = "v2" }`), 0o644))
+	git("commit", "-q", "-a", "-m", "v2")
+
+	mapping := `import "git+file://` + repoDir + `//maps/lib.blobl?ref=v1.0.0"
+root = this.apply("foo")`
+
+	remote := NewRemoteImports(t.TempDir(), nil, true)
+	mExec, perr := ParseMapping(GlobalContext().WithRemoteImports(remote), mapping)
+	require.Nil(t, perr)
+
+	res, err := mExec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `v1`, string(res.AsBytes()))
+
+	assert.Equal(t, []string{
+		"git+file://" + repoDir + "//maps/lib.blobl?ref=v1.0.0",
+		"git+file://" + repoDir + "//maps/nested.blobl?ref=v1.0.0",
+	}, remote.Lock().URLs())
+
+	_, err = NewRemoteImports(t.TempDir(), nil, true).Import("git+file://" + repoDir + "//maps/nope.blobl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read maps/nope.blobl")
+
+	require.NoError(t, os.WriteFile(filepath.Join(repoDir, "maps", "huge.blobl"), bytes.Repeat([]byte("#"), maxRemoteImportBytes+1), 0o644))
+	git("add", "-A")
+	git("commit", "-q", "-m", "huge")
+
+	_, err = NewRemoteImports(t.TempDir(), nil, true).Import("git+file://" + repoDir + "//maps/huge.blobl")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeds the maximum size")
+
+	_, err = NewRemoteImports(t.TempDir(), nil, true).Import("git+file://" + repoDir + "//maps/lib.blobl?ref=--upload-pack=nope")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid git ref")
+}
+
+func ociDigest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestRemoteImportOCI(t *testing.T) {
+	libContent := []byte(`import "./nested.blobl"`)
+	nestedContent := []byte(`map foo { root = "oci" }`)
+
+	manifest, err := json.Marshal(ociManifest{Layers: []ociDescriptor{
+		{
+			MediaType:   "application/vnd.benthos.blobl",
+			Digest:      ociDigest(libContent),
+			Size:        int64(len(libContent)),
+			Annotations: map[string]string{ociTitleAnnotation: "lib.blobl"},
+		},
+		{
+			MediaType:   "application/vnd.benthos.blobl",
+			Digest:      ociDigest(nestedContent),
+			Size:        int64(len(nestedContent)),
+			Annotations: map[string]string{ociTitleAnnotation: "nested.blobl"},
+		},
+	}})
+	require.NoError(t, err)
+
+	var srvURL string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			assert.Equal(t, "registry", r.URL.Query().Get("service"))
+			assert.Equal(t, "repository:foo/maps:pull", r.URL.Query().Get("scope"))
+			_, _ = w.Write([]byte(`{"token":"meow"}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer meow" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="`+srvURL+`/token",service="registry",scope="repository:foo/maps:pull"`)
+			http.Error(w, "nope", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/foo/maps/manifests/v1":
+			_, _ = w.Write(manifest)
+		case "/v2/foo/maps/blobs/" + ociDigest(libContent):
+			_, _ = w.Write(libContent)
+		case "/v2/foo/maps/blobs/" + ociDigest(nestedContent):
+			_, _ = w.Write(nestedContent)
+		default:
+			http.Error(w, "nope", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	srvURL = srv.URL
+
+	importPath := "oci+http://" + strings.TrimPrefix(srv.URL, "http://") + "/foo/maps:v1//lib.blobl"
+	mapping := `import "` + importPath + `"
+root = this.apply("foo")`
+
+	remote := NewRemoteImports(t.TempDir(), nil, true)
+	exec, perr := ParseMapping(GlobalContext().WithRemoteImports(remote), mapping)
+	require.Nil(t, perr)
+
+	res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.NoError(t, err)
+	assert.Equal(t, `oci`, string(res.AsBytes()))
+
+	assert.Equal(t, hashImport(nestedContent), remote.Lock().Imports[strings.TrimSuffix(importPath, "lib.blobl")+"nested.blobl"])
+
+	_, err = NewRemoteImports(t.TempDir(), nil, true).Import(strings.TrimSuffix(importPath, "//lib.blobl"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "artifact contains 2 layers, a file must be specified")
+}
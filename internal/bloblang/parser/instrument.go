@@ -0,0 +1,353 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"runtime/metrics"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// instrumentSource describes the mapping currently being parsed, which is used
+// in order to determine the position of instrumented functions.
+type instrumentSource struct {
+	name  string
+	input []rune
+}
+
+// Position describes where within a mapping an instrumented function was
+// parsed.
+type Position struct {
+	Source string
+	Line   int
+	Column int
+}
+
+// String returns a human readable representation of the position.
+func (p Position) String() string {
+	if p.Source != "" {
+		return fmt.Sprintf("%v:%v:%v", p.Source, p.Line, p.Column)
+	}
+	return fmt.Sprintf("line %v char %v", p.Line, p.Column)
+}
+
+func (pCtx Context) withInstrumentSource(name string, input []rune) Context {
+	if pCtx.profiler == nil && pCtx.coverage == nil && pCtx.linter == nil {
+		return pCtx
+	}
+	pCtx.instrumentSrc = &instrumentSource{name: name, input: input}
+	return pCtx
+}
+
+func (pCtx Context) positionOf(input []rune) Position {
+	if pCtx.instrumentSrc == nil {
+		return Position{}
+	}
+	line, col := mapping.LineAndColOf(pCtx.instrumentSrc.input, input)
+	return Position{
+		Source: pCtx.instrumentSrc.name,
+		Line:   line,
+		Column: col,
+	}
+}
+
+func firstLineOf(input []rune) string {
+	line := string(input)
+	if i := strings.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+	line = strings.TrimSpace(line)
+	if len(line) > 60 {
+		line = line[:57] + "..."
+	}
+	return line
+}
+
+//------------------------------------------------------------------------------
+
+// WithProfiler returns a version of the parser context where statements,
+// function calls and method calls of parsed mappings record their execution
+// time and allocations to a profiler.
+func (pCtx Context) WithProfiler(p *Profiler) Context {
+	pCtx.profiler = p
+	return pCtx
+}
+
+// ProfileEntry contains the accumulated execution stats of a statement,
+// function or method call within a mapping.
+type ProfileEntry struct {
+	Kind     string
+	Label    string
+	Position Position
+
+	Calls      int64
+	Duration   time.Duration
+	AllocBytes uint64
+}
+
+// Profiler accumulates the execution time and heap allocations of the
+// statements, function calls and method calls of mappings.
+//
+// Allocations are measured from process wide runtime metrics and therefore
+// only make sense when mappings are executed sequentially. Both the durations
+// and allocations of a function are cumulative, meaning they include the cost
+// of any arguments and method targets.
+type Profiler struct {
+	mut     sync.Mutex
+	entries []*ProfileEntry
+	index   map[string]*ProfileEntry
+}
+
+// NewProfiler creates an empty profiler.
+func NewProfiler() *Profiler {
+	return &Profiler{
+		index: map[string]*ProfileEntry{},
+	}
+}
+
+func (p *Profiler) entry(kind, label string, pos Position) *ProfileEntry {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	key := kind + "|" + label + "|" + pos.String()
+	if e, exists := p.index[key]; exists {
+		return e
+	}
+	e := &ProfileEntry{Kind: kind, Label: label, Position: pos}
+	p.index[key] = e
+	p.entries = append(p.entries, e)
+	return e
+}
+
+const heapAllocsMetric = "/gc/heap/allocs:bytes"
+
+func readHeapAllocs(sample []metrics.Sample) uint64 {
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return sample[0].Value.Uint64()
+}
+
+func (p *Profiler) wrap(kind, label string, pos Position, fn query.Function) query.Function {
+	e := p.entry(kind, label, pos)
+	return query.ClosureFunction(fn.Annotation(), func(ctx query.FunctionContext) (any, error) {
+		sample := []metrics.Sample{{Name: heapAllocsMetric}}
+
+		allocsBefore := readHeapAllocs(sample)
+		started := time.Now()
+
+		v, err := fn.Exec(ctx)
+
+		duration := time.Since(started)
+		allocs := readHeapAllocs(sample) - allocsBefore
+
+		p.mut.Lock()
+		e.Calls++
+		e.Duration += duration
+		e.AllocBytes += allocs
+		p.mut.Unlock()
+		return v, err
+	}, fn.QueryTargets)
+}
+
+// Entries returns a copy of all profile entries sorted by their cumulative
+// duration in descending order.
+func (p *Profiler) Entries() []ProfileEntry {
+	p.mut.Lock()
+	entries := make([]ProfileEntry, 0, len(p.entries))
+	for _, e := range p.entries {
+		entries = append(entries, *e)
+	}
+	p.mut.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Duration > entries[j].Duration
+	})
+	return entries
+}
+
+// WriteReport writes a human readable table of the profile entries.
+func (p *Profiler) WriteReport(w io.Writer) {
+	fmt.Fprintf(w, "%-10s %-20s %10s %14s %14s %14s  %s\n", "KIND", "POSITION", "CALLS", "TOTAL", "PER CALL", "ALLOC BYTES", "LABEL")
+	for _, e := range p.Entries() {
+		var perCall time.Duration
+		if e.Calls > 0 {
+			perCall = e.Duration / time.Duration(e.Calls)
+		}
+		fmt.Fprintf(w, "%-10s %-20s %10d %14s %14s %14d  %s\n", e.Kind, e.Position, e.Calls, e.Duration, perCall, e.AllocBytes, e.Label)
+	}
+}
+
+func (pCtx Context) profileStatement(input []rune, fn query.Function) query.Function {
+	if pCtx.profiler == nil {
+		return fn
+	}
+	return pCtx.profiler.wrap("statement", firstLineOf(input), pCtx.positionOf(input), fn)
+}
+
+func (pCtx Context) profileCall(input []rune, kind, name string, fn query.Function) query.Function {
+	if pCtx.profiler == nil {
+		return fn
+	}
+	return pCtx.profiler.wrap(kind, name, pCtx.positionOf(input), fn)
+}
+
+//------------------------------------------------------------------------------
+
+// WithCoverage returns a version of the parser context where the branches of
+// match and if expressions within parsed mappings record whether they were
+// executed to a coverage tracker.
+func (pCtx Context) WithCoverage(c *Coverage) Context {
+	pCtx.coverage = c
+	return pCtx
+}
+
+// CoverageBranch describes a branch of a mapping and the number of times it
+// has been executed.
+type CoverageBranch struct {
+	Kind     string
+	Label    string
+	Position Position
+	Hits     int64
+}
+
+// CoverageMapping contains the branches of a parsed mapping.
+type CoverageMapping struct {
+	Name     string
+	Branches []CoverageBranch
+}
+
+// Covered returns the number of branches that were executed at least once.
+func (c CoverageMapping) Covered() (n int) {
+	for _, b := range c.Branches {
+		if b.Hits > 0 {
+			n++
+		}
+	}
+	return
+}
+
+type coverageSource struct {
+	name     string
+	branches []*CoverageBranch
+	index    map[string]*CoverageBranch
+}
+
+// Coverage tracks the branches of mappings that are executed. Mappings that are
+// parsed multiple times share their coverage, which means branches are
+// aggregated across all instances of the same mapping.
+type Coverage struct {
+	mut     sync.Mutex
+	sources []*coverageSource
+	index   map[string]*coverageSource
+}
+
+// NewCoverage creates an empty coverage tracker.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		index: map[string]*coverageSource{},
+	}
+}
+
+func (c *Coverage) branch(src *instrumentSource, kind, label string, pos Position) *CoverageBranch {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	srcKey, srcName := "", ""
+	if src != nil {
+		srcKey = src.name + "|" + string(src.input)
+		if srcName = src.name; srcName == "" {
+			srcName = firstLineOf(src.input)
+		}
+	}
+
+	s, exists := c.index[srcKey]
+	if !exists {
+		s = &coverageSource{name: srcName, index: map[string]*CoverageBranch{}}
+		c.index[srcKey] = s
+		c.sources = append(c.sources, s)
+	}
+
+	key := kind + "|" + pos.String()
+	if b, exists := s.index[key]; exists {
+		return b
+	}
+	b := &CoverageBranch{Kind: kind, Label: label, Position: pos}
+	s.index[key] = b
+	s.branches = append(s.branches, b)
+	return b
+}
+
+func (c *Coverage) wrap(src *instrumentSource, kind, label string, pos Position, fn query.Function) query.Function {
+	b := c.branch(src, kind, label, pos)
+	return query.ClosureFunction(fn.Annotation(), func(ctx query.FunctionContext) (any, error) {
+		c.mut.Lock()
+		b.Hits++
+		c.mut.Unlock()
+		return fn.Exec(ctx)
+	}, fn.QueryTargets)
+}
+
+// Mappings returns a copy of the coverage of each parsed mapping.
+func (c *Coverage) Mappings() []CoverageMapping {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	mappings := make([]CoverageMapping, 0, len(c.sources))
+	for _, s := range c.sources {
+		if len(s.branches) == 0 {
+			continue
+		}
+		m := CoverageMapping{Name: s.name}
+		for _, b := range s.branches {
+			m.Branches = append(m.Branches, *b)
+		}
+		sort.SliceStable(m.Branches, func(i, j int) bool {
+			bi, bj := m.Branches[i].Position, m.Branches[j].Position
+			if bi.Line == bj.Line {
+				return bi.Column < bj.Column
+			}
+			return bi.Line < bj.Line
+		})
+		mappings = append(mappings, m)
+	}
+	return mappings
+}
+
+// WriteReport writes a human readable report of the branch coverage of each
+// mapping, including the branches that were never executed.
+func (c *Coverage) WriteReport(w io.Writer) {
+	mappings := c.Mappings()
+	if len(mappings) == 0 {
+		fmt.Fprintln(w, "No mapping branches were found")
+		return
+	}
+
+	var total, covered int
+	for _, m := range mappings {
+		mCovered := m.Covered()
+		total += len(m.Branches)
+		covered += mCovered
+
+		fmt.Fprintf(w, "%v: %v/%v branches covered\n", m.Name, mCovered, len(m.Branches))
+		for _, b := range m.Branches {
+			if b.Hits == 0 {
+				fmt.Fprintf(w, "  %v: %v not covered: %v\n", b.Position, b.Kind, b.Label)
+			}
+		}
+	}
+	fmt.Fprintf(w, "Total: %v/%v branches covered (%.1f%%)\n", covered, total, float64(covered)/float64(total)*100)
+}
+
+func (pCtx Context) coverBranch(input []rune, kind string, fn query.Function) query.Function {
+	if pCtx.coverage == nil {
+		return fn
+	}
+	return pCtx.coverage.wrap(pCtx.instrumentSrc, kind, firstLineOf(input), pCtx.positionOf(input), fn)
+}
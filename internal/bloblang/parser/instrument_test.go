@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestProfiler(t *testing.T) {
+	profiler := NewProfiler()
+
+	exec, perr := ParseMapping(GlobalContext().WithProfiler(profiler), `root.a = this.a.uppercase()
+root.b = this.b.split(",").map_each(ele -> ele.trim())`)
+	require.Nil(t, perr)
+
+	for i := 0; i < 3; i++ {
+		_, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{"a":"foo","b":"x, y"}`)}))
+		require.NoError(t, err)
+	}
+
+	type entryKey struct {
+		kind, label string
+		line, col   int
+	}
+	calls := map[entryKey]int64{}
+	for _, e := range profiler.Entries() {
+		calls[entryKey{e.Kind, e.Label, e.Position.Line, e.Position.Column}] = e.Calls
+	}
+
+	assert.Equal(t, map[entryKey]int64{
+		{"statement", "root.a = this.a.uppercase()", 1, 1}:                            3,
+		{"statement", `root.b = this.b.split(",").map_each(ele -> ele.trim())`, 2, 1}: 3,
+		{"method", "uppercase", 1, 17}:                                                3,
+		{"method", "split", 2, 17}:                                                    3,
+		{"method", "map_each", 2, 28}:                                                 3,
+		{"method", "trim", 2, 48}:                                                     6,
+	}, calls)
+
+	var buf bytes.Buffer
+	profiler.WriteReport(&buf)
+	assert.Contains(t, buf.String(), "map_each")
+}
+
+func TestCoverage(t *testing.T) {
+	coverage := NewCoverage()
+
+	mapping := `root.a = match this.a {
+  "foo" => "is foo"
+  "bar" => "is bar"
+  _ => "is other"
+}
+root.b = if this.b > 10 {
+  "big"
+} else if this.b > 5 {
+  "medium"
+} else {
+  "small"
+}`
+
+	// Parsing the same mapping twice aggregates coverage.
+	for _, input := range []string{`{"a":"foo","b":20}`, `{"a":"baz","b":1}`} {
+		exec, perr := ParseMapping(GlobalContext().WithCoverage(coverage), mapping)
+		require.Nil(t, perr)
+
+		_, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(input)}))
+		require.NoError(t, err)
+	}
+
+	mappings := coverage.Mappings()
+	require.Len(t, mappings, 1)
+	assert.Equal(t, "root.a = match this.a {", mappings[0].Name)
+
+	type branchKey struct {
+		kind      string
+		line, col int
+	}
+	hits := map[branchKey]int64{}
+	for _, b := range mappings[0].Branches {
+		hits[branchKey{b.Kind, b.Position.Line, b.Position.Column}] = b.Hits
+	}
+	assert.Equal(t, map[branchKey]int64{
+		{"match case", 2, 3}: 1,
+		{"match case", 3, 3}: 0,
+		{"match case", 4, 3}: 1,
+		{"if", 6, 10}:        1,
+		{"else if", 8, 3}:    0,
+		{"else", 10, 3}:      1,
+	}, hits)
+	assert.Equal(t, 4, mappings[0].Covered())
+
+	var buf bytes.Buffer
+	coverage.WriteReport(&buf)
+	assert.Contains(t, buf.String(), "4/6 branches covered")
+	assert.Contains(t, buf.String(), `line 3 char 3: match case not covered: "bar" => "is bar"`)
+}
@@ -0,0 +1,572 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// Lint describes a likely problem within a mapping that was found by a static
+// analysis of the mapping.
+type Lint struct {
+	Position Position
+	What     string
+}
+
+// String returns a human readable representation of the lint.
+func (l Lint) String() string {
+	return fmt.Sprintf("%v: %v", l.Position, l.What)
+}
+
+// LintMapping parses a mapping and performs a static analysis of it, returning
+// any definite type mismatches, unreachable match cases, unused variables,
+// shadowed assignments within the mapping and its maps, and shadowed object
+// keys that were found.
+//
+// The types of values are inferred statically as the mapping is parsed, each
+// parsed literal, field, function, method and arithmetic expression reports the
+// type of the values it yields when known, which is then checked against the
+// types accepted by the methods and operators that consume it. Types are known
+// for literals, for the functions and methods listed within this package and,
+// when an optional JSON Schema of the input documents is provided, for fields
+// of the input document that have a single definite type within the schema.
+// The schema is also used in order to report references to fields that cannot
+// exist.
+//
+// No part of the mapping is executed during the analysis.
+func LintMapping(pCtx Context, expr string, inputSchema any) ([]Lint, *Error) {
+	l := newLinter(inputSchema)
+
+	pCtx.linter = l
+	if _, err := ParseMapping(pCtx, expr); err != nil {
+		return nil, err
+	}
+	return l.results(), nil
+}
+
+type lintLet struct {
+	name     string
+	position Position
+}
+
+// linter accumulates the findings of a static analysis during the parsing of
+// a mapping. Since parsers may attempt to parse the same input more than once
+// all findings are deduplicated by their position.
+type linter struct {
+	mut sync.Mutex
+
+	schema any
+
+	lints    []Lint
+	seen     map[string]struct{}
+	lets     []lintLet
+	usedVars map[string]struct{}
+
+	// nothings counts the parsed expressions that might result in nothing,
+	// and maybeNothing records the assignments containing any of them.
+	nothings     int
+	maybeNothing map[Position]bool
+}
+
+func newLinter(schema any) *linter {
+	return &linter{
+		schema:       schema,
+		seen:         map[string]struct{}{},
+		usedVars:     map[string]struct{}{},
+		maybeNothing: map[Position]bool{},
+	}
+}
+
+// lintFork returns a version of the parser context with a fresh linter, which
+// allows the findings of parse attempts to be discarded when the attempt
+// doesn't end up being used.
+func (pCtx Context) lintFork() Context {
+	if pCtx.linter != nil {
+		pCtx.linter = newLinter(pCtx.linter.schema)
+	}
+	return pCtx
+}
+
+// lintMerge adds the findings of a forked parser context to the linter of this
+// context.
+func (pCtx Context) lintMerge(from Context) {
+	if pCtx.linter == nil || from.linter == nil {
+		return
+	}
+	from.linter.mut.Lock()
+	defer from.linter.mut.Unlock()
+	for _, l := range from.linter.lints {
+		pCtx.linter.add(l.Position, "%v", l.What)
+	}
+
+	pCtx.linter.mut.Lock()
+	defer pCtx.linter.mut.Unlock()
+	pCtx.linter.lets = append(pCtx.linter.lets, from.linter.lets...)
+	for k := range from.linter.usedVars {
+		pCtx.linter.usedVars[k] = struct{}{}
+	}
+}
+
+func (l *linter) add(pos Position, msg string, args ...any) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+
+	lint := Lint{Position: pos, What: fmt.Sprintf(msg, args...)}
+	key := lint.String()
+	if _, exists := l.seen[key]; exists {
+		return
+	}
+	l.seen[key] = struct{}{}
+	l.lints = append(l.lints, lint)
+}
+
+func (l *linter) results() []Lint {
+	l.mut.Lock()
+	var unused []lintLet
+	for _, let := range l.lets {
+		if _, used := l.usedVars[let.name]; !used {
+			unused = append(unused, let)
+		}
+	}
+	l.mut.Unlock()
+
+	for _, let := range unused {
+		l.add(let.position, "variable `%v` is assigned but never used", let.name)
+	}
+
+	lints := append([]Lint{}, l.lints...)
+	sort.SliceStable(lints, func(i, j int) bool {
+		pi, pj := lints[i].Position, lints[j].Position
+		if pi.Source != pj.Source {
+			return pi.Source < pj.Source
+		}
+		if pi.Line == pj.Line {
+			return pi.Column < pj.Column
+		}
+		return pi.Line < pj.Line
+	})
+	return lints
+}
+
+//------------------------------------------------------------------------------
+
+// lintWithUnknownContext returns a version of the parser context used for
+// parsing queries where the context (this) is something other than the input
+// document, such as method arguments and the bodies of maps.
+func (pCtx Context) lintWithUnknownContext() Context {
+	if pCtx.linter != nil {
+		pCtx.lintUnknownContext = true
+	}
+	return pCtx
+}
+
+// lintFunction associates the type of the values returned by a function with
+// the parsed function.
+func (pCtx Context) lintFunction(name string, fn query.Function) query.Function {
+	typ, exists := lintFunctionTypes[name]
+	if !exists {
+		return fn
+	}
+	return pCtx.lintWithType(fn, typ)
+}
+
+// lintMethod reports a method call where the type of the target is known and
+// is rejected by the method, and otherwise associates the type of the values
+// returned by the method with the parsed method.
+func (pCtx Context) lintMethod(input []rune, name string, target, method query.Function) query.Function {
+	if pCtx.linter == nil {
+		return method
+	}
+	mType, exists := lintMethodTypes[name]
+	if !exists {
+		return method
+	}
+
+	targetType := lintTypeOf(target)
+	if targetType != query.ValueUnknown && !lintAccepts(mType.accepts, targetType) {
+		pCtx.linter.add(pCtx.positionOf(input), "method %v: %v", name, &query.TypeError{
+			From:     target.Annotation(),
+			Expected: mType.accepts,
+			Actual:   targetType,
+		})
+		return method
+	}
+
+	returns := mType.returns
+	if returns == lintSameType {
+		returns = targetType
+	}
+	return pCtx.lintWithType(method, returns)
+}
+
+// lintArithmeticPasses lists the operators of arithmetic expressions in the
+// order that they are resolved.
+var lintArithmeticPasses = [][]query.ArithmeticOperator{
+	{query.ArithmeticMul, query.ArithmeticDiv, query.ArithmeticMod, query.ArithmeticPipe},
+	{query.ArithmeticAdd, query.ArithmeticSub},
+	{query.ArithmeticEq, query.ArithmeticNeq, query.ArithmeticGt, query.ArithmeticLt, query.ArithmeticGte, query.ArithmeticLte},
+	{query.ArithmeticAnd, query.ArithmeticOr},
+}
+
+// lintArithmetic reports arithmetic operations where the types of both operands
+// are known and cannot be combined, and otherwise associates the type of the
+// result with the parsed expression. Operations are resolved in the same order
+// as query.NewArithmeticExpression.
+func (pCtx Context) lintArithmetic(input []rune, fns []query.Function, ops []query.ArithmeticOperator, fn query.Function) query.Function {
+	if pCtx.linter == nil || len(fns) < 2 {
+		return fn
+	}
+
+	operands := make([]lintOperand, len(fns))
+	for i, f := range fns {
+		operands[i] = lintOperand{annotation: f.Annotation(), typ: lintTypeOf(f)}
+	}
+
+	for _, pass := range lintArithmeticPasses {
+		newOperands, newOps := []lintOperand{operands[0]}, []query.ArithmeticOperator{}
+		for i, op := range ops {
+			if !lintAcceptsOp(pass, op) {
+				newOperands = append(newOperands, operands[i+1])
+				newOps = append(newOps, op)
+				continue
+			}
+			lhs, rhs := &newOperands[len(newOperands)-1], operands[i+1]
+			typ, ok := lintArithmeticType(op, lhs.typ, rhs.typ)
+			if !ok {
+				pCtx.linter.add(pCtx.positionOf(input), "cannot %v types %v (from %v) and %v (from %v)", op, lhs.typ, lhs.annotation, rhs.typ, rhs.annotation)
+			}
+			*lhs = lintOperand{annotation: rhs.annotation, typ: typ}
+		}
+		operands, ops = newOperands, newOps
+	}
+	return pCtx.lintWithType(fn, operands[0].typ)
+}
+
+func lintAcceptsOp(ops []query.ArithmeticOperator, op query.ArithmeticOperator) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// lintField reports references to fields of the input document that cannot
+// exist according to the input schema, and otherwise associates the schema of
+// the field with the parsed query. A nil target means the field is referenced
+// from the context (this) of the query.
+func (pCtx Context) lintField(input []rune, target query.Function, pathStr string, fn query.Function) query.Function {
+	if pCtx.linter == nil || pCtx.linter.schema == nil {
+		return fn
+	}
+
+	var schema any
+	var path []string
+	if target == nil {
+		if pCtx.lintUnknownContext {
+			return fn
+		}
+		schema = pCtx.linter.schema
+	} else if t, ok := target.(*lintTyped); ok && t.schema != nil {
+		schema, path = t.schema, t.path
+	} else {
+		return fn
+	}
+
+	if pathStr != "" {
+		for _, seg := range gabs.DotPathToSlice(pathStr) {
+			path = append(path[:len(path):len(path)], seg)
+			if !schemaAllowsPath(schema, []string{seg}) {
+				pCtx.linter.add(pCtx.positionOf(input), "field `this.%v` does not exist according to the input schema", query.SliceToDotPath(path...))
+				return fn
+			}
+			if schema = schemaChild(schema, seg); schema == nil {
+				return fn
+			}
+		}
+	}
+	return &lintTyped{Function: fn, typ: schemaValueType(schema), schema: schema, path: path}
+}
+
+func (pCtx Context) lintLet(input []rune, name string) {
+	if pCtx.linter == nil {
+		return
+	}
+	pos := pCtx.positionOf(input)
+
+	pCtx.linter.mut.Lock()
+	defer pCtx.linter.mut.Unlock()
+	for _, l := range pCtx.linter.lets {
+		if l.position == pos {
+			return
+		}
+	}
+	pCtx.linter.lets = append(pCtx.linter.lets, lintLet{name: name, position: pos})
+}
+
+func (pCtx Context) lintVarUsed(name string) {
+	if pCtx.linter == nil {
+		return
+	}
+	pCtx.linter.mut.Lock()
+	pCtx.linter.usedVars[name] = struct{}{}
+	pCtx.linter.mut.Unlock()
+}
+
+// lintMatchCase contains the information of a parsed match case required for
+// static analysis.
+type lintMatchCase struct {
+	input     []rune
+	catchAll  bool
+	literal   any
+	isLiteral bool
+}
+
+// lintMatchCases reports match cases that can never be reached, either because
+// they follow a catch-all case or because they duplicate an earlier literal
+// case.
+func (pCtx Context) lintMatchCases(cases []lintMatchCase) {
+	if pCtx.linter == nil {
+		return
+	}
+	for i, c := range cases {
+		for _, prev := range cases[:i] {
+			if prev.catchAll {
+				pCtx.linter.add(pCtx.positionOf(c.input), "match case is unreachable as it follows a catch-all case")
+				break
+			}
+			if c.isLiteral && prev.isLiteral && query.ICompare(c.literal, prev.literal) {
+				pCtx.linter.add(pCtx.positionOf(c.input), "match case is unreachable as it duplicates an earlier case")
+				break
+			}
+		}
+	}
+}
+
+// lintObjectKeys reports static keys of an object literal that are shadowed
+// by a later key of the same name.
+func (pCtx Context) lintObjectKeys(input []rune, values [][2]any) {
+	if pCtx.linter == nil {
+		return
+	}
+	seen := map[string]struct{}{}
+	for i := len(values) - 1; i >= 0; i-- {
+		key, isStr := values[i][0].(string)
+		if !isStr {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			pCtx.linter.add(pCtx.positionOf(input), "object key `%v` is shadowed by a later value with the same key", key)
+		}
+		seen[key] = struct{}{}
+	}
+}
+
+// lintMaybeNothing marks that an expression has been parsed that might result
+// in nothing, such as an if expression without an else branch, in which case
+// an assignment of it might be skipped.
+func (pCtx Context) lintMaybeNothing() {
+	if pCtx.linter == nil {
+		return
+	}
+	pCtx.linter.mut.Lock()
+	pCtx.linter.nothings++
+	pCtx.linter.mut.Unlock()
+}
+
+func (pCtx Context) lintNothings() int {
+	if pCtx.linter == nil {
+		return 0
+	}
+	pCtx.linter.mut.Lock()
+	defer pCtx.linter.mut.Unlock()
+	return pCtx.linter.nothings
+}
+
+// lintAssignment records whether the query of a parsed assignment might
+// result in nothing.
+func (pCtx Context) lintAssignment(input []rune, maybeNothing bool) {
+	if pCtx.linter == nil {
+		return
+	}
+	pos := pCtx.positionOf(input)
+
+	pCtx.linter.mut.Lock()
+	pCtx.linter.maybeNothing[pos] = pCtx.linter.maybeNothing[pos] || maybeNothing
+	pCtx.linter.mut.Unlock()
+}
+
+func (pCtx Context) lintIsMaybeNothing(pos Position) bool {
+	pCtx.linter.mut.Lock()
+	defer pCtx.linter.mut.Unlock()
+
+	maybeNothing, exists := pCtx.linter.maybeNothing[pos]
+	return maybeNothing || !exists
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, p := range prefix {
+		if path[i] != p {
+			return false
+		}
+	}
+	return true
+}
+
+// lintShadowedAssignments reports assignments within a mapping, map or
+// function body that never have an effect as their target is always
+// overwritten by a later assignment before the value could be read. Later
+// assignments that might result in nothing, and are therefore skipped, are not
+// considered, and any statement in between that references `root` prevents the
+// earlier assignment from being reported.
+func (pCtx Context) lintShadowedAssignments(statements []mapping.Statement) {
+	if pCtx.linter == nil {
+		return
+	}
+
+	readsRoot := make([]bool, len(statements))
+	for i, s := range statements {
+		_, targets := s.Query().QueryTargets(query.TargetsContext{Maps: map[string]query.Function{}})
+		for _, t := range targets {
+			if t.Type == query.TargetRoot {
+				readsRoot[i] = true
+				break
+			}
+		}
+	}
+
+	for i, s := range statements {
+		target := s.Assignment().Target()
+		if target.Type != mapping.TargetValue || len(s.Input()) == 0 {
+			continue
+		}
+		for j := i + 1; j < len(statements); j++ {
+			if readsRoot[j] {
+				break
+			}
+			later := statements[j]
+			laterTarget := later.Assignment().Target()
+			if laterTarget.Type != mapping.TargetValue || len(later.Input()) == 0 || !isPathPrefix(laterTarget.Path, target.Path) {
+				continue
+			}
+			laterPos := pCtx.positionOf(later.Input())
+			if pCtx.lintIsMaybeNothing(laterPos) {
+				continue
+			}
+			pCtx.linter.add(pCtx.positionOf(s.Input()), "assignment to `%v` has no effect as it is always overwritten by the assignment to `%v` at line %v",
+				query.SliceToDotPath(append([]string{"root"}, target.Path...)...),
+				query.SliceToDotPath(append([]string{"root"}, laterTarget.Path...)...),
+				laterPos.Line,
+			)
+			break
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func schemaObj(schema any) map[string]any {
+	obj, _ := schema.(map[string]any)
+	return obj
+}
+
+// schemaType returns the single type of a schema, or an empty string if the
+// type is absent or ambiguous.
+func schemaType(obj map[string]any) string {
+	switch t := obj["type"].(type) {
+	case string:
+		return t
+	case []any:
+		if len(t) == 1 {
+			s, _ := t[0].(string)
+			return s
+		}
+	}
+	if _, exists := obj["properties"]; exists {
+		return "object"
+	}
+	return ""
+}
+
+// schemaValueType returns the type of the values of a schema, or
+// query.ValueUnknown if the type is absent or ambiguous.
+func schemaValueType(schema any) query.ValueType {
+	obj := schemaObj(schema)
+	if obj == nil {
+		return query.ValueUnknown
+	}
+	if c, exists := obj["const"]; exists {
+		return query.ITypeOf(c)
+	}
+	switch schemaType(obj) {
+	case "string":
+		return query.ValueString
+	case "integer", "number":
+		return query.ValueNumber
+	case "boolean":
+		return query.ValueBool
+	case "null":
+		return query.ValueNull
+	case "array":
+		return query.ValueArray
+	case "object":
+		return query.ValueObject
+	}
+	return query.ValueUnknown
+}
+
+// schemaChild returns the schema of a field of values that satisfy a schema,
+// or nil if it is unknown.
+func schemaChild(schema any, key string) any {
+	obj := schemaObj(schema)
+	if obj == nil {
+		return nil
+	}
+	switch schemaType(obj) {
+	case "object":
+		props, _ := obj["properties"].(map[string]any)
+		return props[key]
+	case "array":
+		if _, err := strconv.Atoi(key); err == nil {
+			return obj["items"]
+		}
+	}
+	return nil
+}
+
+// schemaAllowsPath returns false only when a path definitely cannot exist
+// within documents that satisfy a schema.
+func schemaAllowsPath(schema any, path []string) bool {
+	obj := schemaObj(schema)
+	if obj == nil || len(path) == 0 {
+		return true
+	}
+	switch schemaType(obj) {
+	case "object":
+		props, _ := obj["properties"].(map[string]any)
+		if p, exists := props[path[0]]; exists {
+			return schemaAllowsPath(p, path[1:])
+		}
+		if addl, isBool := obj["additionalProperties"].(bool); isBool && !addl {
+			return false
+		}
+		return true
+	case "array":
+		if _, err := strconv.Atoi(path[0]); err != nil {
+			return true
+		}
+		return schemaAllowsPath(obj["items"], path[1:])
+	case "string", "integer", "number", "boolean", "null":
+		return false
+	}
+	return true
+}
@@ -0,0 +1,183 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintMapping(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"age":  map[string]any{"type": "integer"},
+			"tags": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+			"anything": map[string]any{"type": []any{"string", "number"}},
+		},
+		"additionalProperties": false,
+	}
+
+	tests := map[string]struct {
+		mapping string
+		schema  any
+		lints   []string
+	}{
+		"clean mapping": {
+			mapping: `let n = this.name.uppercase()
+root.name = $n
+root.age = this.age + 1
+root.tags = this.tags.map_each(t -> t.uppercase())`,
+			schema: schema,
+		},
+		"literal type mismatch": {
+			mapping: `root.a = 5.uppercase()`,
+			lints: []string{
+				"line 1 char 12: method uppercase: expected string or bytes value, got number from number literal",
+			},
+		},
+		"schema type mismatch": {
+			mapping: `root.a = this.age.uppercase()
+root.b = this.name.uppercase().length().lowercase()
+root.c = this.anything.uppercase()
+root.d = this.tags.index(0).number()
+root.e = this.tags.0.abs()
+root.f = this.uppercase()`,
+			schema: schema,
+			lints: []string{
+				"line 1 char 19: method uppercase: expected string or bytes value, got number from field `this.age`",
+				"line 2 char 41: method lowercase: expected string or bytes value, got number from method length",
+				"line 5 char 22: method abs: expected number value, got string from field `this.tags.0`",
+				"line 6 char 15: method uppercase: expected string or bytes value, got object from field `this`",
+			},
+		},
+		"arithmetic mismatch": {
+			mapping: `root.a = this.name + this.age
+root.b = this.age + 10
+root.c = this.age * 2 + this.name
+root.d = this.name.length() > this.tags
+root.e = (this.age + 1).uppercase()`,
+			schema: schema,
+			lints: []string{
+				"line 1 char 10: cannot add types string (from field `this.name`) and number (from field `this.age`)",
+				"line 3 char 10: cannot add types number (from number literal) and string (from field `this.name`)",
+				"line 4 char 10: cannot compare types number (from method length) and array (from field `this.tags`)",
+				"line 5 char 25: method uppercase: expected string or bytes value, got number from number literal",
+			},
+		},
+		"unknown field": {
+			mapping: `root.a = this.nope
+root.b = this.name.nope
+root.c = nah
+root.d = this.tags.0`,
+			schema: schema,
+			lints: []string{
+				"line 1 char 15: field `this.nope` does not exist according to the input schema",
+				"line 2 char 20: field `this.name.nope` does not exist according to the input schema",
+				"line 3 char 10: field `this.nah` does not exist according to the input schema",
+			},
+		},
+		"unknown field without schema": {
+			mapping: `root.a = this.nope`,
+		},
+		"unused variables": {
+			mapping: `let a = "used"
+let b = "unused"
+map foo {
+  let c = "also unused"
+  root = this
+}
+root.a = $a`,
+			lints: []string{
+				"line 2 char 1: variable `b` is assigned but never used",
+				"line 4 char 3: variable `c` is assigned but never used",
+			},
+		},
+		"unreachable match cases": {
+			mapping: `root.a = match this.a {
+  "foo" => "first"
+  "bar" => "second"
+  "foo" => "third"
+  _ => "default"
+  "baz" => "never"
+}`,
+			lints: []string{
+				"line 4 char 3: match case is unreachable as it duplicates an earlier case",
+				"line 6 char 3: match case is unreachable as it follows a catch-all case",
+			},
+		},
+		"shadowed object keys": {
+			mapping: `root = {"a": 1, "b": 2, "a": 3}`,
+			lints: []string{
+				"line 1 char 8: object key `a` is shadowed by a later value with the same key",
+			},
+		},
+		"shadowed assignments": {
+			mapping: `root.a = this.a
+root.b.c = this.b
+root.b = this.c
+root.d = "default"
+root.d = if this.d != null { this.d }
+root.e = "first"
+root.f = root.e
+root.e = "second"
+map foo {
+  root.bar = "unused"
+  root = this
+}
+root.g = this.g
+root = this.apply("foo")`,
+			lints: []string{
+				"line 2 char 1: assignment to `root.b.c` has no effect as it is always overwritten by the assignment to `root.b` at line 3",
+				"line 10 char 3: assignment to `root.bar` has no effect as it is always overwritten by the assignment to `root` at line 11",
+			},
+		},
+		"shadowed assignments before a reference to root": {
+			mapping: `root.a = this.a
+root.b = root.a.uppercase()
+root = this`,
+			lints: []string{
+				"line 2 char 1: assignment to `root.b` has no effect as it is always overwritten by the assignment to `root` at line 3",
+			},
+		},
+		"context is unknown within maps and methods": {
+			mapping: `map foo {
+  root = this.nope.uppercase()
+}
+root.a = this.tags.map_each(this.nope)
+root.b = this.name.apply("foo")`,
+			schema: schema,
+		},
+		"function types": {
+			mapping: `root.a = count("lint_test").uppercase()
+root.b = uuid_v4().uppercase()
+root.c = content().length() + 1`,
+			lints: []string{
+				"line 1 char 29: method uppercase: expected string or bytes value, got number from function count",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			lints, perr := LintMapping(GlobalContext(), test.mapping, test.schema)
+			require.Nil(t, perr, "%v", perr)
+
+			var lintStrs []string
+			for _, l := range lints {
+				lintStrs = append(lintStrs, l.String())
+			}
+			assert.Equal(t, test.lints, lintStrs)
+		})
+	}
+}
+
+func TestLintMappingParseError(t *testing.T) {
+	_, perr := LintMapping(GlobalContext(), `root = this.`, nil)
+	require.NotNil(t, perr)
+}
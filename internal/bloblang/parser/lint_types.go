@@ -0,0 +1,219 @@
+package parser
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// lintSameType is the result type of methods that return a value of the same
+// type as their target.
+const lintSameType query.ValueType = "same"
+
+// lintMethodType describes the types of values that a method accepts as its
+// target and the type of value that it returns. An empty list of accepted types
+// means any type is accepted.
+type lintMethodType struct {
+	accepts []query.ValueType
+	returns query.ValueType
+}
+
+var (
+	lintStrTypes       = []query.ValueType{query.ValueString, query.ValueBytes}
+	lintNumTypes       = []query.ValueType{query.ValueNumber}
+	lintArrTypes       = []query.ValueType{query.ValueArray}
+	lintObjTypes       = []query.ValueType{query.ValueObject}
+	lintStructuredType = []query.ValueType{query.ValueArray, query.ValueObject}
+)
+
+// lintMethodTypes contains the types of the methods that are known to the
+// static analysis of mappings. Methods that are not listed here, or that are
+// only able to yield a value of some type when given a default argument, are
+// never reported and result in a value of an unknown type.
+var lintMethodTypes = map[string]lintMethodType{
+	// Strings
+	"capitalize":     {lintStrTypes, lintSameType},
+	"has_prefix":     {lintStrTypes, query.ValueBool},
+	"has_suffix":     {lintStrTypes, query.ValueBool},
+	"lowercase":      {lintStrTypes, lintSameType},
+	"re_match":       {lintStrTypes, query.ValueBool},
+	"re_replace_all": {lintStrTypes, lintSameType},
+	"replace_all":    {lintStrTypes, lintSameType},
+	"split":          {lintStrTypes, query.ValueArray},
+	"trim":           {lintStrTypes, lintSameType},
+	"uppercase":      {lintStrTypes, lintSameType},
+
+	// Numbers
+	"abs":   {lintNumTypes, query.ValueNumber},
+	"ceil":  {lintNumTypes, query.ValueNumber},
+	"floor": {lintNumTypes, query.ValueNumber},
+	"log":   {lintNumTypes, query.ValueNumber},
+	"log10": {lintNumTypes, query.ValueNumber},
+	"round": {lintNumTypes, query.ValueNumber},
+
+	// Objects and arrays
+	"contains":   {[]query.ValueType{query.ValueString, query.ValueBytes, query.ValueArray, query.ValueObject}, query.ValueBool},
+	"filter":     {lintStructuredType, lintSameType},
+	"join":       {lintArrTypes, query.ValueString},
+	"keys":       {lintObjTypes, query.ValueArray},
+	"key_values": {lintObjTypes, query.ValueArray},
+	"length":     {[]query.ValueType{query.ValueString, query.ValueBytes, query.ValueArray, query.ValueObject}, query.ValueNumber},
+	"map_each":   {lintStructuredType, lintSameType},
+	"max":        {lintArrTypes, query.ValueNumber},
+	"min":        {lintArrTypes, query.ValueNumber},
+	"slice":      {[]query.ValueType{query.ValueString, query.ValueBytes, query.ValueArray}, lintSameType},
+	"sum":        {[]query.ValueType{query.ValueNumber, query.ValueArray}, query.ValueNumber},
+	"values":     {lintObjTypes, query.ValueArray},
+
+	// Coercion
+	"bytes":       {nil, query.ValueBytes},
+	"format_json": {nil, query.ValueBytes},
+	"not_null":    {nil, lintSameType},
+	"string":      {nil, query.ValueString},
+	"type":        {nil, query.ValueString},
+}
+
+// lintFunctionTypes contains the types of values returned by the functions
+// that are known to the static analysis of mappings.
+var lintFunctionTypes = map[string]query.ValueType{
+	"batch_index":         query.ValueNumber,
+	"batch_size":          query.ValueNumber,
+	"content":             query.ValueBytes,
+	"count":               query.ValueNumber,
+	"ksuid":               query.ValueString,
+	"nanoid":              query.ValueString,
+	"now":                 query.ValueString,
+	"random_int":          query.ValueNumber,
+	"timestamp_unix":      query.ValueNumber,
+	"timestamp_unix_nano": query.ValueNumber,
+	"uuid_v4":             query.ValueString,
+}
+
+// lintTyped wraps a parsed query function with the type of the values that it
+// yields, which is how types are propagated from the operands and targets of a
+// query to the functions, methods and expressions that consume them. When the
+// value is a field of the input document the schema and path of the field are
+// also known.
+type lintTyped struct {
+	query.Function
+
+	typ    query.ValueType
+	schema any
+	path   []string
+}
+
+// lintTypeOf returns the type of the values yielded by a parsed query function,
+// or query.ValueUnknown if the type is not known.
+func lintTypeOf(fn query.Function) query.ValueType {
+	switch t := fn.(type) {
+	case *lintTyped:
+		return t.typ
+	case *query.Literal:
+		switch t.Value.(type) {
+		case query.Delete, query.Nothing:
+			return query.ValueUnknown
+		}
+		return query.ITypeOf(t.Value)
+	}
+	return query.ValueUnknown
+}
+
+// lintWithType associates a type with a parsed query function. Literals are
+// never wrapped as their type is already known, and the parser relies on
+// detecting them.
+func (pCtx Context) lintWithType(fn query.Function, typ query.ValueType) query.Function {
+	if pCtx.linter == nil || typ == query.ValueUnknown {
+		return fn
+	}
+	if _, isLit := fn.(*query.Literal); isLit {
+		return fn
+	}
+	return &lintTyped{Function: fn, typ: typ}
+}
+
+// lintUnwrap returns the query function wrapped by lintWithType, which must be
+// used for constructors that inspect the function that they are given.
+func lintUnwrap(fn query.Function) query.Function {
+	if t, ok := fn.(*lintTyped); ok {
+		return t.Function
+	}
+	return fn
+}
+
+func lintAccepts(accepts []query.ValueType, typ query.ValueType) bool {
+	if len(accepts) == 0 {
+		return true
+	}
+	for _, t := range accepts {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+//------------------------------------------------------------------------------
+
+// lintOperand is an operand of an arithmetic expression during the resolution
+// of its types.
+type lintOperand struct {
+	annotation string
+	typ        query.ValueType
+}
+
+func lintIsStr(t query.ValueType) bool {
+	return t == query.ValueString || t == query.ValueBytes
+}
+
+// lintArithmeticType returns the type of the result of an arithmetic operation
+// on two operands, or false if the types of the operands definitely cannot be
+// combined by the operation.
+func lintArithmeticType(op query.ArithmeticOperator, lhs, rhs query.ValueType) (query.ValueType, bool) {
+	known := lhs != query.ValueUnknown && rhs != query.ValueUnknown
+	switch op {
+	case query.ArithmeticMul, query.ArithmeticDiv, query.ArithmeticMod, query.ArithmeticSub:
+		if known && (lhs != query.ValueNumber || rhs != query.ValueNumber) {
+			return query.ValueUnknown, false
+		}
+		return query.ValueNumber, true
+	case query.ArithmeticAdd:
+		switch {
+		case lhs == query.ValueNumber:
+			if known && rhs != query.ValueNumber {
+				return query.ValueUnknown, false
+			}
+			return query.ValueNumber, true
+		case lintIsStr(lhs):
+			if known && !lintIsStr(rhs) && rhs != query.ValueTimestamp {
+				return query.ValueUnknown, false
+			}
+			return query.ValueString, true
+		case known:
+			return query.ValueUnknown, false
+		}
+		return query.ValueUnknown, true
+	case query.ArithmeticEq, query.ArithmeticNeq:
+		return query.ValueBool, true
+	case query.ArithmeticGt, query.ArithmeticLt, query.ArithmeticGte, query.ArithmeticLte:
+		if known {
+			switch {
+			case lintIsStr(lhs):
+				if !lintIsStr(rhs) && rhs != query.ValueTimestamp {
+					return query.ValueUnknown, false
+				}
+			case lhs == query.ValueNumber:
+				if rhs != query.ValueNumber {
+					return query.ValueUnknown, false
+				}
+			default:
+				return query.ValueUnknown, false
+			}
+		}
+		return query.ValueBool, true
+	case query.ArithmeticAnd, query.ArithmeticOr:
+		return query.ValueBool, true
+	case query.ArithmeticPipe:
+		if lhs == rhs {
+			return lhs, true
+		}
+	}
+	return query.ValueUnknown, true
+}
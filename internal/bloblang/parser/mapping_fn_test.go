@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestMappingUserFunctions(t *testing.T) {
+	dir := t.TempDir()
+
+	fnsFile := filepath.Join(dir, "fns.blobl")
+	require.NoError(t, os.WriteFile(fnsFile, []byte(`fn greet(name) {
+  root = "hello " + $name
+}`), 0o777))
+
+	tests := map[string]struct {
+		mapping string
+		input   string
+		output  string
+	}{
+		"called as function": {
+			mapping: `fn add(a, b) {
+  root = $a + $b
+}
+root.sum = add(this.x, 3)`,
+			input:  `{"x":2}`,
+			output: `{"sum":5}`,
+		},
+		"called with named args": {
+			mapping: `fn sub(a, b) {
+  root = $a - $b
+}
+root.diff = sub(b: 2, a: 10)`,
+			input:  `{}`,
+			output: `{"diff":8}`,
+		},
+		"called as method": {
+			mapping: `fn wrap(key) {
+  root.key = $key
+  root.value = this.uppercase()
+}
+root = this.name.wrap("NAME")`,
+			input:  `{"name":"foo"}`,
+			output: `{"key":"NAME","value":"FOO"}`,
+		},
+		"no params": {
+			mapping: `fn nowt() {
+  root = "nothing"
+}
+root.a = nowt()`,
+			input:  `{}`,
+			output: `{"a":"nothing"}`,
+		},
+		"variables are isolated": {
+			mapping: `fn double(v) {
+  let tmp = $v * 2
+  root = $tmp
+}
+let tmp = "outer"
+root.a = double(4)
+root.b = $tmp`,
+			input:  `{}`,
+			output: `{"a":8,"b":"outer"}`,
+		},
+		"recursive": {
+			mapping: `fn fact(n) {
+  root = if $n <= 1 { 1 } else { $n * fact($n - 1) }
+}
+root.res = fact(this.n)`,
+			input:  `{"n":5}`,
+			output: `{"res":120}`,
+		},
+		"calls maps": {
+			mapping: `map upper {
+  root = this.uppercase()
+}
+fn upper_both(a, b) {
+  root = [ $a.apply("upper"), $b.apply("upper") ]
+}
+root = upper_both(this.a, this.b)`,
+			input:  `{"a":"foo","b":"bar"}`,
+			output: `["FOO","BAR"]`,
+		},
+		"imported": {
+			mapping: `import "` + fnsFile + `"
+root = greet(this.name)`,
+			input:  `{"name":"bob"}`,
+			output: `hello bob`,
+		},
+		"field named fn": {
+			mapping: `fn = "still a field"`,
+			input:   `{}`,
+			output:  `{"fn":"still a field"}`,
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			exec, perr := ParseMapping(GlobalContext(), test.mapping)
+			require.Nil(t, perr, "%v", perr)
+
+			res, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(test.input)}))
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(res.AsBytes()))
+		})
+	}
+}
+
+func TestMappingUserFunctionErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	noFnsFile := filepath.Join(dir, "no_fns.blobl")
+	require.NoError(t, os.WriteFile(noFnsFile, []byte(`root = "nope"`), 0o777))
+
+	tests := map[string]struct {
+		mapping     string
+		errContains string
+	}{
+		"name collision": {
+			mapping: `fn foo(a) {
+  root = $a
+}
+fn foo(b) {
+  root = $b
+}`,
+			errContains: "function name collision: foo",
+		},
+		"builtin function collision": {
+			mapping: `fn uuid_v4() {
+  root = "nope"
+}`,
+			errContains: "collides with an existing function: uuid_v4",
+		},
+		"builtin method collision": {
+			mapping: `fn uppercase(a) {
+  root = $a
+}`,
+			errContains: "collides with an existing method: uppercase",
+		},
+		"duplicate param": {
+			mapping: `fn foo(a, a) {
+  root = $a
+}`,
+			errContains: "duplicate parameter name: a",
+		},
+		"wrong arg count": {
+			mapping: `fn foo(a, b) {
+  root = $a
+}
+root = foo(1)`,
+			errContains: "missing parameter: b",
+		},
+		"called before definition": {
+			mapping: `root = foo(1)
+fn foo(a) {
+  root = $a
+}`,
+			errContains: "unrecognised function 'foo'",
+		},
+		"no functions or maps in import": {
+			mapping:     `import "` + noFnsFile + `"`,
+			errContains: "no maps or functions to import",
+		},
+	}
+
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, perr := ParseMapping(GlobalContext(), test.mapping)
+			require.NotNil(t, perr)
+			assert.Contains(t, perr.ErrorAtPosition([]rune(test.mapping)), test.errContains)
+		})
+	}
+}
+
+func TestMappingUserFunctionRecursionLimit(t *testing.T) {
+	exec, perr := ParseMapping(GlobalContext(), `fn forever(n) {
+  root = forever($n + 1)
+}
+root = forever(0)`)
+	require.Nil(t, perr)
+
+	_, err := exec.MapPart(0, message.QuickBatch([][]byte{[]byte(`{}`)}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exceeded maximum allowed stacks")
+}
@@ -18,6 +18,7 @@ import (
 // messages.
 func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 	in := []rune(expr)
+	pCtx = pCtx.withUserFunctions().withInstrumentSource("", in)
 
 	resDirectImport := singleRootImport(pCtx)(in)
 	if resDirectImport.Err != nil && resDirectImport.Err.IsFatal() {
@@ -27,17 +28,28 @@ func ParseMapping(pCtx Context, expr string) (*mapping.Executor, *Error) {
 		return resDirectImport.Payload.(*mapping.Executor), nil
 	}
 
-	resExe := parseExecutor(pCtx)(in)
+	// When linting, the findings of each parse attempt are isolated so that
+	// only those of the successful attempt are reported.
+	exeCtx, singleCtx := pCtx.lintFork(), pCtx.lintFork()
+
+	resExe := parseExecutor(exeCtx)(in)
 	if resExe.Err != nil && resExe.Err.IsFatal() {
 		return nil, resExe.Err
 	}
-	resSingle := singleRootMapping(pCtx)(in)
+	resSingle := singleRootMapping(singleCtx)(in)
 
 	res := bestMatch(resExe, resSingle)
 	if res.Err != nil {
 		return nil, res.Err
 	}
-	return res.Payload.(*mapping.Executor), nil
+
+	exec := res.Payload.(*mapping.Executor)
+	if exec == resExe.Payload {
+		pCtx.lintMerge(exeCtx)
+	} else {
+		pCtx.lintMerge(singleCtx)
+	}
+	return exec, nil
 }
 
 //------------------------------------------------------------------------------'
@@ -54,6 +66,7 @@ func parseExecutor(pCtx Context) Func {
 		statement := OneOf(
 			importParser(maps, pCtx),
 			mapParser(maps, pCtx),
+			fnParser(pCtx),
 			letStatementParser(pCtx),
 			metaStatementParser(false, pCtx),
 			plainMappingStatementParser(pCtx),
@@ -92,6 +105,7 @@ func parseExecutor(pCtx Context) Func {
 				statements = append(statements, mStmt)
 			}
 		}
+		pCtx.lintShadowedAssignments(statements)
 		return Success(mapping.NewExecutor("", input, maps, statements...), res.Remaining)
 	}
 }
@@ -120,9 +134,9 @@ func singleRootImport(pCtx Context) Func {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
 		importContent := []rune(string(contents))
+		nextCtx := pCtx.WithImporterRelativeToFile(fpath).withInstrumentSource(fpath, importContent)
+
 		execRes := parseExecutor(nextCtx)(importContent)
 		if execRes.Err != nil {
 			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
@@ -161,7 +175,7 @@ func singleRootMapping(pCtx Context) Func {
 			return Fail(NewError(res.Remaining, expStr), input)
 		}
 
-		stmt := mapping.NewStatement(input, mapping.NewJSONAssignment(), fn)
+		stmt := mapping.NewStatement(input, mapping.NewJSONAssignment(), pCtx.profileStatement(input, fn))
 		return Success(mapping.NewExecutor("", input, map[string]query.Function{}, stmt), nil)
 	}
 }
@@ -205,17 +219,21 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 			return Fail(NewFatalError(input, fmt.Errorf("failed to read import: %w", err)), input)
 		}
 
-		nextCtx := pCtx.WithImporterRelativeToFile(fpath)
-
 		importContent := []rune(string(contents))
+		nextCtx := pCtx.WithImporterRelativeToFile(fpath).withInstrumentSource(fpath, importContent)
+
+		// Functions defined within the imported file are added to the shared
+		// set of user functions.
+		fnsBefore := pCtx.userFns.len()
+
 		execRes := parseExecutor(nextCtx)(importContent)
 		if execRes.Err != nil {
 			return Fail(NewFatalError(input, NewImportError(fpath, importContent, execRes.Err)), input)
 		}
 
 		exec := execRes.Payload.(*mapping.Executor)
-		if len(exec.Maps()) == 0 {
-			err := fmt.Errorf("no maps to import from '%v'", fpath)
+		if len(exec.Maps()) == 0 && pCtx.userFns.len() == fnsBefore {
+			err := fmt.Errorf("no maps or functions to import from '%v'", fpath)
 			return Fail(NewFatalError(input, err), input)
 		}
 
@@ -237,6 +255,7 @@ func importParser(maps map[string]query.Function, pCtx Context) Func {
 }
 
 func mapParser(maps map[string]query.Function, pCtx Context) Func {
+	bodyCtx := pCtx.lintWithUnknownContext()
 	newline := NewlineAllowComment()
 	whitespace := SpacesAndTabs()
 	allWhitespace := DiscardAll(OneOf(whitespace, newline))
@@ -261,9 +280,9 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 				allWhitespace,
 			),
 			OneOf(
-				letStatementParser(pCtx),
-				metaStatementParser(true, pCtx), // Prevented for now due to .from(int)
-				plainMappingStatementParser(pCtx),
+				letStatementParser(bodyCtx),
+				metaStatementParser(true, bodyCtx), // Prevented for now due to .from(int)
+				plainMappingStatementParser(bodyCtx),
 			),
 			Sequence(
 				Discard(whitespace),
@@ -296,6 +315,7 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 		for i, v := range stmtSlice {
 			statements[i] = v.(mapping.Statement)
 		}
+		pCtx.lintShadowedAssignments(statements)
 
 		maps[ident] = mapping.NewExecutor("map "+ident, input, maps, statements...)
 
@@ -303,6 +323,193 @@ func mapParser(maps map[string]query.Function, pCtx Context) Func {
 	}
 }
 
+//------------------------------------------------------------------------------
+
+// userFunction is a function defined within a mapping with the fn keyword. The
+// body of the function is parsed after the function has been registered in
+// order to allow recursive calls.
+type userFunction struct {
+	name   string
+	params query.Params
+	body   *mapping.Executor
+}
+
+type userFunctionSet struct {
+	fns map[string]*userFunction
+}
+
+func (u *userFunctionSet) len() int {
+	if u == nil {
+		return 0
+	}
+	return len(u.fns)
+}
+
+func (u *userFunctionSet) get(name string) (*userFunction, bool) {
+	if u == nil {
+		return nil, false
+	}
+	fn, exists := u.fns[name]
+	return fn, exists
+}
+
+// newUserFunctionCall creates a query function that executes a user defined
+// function. When the target is non-nil the function is being called as a
+// method and the target becomes the context of the function body.
+func newUserFunctionCall(fn *userFunction, target query.Function, args *query.ParsedParams, rawArgs []any) query.Function {
+	annotation := "function " + fn.name
+	if target != nil {
+		annotation = "method " + fn.name
+	}
+
+	return query.ClosureFunction(annotation, func(ctx query.FunctionContext) (any, error) {
+		resolved, err := args.ResolveDynamic(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if target != nil {
+			v, err := target.Exec(ctx)
+			if err != nil {
+				return nil, err
+			}
+			ctx = ctx.WithValue(v)
+		}
+
+		// The body of a function is given a fresh set of variables containing
+		// only its parameters, so that it neither observes nor modifies the
+		// variables of the mapping that calls it.
+		ctx.Vars = make(map[string]any, len(fn.params.Definitions))
+		for i, p := range fn.params.Definitions {
+			if ctx.Vars[p.Name], err = resolved.Index(i); err != nil {
+				return nil, err
+			}
+		}
+		return fn.body.Exec(ctx)
+	}, func(ctx query.TargetsContext) (query.TargetsContext, []query.TargetPath) {
+		var paths []query.TargetPath
+		for _, arg := range rawArgs {
+			if nArg, ok := arg.(namedArg); ok {
+				arg = nArg.value
+			}
+			if argFn, ok := arg.(query.Function); ok {
+				_, argPaths := argFn.QueryTargets(ctx)
+				paths = append(paths, argPaths...)
+			}
+		}
+		if target != nil {
+			var targetPaths []query.TargetPath
+			ctx, targetPaths = target.QueryTargets(ctx)
+			paths = append(paths, targetPaths...)
+		}
+		return ctx, paths
+	})
+}
+
+func fnParamsParser() Func {
+	whitespace := DiscardAll(OneOf(SpacesAndTabs(), NewlineAllowComment()))
+	return DelimitedPattern(
+		Sequence(Char('('), whitespace),
+		Expect(varNameParser(), "parameter name"),
+		Sequence(Discard(SpacesAndTabs()), Char(','), whitespace),
+		Sequence(whitespace, Char(')')),
+		false,
+	)
+}
+
+func fnParser(pCtx Context) Func {
+	bodyCtx := pCtx.lintWithUnknownContext()
+	newline := NewlineAllowComment()
+	whitespace := SpacesAndTabs()
+	allWhitespace := DiscardAll(OneOf(whitespace, newline))
+
+	signature := Sequence(
+		Term("fn"),
+		whitespace,
+		Expect(SnakeCase(), "function name"),
+		Discard(SpacesAndTabs()),
+		Expect(fnParamsParser(), "function parameters"),
+		Discard(SpacesAndTabs()),
+	)
+
+	body := DelimitedPattern(
+		Sequence(
+			Char('{'),
+			allWhitespace,
+		),
+		OneOf(
+			letStatementParser(bodyCtx),
+			metaStatementParser(true, bodyCtx),
+			plainMappingStatementParser(bodyCtx),
+		),
+		Sequence(
+			Discard(whitespace),
+			newline,
+			allWhitespace,
+		),
+		Sequence(
+			allWhitespace,
+			Char('}'),
+		),
+		true,
+	)
+
+	return func(input []rune) Result {
+		res := signature(input)
+		if res.Err != nil {
+			return res
+		}
+
+		seqSlice := res.Payload.([]any)
+		ident := seqSlice[2].(string)
+
+		if pCtx.userFns == nil {
+			return Fail(NewFatalError(input, errors.New("functions cannot be defined in this context")), input)
+		}
+		if _, exists := pCtx.userFns.get(ident); exists {
+			return Fail(NewFatalError(input, fmt.Errorf("function name collision: %v", ident)), input)
+		}
+		if _, err := pCtx.Functions.Params(ident); err == nil {
+			return Fail(NewFatalError(input, fmt.Errorf("function name collides with an existing function: %v", ident)), input)
+		}
+		if _, err := pCtx.Methods.Params(ident); err == nil {
+			return Fail(NewFatalError(input, fmt.Errorf("function name collides with an existing method: %v", ident)), input)
+		}
+
+		params := query.NewParams()
+		seen := map[string]struct{}{}
+		for _, p := range seqSlice[4].([]any) {
+			pName := p.(string)
+			if _, exists := seen[pName]; exists {
+				return Fail(NewFatalError(input, fmt.Errorf("duplicate parameter name: %v", pName)), input)
+			}
+			seen[pName] = struct{}{}
+			params = params.Add(query.ParamAny(pName, ""))
+		}
+
+		// Register the function before parsing the body so that it can be
+		// called recursively.
+		fn := &userFunction{name: ident, params: params}
+		pCtx.userFns.fns[ident] = fn
+
+		bodyRes := MustBe(Expect(body, "function body"))(res.Remaining)
+		if bodyRes.Err != nil {
+			delete(pCtx.userFns.fns, ident)
+			return Fail(bodyRes.Err, input)
+		}
+
+		stmtSlice := bodyRes.Payload.([]any)
+		statements := make([]mapping.Statement, len(stmtSlice))
+		for i, v := range stmtSlice {
+			statements[i] = v.(mapping.Statement)
+		}
+		pCtx.lintShadowedAssignments(statements)
+		fn.body = mapping.NewExecutor("fn "+ident, input, nil, statements...)
+
+		return Success(ident, bodyRes.Remaining)
+	}
+}
+
 func letStatementParser(pCtx Context) Func {
 	p := Sequence(
 		Expect(Term("let"), "assignment"),
@@ -329,11 +536,12 @@ func letStatementParser(pCtx Context) Func {
 			return res
 		}
 		resSlice := res.Payload.([]any)
+		pCtx.lintLet(input, resSlice[2].(string))
 		return Success(
 			mapping.NewStatement(
 				input,
 				mapping.NewVarAssignment(resSlice[2].(string)),
-				resSlice[6].(query.Function),
+				pCtx.profileStatement(input, resSlice[6].(query.Function)),
 			),
 			res.Remaining,
 		)
@@ -390,7 +598,7 @@ func metaStatementParser(disabled bool, pCtx Context) Func {
 			mapping.NewStatement(
 				input,
 				mapping.NewMetaAssignment(keyPtr),
-				resSlice[6].(query.Function),
+				pCtx.profileStatement(input, resSlice[6].(query.Function)),
 			),
 			res.Remaining,
 		)
@@ -479,10 +687,13 @@ func plainMappingStatementParser(pCtx Context) Func {
 	)
 
 	return func(input []rune) Result {
+		nothingsBefore := pCtx.lintNothings()
 		res := p(input)
 		if res.Err != nil {
 			return res
 		}
+		pCtx.lintAssignment(input, pCtx.lintNothings() != nothingsBefore)
+
 		resSlice := res.Payload.([]any)
 		path := resSlice[0].([]string)
 
@@ -494,7 +705,7 @@ func plainMappingStatementParser(pCtx Context) Func {
 			mapping.NewStatement(
 				input,
 				mapping.NewJSONAssignment(path...),
-				resSlice[4].(query.Function),
+				pCtx.profileStatement(input, resSlice[4].(query.Function)),
 			),
 			res.Remaining,
 		)
@@ -69,7 +69,7 @@ func arithmeticOpParser() Func {
 	}
 }
 
-func arithmeticParser(fnParser Func) Func {
+func arithmeticParser(pCtx Context, fnParser Func) Func {
 	whitespace := DiscardAll(
 		OneOf(
 			SpacesAndTabs(),
@@ -125,6 +125,7 @@ func arithmeticParser(fnParser Func) Func {
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
+		fn = pCtx.lintArithmetic(input, fns, ops, fn)
 		return Success(fn, res.Remaining)
 	}
 }
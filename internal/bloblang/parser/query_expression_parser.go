@@ -6,6 +6,11 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 )
 
+type parsedMatchCase struct {
+	mCase query.MatchCase
+	lint  lintMatchCase
+}
+
 func matchCaseParser(pCtx Context) Func {
 	whitespace := SpacesAndTabs()
 
@@ -39,11 +44,13 @@ func matchCaseParser(pCtx Context) Func {
 		}
 
 		seqSlice := res.Payload.([]any)
+		lintCase := lintMatchCase{input: input}
 
 		var caseFn query.Function
 		switch t := seqSlice[0].([]any)[0].(type) {
 		case query.Function:
 			if lit, isLiteral := t.(*query.Literal); isLiteral {
+				lintCase.literal, lintCase.isLiteral = lit.Value, true
 				caseFn = query.ClosureFunction("case statement", func(ctx query.FunctionContext) (any, error) {
 					v := ctx.Value()
 					if v == nil {
@@ -55,11 +62,15 @@ func matchCaseParser(pCtx Context) Func {
 				caseFn = t
 			}
 		case string:
+			lintCase.catchAll = true
 			caseFn = query.NewLiteralFunction("", true)
 		}
 
 		return Success(
-			query.NewMatchCase(caseFn, seqSlice[2].(query.Function)),
+			parsedMatchCase{
+				mCase: query.NewMatchCase(caseFn, pCtx.coverBranch(input, "match case", seqSlice[2].(query.Function))),
+				lint:  lintCase,
+			},
 			res.Remaining,
 		)
 	}
@@ -85,7 +96,7 @@ func matchExpressionParser(pCtx Context) Func {
 						Char('{'),
 						whitespace,
 					),
-					matchCaseParser(pCtx),
+					matchCaseParser(pCtx.lintWithUnknownContext()),
 					Sequence(
 						Discard(SpacesAndTabs()),
 						OneOf(
@@ -110,9 +121,14 @@ func matchExpressionParser(pCtx Context) Func {
 		contextFn, _ := seqSlice[2].(query.Function)
 
 		cases := []query.MatchCase{}
+		var lintCases []lintMatchCase
 		for _, caseVal := range seqSlice[4].([]any) {
-			cases = append(cases, caseVal.(query.MatchCase))
+			pCase := caseVal.(parsedMatchCase)
+			cases = append(cases, pCase.mCase)
+			lintCases = append(lintCases, pCase.lint)
 		}
+		pCtx.lintMatchCases(lintCases)
+		pCtx.lintMaybeNothing()
 
 		res.Payload = query.NewMatchFunction(contextFn, cases...)
 		return res
@@ -171,10 +187,11 @@ func ifExpressionParser(pCtx Context) Func {
 
 		seqSlice := res.Payload.([]any)
 		queryFn := seqSlice[2].(query.Function)
-		ifFn := seqSlice[6].(query.Function)
+		ifFn := pCtx.coverBranch(input, "if", seqSlice[6].(query.Function))
 
 		var elseIfs []query.ElseIf
 		for {
+			branchInput := optionalWhitespace(res.Remaining).Remaining
 			res = elseIfParser(res.Remaining)
 			if res.Err != nil {
 				return res
@@ -185,20 +202,26 @@ func ifExpressionParser(pCtx Context) Func {
 			seqSlice = res.Payload.([]any)
 			elseIfs = append(elseIfs, query.ElseIf{
 				QueryFn: seqSlice[3].(query.Function),
-				MapFn:   seqSlice[7].(query.Function),
+				MapFn:   pCtx.coverBranch(branchInput, "else if", seqSlice[7].(query.Function)),
 			})
 		}
 
 		var elseFn query.Function
 
+		branchInput := optionalWhitespace(res.Remaining).Remaining
 		res = elseParser(res.Remaining)
 		if res.Err != nil {
 			return res
 		}
 		if res.Payload != nil {
-			elseFn, _ = res.Payload.([]any)[5].(query.Function)
+			if elseFn, _ = res.Payload.([]any)[5].(query.Function); elseFn != nil {
+				elseFn = pCtx.coverBranch(branchInput, "else", elseFn)
+			}
 		}
 
+		if elseFn == nil {
+			pCtx.lintMaybeNothing()
+		}
 		res.Payload = query.NewIfFunction(queryFn, ifFn, elseIfs, elseFn)
 		return res
 	}
@@ -77,12 +77,12 @@ func parseFunctionTail(fn query.Function, pCtx Context) Func {
 			Sequence(
 				Expect(openBracket, "method"),
 				whitespace,
-				queryParser(pCtx),
+				queryParser(pCtx.lintWithUnknownContext()),
 				whitespace,
 				closeBracket,
 			),
 			methodParser(fn, pCtx),
-			fieldLiteralMapParser(fn),
+			fieldLiteralMapParser(fn, pCtx),
 		)(input)
 		if seqSlice, isSlice := res.Payload.([]any); isSlice {
 			method, err := query.NewMapMethod(fn, seqSlice[2].(query.Function))
@@ -159,7 +159,7 @@ func quotedPathSegmentParser() Func {
 	}
 }
 
-func fieldLiteralMapParser(ctxFn query.Function) Func {
+func fieldLiteralMapParser(ctxFn query.Function, pCtx Context) Func {
 	fieldPathParser := Expect(
 		OneOf(
 			JoinStringPayloads(
@@ -183,16 +183,17 @@ func fieldLiteralMapParser(ctxFn query.Function) Func {
 			return res
 		}
 
-		fn, err := query.NewGetMethod(ctxFn, res.Payload.(string))
+		fn, err := query.NewGetMethod(lintUnwrap(ctxFn), res.Payload.(string))
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
 
+		fn = pCtx.lintField(input, ctxFn, res.Payload.(string), fn)
 		return Success(fn, res.Remaining)
 	}
 }
 
-func variableLiteralParser() Func {
+func variableLiteralParser(pCtx Context) Func {
 	varPathParser := Expect(
 		Sequence(
 			Char('$'),
@@ -219,6 +220,7 @@ func variableLiteralParser() Func {
 		path := res.Payload.([]any)[1].(string)
 		fn := query.NewVarFunction(path)
 
+		pCtx.lintVarUsed(path)
 		return Success(fn, res.Remaining)
 	}
 }
@@ -248,14 +250,14 @@ func fieldLiteralRootParser(pCtx Context) Func {
 
 		path := res.Payload.(string)
 		if path == "this" {
-			fn = query.NewFieldFunction("")
+			fn = pCtx.lintField(input, nil, "", query.NewFieldFunction(""))
 		} else if path == "root" {
 			fn = query.NewRootFieldFunction("")
 		} else {
 			if pCtx.HasNamedContext(path) {
 				fn = query.NewNamedContextFieldFunction(path, "")
 			} else {
-				fn = query.NewFieldFunction(path)
+				fn = pCtx.lintField(input, nil, path, query.NewFieldFunction(path))
 			}
 		}
 
@@ -306,7 +308,7 @@ func methodParser(fn query.Function, pCtx Context) Func {
 			SnakeCase(),
 			"method",
 		),
-		functionArgsParser(pCtx),
+		functionArgsParser(pCtx.lintWithUnknownContext()),
 	)
 
 	return func(input []rune) Result {
@@ -318,6 +320,15 @@ func methodParser(fn query.Function, pCtx Context) Func {
 		seqSlice := res.Payload.([]any)
 
 		targetMethod := seqSlice[0].(string)
+		if userFn, exists := pCtx.userFns.get(targetMethod); exists {
+			parsedParams, err := extractArgsParserResult(userFn.params, seqSlice[1].([]any))
+			if err != nil {
+				return Fail(NewFatalError(input, err), input)
+			}
+			pCtx.lintMaybeNothing()
+			return Success(pCtx.profileCall(input, "method", targetMethod, newUserFunctionCall(userFn, fn, parsedParams, seqSlice[1].([]any))), res.Remaining)
+		}
+
 		params, err := pCtx.Methods.Params(targetMethod)
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
@@ -332,7 +343,11 @@ func methodParser(fn query.Function, pCtx Context) Func {
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
-		return Success(method, res.Remaining)
+		method = pCtx.lintMethod(input, targetMethod, fn, method)
+		if targetMethod == "apply" {
+			pCtx.lintMaybeNothing()
+		}
+		return Success(pCtx.profileCall(input, "method", targetMethod, method), res.Remaining)
 	}
 }
 
@@ -354,6 +369,15 @@ func functionParser(pCtx Context) Func {
 		seqSlice := res.Payload.([]any)
 
 		targetFunc := seqSlice[0].(string)
+		if userFn, exists := pCtx.userFns.get(targetFunc); exists {
+			parsedParams, err := extractArgsParserResult(userFn.params, seqSlice[1].([]any))
+			if err != nil {
+				return Fail(NewFatalError(input, err), input)
+			}
+			pCtx.lintMaybeNothing()
+			return Success(pCtx.profileCall(input, "function", targetFunc, newUserFunctionCall(userFn, nil, parsedParams, seqSlice[1].([]any))), res.Remaining)
+		}
+
 		params, err := pCtx.Functions.Params(targetFunc)
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
@@ -368,6 +392,10 @@ func functionParser(pCtx Context) Func {
 		if err != nil {
 			return Fail(NewFatalError(input, err), input)
 		}
-		return Success(fn, res.Remaining)
+		if targetFunc == "nothing" {
+			pCtx.lintMaybeNothing()
+		}
+		fn = pCtx.lintFunction(targetFunc, fn)
+		return Success(pCtx.profileCall(input, "function", targetFunc, fn), res.Remaining)
 	}
 }
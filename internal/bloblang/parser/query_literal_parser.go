@@ -86,6 +86,8 @@ func dynamicObjectParser(pCtx Context) Func {
 			values = append(values, [2]any{slice[0], slice[4]})
 		}
 
+		pCtx.lintObjectKeys(input, values)
+
 		lit, err := query.NewMapLiteral(values)
 		if err != nil {
 			res.Err = NewFatalError(input, err)
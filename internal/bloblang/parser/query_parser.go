@@ -13,14 +13,14 @@ func queryParser(pCtx Context) Func {
 			bracketsExpressionParser(pCtx),
 			literalValueParser(pCtx),
 			functionParser(pCtx),
-			variableLiteralParser(),
+			variableLiteralParser(pCtx),
 			fieldLiteralRootParser(pCtx),
 		),
 		"query",
 	), pCtx)
 	return func(input []rune) Result {
 		res := SpacesAndTabs()(input)
-		return arithmeticParser(rootParser)(res.Remaining)
+		return arithmeticParser(pCtx, rootParser)(res.Remaining)
 	}
 }
 
@@ -0,0 +1,32 @@
+package bundle
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/codec"
+)
+
+// AllCodecs is a set containing every custom codec that has been imported.
+var AllCodecs = codec.NewSet()
+
+//------------------------------------------------------------------------------
+
+// ReaderCodecAdd adds a new custom reader codec to this environment.
+func (e *Environment) ReaderCodecAdd(name string, ctor codec.ReaderPluginConstructor) error {
+	return e.codecs.AddReader(name, ctor)
+}
+
+// WriterCodecAdd adds a new custom writer codec to this environment.
+func (e *Environment) WriterCodecAdd(name string, ctor codec.WriterPluginConstructor) error {
+	return e.codecs.AddWriter(name, ctor)
+}
+
+// GetReaderCodec returns a constructor that creates reader codecs, including
+// custom codecs added to this environment.
+func (e *Environment) GetReaderCodec(codecStr string, conf codec.ReaderConfig) (codec.ReaderConstructor, error) {
+	return e.codecs.GetReader(codecStr, conf)
+}
+
+// GetWriterCodec returns a constructor that creates writer codecs, including
+// custom codecs added to this environment.
+func (e *Environment) GetWriterCodec(codecStr string) (codec.WriterConstructor, codec.WriterConfig, error) {
+	return e.codecs.GetWriter(codecStr)
+}
@@ -1,6 +1,7 @@
 package bundle
 
 import (
+	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
@@ -16,6 +17,7 @@ type Environment struct {
 	rateLimits *RateLimitSet
 	metrics    *MetricsSet
 	tracers    *TracerSet
+	codecs     *codec.Set
 }
 
 // NewEnvironment creates an empty environment.
@@ -29,6 +31,7 @@ func NewEnvironment() *Environment {
 		rateLimits: &RateLimitSet{},
 		metrics:    &MetricsSet{},
 		tracers:    &TracerSet{},
+		codecs:     codec.NewSet(),
 	}
 }
 
@@ -60,6 +63,7 @@ func (e *Environment) Clone() *Environment {
 	for _, v := range e.tracers.specs {
 		_ = newEnv.tracers.Add(v.constructor, v.spec)
 	}
+	newEnv.codecs = e.codecs.Clone()
 	return newEnv
 }
 
@@ -102,4 +106,5 @@ var GlobalEnvironment = &Environment{
 	rateLimits: AllRateLimits,
 	metrics:    AllMetrics,
 	tracers:    AllTracers,
+	codecs:     AllCodecs,
 }
@@ -47,6 +47,7 @@ type NewManagement interface {
 	Logger() log.Modular
 	Tracer() trace.TracerProvider
 	BloblEnvironment() *bloblang.Environment
+	Environment() *Environment
 
 	RegisterEndpoint(path, desc string, h http.HandlerFunc)
 
@@ -9,6 +9,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
 // AllTracers is a set containing every single tracer that has been imported.
@@ -73,7 +74,11 @@ func (s *TracerSet) Init(conf tracer.Config, nm NewManagement) (trace.TracerProv
 	if !exists {
 		return nil, component.ErrInvalidType("tracer", conf.Type)
 	}
-	return spec.constructor(conf, nm)
+	prov, err := spec.constructor(conf, nm)
+	if err != nil {
+		return nil, err
+	}
+	return tracing.WithMetadataPropagation(prov, conf.Propagation.Extract, conf.Propagation.Inject), nil
 }
 
 // Docs returns a slice of tracer specs, which document each method.
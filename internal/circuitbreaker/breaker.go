@@ -0,0 +1,212 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned by components when a request is rejected due to an open
+// circuit breaker.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State represents the state of a circuit breaker.
+type State int
+
+// All possible states of a circuit breaker, the values of which are used as
+// gauge metrics.
+const (
+	StateClosed   State = 0
+	StateHalfOpen State = 1
+	StateOpen     State = 2
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateHalfOpen:
+		return "half_open"
+	case StateOpen:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Breaker is a circuit breaker that tracks the ratio of failed requests within
+// a window of time, and when a threshold is reached it opens in order to reject
+// requests for a period. Once that period has passed a limited number of probe
+// requests are allowed through (half-open), and if they succeed the breaker
+// closes again.
+type Breaker struct {
+	failureRatio    float64
+	minimumRequests int
+	window          time.Duration
+	openDuration    time.Duration
+	halfOpenProbes  int
+
+	onChange func(from, to State)
+	now      func() time.Time
+
+	mut            sync.Mutex
+	state          State
+	generation     uint64
+	windowStart    time.Time
+	requests       int
+	failures       int
+	openedAt       time.Time
+	probesInFlight int
+	probeSuccesses int
+}
+
+// New creates a new circuit breaker from a config. An optional closure can be
+// provided that is called (whilst the breaker is locked) each time the state
+// of the breaker changes.
+func New(conf Config, onChange func(from, to State)) (*Breaker, error) {
+	if conf.FailureRatio <= 0 || conf.FailureRatio > 1 {
+		return nil, fmt.Errorf("failure ratio must be greater than 0 and at most 1, got %v", conf.FailureRatio)
+	}
+	if conf.MinimumRequests < 1 {
+		return nil, fmt.Errorf("minimum requests must be at least 1, got %v", conf.MinimumRequests)
+	}
+	if conf.HalfOpenProbes < 1 {
+		return nil, fmt.Errorf("half open probes must be at least 1, got %v", conf.HalfOpenProbes)
+	}
+
+	b := &Breaker{
+		failureRatio:    conf.FailureRatio,
+		minimumRequests: conf.MinimumRequests,
+		halfOpenProbes:  conf.HalfOpenProbes,
+		onChange:        onChange,
+		now:             time.Now,
+	}
+
+	var err error
+	if b.window, err = time.ParseDuration(conf.Window); err != nil {
+		return nil, fmt.Errorf("failed to parse window: %w", err)
+	}
+	if b.openDuration, err = time.ParseDuration(conf.OpenDuration); err != nil {
+		return nil, fmt.Errorf("failed to parse open duration: %w", err)
+	}
+	b.windowStart = b.now()
+	return b, nil
+}
+
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.generation++
+	b.requests, b.failures = 0, 0
+	b.probesInFlight, b.probeSuccesses = 0, 0
+
+	now := b.now()
+	b.windowStart = now
+	if to == StateOpen {
+		b.openedAt = now
+	}
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+}
+
+// refresh moves the breaker into half-open once the open duration has passed,
+// and resets counts once the window has passed.
+func (b *Breaker) refresh() {
+	now := b.now()
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.openedAt) >= b.openDuration {
+			b.setState(StateHalfOpen)
+		}
+	case StateClosed:
+		if now.Sub(b.windowStart) >= b.window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refresh()
+	return b.state
+}
+
+// Available returns true if a call to Allow would currently succeed.
+func (b *Breaker) Available() bool {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refresh()
+	switch b.state {
+	case StateOpen:
+		return false
+	case StateHalfOpen:
+		return b.probesInFlight < b.halfOpenProbes
+	}
+	return true
+}
+
+// Allow attempts to reserve a request, if the request is allowed then a
+// closure is returned that must be called exactly once with the result of the
+// request. If the request is not allowed then false is returned.
+func (b *Breaker) Allow() (done func(err error), allowed bool) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.refresh()
+	switch b.state {
+	case StateOpen:
+		return nil, false
+	case StateHalfOpen:
+		if b.probesInFlight >= b.halfOpenProbes {
+			return nil, false
+		}
+		b.probesInFlight++
+	}
+
+	gen := b.generation
+	return func(err error) {
+		b.record(gen, err)
+	}, true
+}
+
+func (b *Breaker) record(gen uint64, err error) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	// Results of requests allowed before the last change of state are stale
+	// and therefore ignored.
+	if gen != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		b.refresh()
+		b.requests++
+		if err != nil {
+			b.failures++
+		}
+		if b.requests >= b.minimumRequests && float64(b.failures)/float64(b.requests) >= b.failureRatio {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		b.probesInFlight--
+		if err != nil {
+			b.setState(StateOpen)
+			return
+		}
+		if b.probeSuccesses++; b.probeSuccesses >= b.halfOpenProbes {
+			b.setState(StateClosed)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBreaker(t *testing.T, conf Config) (*Breaker, *time.Time, *[]string) {
+	t.Helper()
+
+	var changes []string
+	b, err := New(conf, func(from, to State) {
+		changes = append(changes, from.String()+"->"+to.String())
+	})
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+	b.windowStart = now
+	return b, &now, &changes
+}
+
+func request(t *testing.T, b *Breaker, err error) {
+	t.Helper()
+	done, ok := b.Allow()
+	require.True(t, ok)
+	done(err)
+}
+
+func TestBreakerOpens(t *testing.T) {
+	conf := NewConfig()
+	conf.MinimumRequests = 4
+	conf.FailureRatio = 0.5
+
+	b, _, changes := testBreaker(t, conf)
+
+	errFailed := errors.New("failed")
+	request(t, b, nil)
+	request(t, b, errFailed)
+	request(t, b, nil)
+	assert.Equal(t, StateClosed, b.State())
+
+	request(t, b, errFailed)
+	assert.Equal(t, StateOpen, b.State())
+	assert.False(t, b.Available())
+
+	_, ok := b.Allow()
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"closed->open"}, *changes)
+}
+
+func TestBreakerWindowResets(t *testing.T) {
+	conf := NewConfig()
+	conf.MinimumRequests = 2
+	conf.Window = "1s"
+
+	b, now, _ := testBreaker(t, conf)
+
+	request(t, b, errors.New("failed"))
+	*now = now.Add(time.Second)
+	request(t, b, nil)
+	request(t, b, nil)
+	assert.Equal(t, StateClosed, b.State())
+}
+
+func TestBreakerHalfOpen(t *testing.T) {
+	conf := NewConfig()
+	conf.MinimumRequests = 1
+	conf.OpenDuration = "1s"
+	conf.HalfOpenProbes = 2
+
+	b, now, changes := testBreaker(t, conf)
+
+	request(t, b, errors.New("failed"))
+	assert.Equal(t, StateOpen, b.State())
+
+	*now = now.Add(time.Second)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	doneA, ok := b.Allow()
+	require.True(t, ok)
+	doneB, ok := b.Allow()
+	require.True(t, ok)
+
+	_, ok = b.Allow()
+	assert.False(t, ok)
+	assert.False(t, b.Available())
+
+	doneA(nil)
+	assert.Equal(t, StateHalfOpen, b.State())
+
+	doneB(errors.New("failed again"))
+	assert.Equal(t, StateOpen, b.State())
+
+	*now = now.Add(time.Second)
+	request(t, b, nil)
+	request(t, b, nil)
+	assert.Equal(t, StateClosed, b.State())
+
+	assert.Equal(t, []string{
+		"closed->open",
+		"open->half_open",
+		"half_open->open",
+		"open->half_open",
+		"half_open->closed",
+	}, *changes)
+}
+
+func TestBreakerStaleResults(t *testing.T) {
+	conf := NewConfig()
+	conf.MinimumRequests = 1
+
+	b, _, _ := testBreaker(t, conf)
+
+	doneStale, ok := b.Allow()
+	require.True(t, ok)
+
+	request(t, b, errors.New("failed"))
+	assert.Equal(t, StateOpen, b.State())
+
+	// A result from before the breaker opened does not affect it.
+	doneStale(nil)
+	assert.Equal(t, StateOpen, b.State())
+}
+
+func TestBreakerBadConfig(t *testing.T) {
+	for _, fn := range []func(c *Config){
+		func(c *Config) { c.FailureRatio = 0 },
+		func(c *Config) { c.FailureRatio = 1.5 },
+		func(c *Config) { c.MinimumRequests = 0 },
+		func(c *Config) { c.HalfOpenProbes = 0 },
+		func(c *Config) { c.Window = "nope" },
+		func(c *Config) { c.OpenDuration = "nope" },
+	} {
+		conf := NewConfig()
+		fn(&conf)
+		_, err := New(conf, nil)
+		assert.Error(t, err)
+	}
+}
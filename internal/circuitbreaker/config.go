@@ -0,0 +1,34 @@
+package circuitbreaker
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// Config contains configuration params for a circuit breaker.
+type Config struct {
+	FailureRatio    float64 `json:"failure_ratio" yaml:"failure_ratio"`
+	MinimumRequests int     `json:"minimum_requests" yaml:"minimum_requests"`
+	Window          string  `json:"window" yaml:"window"`
+	OpenDuration    string  `json:"open_duration" yaml:"open_duration"`
+	HalfOpenProbes  int     `json:"half_open_probes" yaml:"half_open_probes"`
+}
+
+// NewConfig creates a new Config with default values.
+func NewConfig() Config {
+	return Config{
+		FailureRatio:    0.5,
+		MinimumRequests: 10,
+		Window:          "10s",
+		OpenDuration:    "30s",
+		HalfOpenProbes:  1,
+	}
+}
+
+// FieldSpecs returns documentation specs for circuit breaker fields.
+func FieldSpecs() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldFloat("failure_ratio", "The ratio of failed requests to total requests within a `window` at which the circuit breaker opens.").HasDefault(0.5),
+		docs.FieldInt("minimum_requests", "The minimum number of requests within a `window` before the `failure_ratio` is considered, this prevents the circuit breaker from opening on the back of a small number of failures.").HasDefault(10),
+		docs.FieldString("window", "The period over which requests and failures are counted, counts are reset at the end of each window.").HasDefault("10s"),
+		docs.FieldString("open_duration", "The period to remain open before allowing probe requests through (half-open).").HasDefault("30s"),
+		docs.FieldInt("half_open_probes", "The number of probe requests allowed through when half-open. If all probes succeed the circuit breaker closes, and if any fail it opens again.").HasDefault(1).Advanced(),
+	}
+}
@@ -32,6 +32,8 @@ Provides a convenient tool for mapping JSON documents over the command line:
 
   echo '{"foo":"bar"}' | benthos blobl -f ./mapping.blobl
 
+  cat samples.jsonl | benthos blobl --profile -f ./mapping.blobl > /dev/null
+
 Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`[1:],
 		Flags: []cli.Flag{
 			&cli.IntFlag{
@@ -55,6 +57,10 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 				Aliases: []string{"f"},
 				Usage:   "execute a mapping from a file.",
 			},
+			&cli.BoolFlag{
+				Name:  "profile",
+				Usage: "execute documents sequentially and print the execution time and allocations of each statement, function and method call to stderr once all documents are consumed.",
+			},
 			&cli.IntFlag{
 				Name:  "max-token-length",
 				Usage: "Set the buffer size for document lines.",
@@ -98,6 +104,12 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 						Aliases: []string{"i"},
 						Usage:   "an optional path to an input file to load as the initial input to the mapping within the app.",
 					},
+					&cli.StringFlag{
+						Name:    "input-schema",
+						Value:   "",
+						Aliases: []string{"s"},
+						Usage:   "an optional path to a JSON Schema (in JSON or YAML format) describing input documents, used for the static analysis of mappings within the app.",
+					},
 					&cli.BoolFlag{
 						Name:    "write",
 						Value:   false,
@@ -106,6 +118,7 @@ Find out more about Bloblang at: https://benthos.dev/docs/guides/bloblang/about`
 					},
 				},
 			},
+			lockCliCommand(),
 		},
 	}
 }
@@ -235,6 +248,15 @@ func run(c *cli.Context) error {
 	}
 
 	bEnv := bloblang.NewEnvironment().WithImporterRelativeToFile(file)
+
+	var profiler *parser.Profiler
+	if c.Bool("profile") {
+		// Allocations are measured process wide and therefore profiling
+		// requires mappings to be executed sequentially.
+		t = 1
+		profiler = parser.NewProfiler()
+		bEnv = bEnv.WithProfiler(profiler)
+	}
 	exec, err := bEnv.NewMapping(m)
 	if err != nil {
 		if perr, ok := err.(*parser.Error); ok {
@@ -293,6 +315,9 @@ func run(c *cli.Context) error {
 	for res := range resultsChan {
 		fmt.Println(res)
 	}
+	if profiler != nil {
+		profiler.WriteReport(os.Stderr)
+	}
 	os.Exit(0)
 	return nil
 }
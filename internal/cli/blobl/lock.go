@@ -0,0 +1,80 @@
+package blobl
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
+)
+
+func lockCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "lock",
+		Usage: "Pin the remote imports of Bloblang mappings to content hashes",
+		Description: `
+Parses the provided mapping files, fetches any remote imports over HTTP, git or
+OCI (and the imports of those mappings) and writes their content hashes to a
+lock file:
+
+  benthos blobl lock ./mappings/foo.blobl ./mappings/bar.blobl
+
+Imports that are already pinned within the lock file are checked against their
+hash rather than updated, in order to update a pinned import remove it from the
+lock file before running this command.
+
+Fetched mappings are cached locally so that pinned imports can be resolved
+without network access.`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "lock-file",
+				Value: parser.ImportLockFileName,
+				Usage: "the path of the lock file to write.",
+			},
+			&cli.StringFlag{
+				Name:  "cache-dir",
+				Value: parser.DefaultImportCacheDir(),
+				Usage: "the directory to cache fetched imports within.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			lockPath := c.String("lock-file")
+
+			lock, err := parser.ReadImportLock(lockPath)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, red(err))
+				os.Exit(1)
+			}
+
+			remote := parser.NewRemoteImports(c.String("cache-dir"), lock, true)
+			pCtx := parser.GlobalContext().WithRemoteImports(remote)
+
+			failed := false
+			for _, path := range c.Args().Slice() {
+				mappingBytes, err := os.ReadFile(path)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, red(fmt.Sprintf("%v: %v", path, err)))
+					failed = true
+					continue
+				}
+				if _, perr := parser.ParseMapping(pCtx.WithImporterRelativeToFile(path), string(mappingBytes)); perr != nil {
+					fmt.Fprintln(os.Stderr, red(fmt.Sprintf("%v: %v", path, perr.ErrorAtPosition([]rune(string(mappingBytes))))))
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+
+			if err := lock.WriteFile(lockPath); err != nil {
+				fmt.Fprintln(os.Stderr, red(err))
+				os.Exit(1)
+			}
+			for _, u := range lock.URLs() {
+				fmt.Printf("%v %v\n", lock.Imports[u], u)
+			}
+			return nil
+		},
+	}
+}
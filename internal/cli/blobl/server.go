@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
@@ -142,6 +143,17 @@ func runServer(c *cli.Context) error {
 	fSync := newFileSync(c.String("input-file"), c.String("mapping-file"), c.Bool("write"))
 	defer fSync.write()
 
+	var inputSchema any
+	if schemaPath := c.String("input-schema"); schemaPath != "" {
+		schemaBytes, err := os.ReadFile(schemaPath)
+		if err != nil {
+			return fmt.Errorf("failed to read input schema: %w", err)
+		}
+		if err := yaml.Unmarshal(schemaBytes, &inputSchema); err != nil {
+			return fmt.Errorf("failed to parse input schema: %w", err)
+		}
+	}
+
 	mux := http.NewServeMux()
 	execCache := newExecCache()
 
@@ -158,10 +170,16 @@ func runServer(c *cli.Context) error {
 
 		fSync.update(req.Input, req.Mapping)
 
+		type lint struct {
+			Line   int    `json:"line"`
+			Column int    `json:"column"`
+			What   string `json:"what"`
+		}
 		res := struct {
 			ParseError   string `json:"parse_error"`
 			MappingError string `json:"mapping_error"`
 			Result       string `json:"result"`
+			Lints        []lint `json:"lints"`
 		}{}
 		defer func() {
 			resBytes, err := json.Marshal(res)
@@ -182,6 +200,20 @@ func runServer(c *cli.Context) error {
 			return
 		}
 
+		if bLints, err := bloblang.GlobalEnvironment().LintMapping(req.Mapping, inputSchema); err == nil {
+			for _, l := range bLints {
+				if l.Position.Source != "" {
+					res.Lints = append(res.Lints, lint{Line: 1, Column: 1, What: l.String()})
+					continue
+				}
+				res.Lints = append(res.Lints, lint{
+					Line:   l.Position.Line,
+					Column: l.Position.Column,
+					What:   l.What,
+				})
+			}
+		}
+
 		output, err := execCache.executeMapping(exec, false, true, []byte(req.Input))
 		if err != nil {
 			res.MappingError = err.Error()
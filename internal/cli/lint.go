@@ -135,6 +135,7 @@ Exits with a status code 1 if any linting errors are detected:
   benthos lint ./configs/*.yaml
   benthos lint ./foo.yaml ./bar.yaml
   benthos lint ./configs/...
+  benthos lint --bloblang --bloblang-schema ./schema.json ./foo.yaml
 
 If a path ends with '...' then Benthos will walk the target and lint any
 files with the .yaml or .yml extension.`[1:],
@@ -149,6 +150,16 @@ files with the .yaml or .yml extension.`[1:],
 				Value: false,
 				Usage: "Print linting errors when components do not have labels.",
 			},
+			&cli.BoolFlag{
+				Name:  "bloblang",
+				Value: false,
+				Usage: "Print linting warnings from a static analysis of Bloblang mappings, such as type mismatches, unreachable match cases and unused variables.",
+			},
+			&cli.StringFlag{
+				Name:  "bloblang-schema",
+				Value: "",
+				Usage: "An optional path to a JSON Schema (in JSON or YAML format) describing the input documents of Bloblang mappings, used during static analysis.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			targets, err := ifilepath.GlobsAndSuperPaths(c.Args().Slice(), "yaml", "yml")
@@ -163,6 +174,18 @@ files with the .yaml or .yml extension.`[1:],
 			lintOpts := config.LintOptions{
 				RejectDeprecated: c.Bool("deprecated"),
 				RequireLabels:    c.Bool("labels"),
+				BloblangStatic:   c.Bool("bloblang") || c.String("bloblang-schema") != "",
+			}
+			if schemaPath := c.String("bloblang-schema"); schemaPath != "" {
+				schemaBytes, err := os.ReadFile(schemaPath)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to read Bloblang schema: %v\n", err)
+					os.Exit(1)
+				}
+				if err := yaml.Unmarshal(schemaBytes, &lintOpts.BloblangInputSchema); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to parse Bloblang schema: %v\n", err)
+					os.Exit(1)
+				}
 			}
 
 			var pathLintMut sync.Mutex
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"runtime/debug"
+	"time"
 
 	"github.com/urfave/cli/v2"
 	"gopkg.in/yaml.v3"
@@ -17,6 +18,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/filepath"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin"
 	"github.com/benthosdev/benthos/v4/internal/template"
 )
 
@@ -152,6 +154,11 @@ func Run() {
 			Aliases: []string{"t"},
 			Usage:   "EXPERIMENTAL: import Benthos templates, supports glob patterns (requires quotes)",
 		},
+		&cli.StringSliceFlag{
+			Name:    "plugins",
+			Aliases: []string{"p"},
+			Usage:   "EXPERIMENTAL: launch plugin executables and register their components, supports glob patterns (requires quotes)",
+		},
 		&cli.BoolFlag{
 			Name:  "chilled",
 			Value: false,
@@ -211,6 +218,17 @@ Either run Benthos as a stream processor or choose a command:
 				fmt.Println("Shutting down due to linter errors, to prevent shutdown run Benthos with --chilled")
 				os.Exit(1)
 			}
+
+			pluginPaths, err := filepath.Globs(c.StringSlice("plugins"))
+			if err != nil {
+				fmt.Printf("Failed to resolve plugin glob pattern: %v\n", err)
+				os.Exit(1)
+			}
+			if err := rpcplugin.InitPlugins(pluginPaths...); err != nil {
+				fmt.Fprintf(os.Stderr, "Plugin launch error: %v\n", err)
+				rpcplugin.Shutdown(time.Second * 5)
+				os.Exit(1)
+			}
 			return nil
 		},
 		Action: func(c *cli.Context) error {
@@ -235,6 +253,7 @@ Either run Benthos as a stream processor or choose a command:
 				false,
 				nil,
 			); code != 0 {
+				rpcplugin.Shutdown(time.Second * 5)
 				os.Exit(code)
 			}
 			return nil
@@ -340,6 +359,7 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 	}
 
 	_ = app.Run(os.Args)
+	rpcplugin.Shutdown(time.Second * 5)
 }
 
 //------------------------------------------------------------------------------
@@ -19,6 +19,7 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/config"
@@ -282,6 +283,15 @@ func cmdService(
 		fmt.Printf("Failed to create logger: %v\n", err)
 		return 1
 	}
+	defer func() {
+		if shutter, ok := logger.(interface {
+			Shutdown(context.Context) error
+		}); ok {
+			ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+			_ = shutter.Shutdown(ctx)
+			done()
+		}
+	}()
 
 	if mainPath == "" {
 		logger.Infof("Running without a main config file")
@@ -355,15 +365,19 @@ func cmdService(
 	}
 
 	// Create resource manager.
-	manager, err := manager.New(
-		conf.ResourceConfig,
+	mgrOpts := []manager.OptFunc{
 		manager.OptSetAPIReg(httpServer),
 		manager.OptSetStreamHTTPNamespacing(namespaceStreamEndpoints),
 		manager.OptSetLogger(logger),
 		manager.OptSetMetrics(stats),
 		manager.OptSetTracer(trac),
 		manager.OptSetStreamsMode(streamsMode),
-	)
+	}
+	if mainPath != "" {
+		// Locks of remote Bloblang imports are resolved relative to the config.
+		mgrOpts = append(mgrOpts, manager.OptSetBloblangEnvironment(bloblang.GlobalEnvironment().WithImportLockRelativeToFile(mainPath)))
+	}
+	manager, err := manager.New(conf.ResourceConfig, mgrOpts...)
 	if err != nil {
 		logger.Errorf("Failed to create resource: %v\n", err)
 		return 1
@@ -3,10 +3,12 @@ package template
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/fatih/color"
 	"github.com/urfave/cli/v2"
 
+	clitest "github.com/benthosdev/benthos/v4/internal/cli/test"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	ifilepath "github.com/benthosdev/benthos/v4/internal/filepath"
 	"github.com/benthosdev/benthos/v4/internal/template"
@@ -39,7 +41,7 @@ func lintFile(path string) (pathLints []pathLint) {
 		})
 	}
 
-	testErrors, err := conf.Test()
+	testErrors, err := conf.Test(filepath.Dir(path), clitest.RunTemplateTest)
 	if err != nil {
 		pathLints = append(pathLints, pathLint{
 			source: path,
@@ -6,6 +6,7 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/filepath"
 	"github.com/benthosdev/benthos/v4/internal/log"
 )
@@ -22,6 +23,7 @@ fail the process will report the errors and exit with a status code 1.
   benthos test ./path/to/configs/...
   benthos test ./foo_configs/*.yaml ./bar_configs/*.yaml
   benthos test ./foo.yaml
+  benthos test --coverage ./path/to/configs/...
 
 For more information check out the docs at:
 https://benthos.dev/docs/configuration/unit_testing`[1:],
@@ -31,6 +33,11 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				Value: "",
 				Usage: "allow components to write logs at a provided level to stdout.",
 			},
+			&cli.BoolFlag{
+				Name:  "coverage",
+				Value: false,
+				Usage: "print a report of the Bloblang mapping branches (match cases and if arms) executed by the tests.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if len(c.StringSlice("set")) > 0 {
@@ -43,18 +50,27 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			var logger log.Modular = log.Noop()
 			if logLevel := c.String("log"); len(logLevel) > 0 {
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
-				logger, err := log.NewV2(os.Stdout, logConf)
-				if err != nil {
+				if logger, err = log.NewV2(os.Stdout, logConf); err != nil {
 					fmt.Printf("Failed to init logger: %v\n", err)
 					os.Exit(1)
 				}
-				if RunAll(c.Args().Slice(), testSuffix, true, logger, resourcesPaths) {
-					os.Exit(0)
-				}
-			} else if RunAll(c.Args().Slice(), testSuffix, true, log.Noop(), resourcesPaths) {
+			}
+			var coverage *parser.Coverage
+			var opts []func(*ProcessorsProvider)
+			if c.Bool("coverage") {
+				coverage = parser.NewCoverage()
+				opts = append(opts, OptProcessorsProviderSetCoverage(coverage))
+			}
+			success := RunAll(c.Args().Slice(), testSuffix, true, logger, resourcesPaths, opts...)
+			if coverage != nil {
+				fmt.Printf("\nBloblang coverage:\n\n")
+				coverage.WriteReport(os.Stdout)
+			}
+			if success {
 				os.Exit(0)
 			}
 			os.Exit(1)
@@ -105,7 +105,7 @@ func lintTarget(path, testSuffix string) ([]docs.Lint, error) {
 // RunAll executes the test command for a slice of paths. The path can either be
 // a config file, a config files test definition file, a directory, or the
 // wildcard pattern './...'.
-func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string) bool {
+func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, resourcesPaths []string, opts ...func(*ProcessorsProvider)) bool {
 	targets, err := GetTestTargets(paths, testSuffix)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to obtain test targets: %v\n", err)
@@ -138,7 +138,7 @@ func RunAll(paths []string, testSuffix string, lint bool, logger log.Modular, re
 				return false
 			}
 		}
-		if failCases, err = targets[target].Execute(target, resourcesPaths, logger); err != nil {
+		if failCases, err = targets[target].Execute(target, resourcesPaths, logger, opts...); err != nil {
 			fmt.Fprintf(os.Stderr, "Failed to execute test target '%v': %v\n", target, err)
 			return false
 		}
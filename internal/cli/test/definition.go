@@ -12,12 +12,15 @@ type Definition struct {
 	Cases []Case `yaml:"tests"`
 }
 
-// Execute the test definition.
-func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular) ([]CaseFailure, error) {
+// Execute the test definition. Optional processors provider options can be
+// provided in order to customise how tested components are constructed.
+func (d Definition) Execute(testFilePath string, resourcesPaths []string, logger log.Modular, opts ...func(*ProcessorsProvider)) ([]CaseFailure, error) {
 	procsProvider := NewProcessorsProvider(
 		testFilePath,
-		OptAddResourcesPaths(resourcesPaths),
-		OptProcessorsProviderSetLogger(logger),
+		append([]func(*ProcessorsProvider){
+			OptAddResourcesPaths(resourcesPaths),
+			OptProcessorsProviderSetLogger(logger),
+		}, opts...)...,
 	)
 
 	dir := filepath.Dir(testFilePath)
@@ -11,6 +11,7 @@ import (
 	"github.com/Jeffail/gabs/v2"
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -35,7 +36,8 @@ type ProcessorsProvider struct {
 	resourcesPaths []string
 	cachedConfigs  map[string]cachedConfig
 
-	logger log.Modular
+	logger   log.Modular
+	coverage *parser.Coverage
 }
 
 // NewProcessorsProvider returns a new processors provider aimed at a filepath.
@@ -65,6 +67,14 @@ func OptProcessorsProviderSetLogger(logger log.Modular) func(*ProcessorsProvider
 	}
 }
 
+// OptProcessorsProviderSetCoverage sets a coverage tracker that records the
+// branches executed by mappings of the tested components.
+func OptProcessorsProviderSetCoverage(coverage *parser.Coverage) func(*ProcessorsProvider) {
+	return func(p *ProcessorsProvider) {
+		p.coverage = coverage
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // Provide attempts to extract an array of processors from a Benthos config.
@@ -92,6 +102,9 @@ func (p *ProcessorsProvider) ProvideBloblang(pathStr string) ([]processor.V1, er
 	}
 
 	pCtx := parser.GlobalContext().WithImporterRelativeToFile(pathStr)
+	if p.coverage != nil {
+		pCtx = pCtx.WithCoverage(p.coverage)
+	}
 	exec, mapErr := parser.ParseMapping(pCtx, string(mappingBytes))
 	if mapErr != nil {
 		return nil, mapErr
@@ -143,7 +156,12 @@ func (b *bloblangProc) Close(context.Context) error {
 //------------------------------------------------------------------------------
 
 func (p *ProcessorsProvider) initProcs(confs cachedConfig) ([]processor.V1, error) {
-	mgr, err := manager.New(confs.mgr, manager.OptSetLogger(p.logger))
+	opts := []manager.OptFunc{manager.OptSetLogger(p.logger)}
+	if p.coverage != nil {
+		opts = append(opts, manager.OptSetBloblangEnvironment(bloblang.GlobalEnvironment().WithCoverage(p.coverage)))
+	}
+
+	mgr, err := manager.New(confs.mgr, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialise resources: %v", err)
 	}
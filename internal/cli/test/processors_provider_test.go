@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/cli/test"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -408,3 +409,41 @@ pipeline:
 	_, err = provider.Provide("/pipeline/processors", nil, nil)
 	require.EqualError(t, err, "failed to initialise resources: cache resource label 'barcache' collides with a previously defined resource")
 }
+
+func TestProcessorsProviderCoverage(t *testing.T) {
+	files := map[string]string{
+		"config1.yaml": `
+pipeline:
+  processors:
+  - mapping: |
+      root = match content().string() {
+        "foo" => "was foo"
+        _ => "was other"
+      }
+`,
+	}
+
+	testDir, err := initTestFiles(t, files)
+	require.NoError(t, err)
+
+	coverage := parser.NewCoverage()
+	provider := test.NewProcessorsProvider(
+		filepath.Join(testDir, "config1.yaml"),
+		test.OptProcessorsProviderSetCoverage(coverage),
+	)
+
+	procs, err := provider.Provide("/pipeline/processors", nil, nil)
+	require.NoError(t, err)
+	require.Len(t, procs, 1)
+
+	msgs, res := processor.ExecuteAll(context.Background(), procs, message.QuickBatch([][]byte{[]byte("foo")}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, "was foo", string(msgs[0].Get(0).AsBytes()))
+
+	mappings := coverage.Mappings()
+	require.Len(t, mappings, 1)
+	require.Len(t, mappings[0].Branches, 2)
+	assert.Equal(t, int64(1), mappings[0].Branches[0].Hits)
+	assert.Equal(t, int64(0), mappings[0].Branches[1].Hits)
+}
@@ -0,0 +1,49 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	iprocessor "github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/template"
+)
+
+// RunTemplateTest implements template.TestRunner by executing the input batch
+// of a template test as a test case against the processors resulting from the
+// template.
+func RunTemplateTest(dir string, test template.TestConfig, procs []iprocessor.V1) ([]string, error) {
+	tCase := NewCase()
+	tCase.Name = test.Name
+	if err := test.InputBatch.Decode(&tCase.InputBatch); err != nil {
+		return nil, fmt.Errorf("failed to parse input_batch: %w", err)
+	}
+	if len(test.OutputBatches.Content) > 0 {
+		if err := test.OutputBatches.Decode(&tCase.OutputBatches); err != nil {
+			return nil, fmt.Errorf("failed to parse output_batches: %w", err)
+		}
+	}
+
+	caseFailures, err := tCase.ExecuteFrom(dir, staticProcProvider(procs))
+	if err != nil {
+		return nil, err
+	}
+
+	failures := make([]string, 0, len(caseFailures))
+	for _, f := range caseFailures {
+		failures = append(failures, f.Reason)
+	}
+	return failures, nil
+}
+
+// staticProcProvider provides the same processors to each test case.
+type staticProcProvider []iprocessor.V1
+
+func (s staticProcProvider) Provide(string, map[string]string, map[string]yaml.Node) ([]iprocessor.V1, error) {
+	return s, nil
+}
+
+func (s staticProcProvider) ProvideBloblang(string) ([]iprocessor.V1, error) {
+	return nil, errors.New("bloblang targets are not supported by template tests")
+}
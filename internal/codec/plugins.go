@@ -0,0 +1,140 @@
+package codec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReaderPluginConstructor is called for each component that uses a custom
+// reader codec, and returns a constructor for the readers of that component.
+// The args contain any parameters following the codec name, e.g. for the codec
+// `foo:bar` the args would be `bar`.
+type ReaderPluginConstructor func(args string, conf ReaderConfig) (ReaderConstructor, error)
+
+// WriterPluginConstructor is called for each component that uses a custom
+// writer codec, and returns a constructor for the writers of that component.
+// The args contain any parameters following the codec name.
+type WriterPluginConstructor func(args string) (WriterConstructor, WriterConfig, error)
+
+// Set contains custom reader and writer codecs that are available in addition
+// to those built in.
+type Set struct {
+	readers map[string]ReaderPluginConstructor
+	writers map[string]WriterPluginConstructor
+}
+
+// NewSet creates an empty set of custom codecs.
+func NewSet() *Set {
+	return &Set{
+		readers: map[string]ReaderPluginConstructor{},
+		writers: map[string]WriterPluginConstructor{},
+	}
+}
+
+// Clone the set into a new one that can be modified independently.
+func (s *Set) Clone() *Set {
+	n := NewSet()
+	for k, v := range s.readers {
+		n.readers[k] = v
+	}
+	for k, v := range s.writers {
+		n.writers[k] = v
+	}
+	return n
+}
+
+var (
+	codecNameRegexpRaw = `^[a-z0-9]+(_[a-z0-9]+)*$`
+	codecNameRegexp    = regexp.MustCompile(codecNameRegexpRaw)
+)
+
+var builtInReaders = map[string]struct{}{
+	"all-bytes": {}, "auto": {}, "avro-ocf": {}, "chunker": {}, "csv": {},
+	"csv-gzip": {}, "delim": {}, "gzip": {}, "lines": {}, "multipart": {},
+	"regex": {}, "tar": {}, "tar-gzip": {},
+}
+
+var builtInWriters = map[string]struct{}{
+	"all-bytes": {}, "append": {}, "delim": {}, "lines": {},
+}
+
+func checkCodecName(name string, builtIn map[string]struct{}) error {
+	if !codecNameRegexp.MatchString(name) {
+		return fmt.Errorf("codec name '%v' does not match the required regular expression /%v/", name, codecNameRegexpRaw)
+	}
+	if _, exists := builtIn[name]; exists {
+		return fmt.Errorf("codec name '%v' collides with a built in codec", name)
+	}
+	return nil
+}
+
+// AddReader adds a custom reader codec to the set.
+func (s *Set) AddReader(name string, ctor ReaderPluginConstructor) error {
+	if err := checkCodecName(name, builtInReaders); err != nil {
+		return err
+	}
+	s.readers[name] = ctor
+	return nil
+}
+
+// AddWriter adds a custom writer codec to the set.
+func (s *Set) AddWriter(name string, ctor WriterPluginConstructor) error {
+	if err := checkCodecName(name, builtInWriters); err != nil {
+		return err
+	}
+	s.writers[name] = ctor
+	return nil
+}
+
+func splitCodecArgs(codec string) (name, args string) {
+	if i := strings.Index(codec, ":"); i >= 0 {
+		return codec[:i], codec[i+1:]
+	}
+	return codec, ""
+}
+
+func (s *Set) partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+	name, args := splitCodecArgs(codec)
+	ctor, exists := s.readers[name]
+	if !exists {
+		return nil, false, nil
+	}
+	rCtor, err := ctor(args, conf)
+	if err != nil {
+		return nil, false, fmt.Errorf("codec %v: %w", name, err)
+	}
+	return rCtor, true, nil
+}
+
+// GetReader returns a constructor that creates reader codecs, where custom
+// codecs of the set can be used in addition to those built in.
+func (s *Set) GetReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
+	codec = convertDeprecatedCodec(codec)
+	if codec == "auto" {
+		return autoCodec(conf), nil
+	}
+	return chainedReader(codec, conf, s)
+}
+
+// GetWriter returns a constructor that creates writer codecs, where custom
+// codecs of the set can be used in addition to those built in.
+func (s *Set) GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
+	if ctor, conf, ok, err := builtInWriter(codec); ok || err != nil {
+		return ctor, conf, err
+	}
+	if s != nil {
+		name, args := splitCodecArgs(codec)
+		if ctor, exists := s.writers[name]; exists {
+			wCtor, wConf, err := ctor(args)
+			if err != nil {
+				return nil, WriterConfig{}, fmt.Errorf("codec %v: %w", name, err)
+			}
+			return wCtor, wConf, nil
+		}
+	}
+	return nil, WriterConfig{}, fmt.Errorf("codec was not recognised: %v", codec)
+}
@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// upperReader is a custom reader that emits lines in upper case, prefixed by
+// the args of the codec.
+type upperReader struct {
+	prefix string
+	lines  Reader
+}
+
+func (u *upperReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	parts, ackFn, err := u.lines.Next(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range parts {
+		p.SetBytes([]byte(u.prefix + strings.ToUpper(string(p.AsBytes()))))
+	}
+	return parts, ackFn, nil
+}
+
+func (u *upperReader) Close(ctx context.Context) error {
+	return u.lines.Close(ctx)
+}
+
+func testUpperSet(t *testing.T) *Set {
+	t.Helper()
+
+	s := NewSet()
+	require.NoError(t, s.AddReader("upper", func(args string, conf ReaderConfig) (ReaderConstructor, error) {
+		if args == "fail" {
+			return nil, errors.New("nope")
+		}
+		return func(path string, r io.ReadCloser, ackFn ReaderAckFn) (Reader, error) {
+			lines, err := newLinesReader(conf, r, ackFn)
+			if err != nil {
+				return nil, err
+			}
+			return &upperReader{prefix: args, lines: lines}, nil
+		}, nil
+	}))
+	return s
+}
+
+func readAllFromSet(t *testing.T, s *Set, codec string, data []byte) []string {
+	t.Helper()
+
+	ctor, err := s.GetReader(codec, NewReaderConfig())
+	require.NoError(t, err)
+
+	var acked bool
+	r, err := ctor("foo.txt", noopCloser{bytes.NewReader(data), false}, func(ctx context.Context, err error) error {
+		require.NoError(t, err)
+		acked = true
+		return nil
+	})
+	require.NoError(t, err)
+
+	var res []string
+	for {
+		parts, ackFn, err := r.Next(context.Background())
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		res = append(res, strsFromParts(parts)...)
+		require.NoError(t, ackFn(context.Background(), nil))
+	}
+	require.NoError(t, r.Close(context.Background()))
+	assert.True(t, acked)
+	return res
+}
+
+func TestSetCustomReader(t *testing.T) {
+	s := testUpperSet(t)
+
+	assert.Equal(t, []string{"FOO", "BAR"}, readAllFromSet(t, s, "upper", []byte("foo\nbar")))
+	assert.Equal(t, []string{"x: FOO", "x: BAR"}, readAllFromSet(t, s, "upper:x: ", []byte("foo\nbar")))
+	assert.Equal(t, []string{"foo", "bar"}, readAllFromSet(t, s, "lines", []byte("foo\nbar")))
+
+	var gzipBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzipBuf)
+	_, err := zw.Write([]byte("foo\nbar"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	assert.Equal(t, []string{"FOO", "BAR"}, readAllFromSet(t, s, "gzip/upper", gzipBuf.Bytes()))
+
+	_, err = s.GetReader("upper:fail", NewReaderConfig())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "codec upper: nope")
+
+	_, err = s.Clone().GetReader("nope", NewReaderConfig())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "codec was not recognised: nope")
+
+	_, err = GetReader("upper", NewReaderConfig())
+	require.Error(t, err)
+}
+
+type prefixWriter struct {
+	prefix string
+	w      io.WriteCloser
+}
+
+func (p *prefixWriter) Write(ctx context.Context, part *message.Part) error {
+	_, err := p.w.Write(append([]byte(p.prefix), part.AsBytes()...))
+	return err
+}
+
+func (p *prefixWriter) Close(ctx context.Context) error {
+	return p.w.Close()
+}
+
+type noopWriteCloser struct {
+	io.Writer
+}
+
+func (n noopWriteCloser) Close() error {
+	return nil
+}
+
+func TestSetCustomWriter(t *testing.T) {
+	s := NewSet()
+	require.NoError(t, s.AddWriter("prefix", func(args string) (WriterConstructor, WriterConfig, error) {
+		return func(w io.WriteCloser) (Writer, error) {
+			return &prefixWriter{prefix: args, w: w}, nil
+		}, WriterConfig{CloseAfter: true}, nil
+	}))
+
+	ctor, conf, err := s.GetWriter("prefix:foo:")
+	require.NoError(t, err)
+	assert.True(t, conf.CloseAfter)
+
+	var buf bytes.Buffer
+	w, err := ctor(noopWriteCloser{&buf})
+	require.NoError(t, err)
+	require.NoError(t, w.Write(context.Background(), message.NewPart([]byte("bar"))))
+	require.NoError(t, w.Close(context.Background()))
+	assert.Equal(t, "foo:bar", buf.String())
+
+	_, conf, err = s.Clone().GetWriter("append")
+	require.NoError(t, err)
+	assert.True(t, conf.Append)
+
+	_, _, err = s.GetWriter("nope")
+	require.Error(t, err)
+
+	_, _, err = GetWriter("prefix")
+	require.Error(t, err)
+}
+
+func TestSetCodecNames(t *testing.T) {
+	s := NewSet()
+	noopReader := func(string, ReaderConfig) (ReaderConstructor, error) { return nil, nil }
+	noopWriter := func(string) (WriterConstructor, WriterConfig, error) { return nil, WriterConfig{}, nil }
+
+	for _, name := range []string{"Foo", "foo-bar", "foo:bar", "foo/bar", ""} {
+		assert.Error(t, s.AddReader(name, noopReader), name)
+		assert.Error(t, s.AddWriter(name, noopWriter), name)
+	}
+
+	assert.EqualError(t, s.AddReader("lines", noopReader), "codec name 'lines' collides with a built in codec")
+	assert.EqualError(t, s.AddWriter("append", noopWriter), "codec name 'append' collides with a built in codec")
+
+	assert.NoError(t, s.AddReader("foo_bar2", noopReader))
+	assert.NoError(t, s.AddWriter("foo_bar2", noopWriter))
+}
@@ -20,7 +20,6 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/message"
-	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 // ReaderDocs is a static field documentation for input codecs.
@@ -135,7 +134,7 @@ func chainPartIntoReaderCtor(first ReaderConstructor, second readerReaderConstru
 	}
 }
 
-func chainedReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
+func chainedReader(codec string, conf ReaderConfig, set *Set) (ReaderConstructor, error) {
 	codecs := strings.Split(codec, "/")
 
 	var ioCtor ioReaderConstructor
@@ -157,6 +156,11 @@ func chainedReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
 		if err != nil {
 			return nil, err
 		}
+		if !ok {
+			if tmpPartCtor, ok, err = set.partReader(codec, conf); err != nil {
+				return nil, err
+			}
+		}
 		if ok {
 			if partCtor != nil {
 				return nil, fmt.Errorf("unable to follow codec '%v' with '%v'", codecs[i-1], codec)
@@ -298,13 +302,11 @@ func convertDeprecatedCodec(codec string) string {
 	return codec
 }
 
-// GetReader returns a constructor that creates reader codecs.
+// GetReader returns a constructor that creates reader codecs. Only built in
+// codecs are supported, custom codecs can be used via a Set.
 func GetReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
-	codec = convertDeprecatedCodec(codec)
-	if codec == "auto" {
-		return autoCodec(conf), nil
-	}
-	return chainedReader(codec, conf)
+	var set *Set
+	return set.GetReader(codec, conf)
 }
 
 func autoCodec(conf ReaderConfig) ReaderConstructor {
@@ -395,27 +397,16 @@ func newAvroOCFReader(conf ReaderConfig, marshaler string, r io.ReadCloser, ackF
 			return nil, err
 		}
 		a.pending++
-		m := service.NewMessage(nil)
 		if !a.logicalTypes {
-			m.SetStructured(datum)
-			mp, err := m.AsBytes()
-			if err != nil {
-				return nil, err
-			}
-			part := message.NewPart(mp)
-			return part, nil
+			part := message.NewPart(nil)
+			part.SetStructured(datum)
+			return message.NewPart(part.AsBytes()), nil
 		}
 		jb, err := a.avroCodec.TextualFromNative(nil, datum)
 		if err != nil {
 			return nil, err
 		}
-		m.SetBytes(jb)
-		mp, err := m.AsBytes()
-		if err != nil {
-			return nil, err
-		}
-		part := message.NewPart(mp)
-		return part, nil
+		return message.NewPart(jb), nil
 	}
 
 	var logicalTypes bool
@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"strings"
 
@@ -41,30 +40,36 @@ type WriterConfig struct {
 // WriterConstructor creates a writer from an io.WriteCloser.
 type WriterConstructor func(io.WriteCloser) (Writer, error)
 
-// GetWriter returns a constructor that creates write codecs.
+// GetWriter returns a constructor that creates write codecs. Only built in
+// codecs are supported, custom codecs can be used via a Set.
 func GetWriter(codec string) (WriterConstructor, WriterConfig, error) {
+	var set *Set
+	return set.GetWriter(codec)
+}
+
+func builtInWriter(codec string) (WriterConstructor, WriterConfig, bool, error) {
 	switch codec {
 	case "all-bytes":
 		return func(w io.WriteCloser) (Writer, error) {
 			return &allBytesWriter{w}, nil
-		}, allBytesConfig, nil
+		}, allBytesConfig, true, nil
 	case "append":
 		return func(w io.WriteCloser) (Writer, error) {
 			return newCustomDelimWriter(w, "")
-		}, customDelimConfig, nil
+		}, customDelimConfig, true, nil
 	case "lines":
-		return newLinesWriter, linesWriterConfig, nil
+		return newLinesWriter, linesWriterConfig, true, nil
 	}
 	if strings.HasPrefix(codec, "delim:") {
 		by := strings.TrimPrefix(codec, "delim:")
 		if by == "" {
-			return nil, WriterConfig{}, errors.New("custom delimiter codec requires a non-empty delimiter")
+			return nil, WriterConfig{}, false, errors.New("custom delimiter codec requires a non-empty delimiter")
 		}
 		return func(w io.WriteCloser) (Writer, error) {
 			return newCustomDelimWriter(w, by)
-		}, customDelimConfig, nil
+		}, customDelimConfig, true, nil
 	}
-	return nil, WriterConfig{}, fmt.Errorf("codec was not recognised: %v", codec)
+	return nil, WriterConfig{}, false, nil
 }
 
 //------------------------------------------------------------------------------
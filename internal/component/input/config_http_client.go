@@ -11,12 +11,26 @@ type StreamConfig struct {
 	MaxBuffer int    `json:"max_buffer" yaml:"max_buffer"`
 }
 
+// PaginationConfig contains fields for specifying how subsequent pages of a
+// paginated API are requested.
+type PaginationConfig struct {
+	Enabled         bool   `json:"enabled" yaml:"enabled"`
+	Cursor          string `json:"cursor" yaml:"cursor"`
+	Stop            string `json:"stop" yaml:"stop"`
+	MaxPages        int    `json:"max_pages" yaml:"max_pages"`
+	CloseOnEnd      bool   `json:"close_on_end" yaml:"close_on_end"`
+	RestartInterval string `json:"restart_interval" yaml:"restart_interval"`
+	Cache           string `json:"cache" yaml:"cache"`
+	CacheKey        string `json:"cache_key" yaml:"cache_key"`
+}
+
 // HTTPClientConfig contains configuration for the HTTPClient output type.
 type HTTPClientConfig struct {
 	oldconfig.OldConfig `json:",inline" yaml:",inline"`
-	Payload             string       `json:"payload" yaml:"payload"`
-	DropEmptyBodies     bool         `json:"drop_empty_bodies" yaml:"drop_empty_bodies"`
-	Stream              StreamConfig `json:"stream" yaml:"stream"`
+	Payload             string           `json:"payload" yaml:"payload"`
+	DropEmptyBodies     bool             `json:"drop_empty_bodies" yaml:"drop_empty_bodies"`
+	Stream              StreamConfig     `json:"stream" yaml:"stream"`
+	Pagination          PaginationConfig `json:"pagination" yaml:"pagination"`
 }
 
 // NewHTTPClientConfig creates a new HTTPClientConfig with default values.
@@ -34,5 +48,15 @@ func NewHTTPClientConfig() HTTPClientConfig {
 			Codec:     "lines",
 			MaxBuffer: 1000000,
 		},
+		Pagination: PaginationConfig{
+			Enabled:         false,
+			Cursor:          "",
+			Stop:            "",
+			MaxPages:        0,
+			CloseOnEnd:      false,
+			RestartInterval: "",
+			Cache:           "",
+			CacheKey:        "http_client_cursor",
+		},
 	}
 }
@@ -26,18 +26,22 @@ func NewHTTPServerResponseConfig() HTTPServerResponseConfig {
 
 // HTTPServerConfig contains configuration for the HTTPServer input type.
 type HTTPServerConfig struct {
-	Address            string                   `json:"address" yaml:"address"`
-	Path               string                   `json:"path" yaml:"path"`
-	WSPath             string                   `json:"ws_path" yaml:"ws_path"`
-	WSWelcomeMessage   string                   `json:"ws_welcome_message" yaml:"ws_welcome_message"`
-	WSRateLimitMessage string                   `json:"ws_rate_limit_message" yaml:"ws_rate_limit_message"`
-	AllowedVerbs       []string                 `json:"allowed_verbs" yaml:"allowed_verbs"`
-	Timeout            string                   `json:"timeout" yaml:"timeout"`
-	RateLimit          string                   `json:"rate_limit" yaml:"rate_limit"`
-	CertFile           string                   `json:"cert_file" yaml:"cert_file"`
-	KeyFile            string                   `json:"key_file" yaml:"key_file"`
-	CORS               httpserver.CORSConfig    `json:"cors" yaml:"cors"`
-	Response           HTTPServerResponseConfig `json:"sync_response" yaml:"sync_response"`
+	Address            string                             `json:"address" yaml:"address"`
+	Path               string                             `json:"path" yaml:"path"`
+	WSPath             string                             `json:"ws_path" yaml:"ws_path"`
+	WSWelcomeMessage   string                             `json:"ws_welcome_message" yaml:"ws_welcome_message"`
+	WSRateLimitMessage string                             `json:"ws_rate_limit_message" yaml:"ws_rate_limit_message"`
+	AllowedVerbs       []string                           `json:"allowed_verbs" yaml:"allowed_verbs"`
+	Timeout            string                             `json:"timeout" yaml:"timeout"`
+	RateLimit          string                             `json:"rate_limit" yaml:"rate_limit"`
+	CertFile           string                             `json:"cert_file" yaml:"cert_file"`
+	KeyFile            string                             `json:"key_file" yaml:"key_file"`
+	CORS               httpserver.CORSConfig              `json:"cors" yaml:"cors"`
+	ClientTLS          httpserver.ClientTLSConfig         `json:"client_tls" yaml:"client_tls"`
+	Auth               httpserver.AuthConfig              `json:"auth" yaml:"auth"`
+	ClientRateLimit    httpserver.ClientRateLimitConfig   `json:"client_rate_limit" yaml:"client_rate_limit"`
+	Validation         httpserver.RequestValidationConfig `json:"validation" yaml:"validation"`
+	Response           HTTPServerResponseConfig           `json:"sync_response" yaml:"sync_response"`
 }
 
 // NewHTTPServerConfig creates a new HTTPServerConfig with default values.
@@ -51,11 +55,15 @@ func NewHTTPServerConfig() HTTPServerConfig {
 		AllowedVerbs: []string{
 			"POST",
 		},
-		Timeout:   "5s",
-		RateLimit: "",
-		CertFile:  "",
-		KeyFile:   "",
-		CORS:      httpserver.NewServerCORSConfig(),
-		Response:  NewHTTPServerResponseConfig(),
+		Timeout:         "5s",
+		RateLimit:       "",
+		CertFile:        "",
+		KeyFile:         "",
+		CORS:            httpserver.NewServerCORSConfig(),
+		ClientTLS:       httpserver.NewClientTLSConfig(),
+		Auth:            httpserver.NewAuthConfig(),
+		ClientRateLimit: httpserver.NewClientRateLimitConfig(),
+		Validation:      httpserver.NewRequestValidationConfig(),
+		Response:        NewHTTPServerResponseConfig(),
 	}
 }
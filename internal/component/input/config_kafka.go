@@ -33,6 +33,8 @@ type KafkaConfig struct {
 	Group               KafkaBalancedGroupConfig `json:"group" yaml:"group"`
 	CommitPeriod        string                   `json:"commit_period" yaml:"commit_period"`
 	CheckpointLimit     int                      `json:"checkpoint_limit" yaml:"checkpoint_limit"`
+	Ordering            string                   `json:"ordering" yaml:"ordering"`
+	KeyLanes            int                      `json:"key_lanes" yaml:"key_lanes"`
 	ExtractTracingMap   string                   `json:"extract_tracing_map" yaml:"extract_tracing_map"`
 	MaxProcessingPeriod string                   `json:"max_processing_period" yaml:"max_processing_period"`
 	FetchBufferCap      int                      `json:"fetch_buffer_cap" yaml:"fetch_buffer_cap"`
@@ -54,6 +56,8 @@ func NewKafkaConfig() KafkaConfig {
 		Group:               NewKafkaBalancedGroupConfig(),
 		CommitPeriod:        "1s",
 		CheckpointLimit:     1024,
+		Ordering:            "none",
+		KeyLanes:            16,
 		MaxProcessingPeriod: "100ms",
 		FetchBufferCap:      256,
 		StartFromOldest:     true,
@@ -61,6 +61,16 @@ func (c *combinedTimer) Timing(delta int64) {
 	c.c2.Timing(delta)
 }
 
+func (c *combinedTimer) TimingWithTraceID(delta int64, traceID string) {
+	for _, t := range []StatTimer{c.c1, c.c2} {
+		if et, ok := t.(StatTimerExemplar); ok {
+			et.TimingWithTraceID(delta, traceID)
+		} else {
+			t.Timing(delta)
+		}
+	}
+}
+
 type combinedGauge struct {
 	c1 StatGauge
 	c2 StatGauge
@@ -18,6 +18,7 @@ type Config struct {
 	Prometheus    PrometheusConfig `json:"prometheus" yaml:"prometheus"`
 	Statsd        StatsdConfig     `json:"statsd" yaml:"statsd"`
 	Logger        LoggerConfig     `json:"logger" yaml:"logger"`
+	OTLP          OTLPConfig       `json:"open_telemetry_collector" yaml:"open_telemetry_collector"`
 	Plugin        any              `json:"plugin,omitempty" yaml:"plugin,omitempty"`
 }
 
@@ -33,6 +34,7 @@ func NewConfig() Config {
 		Prometheus:    NewPrometheusConfig(),
 		Statsd:        NewStatsdConfig(),
 		Logger:        NewLoggerConfig(),
+		OTLP:          NewOTLPConfig(),
 		Plugin:        nil,
 	}
 }
@@ -0,0 +1,30 @@
+package metrics
+
+// OTLPCollectorConfig contains the address of an Open Telemetry collector.
+type OTLPCollectorConfig struct {
+	URL string `json:"url" yaml:"url"`
+}
+
+// OTLPConfig is config for the Open Telemetry collector metrics type.
+type OTLPConfig struct {
+	HTTP               []OTLPCollectorConfig `json:"http" yaml:"http"`
+	GRPC               []OTLPCollectorConfig `json:"grpc" yaml:"grpc"`
+	ResourceAttributes map[string]string     `json:"resource_attributes" yaml:"resource_attributes"`
+	Temporality        string                `json:"temporality" yaml:"temporality"`
+	PushInterval       string                `json:"push_interval" yaml:"push_interval"`
+	Timeout            string                `json:"timeout" yaml:"timeout"`
+	HistogramBuckets   []float64             `json:"histogram_buckets" yaml:"histogram_buckets"`
+}
+
+// NewOTLPConfig creates an OTLPConfig struct with default values.
+func NewOTLPConfig() OTLPConfig {
+	return OTLPConfig{
+		HTTP:               []OTLPCollectorConfig{},
+		GRPC:               []OTLPCollectorConfig{},
+		ResourceAttributes: map[string]string{},
+		Temporality:        "cumulative",
+		PushInterval:       "10s",
+		Timeout:            "5s",
+		HistogramBuckets:   []float64{},
+	}
+}
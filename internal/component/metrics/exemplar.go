@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
+)
+
+// StatTimerExemplar is an optional interface implemented by StatTimers that
+// are able to attach the ID of a trace to a timing observation as an exemplar,
+// allowing metrics systems to link the observation to the trace.
+type StatTimerExemplar interface {
+	// TimingWithTraceID sets a timing metric with a trace ID exemplar.
+	TimingWithTraceID(delta int64, traceID string)
+}
+
+// TimingWithTrace sets a timing metric, attaching the trace ID of the first
+// message of a batch as an exemplar when the timer supports exemplars and the
+// message is part of a valid trace.
+func TimingWithTrace(t StatTimer, delta int64, batch message.Batch) {
+	if et, ok := t.(StatTimerExemplar); ok && batch.Len() > 0 {
+		if traceID := tracing.GetTraceID(batch.Get(0)); traceID != (trace.TraceID{}).String() {
+			et.TimingWithTraceID(delta, traceID)
+			return
+		}
+	}
+	t.Timing(delta)
+}
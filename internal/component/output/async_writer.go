@@ -223,6 +223,7 @@ func (w *AsyncWriter) loop() {
 
 			w.log.Tracef("Attempting to write %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			_, spans := tracing.WithChildSpans(w.tracer, traceName, ts.Payload)
+			tracing.InjectSpansIntoMetadata(w.tracer, ts.Payload, spans)
 			w.injectSpans(ts.Payload, spans)
 
 			latency, err := w.latencyMeasuringWrite(closeLeisureCtx, ts.Payload)
@@ -250,7 +251,7 @@ func (w *AsyncWriter) loop() {
 			} else {
 				mBatchSent.Incr(1)
 				mSent.Incr(int64(batch.MessageCollapsedCount(ts.Payload)))
-				mLatency.Timing(latency)
+				metrics.TimingWithTrace(mLatency, latency, ts.Payload)
 				w.log.Tracef("Successfully wrote %v messages to '%v'.\n", ts.Payload.Len(), w.typeStr)
 			}
 
@@ -1,11 +1,24 @@
 package processor
 
-import "github.com/benthosdev/benthos/v4/internal/httpclient/oldconfig"
+import (
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/internal/httpclient/oldconfig"
+)
+
+// HTTPCircuitBreakerConfig contains configuration fields for an optional
+// circuit breaker of the HTTP processor.
+type HTTPCircuitBreakerConfig struct {
+	Enabled               bool     `json:"enabled" yaml:"enabled"`
+	OpenAction            string   `json:"open_action" yaml:"open_action"`
+	Fallback              []Config `json:"fallback" yaml:"fallback"`
+	circuitbreaker.Config `json:",inline" yaml:",inline"`
+}
 
 // HTTPConfig contains configuration fields for the HTTP processor.
 type HTTPConfig struct {
-	BatchAsMultipart    bool `json:"batch_as_multipart" yaml:"batch_as_multipart"`
-	Parallel            bool `json:"parallel" yaml:"parallel"`
+	BatchAsMultipart    bool                     `json:"batch_as_multipart" yaml:"batch_as_multipart"`
+	Parallel            bool                     `json:"parallel" yaml:"parallel"`
+	CircuitBreaker      HTTPCircuitBreakerConfig `json:"circuit_breaker" yaml:"circuit_breaker"`
 	oldconfig.OldConfig `json:",inline" yaml:",inline"`
 }
 
@@ -14,6 +27,12 @@ func NewHTTPConfig() HTTPConfig {
 	return HTTPConfig{
 		BatchAsMultipart: false,
 		Parallel:         false,
-		OldConfig:        oldconfig.NewOldConfig(),
+		CircuitBreaker: HTTPCircuitBreakerConfig{
+			Enabled:    false,
+			OpenAction: "fail",
+			Fallback:   []Config{},
+			Config:     circuitbreaker.NewConfig(),
+		},
+		OldConfig: oldconfig.NewOldConfig(),
 	}
 }
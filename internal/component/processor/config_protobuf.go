@@ -2,16 +2,38 @@ package processor
 
 // ProtobufConfig contains configuration fields for the Protobuf processor.
 type ProtobufConfig struct {
-	Operator    string   `json:"operator" yaml:"operator"`
-	Message     string   `json:"message" yaml:"message"`
-	ImportPaths []string `json:"import_paths" yaml:"import_paths"`
+	Operator       string            `json:"operator" yaml:"operator"`
+	Message        string            `json:"message" yaml:"message"`
+	ImportPaths    []string          `json:"import_paths" yaml:"import_paths"`
+	DescriptorSets []string          `json:"descriptor_sets" yaml:"descriptor_sets"`
+	BSR            ProtobufBSRConfig `json:"bsr" yaml:"bsr"`
+	WellKnownTypes string            `json:"well_known_types" yaml:"well_known_types"`
+	FieldMask      []string          `json:"field_mask" yaml:"field_mask"`
+}
+
+// ProtobufBSRConfig contains configuration fields for fetching the schemas of
+// a module from the Buf Schema Registry.
+type ProtobufBSRConfig struct {
+	Module  string `json:"module" yaml:"module"`
+	Version string `json:"version" yaml:"version"`
+	APIKey  string `json:"api_key" yaml:"api_key"`
+	URL     string `json:"url" yaml:"url"`
 }
 
 // NewProtobufConfig returns a ProtobufConfig with default values.
 func NewProtobufConfig() ProtobufConfig {
 	return ProtobufConfig{
-		Operator:    "",
-		Message:     "",
-		ImportPaths: []string{},
+		Operator:       "",
+		Message:        "",
+		ImportPaths:    []string{},
+		DescriptorSets: []string{},
+		BSR: ProtobufBSRConfig{
+			Module:  "",
+			Version: "",
+			APIKey:  "",
+			URL:     "",
+		},
+		WellKnownTypes: "canonical",
+		FieldMask:      []string{},
 	}
 }
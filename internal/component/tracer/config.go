@@ -20,6 +20,23 @@ type Config struct {
 	CloudTrace CloudTraceConfig `json:"gcp_cloudtrace" yaml:"gcp_cloudtrace"`
 	None       struct{}         `json:"none" yaml:"none"`
 	Plugin     any              `json:"plugin,omitempty" yaml:"plugin,omitempty"`
+
+	Propagation PropagationConfig `json:"propagation" yaml:"propagation"`
+}
+
+// PropagationConfig contains settings for automatically propagating trace
+// context through message metadata.
+type PropagationConfig struct {
+	Extract bool `json:"extract" yaml:"extract"`
+	Inject  bool `json:"inject" yaml:"inject"`
+}
+
+// NewPropagationConfig returns a PropagationConfig with default values.
+func NewPropagationConfig() PropagationConfig {
+	return PropagationConfig{
+		Extract: false,
+		Inject:  false,
+	}
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -30,6 +47,8 @@ func NewConfig() Config {
 		CloudTrace: NewCloudTraceConfig(),
 		None:       struct{}{},
 		Plugin:     nil,
+
+		Propagation: NewPropagationConfig(),
 	}
 }
 
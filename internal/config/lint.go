@@ -7,6 +7,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
 
@@ -14,6 +15,12 @@ import (
 type LintOptions struct {
 	RejectDeprecated bool
 	RequireLabels    bool
+
+	// When true Bloblang mappings are statically analysed and any findings are
+	// reported as linting warnings, optionally using a JSON Schema describing
+	// the input documents of mappings.
+	BloblangStatic      bool
+	BloblangInputSchema any
 }
 
 // ReadFileLinted will attempt to read a configuration file path into a
@@ -51,6 +58,10 @@ func LintBytes(opts LintOptions, rawBytes []byte) ([]docs.Lint, error) {
 	lintCtx := docs.NewLintContext()
 	lintCtx.RejectDeprecated = opts.RejectDeprecated
 	lintCtx.RequireLabels = opts.RequireLabels
+	if opts.BloblangStatic {
+		lintCtx.BloblangStaticEnv = bloblang.GlobalEnvironment()
+		lintCtx.BloblangInputSchema = opts.BloblangInputSchema
+	}
 
 	return Spec().LintYAML(lintCtx, &rawNode), nil
 }
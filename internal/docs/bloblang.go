@@ -21,7 +21,7 @@ func LintBloblangMapping(ctx LintContext, line, col int, v any) []Lint {
 	}
 	_, err := ctx.BloblangEnv.NewMapping(str)
 	if err == nil {
-		return nil
+		return lintBloblangStatic(ctx, line, col, str)
 	}
 	if mErr, ok := err.(*parser.Error); ok {
 		bline, bcol := parser.LineAndColOf([]rune(str), mErr.Input)
@@ -32,6 +32,27 @@ func LintBloblangMapping(ctx LintContext, line, col int, v any) []Lint {
 	return []Lint{NewLintError(line, LintBadBloblang, err.Error())}
 }
 
+func lintBloblangStatic(ctx LintContext, line, col int, str string) []Lint {
+	if ctx.BloblangStaticEnv == nil {
+		return nil
+	}
+	bLints, err := ctx.BloblangStaticEnv.LintMapping(str, ctx.BloblangInputSchema)
+	if err != nil {
+		return nil
+	}
+	var lints []Lint
+	for _, bl := range bLints {
+		if bl.Position.Source != "" {
+			lints = append(lints, NewLintWarning(line, LintBloblangStatic, bl.String()))
+			continue
+		}
+		lint := NewLintWarning(line+bl.Position.Line-1, LintBloblangStatic, bl.What)
+		lint.Column = col + bl.Position.Column
+		lints = append(lints, lint)
+	}
+	return lints
+}
+
 // LintBloblangField is function for linting a config field expected to be an
 // interpolation string.
 func LintBloblangField(ctx LintContext, line, col int, v any) []Lint {
@@ -77,6 +77,9 @@ func ReservedFieldsByType(t Type) map[string]FieldSpec {
 	if t == TypeMetrics {
 		m["mapping"] = MetricsMappingFieldSpec("mapping")
 	}
+	if t == TypeTracer {
+		m["propagation"] = TracerPropagationFieldSpec("propagation")
+	}
 	if _, isLabelType := map[Type]struct{}{
 		TypeInput:     {},
 		TypeProcessor: {},
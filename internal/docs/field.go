@@ -566,6 +566,15 @@ type LintContext struct {
 
 	// Require labels for components.
 	RequireLabels bool
+
+	// Perform a static analysis of Bloblang mappings with this environment and
+	// report the findings as linting warnings. Static analysis is skipped when
+	// nil.
+	BloblangStaticEnv *bloblang.Environment
+
+	// An optional JSON Schema describing the input documents of Bloblang
+	// mappings, used in order to infer types during static analysis.
+	BloblangInputSchema any
 }
 
 // NewLintContext creates a new linting context.
@@ -648,6 +657,10 @@ const (
 
 	// LintDeprecated means a field is deprecated and should not be used.
 	LintDeprecated LintType = iota
+
+	// LintBloblangStatic means a static analysis of a Bloblang mapping found a
+	// likely problem.
+	LintBloblangStatic LintType = iota
 )
 
 // Lint describes a single linting issue found with a Benthos config.
@@ -6,6 +6,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
 )
 
 func TestBloblLinter(t *testing.T) {
@@ -67,3 +69,30 @@ func TestBloblLinter(t *testing.T) {
 		})
 	}
 }
+
+func TestBloblangStaticLinter(t *testing.T) {
+	f := FieldBloblang("foo", "")
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`foo: |
+  let unused = "nope"
+  root.a = this.age.uppercase()
+`), &node))
+	fooNode := node.Content[0].Content[1]
+
+	lintCtx := NewLintContext()
+	assert.Empty(t, f.LintYAML(lintCtx, fooNode))
+
+	lintCtx.BloblangStaticEnv = bloblang.GlobalEnvironment()
+	lintCtx.BloblangInputSchema = map[string]any{
+		"properties": map[string]any{
+			"age": map[string]any{"type": "number"},
+		},
+	}
+
+	unusedLint := NewLintWarning(2, LintBloblangStatic, "variable `unused` is assigned but never used")
+	unusedLint.Column = 7
+	typeLint := NewLintWarning(3, LintBloblangStatic, "method uppercase: expected string or bytes value, got number from field `this.age`")
+	typeLint.Column = 25
+	assert.Equal(t, []Lint{unusedLint, typeLint}, f.LintYAML(lintCtx, fooNode))
+}
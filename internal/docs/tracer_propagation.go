@@ -0,0 +1,10 @@
+package docs
+
+// TracerPropagationFieldSpec returns a field spec for the automatic propagation
+// of trace context through message metadata.
+func TracerPropagationFieldSpec(name string) FieldSpec {
+	return FieldObject(name, "Options for automatically propagating [W3C trace context](https://www.w3.org/TR/trace-context/) (the `traceparent` and `tracestate` keys) through message metadata, which connects traces across services without the need for `extract_tracing_map` or `inject_tracing_map` mappings. For more information check out the [tracers documentation](/docs/components/tracers/about#propagation).").WithChildren(
+		FieldBool("extract", "Whether inputs should create the root span of each consumed message as a child of any trace context found within its metadata. Metadata keys are matched case insensitively, which means trace context within Kafka headers, HTTP headers, AMQP properties and NATS headers are all extracted provided the input adds them as metadata.").HasDefault(false),
+		FieldBool("inject", "Whether outputs should add the trace context of the span of each message being written to its metadata. The trace context is only carried to the destination when the output is configured to send metadata, e.g. as Kafka or HTTP headers.").HasDefault(false),
+	).Advanced()
+}
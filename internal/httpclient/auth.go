@@ -4,6 +4,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/docs/interop"
 	"github.com/benthosdev/benthos/v4/internal/httpclient/oldconfig"
+	"github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -29,9 +30,19 @@ func OldAuthFieldSpecsExpanded() docs.FieldSpecs {
 		interop.Unwrap(oAuth2FieldSpec()),
 		interop.Unwrap(jwtFieldSpec()),
 		interop.Unwrap(basicAuthField()),
+		awsSigV4FieldSpec(),
 	}
 }
 
+func awsSigV4FieldSpec() docs.FieldSpec {
+	return docs.FieldObject("aws_sigv4", "Allows you to sign requests with [AWS Signature Version 4](https://docs.aws.amazon.com/general/latest/gr/signature-version-4.html), which is required by IAM protected services such as API Gateway endpoints and OpenSearch. Credentials are resolved in the same way as other AWS components, more information can be found [in this document](/docs/guides/cloud/aws). Signing sets the `Authorization` header and therefore cannot be combined with other authentication methods.").WithChildren(
+		docs.FieldSpecs{
+			docs.FieldBool("enabled", "Whether to sign requests with AWS Signature Version 4.").HasDefault(false),
+			docs.FieldString("service", "The signing name of the AWS service being targeted.", "execute-api", "es", "aoss", "lambda").HasDefault(""),
+		}.Merge(session.FieldSpecs())...,
+	).Advanced().AtVersion("4.10.0")
+}
+
 //------------------------------------------------------------------------------
 
 // AuthFields returns a list of config field specs for all basic, header based,
@@ -0,0 +1,22 @@
+package oldconfig
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+)
+
+// AWSSigV4Config contains configuration params for signing HTTP requests with
+// AWS Signature Version 4.
+type AWSSigV4Config struct {
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	Service        string `json:"service" yaml:"service"`
+	session.Config `json:",inline" yaml:",inline"`
+}
+
+// NewAWSSigV4Config returns a new AWSSigV4Config with default values.
+func NewAWSSigV4Config() AWSSigV4Config {
+	return AWSSigV4Config{
+		Enabled: false,
+		Service: "",
+		Config:  session.NewConfig(),
+	}
+}
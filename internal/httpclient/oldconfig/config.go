@@ -23,7 +23,8 @@ type OldConfig struct {
 	TLS             tls.Config                   `json:"tls" yaml:"tls"`
 	ProxyURL        string                       `json:"proxy_url" yaml:"proxy_url"`
 	AuthConfig      `json:",inline" yaml:",inline"`
-	OAuth2          OAuth2Config `json:"oauth2" yaml:"oauth2"`
+	OAuth2          OAuth2Config   `json:"oauth2" yaml:"oauth2"`
+	AWSSigV4        AWSSigV4Config `json:"aws_sigv4" yaml:"aws_sigv4"`
 }
 
 // NewOldConfig creates a new Config with default values.
@@ -44,5 +45,6 @@ func NewOldConfig() OldConfig {
 		TLS:             tls.NewConfig(),
 		AuthConfig:      NewAuthConfig(),
 		OAuth2:          NewOAuth2Config(),
+		AWSSigV4:        NewAWSSigV4Config(),
 	}
 }
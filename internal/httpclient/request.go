@@ -2,6 +2,7 @@ package httpclient
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -31,6 +32,11 @@ type MultipartExpressions struct {
 // functions, usually authentication.
 type RequestSigner func(req *http.Request) error
 
+// AWSSigV4SignerFn is populated by the AWS plugins in order to create request
+// signers for AWS Signature Version 4 without this package depending on the
+// AWS SDK.
+var AWSSigV4SignerFn func(conf oldconfig.AWSSigV4Config) (RequestSigner, error)
+
 // RequestCreator creates *http.Request types from messages based on various
 // configurable parameters.
 type RequestCreator struct {
@@ -64,6 +70,20 @@ func RequestCreatorFromOldConfig(conf oldconfig.OldConfig, mgr bundle.NewManagem
 	}
 
 	var err error
+	if conf.AWSSigV4.Enabled {
+		// SigV4 sets the Authorization header and would therefore replace the
+		// header set by any other auth strategy.
+		if conf.BasicAuth.Enabled || conf.OAuth.Enabled || conf.OAuth2.Enabled || conf.JWT.Enabled {
+			return nil, errors.New("aws_sigv4 cannot be enabled alongside basic_auth, oauth, oauth2 or jwt as each sets the Authorization header")
+		}
+		if AWSSigV4SignerFn == nil {
+			return nil, errors.New("aws_sigv4 signing is not supported by this build as AWS components have not been imported")
+		}
+		if r.reqSigner, err = AWSSigV4SignerFn(conf.AWSSigV4); err != nil {
+			return nil, fmt.Errorf("failed to create aws_sigv4 signer: %w", err)
+		}
+	}
+
 	if r.url, err = mgr.BloblEnvironment().NewField(conf.URL); err != nil {
 		return nil, fmt.Errorf("failed to parse URL expression: %v", err)
 	}
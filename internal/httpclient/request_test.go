@@ -1,6 +1,7 @@
 package httpclient
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/benthosdev/benthos/v4/internal/httpclient/oldconfig"
@@ -32,3 +33,67 @@ func TestSingleMessageHeaders(t *testing.T) {
 	assert.Equal(t, []string{"barvalue"}, req.Header.Values("more_bar"))
 	assert.Equal(t, []string(nil), req.Header.Values("ignore_baz"))
 }
+
+func TestAWSSigV4Signer(t *testing.T) {
+	oldConf := oldconfig.NewOldConfig()
+	oldConf.URL = "http://example.com/foo"
+	oldConf.Headers["X-Foo"] = "bar"
+	oldConf.AWSSigV4.Enabled = true
+
+	prevFn := AWSSigV4SignerFn
+	t.Cleanup(func() {
+		AWSSigV4SignerFn = prevFn
+	})
+
+	AWSSigV4SignerFn = nil
+	_, err := RequestCreatorFromOldConfig(oldConf, mock.NewManager())
+	require.Error(t, err)
+
+	AWSSigV4SignerFn = func(conf oldconfig.AWSSigV4Config) (RequestSigner, error) {
+		return func(req *http.Request) error {
+			// Headers must be present before signing.
+			req.Header.Set("Authorization", "signed "+req.Header.Get("X-Foo"))
+			return nil
+		}, nil
+	}
+
+	reqCreator, err := RequestCreatorFromOldConfig(oldConf, mock.NewManager())
+	require.NoError(t, err)
+
+	req, err := reqCreator.Create(message.QuickBatch([][]byte{[]byte("hello world")}))
+	require.NoError(t, err)
+
+	assert.Equal(t, "signed bar", req.Header.Get("Authorization"))
+}
+
+func TestAWSSigV4SignerWithOtherAuth(t *testing.T) {
+	prevFn := AWSSigV4SignerFn
+	t.Cleanup(func() {
+		AWSSigV4SignerFn = prevFn
+	})
+	AWSSigV4SignerFn = func(conf oldconfig.AWSSigV4Config) (RequestSigner, error) {
+		return func(req *http.Request) error { return nil }, nil
+	}
+
+	for _, test := range []struct {
+		name   string
+		enable func(c *oldconfig.OldConfig)
+	}{
+		{name: "basic_auth", enable: func(c *oldconfig.OldConfig) { c.BasicAuth.Enabled = true }},
+		{name: "oauth", enable: func(c *oldconfig.OldConfig) { c.OAuth.Enabled = true }},
+		{name: "oauth2", enable: func(c *oldconfig.OldConfig) { c.OAuth2.Enabled = true }},
+		{name: "jwt", enable: func(c *oldconfig.OldConfig) { c.JWT.Enabled = true }},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			oldConf := oldconfig.NewOldConfig()
+			oldConf.URL = "http://example.com/foo"
+			oldConf.AWSSigV4.Enabled = true
+			test.enable(&oldConf)
+
+			_, err := RequestCreatorFromOldConfig(oldConf, mock.NewManager())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "aws_sigv4 cannot be enabled alongside")
+		})
+	}
+}
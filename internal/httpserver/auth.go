@@ -0,0 +1,106 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// AuthConfig contains struct based fields for verifying the authenticity of
+// requests made to an HTTP server.
+type AuthConfig struct {
+	JWT  JWTAuthConfig  `json:"jwt" yaml:"jwt"`
+	HMAC HMACAuthConfig `json:"hmac" yaml:"hmac"`
+}
+
+// NewAuthConfig returns an AuthConfig with default values.
+func NewAuthConfig() AuthConfig {
+	return AuthConfig{
+		JWT:  NewJWTAuthConfig(),
+		HMAC: NewHMACAuthConfig(),
+	}
+}
+
+// AuthFieldSpec returns the spec for an HTTP server auth field.
+func AuthFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("auth", "Allows you to verify the authenticity of requests made to the HTTP server. Requests that fail verification are rejected with a 401 response.").WithChildren(
+		jwtAuthFieldSpec(),
+		hmacAuthFieldSpec(),
+	).AtVersion("4.10.0").Advanced()
+}
+
+// Middleware returns a function that wraps HTTP handlers with middleware that
+// rejects requests that fail any enabled authentication methods and attaches
+// the identity of the client to the context of requests that pass. Handlers
+// wrapped by the same middleware share verification state such as key sets.
+func (a AuthConfig) Middleware() (func(next http.HandlerFunc) http.HandlerFunc, error) {
+	var jwtV *jwtVerifier
+	if a.JWT.Enabled {
+		var err error
+		if jwtV, err = newJWTVerifier(a.JWT); err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+	}
+
+	var hmacV *hmacVerifier
+	if a.HMAC.Enabled {
+		var err error
+		if hmacV, err = newHMACVerifier(a.HMAC); err != nil {
+			return nil, fmt.Errorf("hmac: %w", err)
+		}
+	}
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if hmacV != nil {
+				if err := hmacV.verify(r); err != nil {
+					if errors.Is(err, errBodyTooLarge) {
+						http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+						return
+					}
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+			}
+
+			identity := ""
+			if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+				cert := r.TLS.VerifiedChains[0][0]
+				if identity = cert.Subject.CommonName; identity == "" {
+					identity = cert.Subject.String()
+				}
+			}
+
+			if jwtV != nil {
+				subject, err := jwtV.verify(r)
+				if err != nil {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+					http.Error(w, "Unauthorized", http.StatusUnauthorized)
+					return
+				}
+				if subject != "" {
+					identity = subject
+				}
+			}
+
+			if identity != "" {
+				r = r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, identity))
+			}
+			next.ServeHTTP(w, r)
+		}
+	}, nil
+}
+
+type clientIdentityKey struct{}
+
+// ClientIdentity returns the identity of the client that made a request, which
+// is the subject of a verified JWT when present, otherwise the common name of a
+// verified client certificate. An empty string is returned when the client
+// could not be identified.
+func ClientIdentity(r *http.Request) string {
+	id, _ := r.Context().Value(clientIdentityKey{}).(string)
+	return id
+}
@@ -0,0 +1,302 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func authTestHandler(t *testing.T, conf AuthConfig) http.HandlerFunc {
+	t.Helper()
+
+	mw, err := conf.Middleware()
+	require.NoError(t, err)
+
+	return mw(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(ClientIdentity(r) + ":" + string(body)))
+	})
+}
+
+func authTestRequest(h http.HandlerFunc, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	return res
+}
+
+func signTestJWT(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	require.NoError(t, err)
+	return "Bearer " + s
+}
+
+func TestAuthJWTSecret(t *testing.T) {
+	conf := NewAuthConfig()
+	conf.JWT.Enabled = true
+	conf.JWT.Secret = "foosecret"
+	conf.JWT.Issuer = "benthos"
+	conf.JWT.Audience = "webhooks"
+
+	h := authTestHandler(t, conf)
+	exp := time.Now().Add(time.Hour).Unix()
+
+	res := authTestRequest(h, "hello", map[string]string{
+		"Authorization": signTestJWT(t, jwt.SigningMethodHS256, []byte("foosecret"), "", jwt.MapClaims{
+			"sub": "alice", "iss": "benthos", "aud": "webhooks", "exp": exp,
+		}),
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "alice:hello", res.Body.String())
+
+	for name, headers := range map[string]map[string]string{
+		"no token": {},
+		"wrong secret": {"Authorization": signTestJWT(t, jwt.SigningMethodHS256, []byte("barsecret"), "", jwt.MapClaims{
+			"sub": "alice", "iss": "benthos", "aud": "webhooks", "exp": exp,
+		})},
+		"expired": {"Authorization": signTestJWT(t, jwt.SigningMethodHS256, []byte("foosecret"), "", jwt.MapClaims{
+			"sub": "alice", "iss": "benthos", "aud": "webhooks", "exp": time.Now().Add(-time.Hour).Unix(),
+		})},
+		"wrong issuer": {"Authorization": signTestJWT(t, jwt.SigningMethodHS256, []byte("foosecret"), "", jwt.MapClaims{
+			"sub": "alice", "iss": "nope", "aud": "webhooks", "exp": exp,
+		})},
+		"wrong audience": {"Authorization": signTestJWT(t, jwt.SigningMethodHS256, []byte("foosecret"), "", jwt.MapClaims{
+			"sub": "alice", "iss": "benthos", "aud": "nope", "exp": exp,
+		})},
+	} {
+		res := authTestRequest(h, "hello", headers)
+		assert.Equal(t, http.StatusUnauthorized, res.Code, name)
+		assert.Contains(t, res.Header().Get("WWW-Authenticate"), "Bearer", name)
+	}
+}
+
+func TestAuthJWTPublicKeyFile(t *testing.T) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&privKey.PublicKey)
+	require.NoError(t, err)
+
+	keyPath := filepath.Join(t.TempDir(), "key.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: pubBytes,
+	}), 0o644))
+
+	conf := NewAuthConfig()
+	conf.JWT.Enabled = true
+	conf.JWT.PublicKeyFile = keyPath
+
+	h := authTestHandler(t, conf)
+
+	res := authTestRequest(h, "hello", map[string]string{
+		"Authorization": signTestJWT(t, jwt.SigningMethodRS256, privKey, "", jwt.MapClaims{"sub": "bob"}),
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "bob:hello", res.Body.String())
+
+	// Tokens signed with the public key as an HMAC secret must be rejected.
+	res = authTestRequest(h, "hello", map[string]string{
+		"Authorization": signTestJWT(t, jwt.SigningMethodHS256, pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: pubBytes,
+		}), "", jwt.MapClaims{"sub": "bob"}),
+	})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestAuthJWTJWKS(t *testing.T) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var fetches int32
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []any{
+				map[string]any{
+					"kty": "RSA",
+					"kid": "foo",
+					"use": "sig",
+					"n":   base64.RawURLEncoding.EncodeToString(privKey.N.Bytes()),
+					"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privKey.E)).Bytes()),
+				},
+			},
+		})
+	}))
+	t.Cleanup(jwksServer.Close)
+
+	conf := NewAuthConfig()
+	conf.JWT.Enabled = true
+	conf.JWT.JWKSURL = jwksServer.URL
+	conf.JWT.Algorithms = []string{"RS256"}
+
+	h := authTestHandler(t, conf)
+
+	for i := 0; i < 3; i++ {
+		res := authTestRequest(h, "hello", map[string]string{
+			"Authorization": signTestJWT(t, jwt.SigningMethodRS256, privKey, "foo", jwt.MapClaims{"sub": "carol"}),
+		})
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, "carol:hello", res.Body.String())
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	res := authTestRequest(h, "hello", map[string]string{
+		"Authorization": signTestJWT(t, jwt.SigningMethodRS256, privKey, "bar", jwt.MapClaims{"sub": "carol"}),
+	})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = authTestRequest(h, "hello", map[string]string{
+		"Authorization": signTestJWT(t, jwt.SigningMethodRS512, privKey, "foo", jwt.MapClaims{"sub": "carol"}),
+	})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+}
+
+func TestAuthJWTJWKSFetchFailures(t *testing.T) {
+	var failing int32 = 1
+	var fetches int32
+	release := make(chan struct{})
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "nope", http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"keys":[{"kty":"oct","kid":"foo"}]}`))
+	}))
+	t.Cleanup(jwksServer.Close)
+
+	conf := NewJWTAuthConfig()
+	conf.JWKSURL = jwksServer.URL
+
+	v, err := newJWTVerifier(conf)
+	require.NoError(t, err)
+
+	now := time.Unix(0, 0)
+	v.now = func() time.Time { return now }
+
+	// Concurrent requests share a single fetch of the key set.
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := v.jwksKey("foo")
+			assert.Error(t, err)
+		}()
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// After a failure the key set isn't fetched again until the backoff has
+	// elapsed.
+	_, err = v.jwksKey("foo")
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	atomic.StoreInt32(&failing, 0)
+	now = now.Add(jwksMinRefreshInterval * 2)
+
+	_, err = v.jwksKey("foo")
+	require.EqualError(t, err, `key "foo" not found in key set`)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+}
+
+func TestAuthJWTBadConfig(t *testing.T) {
+	conf := NewAuthConfig()
+	conf.JWT.Enabled = true
+	_, err := conf.Middleware()
+	require.Error(t, err)
+
+	conf.JWT.Secret = "foo"
+	conf.JWT.JWKSURL = "http://localhost"
+	_, err = conf.Middleware()
+	require.Error(t, err)
+}
+
+func TestAuthHMAC(t *testing.T) {
+	conf := NewAuthConfig()
+	conf.HMAC.Enabled = true
+	conf.HMAC.Secret = "foosecret"
+	conf.HMAC.Header = "X-Hub-Signature-256"
+
+	h := authTestHandler(t, conf)
+
+	mac := hmac.New(sha256.New, []byte("foosecret"))
+	_, _ = mac.Write([]byte("hello world"))
+	sig := hex.EncodeToString(mac.Sum(nil))
+
+	res := authTestRequest(h, "hello world", map[string]string{
+		"X-Hub-Signature-256": "sha256=" + sig,
+	})
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, ":hello world", res.Body.String())
+
+	for name, headers := range map[string]map[string]string{
+		"no signature":    {},
+		"no scheme":       {"X-Hub-Signature-256": sig},
+		"wrong signature": {"X-Hub-Signature-256": "sha256=" + strings.Repeat("0", len(sig))},
+		"bad encoding":    {"X-Hub-Signature-256": "sha256=nothex"},
+	} {
+		res := authTestRequest(h, "hello world", headers)
+		assert.Equal(t, http.StatusUnauthorized, res.Code, name)
+	}
+
+	res = authTestRequest(h, "hello tampered", map[string]string{
+		"X-Hub-Signature-256": "sha256=" + sig,
+	})
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	res = authTestRequest(h, strings.Repeat("a", MaxBufferedBodyBytes+1), map[string]string{
+		"X-Hub-Signature-256": "sha256=" + sig,
+	})
+	assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+}
+
+func TestAuthClientCertIdentity(t *testing.T) {
+	h := authTestHandler(t, NewAuthConfig())
+
+	req := httptest.NewRequest("POST", "https://example.com/", strings.NewReader("hello"))
+	req.TLS.VerifiedChains = [][]*x509.Certificate{{
+		{Subject: pkix.Name{CommonName: "dave"}},
+	}}
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "dave:hello", res.Body.String())
+}
@@ -0,0 +1,35 @@
+package httpserver
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// MaxBufferedBodyBytes is the maximum size of a request body that is read in
+// full by middleware, such as HMAC authentication and request validation,
+// before the request reaches the wrapped handler.
+const MaxBufferedBodyBytes = 10 * 1024 * 1024
+
+var errBodyTooLarge = errors.New("request body exceeds the maximum size")
+
+// bufferBody reads the body of a request in full, up to MaxBufferedBodyBytes,
+// and replaces it so that it can be consumed again by subsequent handlers.
+func bufferBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		r.Body = http.NoBody
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxBufferedBodyBytes+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxBufferedBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -0,0 +1,91 @@
+package httpserver
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// RateLimitProvider provides access to rate limit resources.
+type RateLimitProvider interface {
+	ProbeRateLimit(name string) bool
+	AccessRateLimit(ctx context.Context, name string, fn func(ratelimit.V1)) error
+}
+
+// ClientRateLimitConfig contains struct based fields for applying rate limit
+// resources to requests based on the identity of the client.
+type ClientRateLimitConfig struct {
+	Resources map[string]string `json:"resources" yaml:"resources"`
+	Fallback  string            `json:"fallback" yaml:"fallback"`
+}
+
+// NewClientRateLimitConfig returns a ClientRateLimitConfig with default
+// values.
+func NewClientRateLimitConfig() ClientRateLimitConfig {
+	return ClientRateLimitConfig{
+		Resources: map[string]string{},
+		Fallback:  "",
+	}
+}
+
+// ClientRateLimitFieldSpec returns the spec for an HTTP server client rate
+// limit field.
+func ClientRateLimitFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("client_rate_limit", "Allows you to throttle requests by the identity of the client, as established by `auth.jwt` or `client_tls`. Requests that breach their rate limit receive a 429 response with a Retry-After header.").WithChildren(
+		docs.FieldString("resources", "A map of client identities to the [rate limit resource](/docs/components/rate_limits/about) that applies to their requests.", map[string]string{"billing-service": "billing_limit"}).Map().HasDefault(map[string]string{}),
+		docs.FieldString("fallback", "An optional rate limit resource that applies to requests from clients not listed within `resources`, including those that could not be identified.").HasDefault(""),
+	).AtVersion("4.10.0").Advanced()
+}
+
+// WrapHandler wraps the provided HTTP handler with middleware that applies
+// rate limit resources according to the identity of the client. The handler
+// must be wrapped by an AuthConfig handler in order for clients to be
+// identified.
+func (c ClientRateLimitConfig) WrapHandler(next http.HandlerFunc, mgr RateLimitProvider) (http.HandlerFunc, error) {
+	if len(c.Resources) == 0 && c.Fallback == "" {
+		return next, nil
+	}
+	for _, name := range c.Resources {
+		if !mgr.ProbeRateLimit(name) {
+			return nil, fmt.Errorf("rate limit resource '%v' was not found", name)
+		}
+	}
+	if c.Fallback != "" && !mgr.ProbeRateLimit(c.Fallback) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", c.Fallback)
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		name, exists := c.Resources[ClientIdentity(r)]
+		if !exists {
+			name = c.Fallback
+		}
+		if name == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var tUntil time.Duration
+		var err error
+		if rerr := mgr.AccessRateLimit(r.Context(), name, func(rl ratelimit.V1) {
+			tUntil, err = rl.Access(r.Context())
+		}); rerr != nil {
+			err = rerr
+		}
+		if err != nil {
+			http.Error(w, "Server error", http.StatusBadGateway)
+			return
+		}
+		if tUntil > 0 {
+			w.Header().Add("Retry-After", strconv.Itoa(int(math.Ceil(tUntil.Seconds()))))
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}, nil
+}
@@ -0,0 +1,111 @@
+package httpserver
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
+)
+
+type fnRateLimit func(context.Context) (time.Duration, error)
+
+func (f fnRateLimit) Access(ctx context.Context) (time.Duration, error) {
+	return f(ctx)
+}
+
+func (f fnRateLimit) Close(ctx context.Context) error {
+	return nil
+}
+
+type mapRateLimitProvider map[string]ratelimit.V1
+
+func (m mapRateLimitProvider) ProbeRateLimit(name string) bool {
+	_, exists := m[name]
+	return exists
+}
+
+func (m mapRateLimitProvider) AccessRateLimit(ctx context.Context, name string, fn func(ratelimit.V1)) error {
+	fn(m[name])
+	return nil
+}
+
+func TestClientRateLimit(t *testing.T) {
+	accessed := map[string]int{}
+	countingLimit := func(name string, limit int) ratelimit.V1 {
+		return fnRateLimit(func(context.Context) (time.Duration, error) {
+			accessed[name]++
+			if accessed[name] > limit {
+				return time.Second, nil
+			}
+			return 0, nil
+		})
+	}
+
+	mgr := mapRateLimitProvider{
+		"alice_limit":    countingLimit("alice_limit", 2),
+		"fallback_limit": countingLimit("fallback_limit", 1),
+	}
+
+	conf := NewClientRateLimitConfig()
+	conf.Resources["alice"] = "alice_limit"
+	conf.Fallback = "fallback_limit"
+
+	h, err := conf.WrapHandler(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}, mgr)
+	require.NoError(t, err)
+
+	request := func(identity string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/", http.NoBody)
+		if identity != "" {
+			req = req.WithContext(context.WithValue(req.Context(), clientIdentityKey{}, identity))
+		}
+		res := httptest.NewRecorder()
+		h.ServeHTTP(res, req)
+		return res
+	}
+
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+	assert.Equal(t, http.StatusOK, request("alice").Code)
+	assert.Equal(t, http.StatusOK, request("bob").Code)
+
+	res := request("alice")
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "1", res.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusTooManyRequests, request("").Code)
+	assert.Equal(t, map[string]int{"alice_limit": 3, "fallback_limit": 2}, accessed)
+}
+
+func TestClientRateLimitMissingResource(t *testing.T) {
+	conf := NewClientRateLimitConfig()
+	conf.Resources["alice"] = "nope"
+
+	_, err := conf.WrapHandler(func(w http.ResponseWriter, r *http.Request) {}, mapRateLimitProvider{})
+	require.Error(t, err)
+}
+
+func TestClientRateLimitRetryAfterRoundsUp(t *testing.T) {
+	mgr := mapRateLimitProvider{
+		"limit": fnRateLimit(func(context.Context) (time.Duration, error) {
+			return time.Millisecond * 300, nil
+		}),
+	}
+
+	conf := NewClientRateLimitConfig()
+	conf.Fallback = "limit"
+
+	h, err := conf.WrapHandler(func(w http.ResponseWriter, r *http.Request) {}, mgr)
+	require.NoError(t, err)
+
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, httptest.NewRequest("POST", "/", http.NoBody))
+	assert.Equal(t, http.StatusTooManyRequests, res.Code)
+	assert.Equal(t, "1", res.Header().Get("Retry-After"))
+}
@@ -0,0 +1,67 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// ClientTLSConfig contains struct based fields for verifying client
+// certificates presented to an HTTPS server (mutual TLS).
+type ClientTLSConfig struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	CAFile  string `json:"ca_file" yaml:"ca_file"`
+	Require bool   `json:"require" yaml:"require"`
+}
+
+// NewClientTLSConfig returns a ClientTLSConfig with default values.
+func NewClientTLSConfig() ClientTLSConfig {
+	return ClientTLSConfig{
+		Enabled: false,
+		CAFile:  "",
+		Require: true,
+	}
+}
+
+// ClientTLSFieldSpec returns the spec for an HTTP server client TLS field.
+func ClientTLSFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("client_tls", "Allows you to verify client certificates presented to the server (mutual TLS). The common name of a verified certificate becomes the identity of the client. Only valid with a custom `address` and when TLS is enabled.").WithChildren(
+		docs.FieldBool("enabled", "Whether to verify client certificates.").HasDefault(false),
+		docs.FieldString("ca_file", "A path to a PEM encoded file of certificate authorities used to verify client certificates.").HasDefault(""),
+		docs.FieldBool("require", "Whether to reject connections that do not present a certificate. When `false` certificates are only verified when presented.").HasDefault(true),
+	).AtVersion("4.10.0").Advanced()
+}
+
+// ApplyToServer configures the TLS settings of an HTTP server in order to
+// verify client certificates (when enabled).
+func (c ClientTLSConfig) ApplyToServer(server *http.Server) error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.CAFile == "" {
+		return errors.New("a ca_file is required")
+	}
+
+	caPEM, err := os.ReadFile(c.CAFile)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return errors.New("no valid certificates found within ca_file")
+	}
+
+	if server.TLSConfig == nil {
+		server.TLSConfig = &tls.Config{}
+	}
+	server.TLSConfig.ClientCAs = pool
+	server.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	if c.Require {
+		server.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package httpserver
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"net/http"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// HMACAuthConfig contains struct based fields for verifying an HMAC signature
+// of request bodies.
+type HMACAuthConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	Secret    string `json:"secret" yaml:"secret"`
+	Header    string `json:"header" yaml:"header"`
+	Scheme    string `json:"scheme" yaml:"scheme"`
+	Algorithm string `json:"algorithm" yaml:"algorithm"`
+	Encoding  string `json:"encoding" yaml:"encoding"`
+}
+
+// NewHMACAuthConfig returns an HMACAuthConfig with default values.
+func NewHMACAuthConfig() HMACAuthConfig {
+	return HMACAuthConfig{
+		Enabled:   false,
+		Secret:    "",
+		Header:    "X-Signature",
+		Scheme:    "sha256=",
+		Algorithm: "sha256",
+		Encoding:  "hex",
+	}
+}
+
+func hmacAuthFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("hmac", "Verify an HMAC signature of the request body, calculated with a shared secret and provided by the client within a header. Request bodies larger than 10MiB are rejected with a 413 response.").WithChildren(
+		docs.FieldBool("enabled", "Whether to verify HMAC signatures of requests.").HasDefault(false),
+		docs.FieldString("secret", "The shared secret used to calculate signatures.").HasDefault(""),
+		docs.FieldString("header", "The header containing the signature.").HasDefault("X-Signature"),
+		docs.FieldString("scheme", "A prefix expected before the signature within the header value, which can be empty.", "sha256=", "sha1=", "").HasDefault("sha256="),
+		docs.FieldString("algorithm", "The hashing algorithm used to calculate signatures.").HasOptions("sha1", "sha256", "sha512").HasDefault("sha256"),
+		docs.FieldString("encoding", "The encoding of signatures within the header.").HasOptions("hex", "base64").HasDefault("hex"),
+	)
+}
+
+type hmacVerifier struct {
+	secret   []byte
+	header   string
+	scheme   string
+	hashFn   func() hash.Hash
+	decodeFn func(string) ([]byte, error)
+}
+
+func newHMACVerifier(conf HMACAuthConfig) (*hmacVerifier, error) {
+	if conf.Secret == "" {
+		return nil, errors.New("a secret is required")
+	}
+	if conf.Header == "" {
+		return nil, errors.New("a header is required")
+	}
+
+	v := &hmacVerifier{
+		secret: []byte(conf.Secret),
+		header: conf.Header,
+		scheme: conf.Scheme,
+	}
+
+	switch conf.Algorithm {
+	case "sha1":
+		v.hashFn = sha1.New
+	case "sha256":
+		v.hashFn = sha256.New
+	case "sha512":
+		v.hashFn = sha512.New
+	default:
+		return nil, errors.New("algorithm should be one of sha1, sha256 or sha512")
+	}
+
+	switch conf.Encoding {
+	case "hex":
+		v.decodeFn = hex.DecodeString
+	case "base64":
+		v.decodeFn = base64.StdEncoding.DecodeString
+	default:
+		return nil, errors.New("encoding should be one of hex or base64")
+	}
+	return v, nil
+}
+
+// verify checks the signature of a request body, the body is read in full (up
+// to MaxBufferedBodyBytes) and replaced so that it can be consumed again by
+// subsequent handlers.
+func (v *hmacVerifier) verify(r *http.Request) error {
+	sigStr := r.Header.Get(v.header)
+	if !strings.HasPrefix(sigStr, v.scheme) {
+		return errors.New("signature header missing or malformed")
+	}
+	sig, err := v.decodeFn(strings.TrimPrefix(sigStr, v.scheme))
+	if err != nil {
+		return err
+	}
+
+	body, err := bufferBody(r)
+	if err != nil {
+		return err
+	}
+
+	mac := hmac.New(v.hashFn, v.secret)
+	_, _ = mac.Write(body)
+	if !hmac.Equal(sig, mac.Sum(nil)) {
+		return errors.New("signature mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,366 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/golang-jwt/jwt"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// JWTAuthConfig contains struct based fields for verifying bearer JWTs.
+type JWTAuthConfig struct {
+	Enabled             bool     `json:"enabled" yaml:"enabled"`
+	JWKSURL             string   `json:"jwks_url" yaml:"jwks_url"`
+	JWKSRefreshInterval string   `json:"jwks_refresh_interval" yaml:"jwks_refresh_interval"`
+	Secret              string   `json:"secret" yaml:"secret"`
+	PublicKeyFile       string   `json:"public_key_file" yaml:"public_key_file"`
+	Algorithms          []string `json:"algorithms" yaml:"algorithms"`
+	Issuer              string   `json:"issuer" yaml:"issuer"`
+	Audience            string   `json:"audience" yaml:"audience"`
+}
+
+// NewJWTAuthConfig returns a JWTAuthConfig with default values.
+func NewJWTAuthConfig() JWTAuthConfig {
+	return JWTAuthConfig{
+		Enabled:             false,
+		JWKSURL:             "",
+		JWKSRefreshInterval: "1h",
+		Secret:              "",
+		PublicKeyFile:       "",
+		Algorithms:          []string{},
+		Issuer:              "",
+		Audience:            "",
+	}
+}
+
+func jwtAuthFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("jwt", "Verify bearer JSON Web Tokens provided within the `Authorization` header of requests. Exactly one of `jwks_url`, `secret` or `public_key_file` must be specified. The subject (`sub`) claim of a verified token becomes the identity of the client.").WithChildren(
+		docs.FieldBool("enabled", "Whether to require a valid JWT for requests.").HasDefault(false),
+		docs.FieldString("jwks_url", "A URL from which to fetch a [JSON Web Key Set](https://datatracker.ietf.org/doc/html/rfc7517) used to verify tokens. RSA, EC and Ed25519 keys are supported and are selected by the `kid` header of tokens.", "https://example.com/.well-known/jwks.json").HasDefault(""),
+		docs.FieldString("jwks_refresh_interval", "The period after which the key set is fetched again. The key set is also refreshed when a token references an unknown key, at most once per minute. Failed fetches are retried with an exponential backoff of up to one minute.").HasDefault("1h"),
+		docs.FieldString("secret", "A static secret used to verify tokens signed with an HMAC algorithm.").HasDefault(""),
+		docs.FieldString("public_key_file", "A path to a PEM encoded RSA, EC or Ed25519 public key used to verify tokens.").HasDefault(""),
+		docs.FieldString("algorithms", "An optional list of signing algorithms to accept. When empty any algorithm compatible with the key type is accepted.", []string{"RS256"}).Array().HasDefault([]string{}),
+		docs.FieldString("issuer", "An optional issuer (`iss`) claim that tokens must match.").HasDefault(""),
+		docs.FieldString("audience", "An optional audience (`aud`) claim that tokens must contain.").HasDefault(""),
+	)
+}
+
+//------------------------------------------------------------------------------
+
+const jwksMinRefreshInterval = time.Minute
+
+type jwtVerifier struct {
+	parser   *jwt.Parser
+	issuer   string
+	audience string
+
+	staticKey any
+
+	jwksURL         string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	fetchGroup singleflight.Group
+
+	keysMut      sync.Mutex
+	keys         map[string]any
+	fetchedAt    time.Time
+	fetchErr     error
+	fetchBackoff *backoff.ExponentialBackOff
+	retryAt      time.Time
+
+	now func() time.Time
+}
+
+func newJWTVerifier(conf JWTAuthConfig) (*jwtVerifier, error) {
+	v := &jwtVerifier{
+		parser:   &jwt.Parser{},
+		issuer:   conf.Issuer,
+		audience: conf.Audience,
+		jwksURL:  conf.JWKSURL,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		now:      time.Now,
+	}
+	if len(conf.Algorithms) > 0 {
+		v.parser.ValidMethods = conf.Algorithms
+	}
+
+	sources := 0
+	if conf.JWKSURL != "" {
+		sources++
+		var err error
+		if v.refreshInterval, err = time.ParseDuration(conf.JWKSRefreshInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse jwks_refresh_interval: %w", err)
+		}
+		v.fetchBackoff = backoff.NewExponentialBackOff()
+		v.fetchBackoff.InitialInterval = time.Second
+		v.fetchBackoff.MaxInterval = jwksMinRefreshInterval
+		v.fetchBackoff.MaxElapsedTime = 0
+	}
+	if conf.Secret != "" {
+		sources++
+		v.staticKey = []byte(conf.Secret)
+	}
+	if conf.PublicKeyFile != "" {
+		sources++
+		pemBytes, err := os.ReadFile(conf.PublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if v.staticKey, err = parsePublicKeyPEM(pemBytes); err != nil {
+			return nil, err
+		}
+	}
+	if sources != 1 {
+		return nil, errors.New("exactly one of jwks_url, secret or public_key_file must be specified")
+	}
+	return v, nil
+}
+
+// verify checks the bearer token of a request and returns its subject.
+func (v *jwtVerifier) verify(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if len(authHeader) < 7 || !strings.EqualFold(authHeader[:7], "bearer ") {
+		return "", errors.New("bearer token missing")
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := v.parser.ParseWithClaims(strings.TrimSpace(authHeader[7:]), claims, v.keyFor); err != nil {
+		return "", err
+	}
+	if v.issuer != "" && !claims.VerifyIssuer(v.issuer, true) {
+		return "", errors.New("token issuer mismatch")
+	}
+	if v.audience != "" && !claims.VerifyAudience(v.audience, true) {
+		return "", errors.New("token audience mismatch")
+	}
+
+	sub, _ := claims["sub"].(string)
+	return sub, nil
+}
+
+func (v *jwtVerifier) keyFor(t *jwt.Token) (any, error) {
+	key := v.staticKey
+	if key == nil {
+		kid, _ := t.Header["kid"].(string)
+		var err error
+		if key, err = v.jwksKey(kid); err != nil {
+			return nil, err
+		}
+	}
+
+	// Prevent algorithm confusion by only accepting methods that match the
+	// type of the key.
+	var compatible bool
+	switch key.(type) {
+	case []byte:
+		_, compatible = t.Method.(*jwt.SigningMethodHMAC)
+	case *rsa.PublicKey:
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			compatible = true
+		}
+	case *ecdsa.PublicKey:
+		_, compatible = t.Method.(*jwt.SigningMethodECDSA)
+	case ed25519.PublicKey:
+		_, compatible = t.Method.(*jwt.SigningMethodEd25519)
+	}
+	if !compatible {
+		return nil, fmt.Errorf("signing method %v is not compatible with key", t.Header["alg"])
+	}
+	return key, nil
+}
+
+func (v *jwtVerifier) jwksKey(kid string) (any, error) {
+	v.keysMut.Lock()
+	keys, fetchedAt, fetchErr, retryAt := v.keys, v.fetchedAt, v.fetchErr, v.retryAt
+	v.keysMut.Unlock()
+
+	now := v.now()
+	sinceFetch := now.Sub(fetchedAt)
+	key, exists := keys[kid]
+	if keys == nil || sinceFetch >= v.refreshInterval || (!exists && sinceFetch >= jwksMinRefreshInterval) {
+		if now.Before(retryAt) {
+			// A recent fetch failed, and therefore the key set is only fetched
+			// again once the backoff has elapsed.
+			if keys == nil {
+				return nil, fmt.Errorf("failed to fetch key set: %w", fetchErr)
+			}
+		} else {
+			// The key set is fetched outside of the lock, and concurrent
+			// requests share the result of a single fetch.
+			res, err, _ := v.fetchGroup.Do("jwks", v.refreshJWKS)
+			if err == nil {
+				keys = res.(map[string]any)
+			} else if keys == nil {
+				return nil, err
+			}
+			key, exists = keys[kid]
+		}
+	}
+	if !exists {
+		return nil, fmt.Errorf("key %q not found in key set", kid)
+	}
+	return key, nil
+}
+
+// refreshJWKS fetches the key set and replaces the current keys with it. When
+// the fetch fails the next attempt is delayed with an exponential backoff.
+func (v *jwtVerifier) refreshJWKS() (any, error) {
+	keys, err := v.fetchJWKS()
+
+	v.keysMut.Lock()
+	defer v.keysMut.Unlock()
+
+	if err != nil {
+		v.fetchErr = err
+		v.retryAt = v.now().Add(v.fetchBackoff.NextBackOff())
+		return nil, err
+	}
+	v.fetchBackoff.Reset()
+	v.fetchErr, v.retryAt = nil, time.Time{}
+	v.keys, v.fetchedAt = keys, v.now()
+	return keys, nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (v *jwtVerifier) fetchJWKS() (map[string]any, error) {
+	res, err := v.client.Get(v.jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("unexpected status code fetching key set: %v", res.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode key set: %w", err)
+	}
+
+	keys := map[string]any{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		pub, err := k.publicKey()
+		if err != nil {
+			// Unsupported keys are ignored as they might be used by other
+			// consumers of the same key set.
+			continue
+		}
+		keys[k.Kid] = pub
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	switch k.Kty {
+	case "RSA":
+		n, err := decode(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decode(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve: %v", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decode(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve: %v", k.Crv)
+		}
+		x, err := decode(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type: %v", k.Kty)
+}
+
+func parsePublicKeyPEM(pemBytes []byte) (any, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("failed to decode public key PEM")
+	}
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+			return key, nil
+		}
+		return nil, fmt.Errorf("unsupported public key type: %T", key)
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if cert, err := x509.ParseCertificate(block.Bytes); err == nil {
+		return cert.PublicKey, nil
+	}
+	return nil, errors.New("failed to parse public key")
+}
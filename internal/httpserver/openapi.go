@@ -0,0 +1,424 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+var openAPIMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+type openAPIParam struct {
+	name     string
+	in       string
+	required bool
+	explode  bool
+	typ      string
+	itemsTyp string
+	schema   *gojsonschema.Schema
+}
+
+type openAPIBody struct {
+	required bool
+	// Media types mapped to the schema of their content, which is nil for
+	// media types without a schema.
+	content map[string]*gojsonschema.Schema
+}
+
+type openAPIOperation struct {
+	params []openAPIParam
+	body   *openAPIBody
+}
+
+type openAPIPath struct {
+	template   pathTemplate
+	operations map[string]*openAPIOperation
+}
+
+type openAPIValidator struct {
+	basePaths []string
+	paths     []openAPIPath
+}
+
+func newOpenAPIValidator(path string) (*openAPIValidator, error) {
+	specBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawSpec any
+	if err := yaml.Unmarshal(specBytes, &rawSpec); err != nil {
+		return nil, fmt.Errorf("failed to parse spec: %w", err)
+	}
+	spec, ok := normaliseOpenAPINode(rawSpec).(map[string]any)
+	if !ok {
+		return nil, errors.New("expected spec to be an object")
+	}
+	if version, _ := spec["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		return nil, fmt.Errorf("unsupported openapi version: %v", spec["openapi"])
+	}
+
+	c := openAPICompiler{spec: spec}
+	v := &openAPIValidator{}
+
+	servers, _ := spec["servers"].([]any)
+	for _, s := range servers {
+		sURL, _ := c.obj(s)["url"].(string)
+		if u, err := url.Parse(sURL); err == nil && u.Path != "" && u.Path != "/" && !strings.Contains(u.Path, "{") {
+			v.basePaths = append(v.basePaths, strings.TrimSuffix(u.Path, "/"))
+		}
+	}
+
+	paths, _ := spec["paths"].(map[string]any)
+	for tmpl, rawItem := range paths {
+		item := c.obj(rawItem)
+		itemParams, err := c.params(item["parameters"], nil)
+		if err != nil {
+			return nil, fmt.Errorf("path '%v': %w", tmpl, err)
+		}
+
+		p := openAPIPath{
+			template:   newPathTemplate(tmpl),
+			operations: map[string]*openAPIOperation{},
+		}
+		for _, method := range openAPIMethods {
+			rawOp, exists := item[method]
+			if !exists {
+				continue
+			}
+			op := c.obj(rawOp)
+
+			var compiled openAPIOperation
+			if compiled.params, err = c.params(op["parameters"], itemParams); err != nil {
+				return nil, fmt.Errorf("path '%v' %v: %w", tmpl, method, err)
+			}
+			if rawBody, exists := op["requestBody"]; exists {
+				if compiled.body, err = c.body(rawBody); err != nil {
+					return nil, fmt.Errorf("path '%v' %v: %w", tmpl, method, err)
+				}
+			}
+			p.operations[strings.ToUpper(method)] = &compiled
+		}
+		v.paths = append(v.paths, p)
+	}
+	return v, nil
+}
+
+func (v *openAPIValidator) match(path string) (*openAPIPath, map[string]string) {
+	candidates := []string{path}
+	for _, base := range v.basePaths {
+		if strings.HasPrefix(path, base+"/") {
+			candidates = append(candidates, strings.TrimPrefix(path, base))
+		}
+	}
+
+	var match *openAPIPath
+	var matchParams map[string]string
+	for _, c := range candidates {
+		for i, p := range v.paths {
+			if params, ok := p.template.match(c); ok && (match == nil || p.template.literals > match.template.literals) {
+				match, matchParams = &v.paths[i], params
+			}
+		}
+	}
+	return match, matchParams
+}
+
+func (v *openAPIValidator) validate(r *http.Request, reqPath string, body []byte) []ValidationProblem {
+	path, pathParams := v.match(reqPath)
+	if path == nil {
+		return []ValidationProblem{{In: "path", Message: fmt.Sprintf("no operation found for path %v", r.URL.Path)}}
+	}
+	op, exists := path.operations[r.Method]
+	if !exists {
+		return []ValidationProblem{{In: "method", Message: fmt.Sprintf("method %v is not allowed for path %v", r.Method, r.URL.Path)}}
+	}
+
+	var problems []ValidationProblem
+	query := r.URL.Query()
+	for _, p := range op.params {
+		var values []string
+		switch p.in {
+		case "path":
+			if v, exists := pathParams[p.name]; exists {
+				values = []string{v}
+			}
+		case "query":
+			values = query[p.name]
+		case "header":
+			values = r.Header.Values(p.name)
+		case "cookie":
+			if c, err := r.Cookie(p.name); err == nil {
+				values = []string{c.Value}
+			}
+		}
+		if len(values) == 0 {
+			if p.required {
+				problems = append(problems, ValidationProblem{In: p.in, Name: p.name, Message: "parameter is required"})
+			}
+			continue
+		}
+		if p.schema != nil {
+			problems = append(problems, schemaProblems(p.schema, p.coerce(values), p.in, p.name)...)
+		}
+	}
+
+	if op.body != nil {
+		problems = append(problems, op.body.validate(r, body)...)
+	}
+	return problems
+}
+
+func (b *openAPIBody) validate(r *http.Request, body []byte) []ValidationProblem {
+	if len(body) == 0 {
+		if b.required {
+			return []ValidationProblem{{In: "body", Message: "request body is required"}}
+		}
+		return nil
+	}
+
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return []ValidationProblem{{In: "header", Name: "Content-Type", Message: err.Error()}}
+	}
+
+	schema, exists := b.schemaFor(mediaType)
+	if !exists {
+		// Multipart requests that aren't described explicitly are consumed as a
+		// batch and so each part is validated as an individual body.
+		if strings.HasPrefix(mediaType, "multipart/") {
+			return multipartProblems(body, params["boundary"], func(p bodyPart, name string) []ValidationProblem {
+				partSchema, exists := b.schemaFor(p.mediaType)
+				if !exists {
+					return []ValidationProblem{{In: "body", Name: name, Message: fmt.Sprintf("content type %v is not supported", p.mediaType)}}
+				}
+				return validateContent(partSchema, p.mediaType, p.body, name)
+			})
+		}
+		return []ValidationProblem{{In: "header", Name: "Content-Type", Message: fmt.Sprintf("content type %v is not supported", mediaType)}}
+	}
+	return validateContent(schema, mediaType, body, "")
+}
+
+// schemaFor returns the schema of a media type, falling back to wildcard media
+// types when it isn't listed explicitly.
+func (b *openAPIBody) schemaFor(mediaType string) (*gojsonschema.Schema, bool) {
+	for _, t := range []string{mediaType, strings.Split(mediaType, "/")[0] + "/*", "*/*"} {
+		if schema, exists := b.content[t]; exists {
+			return schema, true
+		}
+	}
+	return nil, false
+}
+
+// validateContent validates content against the schema of its media type, only
+// JSON content is validated.
+func validateContent(schema *gojsonschema.Schema, mediaType string, body []byte, name string) []ValidationProblem {
+	if schema == nil || !(mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		return nil
+	}
+	return validateJSONBody(schema, body, name)
+}
+
+// coerce converts the string values of a parameter into the type described by
+// its schema, values that cannot be converted are left as strings in order for
+// the schema to report them.
+func (p openAPIParam) coerce(values []string) any {
+	if p.typ == "array" {
+		if !p.explode || p.in != "query" {
+			values = strings.Split(values[0], ",")
+		}
+		items := make([]any, len(values))
+		for i, v := range values {
+			items[i] = coerceParamValue(p.itemsTyp, v)
+		}
+		return items
+	}
+	return coerceParamValue(p.typ, values[0])
+}
+
+func coerceParamValue(typ, v string) any {
+	switch typ {
+	case "integer":
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
+
+//------------------------------------------------------------------------------
+
+type openAPICompiler struct {
+	spec map[string]any
+}
+
+func (c openAPICompiler) obj(v any) map[string]any {
+	m, _ := c.deref(v).(map[string]any)
+	return m
+}
+
+// deref follows a local reference object to its target.
+func (c openAPICompiler) deref(v any) any {
+	for i := 0; i < 32; i++ {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		ref, ok := m["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return v
+		}
+		var target any = c.spec
+		for _, seg := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+			tm, _ := target.(map[string]any)
+			target = tm[seg]
+		}
+		v = target
+	}
+	return v
+}
+
+func (c openAPICompiler) schema(v any) (*gojsonschema.Schema, error) {
+	raw, ok := v.(map[string]any)
+	if !ok {
+		return nil, nil
+	}
+	root := make(map[string]any, len(raw)+1)
+	for k, v := range raw {
+		root[k] = v
+	}
+	// Embedding components allows references within the schema to resolve.
+	root["components"] = c.spec["components"]
+	return gojsonschema.NewSchema(gojsonschema.NewGoLoader(root))
+}
+
+func (c openAPICompiler) params(v any, inherited []openAPIParam) ([]openAPIParam, error) {
+	rawParams, _ := v.([]any)
+
+	params := make([]openAPIParam, 0, len(inherited)+len(rawParams))
+	params = append(params, inherited...)
+	for _, rawParam := range rawParams {
+		p := c.obj(rawParam)
+		name, _ := p["name"].(string)
+		in, _ := p["in"].(string)
+		if name == "" || in == "" {
+			return nil, errors.New("parameters must have a name and location")
+		}
+
+		param := openAPIParam{
+			name:    name,
+			in:      in,
+			explode: true,
+		}
+		param.required, _ = p["required"].(bool)
+		if explode, ok := p["explode"].(bool); ok {
+			param.explode = explode
+		} else if style, _ := p["style"].(string); style != "" && style != "form" {
+			param.explode = false
+		}
+		if rawSchema, exists := p["schema"]; exists {
+			schemaObj := c.obj(rawSchema)
+			param.typ = schemaType(schemaObj)
+			param.itemsTyp = schemaType(c.obj(schemaObj["items"]))
+
+			var err error
+			if param.schema, err = c.schema(rawSchema); err != nil {
+				return nil, fmt.Errorf("parameter '%v': %w", name, err)
+			}
+		}
+
+		// Parameters of an operation override those of the path with the same
+		// name and location.
+		replaced := false
+		for i, existing := range params[:len(inherited)] {
+			if existing.name == name && existing.in == in {
+				params[i], replaced = param, true
+			}
+		}
+		if !replaced {
+			params = append(params, param)
+		}
+	}
+	return params, nil
+}
+
+func (c openAPICompiler) body(v any) (*openAPIBody, error) {
+	b := c.obj(v)
+	body := &openAPIBody{content: map[string]*gojsonschema.Schema{}}
+	body.required, _ = b["required"].(bool)
+
+	content, _ := b["content"].(map[string]any)
+	for mediaType, rawMedia := range content {
+		var err error
+		if body.content[mediaType], err = c.schema(c.obj(rawMedia)["schema"]); err != nil {
+			return nil, fmt.Errorf("request body %v: %w", mediaType, err)
+		}
+	}
+	return body, nil
+}
+
+// schemaType returns the non-null type of a schema.
+func schemaType(schema map[string]any) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, v := range t {
+			if s, _ := v.(string); s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// normaliseOpenAPINode converts a parsed YAML document into a structure that
+// can be serialised as JSON, and converts the OpenAPI 3.0 `nullable` keyword
+// into its JSON Schema equivalent.
+func normaliseOpenAPINode(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, v := range t {
+			t[k] = normaliseOpenAPINode(v)
+		}
+		if nullable, _ := t["nullable"].(bool); nullable {
+			if typ, ok := t["type"].(string); ok {
+				t["type"] = []any{typ, "null"}
+			}
+		}
+		return t
+	case map[any]any:
+		m := make(map[string]any, len(t))
+		for k, v := range t {
+			m[fmt.Sprintf("%v", k)] = v
+		}
+		return normaliseOpenAPINode(m)
+	case []any:
+		for i, v := range t {
+			t[i] = normaliseOpenAPINode(v)
+		}
+	}
+	return v
+}
@@ -0,0 +1,270 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// RequestValidationConfig contains struct based fields for validating requests
+// made to an HTTP server against an OpenAPI specification or a JSON Schema.
+type RequestValidationConfig struct {
+	OpenAPIPath string `json:"openapi_path" yaml:"openapi_path"`
+	JSONSchema  string `json:"json_schema" yaml:"json_schema"`
+}
+
+// NewRequestValidationConfig returns a RequestValidationConfig with default
+// values.
+func NewRequestValidationConfig() RequestValidationConfig {
+	return RequestValidationConfig{
+		OpenAPIPath: "",
+		JSONSchema:  "",
+	}
+}
+
+// RequestValidationFieldSpec returns the spec for an HTTP server request
+// validation field.
+func RequestValidationFieldSpec() docs.FieldSpec {
+	return docs.FieldObject("validation", "Allows you to validate requests before they are consumed, either against an [OpenAPI 3](https://spec.openapis.org/oas/v3.0.3) specification or a JSON Schema of the request body. Requests that fail validation are rejected with a 400 response containing a JSON description of each problem, and request bodies larger than 10MiB are rejected with a 413 response. Each part of a multipart request is validated as an individual body unless the multipart content type is described by the OpenAPI specification.").WithChildren(
+		docs.FieldString("openapi_path", "A path to an OpenAPI 3 specification in YAML or JSON format. The method, path parameters, query parameters, headers and body of requests are validated against the operation matching the request path.", "./openapi.yaml").HasDefault(""),
+		docs.FieldString("json_schema", "A JSON Schema that the body of requests to the endpoint must satisfy. The schema can either be specified inline or as a reference starting with `file://` or `http://`.", "file://./schemas/post.json", `{"type":"object","required":["items"]}`).HasDefault(""),
+	).AtVersion("4.10.0").Advanced()
+}
+
+// ValidationProblem describes a single reason why a request failed validation.
+type ValidationProblem struct {
+	In      string `json:"in"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+type requestValidator interface {
+	validate(r *http.Request, path string, body []byte) []ValidationProblem
+}
+
+// WrapHandler wraps the provided HTTP handler with middleware that validates
+// requests (when configured) and rejects those that fail with a 400 response.
+// When a mount path is provided it is the path that the handler is registered
+// under, and any prefix added to it during registration (such as the stream
+// name in streams mode) is removed from request paths before they are matched
+// against operations.
+func (c RequestValidationConfig) WrapHandler(next http.HandlerFunc, mountPath string) (http.HandlerFunc, error) {
+	if c.OpenAPIPath != "" && c.JSONSchema != "" {
+		return nil, errors.New("cannot specify both openapi_path and json_schema")
+	}
+
+	var v requestValidator
+	var err error
+	if c.OpenAPIPath != "" {
+		if v, err = newOpenAPIValidator(c.OpenAPIPath); err != nil {
+			return nil, fmt.Errorf("openapi_path: %w", err)
+		}
+	} else if c.JSONSchema != "" {
+		if v, err = newJSONSchemaValidator(c.JSONSchema); err != nil {
+			return nil, fmt.Errorf("json_schema: %w", err)
+		}
+	} else {
+		return next, nil
+	}
+
+	trimPath := func(p string) string { return p }
+	if mountPath != "" {
+		trimPath = newPathTemplate(mountPath).trimPrefix
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := bufferBody(r)
+		if err != nil {
+			if errors.Is(err, errBodyTooLarge) {
+				http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "Bad request", http.StatusBadRequest)
+			return
+		}
+
+		if problems := v.validate(r, trimPath(r.URL.Path), body); len(problems) > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_ = json.NewEncoder(w).Encode(struct {
+				Error    string              `json:"error"`
+				Problems []ValidationProblem `json:"problems"`
+			}{
+				Error:    "request failed validation",
+				Problems: problems,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// pathTemplate matches request paths against templates of the form
+// `/foo/{bar}`.
+type pathTemplate struct {
+	segments []string
+	literals int
+}
+
+func newPathTemplate(tmpl string) pathTemplate {
+	t := pathTemplate{segments: strings.Split(strings.Trim(tmpl, "/"), "/")}
+	for _, s := range t.segments {
+		if !isPathParam(s) {
+			t.literals++
+		}
+	}
+	return t
+}
+
+func isPathParam(segment string) bool {
+	return strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+}
+
+// match returns the path parameters extracted from a path when it matches the
+// template.
+func (t pathTemplate) match(path string) (map[string]string, bool) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(t.segments) {
+		return nil, false
+	}
+	params := map[string]string{}
+	for i, s := range t.segments {
+		if isPathParam(s) {
+			params[s[1:len(s)-1]] = segments[i]
+		} else if s != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// trimPrefix removes any leading segments of a path beyond those of the
+// template, which is how a prefix added to a mounted path is removed.
+func (t pathTemplate) trimPrefix(path string) string {
+	n := len(t.segments)
+	if n == 1 && t.segments[0] == "" {
+		n = 0
+	}
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) <= n {
+		return path
+	}
+	return "/" + strings.Join(segments[len(segments)-n:], "/")
+}
+
+//------------------------------------------------------------------------------
+
+// bodyPart is a single part of a multipart request body, each of which is
+// consumed as an individual message of a batch.
+type bodyPart struct {
+	mediaType string
+	body      []byte
+}
+
+func readMultipartBody(body []byte, boundary string) ([]bodyPart, error) {
+	var parts []bodyPart
+	mr := multipart.NewReader(bytes.NewReader(body), boundary)
+	for {
+		p, err := mr.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return parts, nil
+			}
+			return nil, err
+		}
+
+		// As per RFC 2046 parts without a content type are plain text.
+		part := bodyPart{mediaType: "text/plain"}
+		if contentType := p.Header.Get("Content-Type"); contentType != "" {
+			if part.mediaType, _, err = mime.ParseMediaType(contentType); err != nil {
+				return nil, err
+			}
+		}
+		if part.body, err = io.ReadAll(p); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+}
+
+// multipartProblems validates each part of a multipart request body with the
+// provided func, problems are reported with the index of the part.
+func multipartProblems(body []byte, boundary string, fn func(p bodyPart, name string) []ValidationProblem) []ValidationProblem {
+	parts, err := readMultipartBody(body, boundary)
+	if err != nil {
+		return []ValidationProblem{{In: "body", Message: fmt.Sprintf("body is not valid multipart content: %v", err)}}
+	}
+	var problems []ValidationProblem
+	for i, p := range parts {
+		problems = append(problems, fn(p, fmt.Sprintf("parts.%v", i))...)
+	}
+	return problems
+}
+
+//------------------------------------------------------------------------------
+
+// jsonSchemaValidator validates the body of all requests made to the wrapped
+// handler against a single schema.
+type jsonSchemaValidator struct {
+	schema *gojsonschema.Schema
+}
+
+func newJSONSchemaValidator(schemaStr string) (*jsonSchemaValidator, error) {
+	var loader gojsonschema.JSONLoader
+	if strings.HasPrefix(schemaStr, "file://") || strings.HasPrefix(schemaStr, "http://") || strings.HasPrefix(schemaStr, "https://") {
+		loader = gojsonschema.NewReferenceLoader(schemaStr)
+	} else {
+		loader = gojsonschema.NewStringLoader(schemaStr)
+	}
+	schema, err := gojsonschema.NewSchema(loader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	return &jsonSchemaValidator{schema: schema}, nil
+}
+
+func (v *jsonSchemaValidator) validate(r *http.Request, path string, body []byte) []ValidationProblem {
+	if mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil && strings.HasPrefix(mediaType, "multipart/") {
+		return multipartProblems(body, params["boundary"], func(p bodyPart, name string) []ValidationProblem {
+			return validateJSONBody(v.schema, p.body, name)
+		})
+	}
+	return validateJSONBody(v.schema, body, "")
+}
+
+func validateJSONBody(schema *gojsonschema.Schema, body []byte, name string) []ValidationProblem {
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return []ValidationProblem{{In: "body", Name: name, Message: fmt.Sprintf("body is not valid JSON: %v", err)}}
+	}
+	return schemaProblems(schema, doc, "body", name)
+}
+
+func schemaProblems(schema *gojsonschema.Schema, value any, in, name string) []ValidationProblem {
+	res, err := schema.Validate(gojsonschema.NewGoLoader(value))
+	if err != nil {
+		return []ValidationProblem{{In: in, Name: name, Message: err.Error()}}
+	}
+	var problems []ValidationProblem
+	for _, e := range res.Errors() {
+		p := ValidationProblem{In: in, Name: name, Message: e.Description()}
+		if name == "" {
+			p.Name = e.Field()
+		} else if field := e.Field(); field != "(root)" {
+			p.Name = name + "." + field
+		}
+		problems = append(problems, p)
+	}
+	return problems
+}
@@ -0,0 +1,381 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testOpenAPISpec = `
+openapi: 3.0.3
+info:
+  title: Orders
+  version: 1.0.0
+servers:
+  - url: https://example.com/v1
+paths:
+  /orders/{order_id}:
+    parameters:
+      - name: order_id
+        in: path
+        required: true
+        schema:
+          type: integer
+          minimum: 1
+    post:
+      parameters:
+        - name: limit
+          in: query
+          schema:
+            type: integer
+            maximum: 100
+        - name: tags
+          in: query
+          explode: false
+          schema:
+            type: array
+            items:
+              type: string
+              enum: [ foo, bar ]
+        - name: X-Tenant
+          in: header
+          required: true
+          schema:
+            type: string
+      requestBody:
+        $ref: '#/components/requestBodies/Order'
+      responses:
+        200:
+          description: OK
+  /orders/latest:
+    post:
+      responses:
+        200:
+          description: OK
+components:
+  requestBodies:
+    Order:
+      required: true
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Order'
+  schemas:
+    Order:
+      type: object
+      required: [ items ]
+      properties:
+        items:
+          type: array
+          items:
+            $ref: '#/components/schemas/Item'
+        note:
+          type: string
+          nullable: true
+    Item:
+      type: object
+      required: [ sku ]
+      properties:
+        sku:
+          type: string
+`
+
+type validationTestResponse struct {
+	Error    string              `json:"error"`
+	Problems []ValidationProblem `json:"problems"`
+}
+
+func validationTestHandler(t *testing.T, conf RequestValidationConfig, mountPath string) http.HandlerFunc {
+	t.Helper()
+
+	h, err := conf.WrapHandler(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}, mountPath)
+	require.NoError(t, err)
+	return h
+}
+
+func validationTestRequest(t *testing.T, h http.HandlerFunc, method, target, body string, headers map[string]string) (int, []ValidationProblem) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	h.ServeHTTP(res, req)
+
+	if res.Code != http.StatusBadRequest {
+		return res.Code, nil
+	}
+	assert.Equal(t, "application/json", res.Header().Get("Content-Type"))
+
+	var resBody validationTestResponse
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &resBody))
+	assert.Equal(t, "request failed validation", resBody.Error)
+	return res.Code, resBody.Problems
+}
+
+// validationTestMultipart returns a multipart body and headers from pairs of
+// content types and bodies.
+func validationTestMultipart(t *testing.T, parts ...[2]string) (string, map[string]string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": []string{p[0]}})
+		require.NoError(t, err)
+		_, err = pw.Write([]byte(p[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+	return buf.String(), map[string]string{
+		"Content-Type": "multipart/mixed; boundary=" + mw.Boundary(),
+		"X-Tenant":     "acme",
+	}
+}
+
+func TestRequestValidationOpenAPI(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(testOpenAPISpec), 0o644))
+
+	conf := NewRequestValidationConfig()
+	conf.OpenAPIPath = specPath
+	h := validationTestHandler(t, conf, "")
+
+	jsonHeaders := map[string]string{
+		"Content-Type": "application/json",
+		"X-Tenant":     "acme",
+	}
+	validParts, validPartsHeaders := validationTestMultipart(t,
+		[2]string{"application/json", `{"items":[]}`},
+		[2]string{"application/json", `{"items":[{"sku":"abc"}]}`},
+	)
+	badParts, badPartsHeaders := validationTestMultipart(t,
+		[2]string{"application/json", `{"items":[]}`},
+		[2]string{"application/json", `{"items":[{"id":"abc"}]}`},
+		[2]string{"text/plain", `hello`},
+	)
+
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		body     string
+		headers  map[string]string
+		problems []ValidationProblem
+	}{
+		{
+			name:    "valid",
+			method:  "POST",
+			target:  "/orders/5?limit=10&tags=foo,bar",
+			body:    `{"items":[{"sku":"abc"}],"note":null}`,
+			headers: jsonHeaders,
+		},
+		{
+			name:    "valid with base path",
+			method:  "POST",
+			target:  "/v1/orders/5",
+			body:    `{"items":[]}`,
+			headers: jsonHeaders,
+		},
+		{
+			name:   "literal path preferred",
+			method: "POST",
+			target: "/orders/latest",
+		},
+		{
+			name:   "unknown path",
+			method: "POST",
+			target: "/nope",
+			problems: []ValidationProblem{
+				{In: "path", Message: "no operation found for path /nope"},
+			},
+		},
+		{
+			name:   "bad method",
+			method: "PUT",
+			target: "/orders/5",
+			problems: []ValidationProblem{
+				{In: "method", Message: "method PUT is not allowed for path /orders/5"},
+			},
+		},
+		{
+			name:    "bad path param",
+			method:  "POST",
+			target:  "/orders/nope",
+			body:    `{"items":[]}`,
+			headers: jsonHeaders,
+			problems: []ValidationProblem{
+				{In: "path", Name: "order_id", Message: "Invalid type. Expected: integer, given: string"},
+			},
+		},
+		{
+			name:    "bad query params",
+			method:  "POST",
+			target:  "/orders/5?limit=200&tags=foo,baz",
+			body:    `{"items":[]}`,
+			headers: jsonHeaders,
+			problems: []ValidationProblem{
+				{In: "query", Name: "limit", Message: "Must be less than or equal to 100"},
+				{In: "query", Name: "tags.1", Message: `1 must be one of the following: "foo", "bar"`},
+			},
+		},
+		{
+			name:    "missing header and body",
+			method:  "POST",
+			target:  "/orders/5",
+			headers: map[string]string{"Content-Type": "application/json"},
+			problems: []ValidationProblem{
+				{In: "header", Name: "X-Tenant", Message: "parameter is required"},
+				{In: "body", Message: "request body is required"},
+			},
+		},
+		{
+			name:    "bad body",
+			method:  "POST",
+			target:  "/orders/5",
+			body:    `{"items":[{"id":"abc"}]}`,
+			headers: jsonHeaders,
+			problems: []ValidationProblem{
+				{In: "body", Name: "items.0", Message: "sku is required"},
+			},
+		},
+		{
+			name:    "bad content type",
+			method:  "POST",
+			target:  "/orders/5",
+			body:    `hello`,
+			headers: map[string]string{"Content-Type": "text/plain", "X-Tenant": "acme"},
+			problems: []ValidationProblem{
+				{In: "header", Name: "Content-Type", Message: "content type text/plain is not supported"},
+			},
+		},
+		{
+			name:    "valid multipart",
+			method:  "POST",
+			target:  "/orders/5",
+			body:    validParts,
+			headers: validPartsHeaders,
+		},
+		{
+			name:    "bad multipart",
+			method:  "POST",
+			target:  "/orders/5",
+			body:    badParts,
+			headers: badPartsHeaders,
+			problems: []ValidationProblem{
+				{In: "body", Name: "parts.1.items.0", Message: "sku is required"},
+				{In: "body", Name: "parts.2", Message: "content type text/plain is not supported"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		code, problems := validationTestRequest(t, h, test.method, test.target, test.body, test.headers)
+		if len(test.problems) == 0 {
+			assert.Equal(t, http.StatusOK, code, test.name)
+		} else {
+			assert.Equal(t, http.StatusBadRequest, code, test.name)
+		}
+		assert.Equal(t, test.problems, problems, test.name)
+	}
+}
+
+func TestRequestValidationOpenAPIMountPrefix(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(testOpenAPISpec), 0o644))
+
+	conf := NewRequestValidationConfig()
+	conf.OpenAPIPath = specPath
+	h := validationTestHandler(t, conf, "/orders/{order_id}")
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+		"X-Tenant":     "acme",
+	}
+	for _, target := range []string{"/orders/5", "/foo/orders/5", "/foo/bar/orders/5"} {
+		code, problems := validationTestRequest(t, h, "POST", target, `{"items":[]}`, headers)
+		assert.Equal(t, http.StatusOK, code, target)
+		assert.Empty(t, problems, target)
+	}
+
+	code, problems := validationTestRequest(t, h, "POST", "/foo/orders/nope", `{"items":[]}`, headers)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []ValidationProblem{
+		{In: "path", Name: "order_id", Message: "Invalid type. Expected: integer, given: string"},
+	}, problems)
+
+	// Without a mount path the prefix is matched as part of the path.
+	h = validationTestHandler(t, conf, "")
+	code, problems = validationTestRequest(t, h, "POST", "/foo/orders/5", `{"items":[]}`, headers)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []ValidationProblem{
+		{In: "path", Message: "no operation found for path /foo/orders/5"},
+	}, problems)
+}
+
+func TestRequestValidationJSONSchemas(t *testing.T) {
+	conf := NewRequestValidationConfig()
+	conf.JSONSchema = `{"type":"object","required":["name"]}`
+	h := validationTestHandler(t, conf, "")
+
+	code, problems := validationTestRequest(t, h, "POST", "/things/1", `{"name":"foo"}`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, problems)
+
+	code, problems = validationTestRequest(t, h, "POST", "/things/1", `{"nope":"foo"}`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Equal(t, []ValidationProblem{{In: "body", Name: "(root)", Message: "name is required"}}, problems)
+
+	code, problems = validationTestRequest(t, h, "POST", "/things/1", `not json`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.Len(t, problems, 1)
+	assert.Contains(t, problems[0].Message, "body is not valid JSON")
+
+	body, headers := validationTestMultipart(t,
+		[2]string{"application/json", `{"name":"foo"}`},
+		[2]string{"application/json", `{"name":"bar"}`},
+	)
+	code, problems = validationTestRequest(t, h, "POST", "/things/1", body, headers)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, problems)
+
+	body, headers = validationTestMultipart(t,
+		[2]string{"application/json", `{"name":"foo"}`},
+		[2]string{"application/json", `{"nope":"bar"}`},
+		[2]string{"text/plain", `not json`},
+	)
+	code, problems = validationTestRequest(t, h, "POST", "/things/1", body, headers)
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.Len(t, problems, 2)
+	assert.Equal(t, ValidationProblem{In: "body", Name: "parts.1", Message: "name is required"}, problems[0])
+	assert.Equal(t, "parts.2", problems[1].Name)
+	assert.Contains(t, problems[1].Message, "body is not valid JSON")
+
+	code, _ = validationTestRequest(t, h, "POST", "/things/1", strings.Repeat(" ", MaxBufferedBodyBytes+1), nil)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, code)
+}
+
+func TestRequestValidationBadConfig(t *testing.T) {
+	conf := NewRequestValidationConfig()
+	conf.OpenAPIPath = "/does/not/exist.yaml"
+	_, err := conf.WrapHandler(func(w http.ResponseWriter, r *http.Request) {}, "")
+	require.Error(t, err)
+
+	conf = NewRequestValidationConfig()
+	conf.JSONSchema = `{"type":`
+	_, err = conf.WrapHandler(func(w http.ResponseWriter, r *http.Request) {}, "")
+	require.Error(t, err)
+}
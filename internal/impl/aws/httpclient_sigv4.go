@@ -0,0 +1,54 @@
+package aws
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/internal/httpclient/oldconfig"
+)
+
+func init() {
+	httpclient.AWSSigV4SignerFn = newSigV4Signer
+}
+
+func newSigV4Signer(conf oldconfig.AWSSigV4Config) (httpclient.RequestSigner, error) {
+	if conf.Service == "" {
+		return nil, errors.New("a service must be specified")
+	}
+
+	sess, err := GetSessionFromConf(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	var region string
+	if sess.Config.Region != nil {
+		region = *sess.Config.Region
+	}
+	if region == "" {
+		return nil, errors.New("a region must be specified")
+	}
+
+	signer := v4.NewSigner(sess.Config.Credentials)
+	return func(req *http.Request) error {
+		// The payload is hashed as part of the signature and therefore the
+		// body is read in full and replaced by the signer.
+		var body io.ReadSeeker
+		if req.Body != nil {
+			bodyBytes, err := io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return err
+			}
+			body = bytes.NewReader(bodyBytes)
+		}
+		_, err := signer.Sign(req, body, conf.Service, region, time.Now())
+		return err
+	}, nil
+}
@@ -0,0 +1,51 @@
+package aws
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient/oldconfig"
+)
+
+func TestSigV4Signer(t *testing.T) {
+	conf := oldconfig.NewAWSSigV4Config()
+	conf.Enabled = true
+	conf.Service = "execute-api"
+	conf.Region = "eu-west-1"
+	conf.Credentials.ID = "fooid"
+	conf.Credentials.Secret = "foosecret"
+
+	signer, err := newSigV4Signer(conf)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest("POST", "https://example.execute-api.eu-west-1.amazonaws.com/prod/foo", bytes.NewReader([]byte("hello world")))
+	require.NoError(t, err)
+	require.NoError(t, signer(req))
+
+	assert.Regexp(t, `^AWS4-HMAC-SHA256 Credential=fooid/\d{8}/eu-west-1/execute-api/aws4_request, SignedHeaders=host;x-amz-date, Signature=[0-9a-f]{64}$`, req.Header.Get("Authorization"))
+	assert.NotEmpty(t, req.Header.Get("X-Amz-Date"))
+
+	body, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(body))
+}
+
+func TestSigV4SignerBadConfig(t *testing.T) {
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+
+	conf := oldconfig.NewAWSSigV4Config()
+	conf.Region = "eu-west-1"
+	_, err := newSigV4Signer(conf)
+	require.Error(t, err)
+
+	conf = oldconfig.NewAWSSigV4Config()
+	conf.Service = "es"
+	_, err = newSigV4Signer(conf)
+	require.Error(t, err)
+}
@@ -537,7 +537,7 @@ func newAmazonS3Reader(conf input.AWSS3Config, nm bundle.NewManagement) (*awsS3R
 		log:  nm.Logger(),
 	}
 	var err error
-	if s.objectScannerCtor, err = codec.GetReader(conf.Codec, codec.NewReaderConfig()); err != nil {
+	if s.objectScannerCtor, err = nm.Environment().GetReaderCodec(conf.Codec, codec.NewReaderConfig()); err != nil {
 		return nil, err
 	}
 	if len(conf.SQS.DelayPeriod) > 0 {
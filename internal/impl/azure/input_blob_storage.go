@@ -27,7 +27,7 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		r, err := newAzureBlobStorage(conf.AzureBlobStorage, nm.Logger(), nm.Metrics())
+		r, err := newAzureBlobStorage(conf.AzureBlobStorage, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -236,7 +236,7 @@ type azureBlobStorage struct {
 }
 
 // newAzureBlobStorage creates a new Azure Blob Storage input type.
-func newAzureBlobStorage(conf input.AzureBlobStorageConfig, log log.Modular, stats metrics.Type) (*azureBlobStorage, error) {
+func newAzureBlobStorage(conf input.AzureBlobStorageConfig, nm bundle.NewManagement) (*azureBlobStorage, error) {
 	if conf.StorageAccount == "" && conf.StorageConnectionString == "" {
 		return nil, errors.New("invalid azure storage account credentials")
 	}
@@ -265,7 +265,7 @@ func newAzureBlobStorage(conf input.AzureBlobStorageConfig, log log.Modular, sta
 	}
 
 	var objectScannerCtor codec.ReaderConstructor
-	if objectScannerCtor, err = codec.GetReader(conf.Codec, codec.NewReaderConfig()); err != nil {
+	if objectScannerCtor, err = nm.Environment().GetReaderCodec(conf.Codec, codec.NewReaderConfig()); err != nil {
 		return nil, fmt.Errorf("invalid azure storage codec: %w", err)
 	}
 
@@ -273,8 +273,8 @@ func newAzureBlobStorage(conf input.AzureBlobStorageConfig, log log.Modular, sta
 	a := &azureBlobStorage{
 		conf:              conf,
 		objectScannerCtor: objectScannerCtor,
-		log:               log,
-		stats:             stats,
+		log:               nm.Logger(),
+		stats:             nm.Metrics(),
 		container:         blobService.GetContainerReference(conf.Container),
 	}
 
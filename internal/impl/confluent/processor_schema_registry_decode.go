@@ -4,19 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"path"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/linkedin/goavro/v2"
-
 	"github.com/benthosdev/benthos/v4/internal/httpclient"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -30,7 +23,15 @@ func schemaRegistryDecoderConfig() *service.ConfigSpec {
 		Description(`
 Decodes messages automatically from a schema stored within a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html) by extracting a schema ID from the message and obtaining the associated schema from the registry. If a message fails to match against the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported, all of which are decoded into JSON documents.
+
+### Protobuf Format
+
+Messages serialised with Protobuf schemas are decoded into the [JSON mapping of protobuf messages](https://developers.google.com/protocol-buffers/docs/proto3#json). The message type within the schema is identified by the message indexes that follow the schema ID of each message, and any schemas imported by the schema are obtained from the registry via the references of the schema.
+
+### JSON Schema Format
+
+Messages serialised with JSON schemas are already JSON documents, and therefore only have the schema ID removed.
 
 ### Avro JSON Format
 
@@ -72,12 +73,9 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryDecoder struct {
-	client      *http.Client
+	*schemaRegistryClient
 	avroRawJSON bool
 
-	schemaRegistryBaseURL *url.URL
-	requestSigner         httpclient.RequestSigner
-
 	schemas    map[int]*cachedSchemaDecoder
 	cacheMut   sync.RWMutex
 	requestMut sync.Mutex
@@ -113,32 +111,17 @@ func newSchemaRegistryDecoder(
 	avroRawJSON bool,
 	logger *service.Logger,
 ) (*schemaRegistryDecoder, error) {
-	u, err := url.Parse(urlStr)
+	client, err := newSchemaRegistryClient(urlStr, reqSigner, tlsConf, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
+		return nil, err
 	}
 
 	s := &schemaRegistryDecoder{
-		avroRawJSON:           avroRawJSON,
-		schemaRegistryBaseURL: u,
-		requestSigner:         reqSigner,
-		schemas:               map[int]*cachedSchemaDecoder{},
-		shutSig:               shutdown.NewSignaller(),
-		logger:                logger,
-	}
-
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
+		schemaRegistryClient: client,
+		avroRawJSON:          avroRawJSON,
+		schemas:              map[int]*cachedSchemaDecoder{},
+		shutSig:              shutdown.NewSignaller(),
+		logger:               logger,
 	}
 
 	go func() {
@@ -265,102 +248,27 @@ func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, error) {
 		return c.decoder, nil
 	}
 
-	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
-	defer done()
-
-	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/schemas/ids/%v", id))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	info, err := s.GetSchemaByID(context.Background(), id)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-	if err := s.requestSigner(req); err != nil {
-		return nil, err
-	}
-
-	var resBytes []byte
-	for i := 0; i < 3; i++ {
-		var res *http.Response
-		if res, err = s.client.Do(req); err != nil {
-			s.logger.Errorf("request failed for schema '%v': %v", id, err)
-			continue
-		}
-
-		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema '%v' not found by registry", id)
-			s.logger.Errorf(err.Error())
-			break
-		}
-
-		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema '%v'", id)
-			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
-		}
-
-		if res.Body == nil {
-			s.logger.Errorf("request for schema '%v' returned an empty body", id)
-			err = errors.New("schema request returned an empty body")
-			continue
-		}
-
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
-		if err != nil {
-			s.logger.Errorf("failed to read response for schema '%v': %v", id, err)
-			continue
-		}
 
-		break
+	var decoder schemaDecoder
+	switch info.schemaType() {
+	case schemaTypeAvro:
+		decoder, err = getAvroDecoder(info, s.avroRawJSON)
+	case schemaTypeProtobuf:
+		decoder, err = s.getProtobufDecoder(context.Background(), info)
+	case schemaTypeJSON:
+		decoder, err = s.getJSONSchemaDecoder(context.Background(), info)
+	default:
+		err = fmt.Errorf("schema type %v not supported", info.Type)
 	}
 	if err != nil {
-		return nil, err
-	}
-
-	resPayload := struct {
-		Schema string `json:"schema"`
-	}{}
-	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
 		s.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
 		return nil, err
 	}
 
-	var codec *goavro.Codec
-	if s.avroRawJSON {
-		if codec, err = goavro.NewCodecForStandardJSONFull(resPayload.Schema); err != nil {
-			s.logger.Errorf("failed to parse response for schema subject '%v': %v", id, err)
-			return nil, err
-		}
-	} else {
-		if codec, err = goavro.NewCodec(resPayload.Schema); err != nil {
-			s.logger.Errorf("failed to parse response for schema subject '%v': %v", id, err)
-			return nil, err
-		}
-	}
-
-	decoder := func(m *service.Message) error {
-		b, err := m.AsBytes()
-		if err != nil {
-			return err
-		}
-
-		native, _, err := codec.NativeFromBinary(b)
-		if err != nil {
-			return err
-		}
-
-		jb, err := codec.TextualFromNative(nil, native)
-		if err != nil {
-			return err
-		}
-		m.SetBytes(jb)
-
-		return nil
-	}
-
 	s.cacheMut.Lock()
 	s.schemas[id] = &cachedSchemaDecoder{
 		lastUsedUnixSeconds: time.Now().Unix(),
@@ -4,19 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"path"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/linkedin/goavro/v2"
-
 	"github.com/benthosdev/benthos/v4/internal/httpclient"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -33,7 +26,15 @@ Encodes messages automatically from schemas obtains from a [Confluent Schema Reg
 
 If a message fails to encode under the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported, and messages are expected to be JSON documents for all of them.
+
+### Protobuf Format
+
+Documents are expected in the [JSON mapping of protobuf messages](https://developers.google.com/protocol-buffers/docs/proto3#json), and are encoded as the message type named by the field ` + "[`protobuf_message_name`](#protobuf_message_name)" + `, or the first message type defined within the schema when the field is empty. Any schemas imported by the schema are obtained from the registry via the references of the schema.
+
+### JSON Schema Format
+
+Documents are validated against the schema, including any schemas it references, and are otherwise left unchanged apart from the schema ID being added. A document that fails validation results in an error.
 
 ### Avro JSON Format
 
@@ -67,7 +68,7 @@ Important! There is an outstanding issue in the [avro serializing library](https
 				Default("")).
 			Advanced().
 			Description("Allows you to specify basic authentication."),
-		).Description("Enable basic authentication").
+		).
 		Field(service.NewInterpolatedStringField("subject").Description("The schema subject to derive schemas from.").
 			Example("foo").
 			Example(`${! meta("kafka_topic") }`)).
@@ -78,7 +79,12 @@ Important! There is an outstanding issue in the [avro serializing library](https
 			Example("1h")).
 		Field(service.NewBoolField("avro_raw_json").
 			Description("Whether messages encoded in Avro format should be parsed as normal JSON (\"json that meets the expectations of regular internet json\") rather than [Avro JSON](https://avro.apache.org/docs/current/specification/_print/#json-encoding). If `true` the schema returned from the subject should be parsed as [standard json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodecForStandardJSONFull) instead of as [avro json](https://pkg.go.dev/github.com/linkedin/goavro/v2#NewCodec). There is a [comment in goavro](https://github.com/linkedin/goavro/blob/5ec5a5ee7ec82e16e6e2b438d610e1cab2588393/union.go#L224-L249), the [underlining library used for avro serialization](https://github.com/linkedin/goavro), that explains in more detail the difference between standard json and avro json.").
-			Advanced().Default(false).Version("3.59.0"))
+			Advanced().Default(false).Version("3.59.0")).
+		Field(service.NewStringField("protobuf_message_name").
+			Description("The fully qualified name of the message type that messages encoded in Protobuf format are encoded as. When empty the first message type defined within the schema is used.").
+			Example("example.v1.Person").
+			Example("example.v1.Person.Address").
+			Advanced().Default("").Version("4.10.0"))
 
 	for _, f := range httpclient.AuthFields() {
 		spec = spec.Field(f.Version("4.7.0"))
@@ -101,14 +107,12 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryEncoder struct {
-	client             *http.Client
+	*schemaRegistryClient
 	subject            *service.InterpolatedString
 	avroRawJSON        bool
+	protobufMsgName    string
 	schemaRefreshAfter time.Duration
 
-	schemaRegistryBaseURL *url.URL
-	requestSigner         httpclient.RequestSigner
-
 	schemas    map[string]*cachedSchemaEncoder
 	cacheMut   sync.RWMutex
 	requestMut sync.Mutex
@@ -131,6 +135,10 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, logger *serv
 	if err != nil {
 		return nil, err
 	}
+	protobufMsgName, err := conf.FieldString("protobuf_message_name")
+	if err != nil {
+		return nil, err
+	}
 	refreshPeriodStr, err := conf.FieldString("refresh_period")
 	if err != nil {
 		return nil, err
@@ -151,7 +159,12 @@ func newSchemaRegistryEncoderFromConfig(conf *service.ParsedConfig, logger *serv
 	if err != nil {
 		return nil, err
 	}
-	return newSchemaRegistryEncoder(urlStr, authSigner, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, logger)
+	encoder, err := newSchemaRegistryEncoder(urlStr, authSigner, tlsConf, subject, avroRawJSON, refreshPeriod, refreshTicker, logger)
+	if err != nil {
+		return nil, err
+	}
+	encoder.protobufMsgName = protobufMsgName
+	return encoder, nil
 }
 
 func newSchemaRegistryEncoder(
@@ -163,35 +176,20 @@ func newSchemaRegistryEncoder(
 	schemaRefreshAfter, schemaRefreshTicker time.Duration,
 	logger *service.Logger,
 ) (*schemaRegistryEncoder, error) {
-	u, err := url.Parse(urlStr)
+	client, err := newSchemaRegistryClient(urlStr, reqSigner, tlsConf, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
+		return nil, err
 	}
 
 	s := &schemaRegistryEncoder{
-		schemaRegistryBaseURL: u,
-		requestSigner:         reqSigner,
-		subject:               subject,
-		avroRawJSON:           avroRawJSON,
-		schemaRefreshAfter:    schemaRefreshAfter,
-		schemas:               map[string]*cachedSchemaEncoder{},
-		shutSig:               shutdown.NewSignaller(),
-		logger:                logger,
-		nowFn:                 time.Now,
-	}
-
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
+		schemaRegistryClient: client,
+		subject:              subject,
+		avroRawJSON:          avroRawJSON,
+		schemaRefreshAfter:   schemaRefreshAfter,
+		schemas:              map[string]*cachedSchemaEncoder{},
+		shutSig:              shutdown.NewSignaller(),
+		logger:               logger,
+		nowFn:                time.Now,
 	}
 
 	go func() {
@@ -319,101 +317,29 @@ func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder,
 	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
 	defer done()
 
-	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/subjects/%s/versions/latest", subject))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	info, err := s.GetSchemaBySubjectAndVersion(ctx, subject, nil)
 	if err != nil {
 		return nil, 0, err
 	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-	if err := s.requestSigner(req); err != nil {
-		return nil, 0, err
-	}
 
-	var resBytes []byte
-	for i := 0; i < 3; i++ {
-		var res *http.Response
-		if res, err = s.client.Do(req); err != nil {
-			s.logger.Errorf("request failed for schema subject '%v': %v", subject, err)
-			continue
-		}
-
-		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema subject '%v' not found by registry", subject)
-			s.logger.Errorf(err.Error())
-			break
-		}
-
-		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema subject '%v'", subject)
-			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
-		}
-
-		if res.Body == nil {
-			s.logger.Errorf("request for schema subject latest '%v' returned an empty body", subject)
-			err = errors.New("schema request returned an empty body")
-			continue
-		}
-
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
-		if err != nil {
-			s.logger.Errorf("failed to read response for schema subject '%v': %v", subject, err)
-			continue
-		}
-
-		break
+	s.logger.Tracef("Loaded new codec for subject %v: %v", subject, info.Schema)
+
+	var encoder schemaEncoder
+	switch info.schemaType() {
+	case schemaTypeAvro:
+		encoder, err = getAvroEncoder(info, s.avroRawJSON)
+	case schemaTypeProtobuf:
+		encoder, err = s.getProtobufEncoder(ctx, info, s.protobufMsgName)
+	case schemaTypeJSON:
+		encoder, err = s.getJSONSchemaEncoder(ctx, info)
+	default:
+		err = fmt.Errorf("schema type %v not supported", info.Type)
 	}
 	if err != nil {
-		return nil, 0, err
-	}
-
-	resPayload := struct {
-		Schema string `json:"schema"`
-		ID     int    `json:"id"`
-	}{}
-	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
 		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
 		return nil, 0, err
 	}
-
-	s.logger.Tracef("Loaded new codec for subject %v: %s", subject, resBytes)
-
-	var codec *goavro.Codec
-	if s.avroRawJSON {
-		if codec, err = goavro.NewCodecForStandardJSONFull(resPayload.Schema); err != nil {
-			s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-			return nil, 0, err
-		}
-	} else {
-		if codec, err = goavro.NewCodec(resPayload.Schema); err != nil {
-			s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-			return nil, 0, err
-		}
-	}
-
-	return func(m *service.Message) error {
-		b, err := m.AsBytes()
-		if err != nil {
-			return err
-		}
-
-		datum, _, err := codec.NativeFromTextual(b)
-		if err != nil {
-			return err
-		}
-
-		binary, err := codec.BinaryFromNative(nil, datum)
-		if err != nil {
-			return err
-		}
-
-		m.SetBytes(binary)
-		return nil
-	}, resPayload.ID, nil
+	return encoder, info.ID, nil
 }
 
 func (s *schemaRegistryEncoder) getEncoder(subject string) (schemaEncoder, int, error) {
@@ -0,0 +1,211 @@
+package confluent
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/httpclient"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// The types of schema supported by the schema registry, where an empty type
+// within a response indicates an Avro schema.
+const (
+	schemaTypeAvro     = "AVRO"
+	schemaTypeProtobuf = "PROTOBUF"
+	schemaTypeJSON     = "JSON"
+)
+
+type schemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+type schemaInfo struct {
+	ID         int               `json:"id"`
+	Type       string            `json:"schemaType"`
+	Schema     string            `json:"schema"`
+	References []schemaReference `json:"references"`
+}
+
+// schemaType returns the type of the schema, defaulting to Avro.
+func (s schemaInfo) schemaType() string {
+	if s.Type == "" {
+		return schemaTypeAvro
+	}
+	return s.Type
+}
+
+type schemaRegistryClient struct {
+	client                *http.Client
+	schemaRegistryBaseURL *url.URL
+	requestSigner         httpclient.RequestSigner
+	logger                *service.Logger
+}
+
+func newSchemaRegistryClient(
+	urlStr string,
+	reqSigner httpclient.RequestSigner,
+	tlsConf *tls.Config,
+	logger *service.Logger,
+) (*schemaRegistryClient, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	c := &schemaRegistryClient{
+		client:                http.DefaultClient,
+		schemaRegistryBaseURL: u,
+		requestSigner:         reqSigner,
+		logger:                logger,
+	}
+	if tlsConf != nil {
+		c.client = &http.Client{}
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			cloned := t.Clone()
+			cloned.TLSClientConfig = tlsConf
+			c.client.Transport = cloned
+		} else {
+			c.client.Transport = &http.Transport{
+				TLSClientConfig: tlsConf,
+			}
+		}
+	}
+	return c, nil
+}
+
+// GetSchemaByID obtains a schema from its globally unique ID.
+func (c *schemaRegistryClient) GetSchemaByID(ctx context.Context, id int) (schemaInfo, error) {
+	resBytes, err := c.doRequest(ctx, fmt.Sprintf("schema '%v'", id), fmt.Sprintf("/schemas/ids/%v", id))
+	if err != nil {
+		return schemaInfo{}, err
+	}
+
+	var info schemaInfo
+	if err := json.Unmarshal(resBytes, &info); err != nil {
+		c.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
+		return schemaInfo{}, err
+	}
+	// The ID is not included within the response body of this endpoint.
+	info.ID = id
+	return info, nil
+}
+
+// GetSchemaBySubjectAndVersion obtains a schema from a subject at a specific
+// version, or the latest version when version is nil.
+func (c *schemaRegistryClient) GetSchemaBySubjectAndVersion(ctx context.Context, subject string, version *int) (schemaInfo, error) {
+	versionStr := "latest"
+	if version != nil {
+		versionStr = fmt.Sprintf("%v", *version)
+	}
+
+	resBytes, err := c.doRequest(ctx, fmt.Sprintf("schema subject '%v'", subject), fmt.Sprintf("/subjects/%s/versions/%v", subject, versionStr))
+	if err != nil {
+		return schemaInfo{}, err
+	}
+
+	var info schemaInfo
+	if err := json.Unmarshal(resBytes, &info); err != nil {
+		c.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
+		return schemaInfo{}, err
+	}
+	return info, nil
+}
+
+// WalkReferences obtains each schema referenced by a list of references, and
+// the schemas those reference in turn, calling fn for each unique reference
+// name. The references of a schema are walked before the schema itself.
+func (c *schemaRegistryClient) WalkReferences(ctx context.Context, refs []schemaReference, fn func(ctx context.Context, name string, info schemaInfo) error) error {
+	seen := map[string]struct{}{}
+
+	var walk func(refs []schemaReference) error
+	walk = func(refs []schemaReference) error {
+		for _, ref := range refs {
+			if _, exists := seen[ref.Name]; exists {
+				continue
+			}
+			seen[ref.Name] = struct{}{}
+
+			version := ref.Version
+			info, err := c.GetSchemaBySubjectAndVersion(ctx, ref.Subject, &version)
+			if err != nil {
+				return fmt.Errorf("failed to obtain reference '%v': %w", ref.Name, err)
+			}
+			if err := walk(info.References); err != nil {
+				return err
+			}
+			if err := fn(ctx, ref.Name, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(refs)
+}
+
+func (c *schemaRegistryClient) doRequest(ctx context.Context, desc, reqPath string) (resBytes []byte, err error) {
+	ctx, done := context.WithTimeout(ctx, time.Second*5)
+	defer done()
+
+	reqURL := *c.schemaRegistryBaseURL
+	reqURL.Path = path.Join(reqURL.Path, reqPath)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+	if err := c.requestSigner(req); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 3; i++ {
+		var res *http.Response
+		if res, err = c.client.Do(req); err != nil {
+			c.logger.Errorf("request failed for %v: %v", desc, err)
+			continue
+		}
+
+		if res.StatusCode == http.StatusNotFound {
+			err = fmt.Errorf("%v not found by registry", desc)
+			c.logger.Errorf(err.Error())
+			break
+		}
+
+		if res.StatusCode != http.StatusOK {
+			err = fmt.Errorf("request failed for %v", desc)
+			c.logger.Errorf(err.Error())
+			// TODO: Best attempt at parsing out the body
+			continue
+		}
+
+		if res.Body == nil {
+			c.logger.Errorf("request for %v returned an empty body", desc)
+			err = errors.New("schema request returned an empty body")
+			continue
+		}
+
+		resBytes, err = io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			c.logger.Errorf("failed to read response for %v: %v", desc, err)
+			continue
+		}
+
+		break
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resBytes, nil
+}
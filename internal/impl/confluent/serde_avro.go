@@ -0,0 +1,73 @@
+package confluent
+
+import (
+	"errors"
+
+	"github.com/linkedin/goavro/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newAvroCodec(info schemaInfo, rawJSON bool) (*goavro.Codec, error) {
+	if len(info.References) > 0 {
+		return nil, errors.New("avro schemas with references are not supported")
+	}
+	if rawJSON {
+		return goavro.NewCodecForStandardJSONFull(info.Schema)
+	}
+	return goavro.NewCodec(info.Schema)
+}
+
+func getAvroDecoder(info schemaInfo, rawJSON bool) (schemaDecoder, error) {
+	codec, err := newAvroCodec(info, rawJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		native, _, err := codec.NativeFromBinary(b)
+		if err != nil {
+			return err
+		}
+
+		jb, err := codec.TextualFromNative(nil, native)
+		if err != nil {
+			return err
+		}
+		m.SetBytes(jb)
+
+		return nil
+	}, nil
+}
+
+func getAvroEncoder(info schemaInfo, rawJSON bool) (schemaEncoder, error) {
+	codec, err := newAvroCodec(info, rawJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		datum, _, err := codec.NativeFromTextual(b)
+		if err != nil {
+			return err
+		}
+
+		binary, err := codec.BinaryFromNative(nil, datum)
+		if err != nil {
+			return err
+		}
+
+		m.SetBytes(binary)
+		return nil
+	}, nil
+}
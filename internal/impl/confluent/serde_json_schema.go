@@ -0,0 +1,94 @@
+package confluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// jsonSchemaBaseURL is a placeholder location that schemas are loaded from, as
+// references can only be resolved between schemas with absolute URLs.
+const jsonSchemaBaseURL = "http://schema-registry.local/"
+
+// parseJSONSchema parses a JSON schema obtained from the registry along with
+// each of the schemas it references, which are resolved by their names.
+func (c *schemaRegistryClient) parseJSONSchema(ctx context.Context, info schemaInfo) (*gojsonschema.Schema, error) {
+	sl := gojsonschema.NewSchemaLoader()
+	refNames := map[string]struct{}{}
+	if err := c.WalkReferences(ctx, info.References, func(ctx context.Context, name string, ref schemaInfo) error {
+		if ref.schemaType() != schemaTypeJSON {
+			return fmt.Errorf("reference '%v' is not a JSON schema", name)
+		}
+		if err := sl.AddSchema(jsonSchemaBaseURL+name, gojsonschema.NewStringLoader(ref.Schema)); err != nil {
+			return fmt.Errorf("failed to parse reference '%v': %w", name, err)
+		}
+		refNames[name] = struct{}{}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// The schema itself has no name within the registry, and therefore we pick
+	// one that cannot collide with the name of a reference.
+	name := fmt.Sprintf("benthos_schema_registry_%v.json", info.ID)
+	for {
+		if _, exists := refNames[name]; !exists {
+			break
+		}
+		name = "_" + name
+	}
+	rootURL := jsonSchemaBaseURL + name
+	if err := sl.AddSchema(rootURL, gojsonschema.NewStringLoader(info.Schema)); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+
+	schema, err := sl.Compile(gojsonschema.NewReferenceLoader(rootURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	return schema, nil
+}
+
+func (c *schemaRegistryClient) getJSONSchemaDecoder(ctx context.Context, info schemaInfo) (schemaDecoder, error) {
+	// Documents serialised with JSON schemas are already JSON and only need the
+	// schema ID removing, which happens before the decoder is called. We still
+	// parse the schema so that an invalid schema is reported.
+	if _, err := c.parseJSONSchema(ctx, info); err != nil {
+		return nil, err
+	}
+	return func(m *service.Message) error {
+		return nil
+	}, nil
+}
+
+func (c *schemaRegistryClient) getJSONSchemaEncoder(ctx context.Context, info schemaInfo) (schemaEncoder, error) {
+	schema, err := c.parseJSONSchema(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(b))
+		if err != nil {
+			return fmt.Errorf("failed to validate message against JSON schema: %w", err)
+		}
+		if !result.Valid() {
+			var errMsgs []string
+			for _, desc := range result.Errors() {
+				errMsgs = append(errMsgs, desc.String())
+			}
+			return errors.New(strings.Join(errMsgs, ", "))
+		}
+		return nil
+	}, nil
+}
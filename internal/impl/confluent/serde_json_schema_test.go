@@ -0,0 +1,103 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testJSONSchemaAddress = `{
+	"type": "object",
+	"properties": {
+		"city": { "type": "string" }
+	},
+	"required": [ "city" ]
+}`
+
+const testJSONSchemaPerson = `{
+	"type": "object",
+	"properties": {
+		"name": { "type": "string" },
+		"address": { "$ref": "address.json" }
+	},
+	"required": [ "name" ]
+}`
+
+func TestSchemaRegistryEncodeDecodeJSONSchema(t *testing.T) {
+	refs := []schemaReference{{Name: "address.json", Subject: "address", Version: 2}}
+
+	person, err := json.Marshal(schemaInfo{ID: 20, Type: schemaTypeJSON, Schema: testJSONSchemaPerson, References: refs})
+	require.NoError(t, err)
+
+	personByID, err := json.Marshal(schemaInfo{Type: schemaTypeJSON, Schema: testJSONSchemaPerson, References: refs})
+	require.NoError(t, err)
+
+	address, err := json.Marshal(schemaInfo{ID: 21, Type: schemaTypeJSON, Schema: testJSONSchemaAddress})
+	require.NoError(t, err)
+
+	urlStr := runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/person/versions/latest":
+			return person, nil
+		case "/schemas/ids/20":
+			return personByID, nil
+		case "/subjects/address/versions/2":
+			return address, nil
+		}
+		return nil, errors.New("nope")
+	})
+
+	subj, err := service.NewInterpolatedString("person")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = encoder.Close(context.Background())
+	})
+
+	outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"foo","address":{"city":"bar"}}`)),
+		service.NewMessage([]byte(`{"name":"foo","address":{}}`)),
+		service.NewMessage([]byte(`{"address":{"city":"bar"}}`)),
+		service.NewMessage([]byte(`not json`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, outBatches, 1)
+	require.Len(t, outBatches[0], 4)
+
+	require.NoError(t, outBatches[0][0].GetError())
+	b, err := outBatches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00\x00\x14"+`{"name":"foo","address":{"city":"bar"}}`, string(b))
+
+	require.Error(t, outBatches[0][1].GetError())
+	assert.Contains(t, outBatches[0][1].GetError().Error(), "city is required")
+
+	require.Error(t, outBatches[0][2].GetError())
+	assert.Contains(t, outBatches[0][2].GetError().Error(), "name is required")
+
+	require.Error(t, outBatches[0][3].GetError())
+	assert.Contains(t, outBatches[0][3].GetError().Error(), "failed to validate message against JSON schema")
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, noopReqSign, nil, false, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = decoder.Close(context.Background())
+	})
+
+	outMsgs, err := decoder.Process(context.Background(), service.NewMessage(b))
+	require.NoError(t, err)
+	require.Len(t, outMsgs, 1)
+
+	b, err = outMsgs[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"name":"foo","address":{"city":"bar"}}`, string(b))
+}
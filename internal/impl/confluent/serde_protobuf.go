@@ -0,0 +1,235 @@
+package confluent
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"github.com/jhump/protoreflect/dynamic"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// parseProtobufSchema parses a protobuf schema obtained from the registry along
+// with each of the schemas it references.
+func (c *schemaRegistryClient) parseProtobufSchema(ctx context.Context, info schemaInfo) (*desc.FileDescriptor, error) {
+	files := map[string]string{}
+	if err := c.WalkReferences(ctx, info.References, func(ctx context.Context, name string, ref schemaInfo) error {
+		if ref.schemaType() != schemaTypeProtobuf {
+			return fmt.Errorf("reference '%v' is not a protobuf schema", name)
+		}
+		files[name] = ref.Schema
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	// The schema itself has no file name within the registry, and therefore we
+	// pick one that cannot collide with the name of a reference.
+	name := fmt.Sprintf("benthos_schema_registry_%v.proto", info.ID)
+	for {
+		if _, exists := files[name]; !exists {
+			break
+		}
+		name = "_" + name
+	}
+	files[name] = info.Schema
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(files),
+	}
+	fds, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse protobuf schema: %w", err)
+	}
+	return fds[0], nil
+}
+
+// readMessageIndexes extracts the message indexes that follow the schema ID of
+// a message serialised with a protobuf schema, which identify the message type
+// within the schema. A single zero byte is shorthand for the first message.
+func readMessageIndexes(b []byte) (indexes []int, remaining []byte, err error) {
+	count, n := binary.Varint(b)
+	if n <= 0 {
+		return nil, nil, errors.New("failed to read message indexes")
+	}
+	b = b[n:]
+	if count == 0 {
+		return []int{0}, b, nil
+	}
+	if count < 0 || count > int64(len(b)) {
+		return nil, nil, fmt.Errorf("invalid message index count: %v", count)
+	}
+
+	indexes = make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, nil, errors.New("failed to read message indexes")
+		}
+		indexes[i] = int(index)
+		b = b[n:]
+	}
+	return indexes, b, nil
+}
+
+// appendMessageIndexes writes message indexes in the same format as read by
+// readMessageIndexes.
+func appendMessageIndexes(b []byte, indexes []int) []byte {
+	if len(indexes) == 1 && indexes[0] == 0 {
+		return append(b, 0)
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutVarint(buf, int64(len(indexes)))
+	b = append(b, buf[:n]...)
+	for _, index := range indexes {
+		n = binary.PutVarint(buf, int64(index))
+		b = append(b, buf[:n]...)
+	}
+	return b
+}
+
+// messageFromIndexes walks a schema in order to find the message described by
+// a list of message indexes, where the first index identifies a top level
+// message and subsequent indexes identify nested messages.
+func messageFromIndexes(fd *desc.FileDescriptor, indexes []int) (*desc.MessageDescriptor, error) {
+	msgs := fd.GetMessageTypes()
+	var md *desc.MessageDescriptor
+	for _, index := range indexes {
+		if index < 0 || index >= len(msgs) {
+			return nil, fmt.Errorf("message index %v not found within schema", index)
+		}
+		md = msgs[index]
+		msgs = md.GetNestedMessageTypes()
+	}
+	if md == nil {
+		return nil, errors.New("no message indexes provided")
+	}
+	return md, nil
+}
+
+// messageIndexesFromName finds a message by its fully qualified name within a
+// schema and returns it along with the message indexes that identify it.
+func messageIndexesFromName(fd *desc.FileDescriptor, name string) ([]int, *desc.MessageDescriptor, error) {
+	md := fd.FindMessage(name)
+	if md == nil {
+		return nil, nil, fmt.Errorf("message type '%v' not found within schema", name)
+	}
+
+	var indexes []int
+	for d := md; d != nil; {
+		var siblings []*desc.MessageDescriptor
+		parent, isNested := d.GetParent().(*desc.MessageDescriptor)
+		if isNested {
+			siblings = parent.GetNestedMessageTypes()
+		} else {
+			siblings = fd.GetMessageTypes()
+		}
+		index := -1
+		for i, s := range siblings {
+			if s.GetFullyQualifiedName() == d.GetFullyQualifiedName() {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return nil, nil, fmt.Errorf("message type '%v' not found within schema", name)
+		}
+		indexes = append([]int{index}, indexes...)
+		if !isNested {
+			break
+		}
+		d = parent
+	}
+	return indexes, md, nil
+}
+
+func (c *schemaRegistryClient) getProtobufDecoder(ctx context.Context, info schemaInfo) (schemaDecoder, error) {
+	fd, err := c.parseProtobufSchema(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	marshaller := &jsonpb.Marshaler{
+		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), fd),
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		indexes, remaining, err := readMessageIndexes(b)
+		if err != nil {
+			return err
+		}
+
+		md, err := messageFromIndexes(fd, indexes)
+		if err != nil {
+			return err
+		}
+
+		msg := dynamic.NewMessage(md)
+		if err := msg.Unmarshal(remaining); err != nil {
+			return fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+		}
+
+		jb, err := msg.MarshalJSONPB(marshaller)
+		if err != nil {
+			return fmt.Errorf("failed to marshal protobuf message as JSON: %w", err)
+		}
+		m.SetBytes(jb)
+		return nil
+	}, nil
+}
+
+// getProtobufEncoder returns an encoder for the message type of a schema with
+// the provided fully qualified name, or the first message type of the schema
+// when the name is empty.
+func (c *schemaRegistryClient) getProtobufEncoder(ctx context.Context, info schemaInfo, msgName string) (schemaEncoder, error) {
+	fd, err := c.parseProtobufSchema(ctx, info)
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []int
+	var md *desc.MessageDescriptor
+	if msgName == "" {
+		indexes = []int{0}
+		md, err = messageFromIndexes(fd, indexes)
+	} else {
+		indexes, md, err = messageIndexesFromName(fd, msgName)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	unmarshaller := &jsonpb.Unmarshaler{
+		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), fd),
+	}
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		msg := dynamic.NewMessage(md)
+		if err := msg.UnmarshalJSONPB(unmarshaller, b); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
+		}
+
+		pb, err := msg.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal protobuf message: %w", err)
+		}
+		m.SetBytes(append(appendMessageIndexes(nil, indexes), pb...))
+		return nil
+	}, nil
+}
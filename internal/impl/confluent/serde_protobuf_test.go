@@ -0,0 +1,237 @@
+package confluent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testProtoCommonSchema = `
+syntax = "proto3";
+package common;
+
+message Address {
+  string city = 1;
+}
+`
+
+const testProtoPeopleSchema = `
+syntax = "proto3";
+package people;
+
+import "common.proto";
+import "google/protobuf/timestamp.proto";
+
+message Person {
+  string name = 1;
+  common.Address address = 2;
+  google.protobuf.Timestamp updated = 3;
+}
+
+message Other {
+  int32 n = 1;
+
+  message Nested {
+    string v = 1;
+  }
+}
+`
+
+func runProtobufSchemaRegistryServer(t *testing.T) string {
+	t.Helper()
+
+	refs := []schemaReference{{Name: "common.proto", Subject: "common", Version: 1}}
+
+	people, err := json.Marshal(schemaInfo{ID: 10, Type: schemaTypeProtobuf, Schema: testProtoPeopleSchema, References: refs})
+	require.NoError(t, err)
+
+	peopleByID, err := json.Marshal(schemaInfo{Type: schemaTypeProtobuf, Schema: testProtoPeopleSchema, References: refs})
+	require.NoError(t, err)
+
+	common, err := json.Marshal(schemaInfo{ID: 11, Type: schemaTypeProtobuf, Schema: testProtoCommonSchema})
+	require.NoError(t, err)
+
+	return runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/subjects/people/versions/latest":
+			return people, nil
+		case "/schemas/ids/10":
+			return peopleByID, nil
+		case "/subjects/common/versions/1":
+			return common, nil
+		}
+		return nil, errors.New("nope")
+	})
+}
+
+func TestSchemaRegistryEncodeDecodeProtobuf(t *testing.T) {
+	urlStr := runProtobufSchemaRegistryServer(t)
+
+	subj, err := service.NewInterpolatedString("people")
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = encoder.Close(context.Background())
+	})
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, noopReqSign, nil, false, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = decoder.Close(context.Background())
+	})
+
+	outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"foo","address":{"city":"bar"},"updated":"2022-11-20T10:00:00Z"}`)),
+		service.NewMessage([]byte(`{"nope":"foo"}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, outBatches, 1)
+	require.Len(t, outBatches[0], 2)
+
+	require.NoError(t, outBatches[0][0].GetError())
+	b, err := outBatches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "\x00\x00\x00\x00\x0a\x00\x0a\x03foo\x12\x05\x0a\x03bar\x1a\x06\x08\xa0\xf5\xe7\x9b\x06", string(b))
+
+	require.Error(t, outBatches[0][1].GetError())
+	assert.Contains(t, outBatches[0][1].GetError().Error(), "failed to unmarshal JSON message")
+
+	tests := []struct {
+		name        string
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:   "first message",
+			input:  string(b),
+			output: `{"name":"foo","address":{"city":"bar"},"updated":"2022-11-20T10:00:00Z"}`,
+		},
+		{
+			name:   "nested message",
+			input:  "\x00\x00\x00\x00\x0a\x04\x02\x00\x0a\x01x",
+			output: `{"v":"x"}`,
+		},
+		{
+			name:   "second message",
+			input:  "\x00\x00\x00\x00\x0a\x02\x02\x08\x05",
+			output: `{"n":5}`,
+		},
+		{
+			name:        "bad message index",
+			input:       "\x00\x00\x00\x00\x0a\x02\x0a\x08\x05",
+			errContains: "message index 5 not found within schema",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			outMsgs, err := decoder.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, outMsgs, 1)
+
+			b, err := outMsgs[0].AsBytes()
+			require.NoError(t, err)
+			assert.JSONEq(t, test.output, string(b))
+		})
+	}
+}
+
+func TestProtobufMessageIndexes(t *testing.T) {
+	for _, indexes := range [][]int{{0}, {1}, {0, 0}, {3, 1, 2}, {200}} {
+		b := appendMessageIndexes(nil, indexes)
+		b = append(b, "remaining"...)
+
+		res, remaining, err := readMessageIndexes(b)
+		require.NoError(t, err)
+		assert.Equal(t, indexes, res)
+		assert.Equal(t, "remaining", string(remaining))
+	}
+
+	assert.Equal(t, []byte{0}, appendMessageIndexes(nil, []int{0}))
+
+	_, _, err := readMessageIndexes(nil)
+	require.Error(t, err)
+
+	_, _, err = readMessageIndexes([]byte{0x06, 0x02})
+	require.Error(t, err)
+}
+
+func TestSchemaRegistryEncodeProtobufMessageName(t *testing.T) {
+	urlStr := runProtobufSchemaRegistryServer(t)
+
+	subj, err := service.NewInterpolatedString("people")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		msgName     string
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:    "top level message",
+			msgName: "people.Other",
+			input:   `{"n":5}`,
+			output:  "\x00\x00\x00\x00\x0a\x02\x02\x08\x05",
+		},
+		{
+			name:    "nested message",
+			msgName: "people.Other.Nested",
+			input:   `{"v":"x"}`,
+			output:  "\x00\x00\x00\x00\x0a\x04\x02\x00\x0a\x01x",
+		},
+		{
+			name:        "unknown message",
+			msgName:     "people.Nope",
+			input:       `{"v":"x"}`,
+			errContains: "message type 'people.Nope' not found within schema",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			encoder, err := newSchemaRegistryEncoder(urlStr, noopReqSign, nil, subj, false, time.Minute*10, time.Minute, nil)
+			require.NoError(t, err)
+			t.Cleanup(func() {
+				_ = encoder.Close(context.Background())
+			})
+			encoder.protobufMsgName = test.msgName
+
+			outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{
+				service.NewMessage([]byte(test.input)),
+			})
+			require.NoError(t, err)
+			require.Len(t, outBatches, 1)
+			require.Len(t, outBatches[0], 1)
+
+			if test.errContains != "" {
+				require.Error(t, outBatches[0][0].GetError())
+				assert.Contains(t, outBatches[0][0].GetError().Error(), test.errContains)
+				return
+			}
+			require.NoError(t, outBatches[0][0].GetError())
+
+			b, err := outBatches[0][0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, string(b))
+		})
+	}
+}
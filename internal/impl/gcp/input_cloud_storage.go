@@ -25,7 +25,7 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(c input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		r, err := newGCPCloudStorageInput(c.GCPCloudStorage, nm.Logger(), nm.Metrics())
+		r, err := newGCPCloudStorageInput(c.GCPCloudStorage, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -210,18 +210,18 @@ type gcpCloudStorageInput struct {
 }
 
 // newGCPCloudStorageInput creates a new Google Cloud Storage input type.
-func newGCPCloudStorageInput(conf input.GCPCloudStorageConfig, log log.Modular, stats metrics.Type) (*gcpCloudStorageInput, error) {
+func newGCPCloudStorageInput(conf input.GCPCloudStorageConfig, nm bundle.NewManagement) (*gcpCloudStorageInput, error) {
 	var objectScannerCtor codec.ReaderConstructor
 	var err error
-	if objectScannerCtor, err = codec.GetReader(conf.Codec, codec.NewReaderConfig()); err != nil {
+	if objectScannerCtor, err = nm.Environment().GetReaderCodec(conf.Codec, codec.NewReaderConfig()); err != nil {
 		return nil, fmt.Errorf("invalid google cloud storage codec: %v", err)
 	}
 
 	g := &gcpCloudStorageInput{
 		conf:              conf,
 		objectScannerCtor: objectScannerCtor,
-		log:               log,
-		stats:             stats,
+		log:               nm.Logger(),
+		stats:             nm.Metrics(),
 	}
 
 	return g, nil
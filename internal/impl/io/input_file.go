@@ -21,7 +21,7 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		rdr, err := newFileConsumer(conf.File, nm.Logger())
+		rdr, err := newFileConsumer(conf.File, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -90,7 +90,7 @@ type fileConsumer struct {
 	delete bool
 }
 
-func newFileConsumer(conf input.FileConfig, log log.Modular) (*fileConsumer, error) {
+func newFileConsumer(conf input.FileConfig, mgr bundle.NewManagement) (*fileConsumer, error) {
 	expandedPaths, err := filepath.Globs(conf.Paths)
 	if err != nil {
 		return nil, err
@@ -98,13 +98,13 @@ func newFileConsumer(conf input.FileConfig, log log.Modular) (*fileConsumer, err
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
-	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	ctor, err := mgr.Environment().GetReaderCodec(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
 	}
 
 	return &fileConsumer{
-		log:         log,
+		log:         mgr.Logger(),
 		scannerCtor: ctor,
 		paths:       expandedPaths,
 		delete:      conf.DeleteOnFinish,
@@ -2,15 +2,20 @@ package io
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"strings"
 	"sync"
+	"time"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
 	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
 	"github.com/benthosdev/benthos/v4/internal/docs"
@@ -30,12 +35,35 @@ func httpClientInputSpec() docs.FieldSpec {
 		docs.FieldInt("max_buffer", "Must be larger than the largest line of the stream.").Advanced(),
 	}
 
+	paginationSpecs := docs.FieldSpecs{
+		docs.FieldBool("enabled", "Enables pagination."),
+		docs.FieldBloblang(
+			"cursor", "A [Bloblang mapping](/docs/guides/bloblang/about) executed on the last message of each response that determines the cursor of the next page, which is added to the metadata of the reference message under the key `pagination_cursor`. The cursor can be any value, such as a page number, an opaque token or the full URL of the next page. If the mapping results in `null`, an empty string or a deleted message then there are no more pages.",
+			`root = this.next_page_token`,
+			`root = this.page + 1`,
+			`root = meta("link").re_find_object("<(?P<url>[^>]+)>; rel=\"next\"").url | deleted()`,
+		),
+		docs.FieldBloblang(
+			"stop", "An optional [Bloblang query](/docs/guides/bloblang/about) executed on the last message of each response that should return a boolean indicating whether the end of pages has been reached. When a stop condition is met the cursor determined by the `cursor` mapping, if any, is kept in order to resume from it.",
+			`root = this.end_of_stream`,
+			`root = this.items.length() == 0`,
+		).Optional(),
+		docs.FieldInt("max_pages", "The maximum number of pages to consume in a single round of pagination, where `0` means no limit.").Advanced(),
+		docs.FieldBool("close_on_end", "Whether the input should shut down once the end of pages is reached. When `false` a new round of pagination is started after `restart_interval`."),
+		docs.FieldString("restart_interval", "The period to wait after reaching the end of pages before starting a new round of pagination.", "30s", "1h"),
+		docs.FieldString("cache", "An optional [cache resource](/docs/components/caches/about) used to persist the cursor of the next page once each page and all pages before it have been acknowledged, allowing the input to resume from it after a restart.").Optional(),
+		docs.FieldString("cache_key", "The key used to store the cursor within the `cache`.").Advanced(),
+	}
+
 	return httpclient.OldFieldSpec(false,
 		docs.FieldString("payload", "An optional payload to deliver for each request."),
 		docs.FieldBool("drop_empty_bodies", "Whether empty payloads received from the target server should be dropped.").Advanced(),
 		docs.FieldObject(
 			"stream", "Allows you to set streaming mode, where requests are kept open and messages are processed line-by-line.",
 		).WithChildren(streamSpecs...),
+		docs.FieldObject(
+			"pagination", "Allows you to consume paginated APIs, where the cursor of each subsequent page is determined from the previous response. Pagination cannot be combined with streaming mode.",
+		).WithChildren(paginationSpecs...).Advanced(),
 	)
 }
 
@@ -58,7 +86,13 @@ If you enable streaming then Benthos will consume the body of the response as a
 
 ### Pagination
 
-This input supports interpolation functions in the ` + "`url` and `headers`" + ` fields where data from the previous successfully consumed message (if there was one) can be referenced. This can be used in order to support basic levels of pagination. However, in cases where pagination depends on logic it is recommended that you use an ` + "[`http` processor](/docs/components/processors/http) instead, often combined with a [`generate` input](/docs/components/inputs/generate)" + ` in order to schedule the processor.`,
+This input supports interpolation functions in the ` + "`url` and `headers`" + ` fields where data from the previous successfully consumed message (if there was one) can be referenced. This can be used in order to support basic levels of pagination.
+
+For APIs where pagination requires more logic the ` + "`pagination`" + ` block can be enabled, where a ` + "`cursor`" + ` mapping determines the cursor of the next page from each response (including its headers as metadata). The cursor is added to the metadata of the reference message as ` + "`pagination_cursor`" + ` so that it can be referenced within the ` + "`url` and `headers`" + ` fields, and is absent when requesting the first page.
+
+The end of pages is reached when the ` + "`cursor`" + ` mapping results in ` + "`null`" + `, when the optional ` + "`stop`" + ` query returns ` + "`true`" + `, when ` + "`max_pages`" + ` pages have been consumed or when an empty response is received. At that point the input either shuts down (` + "`close_on_end`" + `) or starts another round of pagination after ` + "`restart_interval`" + `. A new round starts from the first page, unless the end was reached via the ` + "`stop`" + ` query with a cursor present, via ` + "`max_pages`" + ` or via an empty response, in which case it resumes from the latest cursor, which enables incremental syncs of APIs that provide a cursor for future changes.
+
+When a ` + "`cache`" + ` is configured the cursor of the next page is persisted each time a page is acknowledged, and is used as the starting cursor when the input is restarted. A cursor is only persisted once all prior pages have also been acknowledged, and therefore a page that is rejected is consumed again after a restart even when later pages were acknowledged.`,
 		Config: httpClientInputSpec().ChildDefaultAndTypesFromStruct(input.NewHTTPClientConfig()),
 		Categories: []string{
 			"Network",
@@ -87,6 +121,27 @@ rate_limit_resources:
     local:
       count: 1
       interval: 30s
+`,
+			},
+			{
+				Title:   "Incremental Sync",
+				Summary: "The `pagination` block can be used to walk all pages of an API with a cursor, resume from the last cursor once the end is reached, and persist the cursor to a cache so that a restarted pipeline continues where it left off.",
+				Config: `
+input:
+  http_client:
+    url: https://example.zendesk.com/api/v2/incremental/tickets/cursor.json?${! "cursor=%v".format(meta("pagination_cursor").not_null()) | "start_time=0" }
+    verb: GET
+    pagination:
+      enabled: true
+      cursor: root = this.after_cursor
+      stop: root = this.end_of_stream
+      restart_interval: 1m
+      cache: cursors
+
+cache_resources:
+  - label: cursors
+    file:
+      directory: ./cursors
 `,
 			},
 		},
@@ -103,6 +158,7 @@ type httpClientInput struct {
 
 	client       *httpclient.Client
 	prevResponse message.Batch
+	pager        *httpClientPager
 
 	codecCtor codec.ReaderConstructor
 
@@ -120,7 +176,7 @@ func newHTTPClientInput(conf input.HTTPClientConfig, mgr bundle.NewManagement) (
 		codecConf.MaxScanTokenSize = conf.Stream.MaxBuffer
 
 		var err error
-		if codecCtor, err = codec.GetReader(conf.Stream.Codec, codecConf); err != nil {
+		if codecCtor, err = mgr.Environment().GetReaderCodec(conf.Stream.Codec, codecConf); err != nil {
 			return nil, err
 		}
 	}
@@ -130,6 +186,16 @@ func newHTTPClientInput(conf input.HTTPClientConfig, mgr bundle.NewManagement) (
 		return nil, fmt.Errorf("failed to parse payload expression: %w", err)
 	}
 
+	var pager *httpClientPager
+	if conf.Pagination.Enabled {
+		if conf.Stream.Enabled {
+			return nil, errors.New("pagination cannot be enabled in streaming mode")
+		}
+		if pager, err = newHTTPClientPager(conf.Pagination, mgr); err != nil {
+			return nil, err
+		}
+	}
+
 	client, err := httpclient.NewClientFromOldConfig(conf.OldConfig, mgr, httpclient.WithExplicitBody(payloadExpr))
 	if err != nil {
 		return nil, err
@@ -139,6 +205,7 @@ func newHTTPClientInput(conf input.HTTPClientConfig, mgr bundle.NewManagement) (
 		conf:         conf,
 		prevResponse: message.QuickBatch(nil),
 		client:       client,
+		pager:        pager,
 
 		codecCtor: codecCtor,
 	}, nil
@@ -248,6 +315,12 @@ func (h *httpClientInput) readStreamed(ctx context.Context) (message.Batch, inpu
 }
 
 func (h *httpClientInput) readNotStreamed(ctx context.Context) (message.Batch, input.AsyncAckFn, error) {
+	if h.pager != nil {
+		if err := h.pager.beforePage(ctx, h); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	msg, err := h.client.Send(ctx, h.prevResponse)
 	if err != nil {
 		if strings.Contains(err.Error(), "(Client.Timeout exceeded while awaiting headers)") {
@@ -256,10 +329,15 @@ func (h *httpClientInput) readNotStreamed(ctx context.Context) (message.Batch, i
 		return nil, nil, err
 	}
 
-	if msg.Len() == 0 {
-		return nil, nil, component.ErrTimeout
+	empty := msg.Len() == 0 || (msg.Len() == 1 && msg.Get(0).IsEmpty() && h.conf.DropEmptyBodies)
+	if h.pager != nil {
+		if empty {
+			h.pager.onEmptyPage(h)
+			return nil, nil, component.ErrTimeout
+		}
+		return msg.ShallowCopy(), h.pager.afterPage(h, msg), nil
 	}
-	if msg.Len() == 1 && msg.Get(0).IsEmpty() && h.conf.DropEmptyBodies {
+	if empty {
 		return nil, nil, component.ErrTimeout
 	}
 
@@ -281,3 +359,249 @@ func (h *httpClientInput) Close(ctx context.Context) (err error) {
 	}
 	return
 }
+
+//------------------------------------------------------------------------------
+
+const paginationCursorKey = "pagination_cursor"
+
+// httpClientPager tracks the cursor of a paginated API across the requests of
+// an http_client input. Its methods are called sequentially from ReadBatch,
+// with the exception of cursor persistence, which happens on acknowledgement.
+type httpClientPager struct {
+	mgr  bundle.NewManagement
+	conf input.PaginationConfig
+
+	cursorMapping   *mapping.Executor
+	stopQuery       *mapping.Executor
+	restartInterval time.Duration
+
+	initialised bool
+	current     any
+	resumeFrom  any
+	pages       int
+	restartAt   time.Time
+	ended       bool
+
+	storeMut    sync.Mutex
+	checkpoints *checkpoint.Type
+	seq         uint64
+	storedSeq   uint64
+}
+
+// pagerCheckpoint is the cursor of the page that follows an acknowledged page.
+type pagerCheckpoint struct {
+	seq    uint64
+	cursor any
+}
+
+func newHTTPClientPager(conf input.PaginationConfig, mgr bundle.NewManagement) (*httpClientPager, error) {
+	p := &httpClientPager{mgr: mgr, conf: conf, checkpoints: checkpoint.New()}
+
+	if conf.Cursor == "" {
+		return nil, errors.New("a pagination cursor mapping must be specified")
+	}
+
+	var err error
+	if p.cursorMapping, err = mgr.BloblEnvironment().NewMapping(conf.Cursor); err != nil {
+		return nil, fmt.Errorf("failed to parse pagination cursor mapping: %w", err)
+	}
+	if conf.Stop != "" {
+		if p.stopQuery, err = mgr.BloblEnvironment().NewMapping(conf.Stop); err != nil {
+			return nil, fmt.Errorf("failed to parse pagination stop query: %w", err)
+		}
+	}
+	if conf.RestartInterval != "" {
+		if p.restartInterval, err = time.ParseDuration(conf.RestartInterval); err != nil {
+			return nil, fmt.Errorf("failed to parse pagination restart interval: %w", err)
+		}
+	}
+	if conf.Cache != "" {
+		if !mgr.ProbeCache(conf.Cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+		}
+		if conf.CacheKey == "" {
+			return nil, errors.New("a pagination cache key must be specified")
+		}
+	}
+	return p, nil
+}
+
+// referenceFor returns a message used as the reference of the next request,
+// which is a shallow copy of the previous response (if any) with the cursor
+// added to its metadata.
+func referenceFor(prev message.Batch, cursor any) message.Batch {
+	if len(prev) == 0 {
+		prev = message.QuickBatch([][]byte{nil})
+	}
+	ref := prev.ShallowCopy()
+	for _, p := range ref {
+		if cursor == nil {
+			p.MetaDelete(paginationCursorKey)
+		} else {
+			p.MetaSetMut(paginationCursorKey, cursor)
+		}
+	}
+	return ref
+}
+
+func (p *httpClientPager) beforePage(ctx context.Context, h *httpClientInput) error {
+	if !p.initialised {
+		if p.conf.Cache != "" {
+			var cached []byte
+			var cerr error
+			if err := p.mgr.AccessCache(ctx, p.conf.Cache, func(c cache.V1) {
+				cached, cerr = c.Get(ctx, p.conf.CacheKey)
+			}); err != nil {
+				return err
+			}
+			if cerr != nil && !errors.Is(cerr, component.ErrKeyNotFound) {
+				return cerr
+			}
+			if len(cached) > 0 {
+				if err := json.Unmarshal(cached, &p.resumeFrom); err != nil {
+					return fmt.Errorf("failed to parse cached pagination cursor: %w", err)
+				}
+			}
+		}
+		p.current = p.resumeFrom
+		h.prevResponse = referenceFor(nil, p.current)
+		p.initialised = true
+	}
+
+	if p.ended {
+		return component.ErrTypeClosed
+	}
+
+	if !p.restartAt.IsZero() {
+		select {
+		case <-time.After(time.Until(p.restartAt)):
+		case <-ctx.Done():
+			return component.ErrTimeout
+		}
+		p.restartAt = time.Time{}
+	}
+	return nil
+}
+
+func (p *httpClientPager) nextCursor(msg message.Batch) (cursor any, stop bool, err error) {
+	index := msg.Len() - 1
+
+	var res *message.Part
+	if res, err = p.cursorMapping.MapPart(index, msg); err != nil {
+		return nil, false, fmt.Errorf("pagination cursor mapping failed: %w", err)
+	}
+	if res != nil {
+		if cursor, err = res.AsStructured(); err != nil {
+			return nil, false, fmt.Errorf("pagination cursor mapping failed: %w", err)
+		}
+		if str, ok := cursor.(string); ok && str == "" {
+			cursor = nil
+		}
+	}
+
+	if p.stopQuery != nil {
+		if stop, err = p.stopQuery.QueryPart(index, msg); err != nil {
+			return nil, false, fmt.Errorf("pagination stop query failed: %w", err)
+		}
+	}
+	return
+}
+
+func (p *httpClientPager) endRound(h *httpClientInput, resumeFrom any) {
+	p.pages = 0
+	p.resumeFrom = resumeFrom
+	p.current = resumeFrom
+	h.prevResponse = referenceFor(nil, resumeFrom)
+	if p.conf.CloseOnEnd {
+		p.ended = true
+	} else {
+		p.restartAt = time.Now().Add(p.restartInterval)
+	}
+}
+
+func (p *httpClientPager) onEmptyPage(h *httpClientInput) {
+	// An empty page means there's no data at the current cursor yet, and
+	// therefore the next round resumes from it.
+	p.endRound(h, p.current)
+}
+
+func (p *httpClientPager) afterPage(h *httpClientInput, msg message.Batch) input.AsyncAckFn {
+	p.pages++
+
+	cursor, stop, err := p.nextCursor(msg)
+
+	switch {
+	case err != nil:
+		p.mgr.Logger().Errorf("Failed to determine the next page, the next round of pagination will resume from the current cursor: %v", err)
+		p.endRound(h, p.current)
+	case stop:
+		p.endRound(h, cursor)
+	case cursor == nil:
+		p.endRound(h, nil)
+	case p.conf.MaxPages > 0 && p.pages >= p.conf.MaxPages:
+		p.endRound(h, cursor)
+	default:
+		p.current = cursor
+		h.prevResponse = referenceFor(msg, cursor)
+	}
+
+	if p.conf.Cache == "" {
+		return func(context.Context, error) error {
+			return nil
+		}
+	}
+
+	p.storeMut.Lock()
+	p.seq++
+	resolveFn := p.checkpoints.Track(&pagerCheckpoint{seq: p.seq, cursor: p.current}, 1)
+	p.storeMut.Unlock()
+
+	var resolveOnce sync.Once
+	return func(ctx context.Context, res error) error {
+		if res != nil {
+			return nil
+		}
+		var err error
+		resolveOnce.Do(func() {
+			p.storeMut.Lock()
+			defer p.storeMut.Unlock()
+			if highest, _ := resolveFn().(*pagerCheckpoint); highest != nil {
+				err = p.storeCursor(ctx, highest)
+			}
+		})
+		return err
+	}
+}
+
+// storeCursor persists the cursor of the page following the latest page where
+// it and all prior pages have been acknowledged, which means a page that is
+// rejected is consumed again after a restart even when later pages were
+// acknowledged. Must be called with storeMut held.
+func (p *httpClientPager) storeCursor(ctx context.Context, cp *pagerCheckpoint) error {
+	if cp.seq <= p.storedSeq {
+		return nil
+	}
+	cursor := cp.cursor
+
+	var cerr error
+	if err := p.mgr.AccessCache(ctx, p.conf.Cache, func(c cache.V1) {
+		if cursor == nil {
+			if cerr = c.Delete(ctx, p.conf.CacheKey); errors.Is(cerr, component.ErrKeyNotFound) {
+				cerr = nil
+			}
+			return
+		}
+		var cursorBytes []byte
+		if cursorBytes, cerr = json.Marshal(cursor); cerr != nil {
+			return
+		}
+		cerr = c.Set(ctx, p.conf.CacheKey, cursorBytes, nil)
+	}); err != nil {
+		return err
+	}
+	if cerr != nil {
+		return fmt.Errorf("failed to persist pagination cursor: %w", cerr)
+	}
+	p.storedSeq = cp.seq
+	return nil
+}
@@ -3,6 +3,7 @@ package io_test
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -138,6 +139,231 @@ func TestHTTPClientPagination(t *testing.T) {
 	}
 }
 
+func paginatedTestServer(t *testing.T, lastPage int, endOfStream bool) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var queries []string
+	var queriesLock sync.Mutex
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queriesLock.Lock()
+		queries = append(queries, r.URL.RawQuery)
+		queriesLock.Unlock()
+
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			_, _ = fmt.Sscanf(p, "%d", &page)
+		}
+		if page > lastPage {
+			return
+		}
+		next := "null"
+		if endOfStream || page < lastPage {
+			next = fmt.Sprintf("%v", page+1)
+		}
+		fmt.Fprintf(w, `{"page":%v,"next":%v,"end":%v}`, page, next, page >= lastPage)
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts, func() []string {
+		queriesLock.Lock()
+		defer queriesLock.Unlock()
+		return append([]string{}, queries...)
+	}
+}
+
+func readHTTPClientPages(t *testing.T, ctx context.Context, h input.Streamed, n int) (pages []string) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		select {
+		case tr, open := <-h.TransactionChan():
+			require.True(t, open)
+			require.Equal(t, 1, tr.Payload.Len())
+			pages = append(pages, string(tr.Payload.Get(0).AsBytes()))
+			require.NoError(t, tr.Ack(ctx, nil))
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+	}
+	return
+}
+
+func TestHTTPClientPaginationCursor(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	ts, queries := paginatedTestServer(t, 3, false)
+
+	conf := input.NewConfig()
+	conf.Type = "http_client"
+	conf.HTTPClient.URL = ts.URL + `/items?page=${! meta("pagination_cursor") | 1 }`
+	conf.HTTPClient.Retry = "1ms"
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.Cursor = "root = this.next"
+	conf.HTTPClient.Pagination.CloseOnEnd = true
+
+	h, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"page":1,"next":2,"end":false}`,
+		`{"page":2,"next":3,"end":false}`,
+		`{"page":3,"next":null,"end":true}`,
+	}, readHTTPClientPages(t, tCtx, h, 3))
+
+	select {
+	case _, open := <-h.TransactionChan():
+		require.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("Action timed out")
+	}
+	require.NoError(t, h.WaitForClose(tCtx))
+
+	assert.Equal(t, []string{"page=1", "page=2", "page=3"}, queries())
+}
+
+func TestHTTPClientPaginationIncrementalCache(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	ts, queries := paginatedTestServer(t, 3, true)
+
+	mgr := mock.NewManager()
+	mgr.Caches["cursors"] = map[string]mock.CacheItem{
+		"http_client_cursor": {Value: "2"},
+	}
+
+	conf := input.NewConfig()
+	conf.Type = "http_client"
+	conf.HTTPClient.URL = ts.URL + `/items?page=${! meta("pagination_cursor") | 1 }`
+	conf.HTTPClient.Retry = "1ms"
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.Cursor = "root = this.next"
+	conf.HTTPClient.Pagination.Stop = "root = this.end"
+	conf.HTTPClient.Pagination.Cache = "cursors"
+	conf.HTTPClient.Pagination.RestartInterval = "1ms"
+
+	h, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+
+	// Resumes from the cached cursor, and once the end of stream is reached
+	// the next round resumes from the retained cursor, where the empty page
+	// means the same cursor is polled again.
+	assert.Equal(t, []string{
+		`{"page":2,"next":3,"end":false}`,
+		`{"page":3,"next":4,"end":true}`,
+	}, readHTTPClientPages(t, tCtx, h, 2))
+
+	assert.Eventually(t, func() bool {
+		q := queries()
+		return len(q) >= 4 && q[2] == "page=4" && q[3] == "page=4"
+	}, time.Second, time.Millisecond*10)
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+
+	assert.Equal(t, "4", mgr.Caches["cursors"]["http_client_cursor"].Value)
+}
+
+func TestHTTPClientPaginationCacheNackedPage(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	ts, _ := paginatedTestServer(t, 3, false)
+
+	mgr := mock.NewManager()
+	mgr.Caches["cursors"] = map[string]mock.CacheItem{}
+
+	conf := input.NewConfig()
+	conf.Type = "http_client"
+	conf.HTTPClient.URL = ts.URL + `/items?page=${! meta("pagination_cursor") | 1 }`
+	conf.HTTPClient.Retry = "1ms"
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.Cursor = "root = this.next"
+	conf.HTTPClient.Pagination.Cache = "cursors"
+	conf.HTTPClient.Pagination.CloseOnEnd = true
+
+	h, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+
+	readTran := func() message.Transaction {
+		t.Helper()
+		select {
+		case tr, open := <-h.TransactionChan():
+			require.True(t, open)
+			return tr
+		case <-time.After(time.Second):
+			t.Fatal("Action timed out")
+		}
+		return message.Transaction{}
+	}
+
+	// The second page is acknowledged but the first is rejected, and
+	// therefore the cursor of the third page must not be persisted.
+	first, second := readTran(), readTran()
+	assert.Equal(t, `{"page":1,"next":2,"end":false}`, string(first.Payload.Get(0).AsBytes()))
+	assert.Equal(t, `{"page":2,"next":3,"end":false}`, string(second.Payload.Get(0).AsBytes()))
+
+	require.NoError(t, second.Ack(tCtx, nil))
+	require.NoError(t, first.Ack(tCtx, errors.New("nope")))
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+
+	assert.NotContains(t, mgr.Caches["cursors"], "http_client_cursor")
+
+	// After a restart the rejected page is consumed again.
+	h, err = mgr.NewInput(conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"page":1,"next":2,"end":false}`,
+	}, readHTTPClientPages(t, tCtx, h, 1))
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+}
+
+func TestHTTPClientPaginationMaxPages(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	ts, _ := paginatedTestServer(t, 3, false)
+
+	conf := input.NewConfig()
+	conf.Type = "http_client"
+	conf.HTTPClient.URL = ts.URL + `/items?page=${! meta("pagination_cursor") | 1 }`
+	conf.HTTPClient.Retry = "1ms"
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.Cursor = "root = this.next"
+	conf.HTTPClient.Pagination.MaxPages = 2
+	conf.HTTPClient.Pagination.RestartInterval = "1ms"
+
+	h, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		`{"page":1,"next":2,"end":false}`,
+		`{"page":2,"next":3,"end":false}`,
+		`{"page":3,"next":null,"end":true}`,
+		`{"page":1,"next":2,"end":false}`,
+	}, readHTTPClientPages(t, tCtx, h, 4))
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+}
+
+func TestHTTPClientPaginationStreamError(t *testing.T) {
+	conf := input.NewConfig()
+	conf.Type = "http_client"
+	conf.HTTPClient.URL = "http://localhost:1234"
+	conf.HTTPClient.Stream.Enabled = true
+	conf.HTTPClient.Pagination.Enabled = true
+	conf.HTTPClient.Pagination.Cursor = "root = this.next"
+
+	_, err := mock.NewManager().NewInput(conf)
+	require.Error(t, err)
+}
+
 func TestHTTPClientGETError(t *testing.T) {
 	t.Parallel()
 
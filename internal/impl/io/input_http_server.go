@@ -52,6 +52,20 @@ The field ` + "`rate_limit`" + ` allows you to specify an optional ` + "[`rate_l
 
 When the rate limit is breached HTTP requests will have a 429 response returned with a Retry-After header. Websocket payloads will be dropped and an optional response payload will be sent as per ` + "`ws_rate_limit_message`" + `.
 
+### Authentication
+
+Requests can be authenticated by verifying bearer JSON Web Tokens, either against a [JSON Web Key Set](https://datatracker.ietf.org/doc/html/rfc7517) fetched from a URL or a static key, with the ` + "`auth.jwt`" + ` field. An HMAC signature of the request body provided within a header can be verified with the ` + "`auth.hmac`" + ` field. Requests that fail verification receive a 401 response.
+
+When TLS is enabled client certificates can also be verified with the ` + "`client_tls`" + ` field. The subject of a verified token, or otherwise the common name of a verified client certificate, becomes the identity of the client, which is added to messages as metadata and can be used to throttle each client with its own rate limit resource via the ` + "`client_rate_limit`" + ` field.
+
+### Validation
+
+Requests to the ` + "`path`" + ` endpoint can be validated before they are consumed with the ` + "`validation`" + ` field, either against an OpenAPI 3 specification, in which case the method, path parameters, query parameters, headers and body of each request are checked against the matching operation, or against a JSON Schema of the request body. Requests that fail validation are rejected with a 400 response and a JSON body describing each problem, for example:
+
+` + "```json" + `
+{"error":"request failed validation","problems":[{"in":"query","name":"limit","message":"Must be less than or equal to 100"}]}
+` + "```" + `
+
 ### Responses
 
 It's possible to return a response for each message received using [synchronous responses](/docs/guides/sync_responses). When doing so you can customise headers with the ` + "`sync_response` field `headers`" + `, which can also use [function interpolation](/docs/configuration/interpolation#bloblang-queries) in the value based on the response message contents.
@@ -94,6 +108,10 @@ If HTTPS is enabled, the following fields are added as well:
 - http_server_tls_subject
 - http_server_tls_cipher_suite
 ` + "```" + `
+When the client has been identified by ` + "`auth.jwt` or `client_tls`" + ` the following field is added:
+` + "``` text" + `
+- http_server_client_identity
+` + "```" + `
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("address", "An alternative address to host from. If left empty the service wide address is used."),
@@ -107,6 +125,10 @@ You can access these metadata fields using [function interpolation](/docs/config
 			docs.FieldString("cert_file", "Enable TLS by specifying a certificate and key file. Only valid with a custom `address`.").Advanced(),
 			docs.FieldString("key_file", "Enable TLS by specifying a certificate and key file. Only valid with a custom `address`.").Advanced(),
 			corsSpec,
+			httpserver.ClientTLSFieldSpec(),
+			httpserver.AuthFieldSpec(),
+			httpserver.ClientRateLimitFieldSpec(),
+			httpserver.RequestValidationFieldSpec(),
 			docs.FieldObject("sync_response", "Customise messages returned via [synchronous responses](/docs/guides/sync_responses).").WithChildren(
 				docs.FieldString(
 					"status",
@@ -168,6 +190,12 @@ func newHTTPServerInput(conf input.Config, mgr bundle.NewManagement) (input.Stre
 		if server.Handler, err = conf.HTTPServer.CORS.WrapHandler(mux); err != nil {
 			return nil, fmt.Errorf("bad CORS configuration: %w", err)
 		}
+		if err = conf.HTTPServer.ClientTLS.ApplyToServer(server); err != nil {
+			return nil, fmt.Errorf("bad client_tls configuration: %w", err)
+		}
+	}
+	if conf.HTTPServer.ClientTLS.Enabled && (server == nil || conf.HTTPServer.CertFile == "") {
+		return nil, errors.New("client_tls requires a custom address with TLS enabled")
 	}
 
 	var timeout time.Duration
@@ -217,8 +245,29 @@ func newHTTPServerInput(conf input.Config, mgr bundle.NewManagement) (input.Stre
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
 
-	postHdlr := gzipHandler(h.postHandler)
-	wsHdlr := gzipHandler(h.wsHandler)
+	authMiddleware, err := h.conf.Auth.Middleware()
+	if err != nil {
+		return nil, fmt.Errorf("bad auth configuration: %w", err)
+	}
+	// Endpoints registered with the manager are prefixed with the stream name
+	// in streams mode, which is removed before requests are validated.
+	var validationMount string
+	if mux == nil {
+		validationMount = h.conf.Path
+	}
+	postHdlr, err := h.conf.Validation.WrapHandler(gzipHandler(h.postHandler), validationMount)
+	if err != nil {
+		return nil, fmt.Errorf("bad validation configuration: %w", err)
+	}
+	if postHdlr, err = h.conf.ClientRateLimit.WrapHandler(postHdlr, mgr); err != nil {
+		return nil, fmt.Errorf("bad client_rate_limit configuration: %w", err)
+	}
+	postHdlr = authMiddleware(postHdlr)
+	wsHdlr, err := h.conf.ClientRateLimit.WrapHandler(gzipHandler(h.wsHandler), mgr)
+	if err != nil {
+		return nil, fmt.Errorf("bad client_rate_limit configuration: %w", err)
+	}
+	wsHdlr = authMiddleware(wsHdlr)
 	if mux != nil {
 		if len(h.conf.Path) > 0 {
 			mux.HandleFunc(h.conf.Path, postHdlr)
@@ -288,6 +337,7 @@ func (h *httpServerInput) extractMessageFromRequest(r *http.Request) (message.Ba
 		msg = append(msg, message.NewPart(msgBytes))
 	}
 
+	identity := httpserver.ClientIdentity(r)
 	_ = msg.Iter(func(i int, p *message.Part) error {
 		p.MetaSetMut("http_server_user_agent", r.UserAgent())
 		p.MetaSetMut("http_server_request_path", r.URL.Path)
@@ -295,6 +345,9 @@ func (h *httpServerInput) extractMessageFromRequest(r *http.Request) (message.Ba
 		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
 			p.MetaSetMut("http_server_remote_ip", host)
 		}
+		if identity != "" {
+			p.MetaSetMut("http_server_client_identity", identity)
+		}
 
 		if r.TLS != nil {
 			var tlsVersion string
@@ -415,7 +468,7 @@ func (h *httpServerInput) postHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		tTaken := time.Since(startedAt).Nanoseconds()
-		h.mLatency.Timing(tTaken)
+		metrics.TimingWithTrace(h.mLatency, tTaken, msg)
 	case <-time.After(h.timeout):
 		http.Error(w, "Request timed out", http.StatusRequestTimeout)
 		return
@@ -566,6 +619,9 @@ func (h *httpServerInput) wsHandler(w http.ResponseWriter, r *http.Request) {
 
 		part := msg.Get(0)
 		part.MetaSetMut("http_server_user_agent", r.UserAgent())
+		if identity := httpserver.ClientIdentity(r); identity != "" {
+			part.MetaSetMut("http_server_client_identity", identity)
+		}
 		for k, v := range r.Header {
 			if len(v) > 0 {
 				part.MetaSetMut(k, v[0])
@@ -601,7 +657,7 @@ func (h *httpServerInput) wsHandler(w http.ResponseWriter, r *http.Request) {
 				throt.Retry()
 			} else {
 				tTaken := time.Since(startedAt).Nanoseconds()
-				h.mLatency.Timing(tTaken)
+				metrics.TimingWithTrace(h.mLatency, tTaken, msg)
 				msgBytes = nil
 				throt.Reset()
 			}
@@ -13,10 +13,13 @@ import (
 	"net/http/httptest"
 	"net/textproto"
 	"net/url"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
@@ -570,6 +573,204 @@ rate_limit_resources:
 	}
 }
 
+func TestHTTPServerAuthClientRateLimit(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	t.Parallel()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+
+	mgrConf := manager.NewResourceConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+rate_limit_resources:
+  - label: alicerl
+    local:
+      count: 1
+      interval: 60s
+`), &mgrConf))
+
+	mgr, err := manager.New(mgrConf, manager.OptSetAPIReg(reg))
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.Type = "http_server"
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Auth.JWT.Enabled = true
+	conf.HTTPServer.Auth.JWT.Secret = "foosecret"
+	conf.HTTPServer.ClientRateLimit.Resources = map[string]string{
+		"alice": "alicerl",
+	}
+
+	h, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	identities := make(chan string)
+	go func() {
+		for ts := range h.TransactionChan() {
+			identities <- ts.Payload.Get(0).MetaGetStr("http_server_client_identity")
+			require.NoError(t, ts.Ack(tCtx, nil))
+		}
+	}()
+
+	post := func(subject string) int {
+		req, err := http.NewRequest("POST", server.URL+"/testpost", bytes.NewBufferString("hello world"))
+		require.NoError(t, err)
+		if subject != "" {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": subject}).SignedString([]byte("foosecret"))
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, post(""))
+
+	for _, subject := range []string{"alice", "bob"} {
+		statusChan := make(chan int, 1)
+		go func(subject string) {
+			statusChan <- post(subject)
+		}(subject)
+		assert.Equal(t, subject, <-identities)
+		assert.Equal(t, http.StatusOK, <-statusChan)
+	}
+
+	assert.Equal(t, http.StatusTooManyRequests, post("alice"))
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+}
+
+func TestHTTPServerValidation(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	t.Parallel()
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(reg))
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.Type = "http_server"
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Validation.JSONSchema = `{"type":"object","required":["id"]}`
+
+	h, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	res, err := http.Post(server.URL+"/testpost", "application/json", bytes.NewBufferString(`{"name":"foo"}`))
+	require.NoError(t, err)
+	resBody, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	res.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	assert.JSONEq(t, `{"error":"request failed validation","problems":[{"in":"body","name":"(root)","message":"id is required"}]}`, string(resBody))
+
+	go func() {
+		ts := <-h.TransactionChan()
+		assert.Equal(t, `{"id":"foo"}`, string(ts.Payload.Get(0).AsBytes()))
+		require.NoError(t, ts.Ack(tCtx, nil))
+	}()
+
+	res, err = http.Post(server.URL+"/testpost", "application/json", bytes.NewBufferString(`{"id":"foo"}`))
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+}
+
+func TestHTTPServerValidationStreamsMode(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	t.Parallel()
+
+	specPath := filepath.Join(t.TempDir(), "openapi.yaml")
+	require.NoError(t, os.WriteFile(specPath, []byte(`
+openapi: 3.0.3
+info:
+  title: Things
+  version: 1.0.0
+paths:
+  /testpost:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required: [ id ]
+      responses:
+        200:
+          description: OK
+`), 0o644))
+
+	reg := apiRegGorillaMutWrapper{mut: mux.NewRouter()}
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(reg))
+	require.NoError(t, err)
+
+	conf := input.NewConfig()
+	conf.Type = "http_server"
+	conf.HTTPServer.Path = "/testpost"
+	conf.HTTPServer.Validation.OpenAPIPath = specPath
+
+	h, err := mgr.ForStream("foo").NewInput(conf)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(reg.mut)
+	defer server.Close()
+
+	postParts := func(parts ...string) (int, string) {
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for _, p := range parts {
+			pw, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": []string{"application/json"}})
+			require.NoError(t, err)
+			_, err = pw.Write([]byte(p))
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+
+		res, err := http.Post(server.URL+"/foo/testpost", "multipart/mixed; boundary="+mw.Boundary(), &buf)
+		require.NoError(t, err)
+		resBody, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		res.Body.Close()
+		return res.StatusCode, string(resBody)
+	}
+
+	code, resBody := postParts(`{"id":"foo"}`, `{"name":"bar"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.JSONEq(t, `{"error":"request failed validation","problems":[{"in":"body","name":"parts.1","message":"id is required"}]}`, resBody)
+
+	go func() {
+		ts := <-h.TransactionChan()
+		require.Equal(t, 2, ts.Payload.Len())
+		assert.Equal(t, `{"id":"foo"}`, string(ts.Payload.Get(0).AsBytes()))
+		assert.Equal(t, `{"id":"bar"}`, string(ts.Payload.Get(1).AsBytes()))
+		require.NoError(t, ts.Ack(tCtx, nil))
+	}()
+
+	code, _ = postParts(`{"id":"foo"}`, `{"id":"bar"}`)
+	assert.Equal(t, http.StatusOK, code)
+
+	h.TriggerStopConsuming()
+	require.NoError(t, h.WaitForClose(tCtx))
+}
+
 func TestHTTPServerWebsockets(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
 	defer done()
@@ -43,7 +43,7 @@ func init() {
 }
 
 func newSocketInput(conf input.Config, mgr bundle.NewManagement, log log.Modular, stats metrics.Type) (input.Streamed, error) {
-	rdr, err := newSocketReader(conf.Socket, log)
+	rdr, err := newSocketReader(conf.Socket, mgr)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +64,7 @@ type socketReader struct {
 	codec    codec.Reader
 }
 
-func newSocketReader(conf input.SocketConfig, logger log.Modular) (*socketReader, error) {
+func newSocketReader(conf input.SocketConfig, mgr bundle.NewManagement) (*socketReader, error) {
 	switch conf.Network {
 	case "tcp", "unix":
 	default:
@@ -73,13 +73,13 @@ func newSocketReader(conf input.SocketConfig, logger log.Modular) (*socketReader
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
-	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	ctor, err := mgr.Environment().GetReaderCodec(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
 	}
 
 	return &socketReader{
-		log:       logger,
+		log:       mgr.Logger(),
 		conf:      conf,
 		codecCtor: ctor,
 	}, nil
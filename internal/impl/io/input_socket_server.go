@@ -83,7 +83,7 @@ func newSocketServerInput(conf input.Config, mgr bundle.NewManagement, log log.M
 
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = sconf.MaxBuffer
-	ctor, err := codec.GetReader(sconf.Codec, codecConf)
+	ctor, err := mgr.Environment().GetReaderCodec(sconf.Codec, codecConf)
 	if err != nil {
 		return nil, err
 	}
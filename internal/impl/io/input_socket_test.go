@@ -926,7 +926,7 @@ func BenchmarkTCPSocketWithCutOff(b *testing.B) {
 	conf.Socket.Network = "tcp"
 	conf.Socket.Address = ln.Addr().String()
 
-	sRdr, err := newSocketReader(conf.Socket, log.Noop())
+	sRdr, err := newSocketReader(conf.Socket, mock.NewManager())
 	require.NoError(b, err)
 
 	rdr, err := input.NewAsyncReader("socket", true, input.NewAsyncCutOff(input.NewAsyncPreserver(sRdr)), mock.NewManager())
@@ -996,7 +996,7 @@ func BenchmarkTCPSocketNoCutOff(b *testing.B) {
 	conf.Socket.Network = "tcp"
 	conf.Socket.Address = ln.Addr().String()
 
-	sRdr, err := newSocketReader(conf.Socket, log.Noop())
+	sRdr, err := newSocketReader(conf.Socket, mock.NewManager())
 	require.NoError(b, err)
 
 	rdr, err := input.NewAsyncReader("socket", true, input.NewAsyncPreserver(sRdr), mock.NewManager())
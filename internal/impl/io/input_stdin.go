@@ -17,7 +17,7 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		rdr, err := newStdinConsumer(conf.STDIN)
+		rdr, err := newStdinConsumer(conf.STDIN, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -46,10 +46,10 @@ type stdinConsumer struct {
 	scanner codec.Reader
 }
 
-func newStdinConsumer(conf input.STDINConfig) (*stdinConsumer, error) {
+func newStdinConsumer(conf input.STDINConfig, mgr bundle.NewManagement) (*stdinConsumer, error) {
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
-	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	ctor, err := mgr.Environment().GetReaderCodec(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
 	}
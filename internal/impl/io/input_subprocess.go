@@ -4,12 +4,12 @@ import (
 	"bufio"
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"os/exec"
 	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
 	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
@@ -19,7 +19,7 @@ import (
 
 func init() {
 	err := bundle.AllInputs.Add(processors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
-		b, err := newSubprocessReader(conf.Subprocess)
+		b, err := newSubprocessReader(conf.Subprocess, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -39,8 +39,8 @@ The execution environment of the subprocess is the same as the Benthos instance,
 			docs.FieldString("name", "The command to execute as a subprocess.", "cat", "sed", "awk"),
 			docs.FieldString("args", "A list of arguments to provide the command.").Array(),
 			docs.FieldString(
-				"codec", "The way in which messages should be consumed from the subprocess.",
-			).HasOptions("lines"),
+				"codec", "The way in which messages should be consumed from the subprocess. Custom codecs added by plugins can also be used.",
+			).HasOptions("lines").LinterFunc(nil),
 			docs.FieldBool("restart_on_exit", "Whether the command should be re-executed each time the subprocess ends."),
 			docs.FieldInt("max_buffer", "The maximum expected size of an individual message.").Advanced(),
 		).ChildDefaultAndTypesFromStruct(input.NewSubprocessConfig()),
@@ -72,14 +72,74 @@ func linesSubprocInputCodec(conf input.SubprocessConfig, stdout, stderr io.Reade
 	return outScanner, errScanner
 }
 
-type subprocInputCodec func(input.SubprocessConfig, io.Reader, io.Reader) (inputSubprocScanner, inputSubprocScanner)
+// codecSubprocScanner adapts a reader codec to the scanner interface, where
+// each part read from the codec is scanned individually.
+type codecSubprocScanner struct {
+	ctx     context.Context
+	r       codec.Reader
+	pending []*message.Part
+	current []byte
+	err     error
+}
+
+func (c *codecSubprocScanner) Scan() bool {
+	for len(c.pending) == 0 {
+		parts, ackFn, err := c.r.Next(c.ctx)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				c.err = err
+			}
+			_ = c.r.Close(c.ctx)
+			return false
+		}
+		// Messages from a subprocess are not acknowledged, and therefore the
+		// codec can release its resources straight away.
+		_ = ackFn(c.ctx, nil)
+		c.pending = parts
+	}
+	c.current, c.pending = c.pending[0].AsBytes(), c.pending[1:]
+	return true
+}
+
+func (c *codecSubprocScanner) Bytes() []byte {
+	return c.current
+}
+
+func (c *codecSubprocScanner) Text() string {
+	return string(c.current)
+}
+
+func (c *codecSubprocScanner) Err() error {
+	return c.err
+}
 
-func subprocInputCodecFromStr(codec string) (subprocInputCodec, error) {
-	// TODO: Flesh this out with more options based on s.conf.Codec.
-	if codec == "lines" {
-		return linesSubprocInputCodec, nil
+type subprocInputCodec func(context.Context, input.SubprocessConfig, io.ReadCloser, io.Reader) (inputSubprocScanner, inputSubprocScanner, error)
+
+func subprocInputCodecFromStr(codecStr string, maxBuffer int, mgr bundle.NewManagement) (subprocInputCodec, error) {
+	if codecStr == "lines" {
+		return func(_ context.Context, conf input.SubprocessConfig, stdout io.ReadCloser, stderr io.Reader) (inputSubprocScanner, inputSubprocScanner, error) {
+			outScanner, errScanner := linesSubprocInputCodec(conf, stdout, stderr)
+			return outScanner, errScanner, nil
+		}, nil
 	}
-	return nil, fmt.Errorf("codec not recognised: %v", codec)
+
+	codecConf := codec.NewReaderConfig()
+	codecConf.MaxScanTokenSize = maxBuffer
+	ctor, err := mgr.Environment().GetReaderCodec(codecStr, codecConf)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, conf input.SubprocessConfig, stdout io.ReadCloser, stderr io.Reader) (inputSubprocScanner, inputSubprocScanner, error) {
+		r, err := ctor(conf.Name, stdout, func(context.Context, error) error { return nil })
+		if err != nil {
+			return nil, nil, err
+		}
+		errScanner := bufio.NewScanner(stderr)
+		if conf.MaxBuffer != bufio.MaxScanTokenSize {
+			errScanner.Buffer([]byte{}, conf.MaxBuffer)
+		}
+		return &codecSubprocScanner{ctx: ctx, r: r}, errScanner, nil
+	}, nil
 }
 
 //------------------------------------------------------------------------------
@@ -95,13 +155,13 @@ type subprocessReader struct {
 	ctx   context.Context
 }
 
-func newSubprocessReader(conf input.SubprocessConfig) (*subprocessReader, error) {
+func newSubprocessReader(conf input.SubprocessConfig, mgr bundle.NewManagement) (*subprocessReader, error) {
 	s := &subprocessReader{
 		conf: conf,
 	}
 	s.ctx, s.close = context.WithCancel(context.Background())
 	var err error
-	if s.codec, err = subprocInputCodecFromStr(s.conf.Codec); err != nil {
+	if s.codec, err = subprocInputCodecFromStr(s.conf.Codec, s.conf.MaxBuffer, mgr); err != nil {
 		return nil, err
 	}
 	return s, nil
@@ -126,11 +186,16 @@ func (s *subprocessReader) Connect(ctx context.Context) error {
 		return err
 	}
 
+	outScanner, errScanner, err := s.codec(s.ctx, s.conf, stdout, stderr)
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return err
+	}
+
 	msgChan := make(chan []byte)
 	errChan := make(chan error)
 
-	outScanner, errScanner := s.codec(s.conf, stdout, stderr)
-
 	go func() {
 		wg := sync.WaitGroup{}
 		wg.Add(2)
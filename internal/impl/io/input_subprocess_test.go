@@ -73,6 +73,41 @@ func main() {
 	}
 }
 
+func TestSubprocessCodec(t *testing.T) {
+	filePath := testProgram(t, `package main
+
+import (
+	"fmt"
+)
+
+func main() {
+	fmt.Print("foo|bar|baz")
+}
+`)
+
+	conf := input.NewConfig()
+	conf.Type = "subprocess"
+	conf.Subprocess.Name = "go"
+	conf.Subprocess.Args = []string{"run", filePath}
+	conf.Subprocess.Codec = "delim:|"
+
+	i, err := mock.NewManager().NewInput(conf)
+	require.NoError(t, err)
+
+	for _, exp := range []string{"foo", "bar", "baz"} {
+		msg := readMsg(t, i.TransactionChan())
+		assert.Equal(t, 1, msg.Len())
+		assert.Equal(t, exp, string(msg.Get(0).AsBytes()))
+	}
+
+	select {
+	case _, open := <-i.TransactionChan():
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Error("timed out")
+	}
+}
+
 func TestSubprocessRestarted(t *testing.T) {
 	ctx, done := context.WithTimeout(context.Background(), time.Second*20)
 	defer done()
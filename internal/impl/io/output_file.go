@@ -66,7 +66,7 @@ type fileWriter struct {
 }
 
 func newFileWriter(pathStr, codecStr string, mgr bundle.NewManagement) (*fileWriter, error) {
-	codec, codecConf, err := codec.GetWriter(codecStr)
+	codec, codecConf, err := mgr.Environment().GetWriterCodec(codecStr)
 	if err != nil {
 		return nil, err
 	}
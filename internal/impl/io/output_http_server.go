@@ -43,7 +43,11 @@ Three endpoints will be registered at the paths specified by the fields ` + "`pa
 
 When messages are batched the ` + "`path`" + ` endpoint encodes the batch according to [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html). This behaviour can be overridden by [archiving your batches](/docs/configuration/batching#post-batch-processing).
 
-Please note, messages are considered delivered as soon as the data is written to the client. There is no concept of at least once delivery on this output.`,
+Please note, messages are considered delivered as soon as the data is written to the client. There is no concept of at least once delivery on this output.
+
+### Authentication
+
+Clients can be required to present a bearer JSON Web Token or an HMAC signature with the ` + "`auth`" + ` field, and when TLS is enabled client certificates can be verified with the ` + "`client_tls`" + ` field. Requests that fail verification receive a 401 response.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("address", "An optional address to listen from. If left empty the service wide HTTP server is used."),
 			docs.FieldString("path", "The path from which discrete messages can be consumed."),
@@ -54,6 +58,8 @@ Please note, messages are considered delivered as soon as the data is written to
 			docs.FieldString("cert_file", "An optional certificate file to use for TLS connections. Only applicable when an `address` is specified.").Advanced(),
 			docs.FieldString("key_file", "An optional certificate key file to use for TLS connections. Only applicable when an `address` is specified.").Advanced(),
 			corsSpec,
+			httpserver.ClientTLSFieldSpec(),
+			httpserver.AuthFieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewHTTPServerConfig()),
 		Categories: []string{
 			"Network",
@@ -105,6 +111,12 @@ func newHTTPServerOutput(conf output.Config, mgr bundle.NewManagement, log log.M
 		if server.Handler, err = conf.HTTPServer.CORS.WrapHandler(mux); err != nil {
 			return nil, fmt.Errorf("bad CORS configuration: %w", err)
 		}
+		if err = conf.HTTPServer.ClientTLS.ApplyToServer(server); err != nil {
+			return nil, fmt.Errorf("bad client_tls configuration: %w", err)
+		}
+	}
+	if conf.HTTPServer.ClientTLS.Enabled && (server == nil || conf.HTTPServer.CertFile == "") {
+		return nil, errors.New("client_tls requires a custom address with TLS enabled")
 	}
 
 	verbs := map[string]struct{}{}
@@ -148,35 +160,43 @@ func newHTTPServerOutput(conf output.Config, mgr bundle.NewManagement, log log.M
 		}
 	}
 
+	authMiddleware, err := h.conf.HTTPServer.Auth.Middleware()
+	if err != nil {
+		return nil, fmt.Errorf("bad auth configuration: %w", err)
+	}
+	getHdlr := authMiddleware(h.getHandler)
+	streamHdlr := authMiddleware(h.streamHandler)
+	wsHdlr := authMiddleware(h.wsHandler)
+
 	if mux != nil {
 		if len(h.conf.HTTPServer.Path) > 0 {
-			h.mux.HandleFunc(h.conf.HTTPServer.Path, h.getHandler)
+			h.mux.HandleFunc(h.conf.HTTPServer.Path, getHdlr)
 		}
 		if len(h.conf.HTTPServer.StreamPath) > 0 {
-			h.mux.HandleFunc(h.conf.HTTPServer.StreamPath, h.streamHandler)
+			h.mux.HandleFunc(h.conf.HTTPServer.StreamPath, streamHdlr)
 		}
 		if len(h.conf.HTTPServer.WSPath) > 0 {
-			h.mux.HandleFunc(h.conf.HTTPServer.WSPath, h.wsHandler)
+			h.mux.HandleFunc(h.conf.HTTPServer.WSPath, wsHdlr)
 		}
 	} else {
 		if len(h.conf.HTTPServer.Path) > 0 {
 			mgr.RegisterEndpoint(
 				h.conf.HTTPServer.Path, "Read a single message from Benthos.",
-				h.getHandler,
+				getHdlr,
 			)
 		}
 		if len(h.conf.HTTPServer.StreamPath) > 0 {
 			mgr.RegisterEndpoint(
 				h.conf.HTTPServer.StreamPath,
 				"Read a continuous stream of messages from Benthos.",
-				h.streamHandler,
+				streamHdlr,
 			)
 		}
 		if len(h.conf.HTTPServer.WSPath) > 0 {
 			mgr.RegisterEndpoint(
 				h.conf.HTTPServer.WSPath,
 				"Read messages from Benthos via websockets.",
-				h.wsHandler,
+				wsHdlr,
 			)
 		}
 	}
@@ -65,7 +65,7 @@ func newSocketWriter(conf output.SocketConfig, mgr bundle.NewManagement, log log
 	default:
 		return nil, fmt.Errorf("socket network '%v' is not supported by this output", conf.Network)
 	}
-	codec, codecConf, err := codec.GetWriter(conf.Codec)
+	codec, codecConf, err := mgr.Environment().GetWriterCodec(conf.Codec)
 	if err != nil {
 		return nil, err
 	}
@@ -14,7 +14,7 @@ import (
 
 func init() {
 	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
-		f, err := newStdoutWriter(conf.STDOUT.Codec)
+		f, err := newStdoutWriter(conf.STDOUT.Codec, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -43,8 +43,8 @@ type stdoutWriter struct {
 	handle codec.Writer
 }
 
-func newStdoutWriter(codecStr string) (*stdoutWriter, error) {
-	codec, _, err := codec.GetWriter(codecStr)
+func newStdoutWriter(codecStr string, mgr bundle.NewManagement) (*stdoutWriter, error) {
+	codec, _, err := mgr.Environment().GetWriterCodec(codecStr)
 	if err != nil {
 		return nil, err
 	}
@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
@@ -18,7 +19,7 @@ import (
 
 func init() {
 	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
-		s, err := newSubprocessWriter(conf.Subprocess, nm.Logger())
+		s, err := newSubprocessWriter(conf.Subprocess, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -38,8 +39,8 @@ The execution environment of the subprocess is the same as the Benthos instance,
 			docs.FieldString("name", "The command to execute as a subprocess."),
 			docs.FieldString("args", "A list of arguments to provide the command.").Array(),
 			docs.FieldString(
-				"codec", "The way in which messages should be written to the subprocess.",
-			).HasOptions("lines"),
+				"codec", "The way in which messages should be written to the subprocess. Custom codecs added by plugins can also be used.",
+			).HasOptions("lines").LinterFunc(nil),
 		).ChildDefaultAndTypesFromStruct(output.NewSubprocessConfig()),
 		Categories: []string{
 			"Utility",
@@ -52,19 +53,27 @@ The execution environment of the subprocess is the same as the Benthos instance,
 
 //------------------------------------------------------------------------------
 
-func subprocOutputLinesCodec(w io.Writer, b []byte) error {
-	_, err := fmt.Fprintln(w, string(b))
+type subprocOutputLinesWriter struct {
+	w io.WriteCloser
+}
+
+func (l *subprocOutputLinesWriter) Write(ctx context.Context, p *message.Part) error {
+	_, err := fmt.Fprintln(l.w, string(p.AsBytes()))
 	return err
 }
 
-type subprocOutputCodec func(io.Writer, []byte) error
+func (l *subprocOutputLinesWriter) Close(ctx context.Context) error {
+	return l.w.Close()
+}
 
-func subprocOutputCodecFromStr(codec string) (subprocOutputCodec, error) {
-	// TODO: Flesh this out with more options based on s.conf.Codec.
-	if codec == "lines" {
-		return subprocOutputLinesCodec, nil
+func subprocOutputCodecFromStr(codecStr string, mgr bundle.NewManagement) (codec.WriterConstructor, error) {
+	if codecStr == "lines" {
+		return func(w io.WriteCloser) (codec.Writer, error) {
+			return &subprocOutputLinesWriter{w: w}, nil
+		}, nil
 	}
-	return nil, fmt.Errorf("codec not recognised: %v", codec)
+	ctor, _, err := mgr.Environment().GetWriterCodec(codecStr)
+	return ctor, err
 }
 
 //------------------------------------------------------------------------------
@@ -73,19 +82,19 @@ type subprocessWriter struct {
 	log  log.Modular
 	conf output.SubprocessConfig
 
-	codec subprocOutputCodec
+	codecCtor codec.WriterConstructor
 
 	cmdMut sync.Mutex
-	stdin  io.WriteCloser
+	stdin  codec.Writer
 }
 
-func newSubprocessWriter(conf output.SubprocessConfig, log log.Modular) (*subprocessWriter, error) {
+func newSubprocessWriter(conf output.SubprocessConfig, mgr bundle.NewManagement) (*subprocessWriter, error) {
 	s := &subprocessWriter{
 		conf: conf,
-		log:  log,
+		log:  mgr.Logger(),
 	}
 	var err error
-	if s.codec, err = subprocOutputCodecFromStr(s.conf.Codec); err != nil {
+	if s.codecCtor, err = subprocOutputCodecFromStr(s.conf.Codec, mgr); err != nil {
 		return nil, err
 	}
 	return s, nil
@@ -100,8 +109,13 @@ func (s *subprocessWriter) Connect(ctx context.Context) error {
 	}
 
 	cmd := exec.Command(s.conf.Name, s.conf.Args...)
-	stdin, err := cmd.StdinPipe()
+	stdinPipe, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdin, err := s.codecCtor(stdinPipe)
 	if err != nil {
+		_ = stdinPipe.Close()
 		return err
 	}
 
@@ -125,7 +139,7 @@ func (s *subprocessWriter) Connect(ctx context.Context) error {
 		}
 		s.cmdMut.Lock()
 		if s.stdin != nil {
-			s.stdin.Close()
+			_ = s.stdin.Close(context.Background())
 			s.stdin = nil
 		}
 		s.cmdMut.Unlock()
@@ -143,7 +157,7 @@ func (s *subprocessWriter) WriteBatch(ctx context.Context, msg message.Batch) er
 	}
 
 	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		return s.codec(s.stdin, p.AsBytes())
+		return s.stdin.Write(ctx, p)
 	})
 }
 
@@ -153,7 +167,7 @@ func (s *subprocessWriter) Close(ctx context.Context) error {
 
 	var err error
 	if s.stdin != nil {
-		err = s.stdin.Close()
+		err = s.stdin.Close(ctx)
 		s.stdin = nil
 	}
 	return err
@@ -109,6 +109,62 @@ func main() {
 	}, time.Second, time.Millisecond*100)
 }
 
+func TestSubprocessOutputCodec(t *testing.T) {
+	integration.CheckSkip(t)
+
+	t.Parallel()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	dir := t.TempDir()
+
+	filePath := testProgram(t, fmt.Sprintf(`package main
+
+import (
+	"io"
+	"os"
+)
+
+func main() {
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		panic(err)
+	}
+	if err := os.WriteFile("%v/output.txt", b, 0o644); err != nil {
+		panic(err)
+	}
+}
+`, dir))
+
+	conf := output.NewConfig()
+	conf.Type = "subprocess"
+	conf.Subprocess.Name = "go"
+	conf.Subprocess.Args = []string{"run", filePath}
+	conf.Subprocess.Codec = "delim:|"
+
+	o, err := mock.NewManager().NewOutput(conf)
+	require.NoError(t, err)
+
+	tranChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tranChan))
+
+	sendMsg(t, "foo", tranChan)
+	sendMsg(t, "bar", tranChan)
+	sendMsg(t, "baz", tranChan)
+
+	o.TriggerCloseNow()
+	require.NoError(t, o.WaitForClose(ctx))
+
+	assert.Eventually(t, func() bool {
+		resBytes, err := os.ReadFile(path.Join(dir, "output.txt"))
+		if err != nil {
+			return false
+		}
+		return string(resBytes) == "foo|bar|baz|"
+	}, time.Second*5, time.Millisecond*100)
+}
+
 func TestSubprocessOutputEarlyExit(t *testing.T) {
 	t.Skip()
 
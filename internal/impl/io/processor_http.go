@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
@@ -67,10 +70,42 @@ headers should be copied into the resulting message from the response.
 When all retry attempts for a message are exhausted the processor cancels the
 attempt. These failed messages will continue through the pipeline unchanged, but
 can be dropped or placed in a dead letter queue according to your config, you
-can read about these patterns [here](/docs/configuration/error_handling).`,
+can read about these patterns [here](/docs/configuration/error_handling).
+
+## Circuit Breaking
+
+When the ` + "`circuit_breaker`" + ` is enabled and the ratio of failed requests
+within a window reaches the ` + "`failure_ratio`" + ` then the processor stops
+sending requests for the ` + "`open_duration`" + `. What happens to messages
+whilst the circuit breaker is open depends on the field ` + "`open_action`" + `:
+
+- ` + "`fail`" + `: Messages are immediately flagged as failed with the error ` + "`circuit breaker is open`" + `.
+- ` + "`block`" + `: Messages are held until the circuit breaker is half-open and a probe request can be attempted.
+- ` + "`fallback`" + `: Messages are processed by the ` + "`fallback`" + ` processors instead, and the result replaces the response.
+
+With the actions ` + "`fail`" + ` and ` + "`block`" + ` an open circuit breaker is
+reported by the ` + "`/ready`" + ` endpoint, which returns a 503 until the
+circuit breaker is half-open.
+
+The state of the circuit breaker is exposed with the gauge metric
+` + "`circuit_breaker_state`" + ` (0 closed, 1 half-open, 2 open) and the counter
+` + "`circuit_breaker_transition`" + `.`,
 		Config: httpclient.OldFieldSpec(false,
 			docs.FieldBool("batch_as_multipart", "Send message batches as a single request using [RFC1341](https://www.w3.org/Protocols/rfc1341/7_2_Multipart.html).").Advanced().HasDefault(false),
-			docs.FieldBool("parallel", "When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent serially.").HasDefault(false)).ChildDefaultAndTypesFromStruct(processor.NewHTTPConfig()),
+			docs.FieldBool("parallel", "When processing batched messages, whether to send messages of the batch in parallel, otherwise they are sent serially.").HasDefault(false),
+			docs.FieldObject("circuit_breaker", "Allows you to configure a circuit breaker, which stops sending requests for a period once the ratio of failed requests reaches a threshold.").WithChildren(
+				append(append(docs.FieldSpecs{
+					docs.FieldBool("enabled", "Whether to enable the circuit breaker.").HasDefault(false),
+				}, circuitbreaker.FieldSpecs()...),
+					docs.FieldString("open_action", "What to do with messages whilst the circuit breaker is open.").HasAnnotatedOptions(
+						"fail", "Flag messages as failed without sending a request.",
+						"block", "Block messages until the circuit breaker is half-open.",
+						"fallback", "Process messages with the `fallback` processors instead of sending a request.",
+					).HasDefault("fail"),
+					docs.FieldProcessor("fallback", "A list of processors to apply to messages whilst the circuit breaker is open, the result of which replaces the response. Only used when `open_action` is `fallback`.").Array().HasDefault([]any{}),
+				)...,
+			).Advanced().AtVersion("4.10.0"),
+		).ChildDefaultAndTypesFromStruct(processor.NewHTTPConfig()),
 		Examples: []docs.AnnotatedExample{
 			{
 				Title: "Branched Request",
@@ -95,8 +130,20 @@ pipeline:
 	}
 }
 
+const (
+	httpBreakerOpenFail     = "fail"
+	httpBreakerOpenBlock    = "block"
+	httpBreakerOpenFallback = "fallback"
+
+	httpBreakerBlockPollPeriod = 100 * time.Millisecond
+)
+
 type httpProc struct {
 	client      *httpclient.Client
+	breaker     *circuitbreaker.Breaker
+	openAction  string
+	fallback    []processor.V1
+	deregister  func()
 	asMultipart bool
 	parallel    bool
 	rawURL      string
@@ -115,15 +162,121 @@ func newHTTPProc(conf processor.HTTPConfig, mgr bundle.NewManagement) (processor
 	if g.client, err = httpclient.NewClientFromOldConfig(conf.OldConfig, mgr); err != nil {
 		return nil, err
 	}
+
+	if conf.CircuitBreaker.Enabled {
+		if err := g.initBreakerOpenAction(conf.CircuitBreaker, mgr); err != nil {
+			return nil, err
+		}
+
+		mState := mgr.Metrics().GetGauge("circuit_breaker_state")
+		mTransition := mgr.Metrics().GetCounterVec("circuit_breaker_transition", "state")
+		mState.Set(int64(circuitbreaker.StateClosed))
+
+		if g.breaker, err = circuitbreaker.New(conf.CircuitBreaker.Config, func(from, to circuitbreaker.State) {
+			g.log.Warnf("Circuit breaker state changed from %v to %v", from, to)
+			mState.Set(int64(to))
+			mTransition.With(to.String()).Incr(1)
+		}); err != nil {
+			return nil, fmt.Errorf("circuit breaker: %w", err)
+		}
+
+		// Whilst open without a fallback messages aren't being processed, and
+		// therefore the stream is reported as not ready.
+		if g.openAction != httpBreakerOpenFallback {
+			if r, ok := mgr.(interface {
+				RegisterReadinessCheck(fn func() error) func()
+			}); ok {
+				g.deregister = r.RegisterReadinessCheck(func() error {
+					if !g.breaker.Available() {
+						return circuitbreaker.ErrOpen
+					}
+					return nil
+				})
+			}
+		}
+	}
 	return g, nil
 }
 
+func (h *httpProc) initBreakerOpenAction(conf processor.HTTPCircuitBreakerConfig, mgr bundle.NewManagement) error {
+	switch conf.OpenAction {
+	case httpBreakerOpenFail, httpBreakerOpenBlock:
+		if len(conf.Fallback) > 0 {
+			return fmt.Errorf("circuit breaker: fallback processors can only be specified when open_action is '%v'", httpBreakerOpenFallback)
+		}
+	case httpBreakerOpenFallback:
+		if len(conf.Fallback) == 0 {
+			return fmt.Errorf("circuit breaker: at least one fallback processor is required when open_action is '%v'", httpBreakerOpenFallback)
+		}
+		for i, pConf := range conf.Fallback {
+			proc, err := mgr.IntoPath("http", "circuit_breaker", "fallback", strconv.Itoa(i)).NewProcessor(pConf)
+			if err != nil {
+				return fmt.Errorf("circuit breaker: fallback processor %v: %w", i, err)
+			}
+			h.fallback = append(h.fallback, proc)
+		}
+	default:
+		return fmt.Errorf("circuit breaker: open_action '%v' not recognised", conf.OpenAction)
+	}
+	h.openAction = conf.OpenAction
+	return nil
+}
+
+func (h *httpProc) send(ctx context.Context, msg message.Batch) (message.Batch, error) {
+	if h.breaker == nil {
+		return h.client.Send(context.Background(), msg)
+	}
+
+	done, allowed := h.breaker.Allow()
+	for !allowed {
+		switch h.openAction {
+		case httpBreakerOpenFallback:
+			return h.sendFallback(ctx, msg)
+		case httpBreakerOpenBlock:
+			select {
+			case <-time.After(httpBreakerBlockPollPeriod):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			done, allowed = h.breaker.Allow()
+		default:
+			return nil, circuitbreaker.ErrOpen
+		}
+	}
+	res, err := h.client.Send(context.Background(), msg)
+	done(err)
+	return res, err
+}
+
+// sendFallback applies the fallback processors to a copy of a request batch,
+// the results of which are combined and treated as the response.
+func (h *httpProc) sendFallback(ctx context.Context, msg message.Batch) (message.Batch, error) {
+	results, err := processor.ExecuteAll(ctx, h.fallback, msg.ShallowCopy())
+	if err != nil {
+		return nil, err
+	}
+
+	var res message.Batch
+	for _, b := range results {
+		res = append(res, b...)
+	}
+	if len(res) == 0 {
+		return nil, errors.New("fallback processors returned no messages")
+	}
+	for _, p := range res {
+		if err := p.ErrorGet(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
 func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg message.Batch) ([]message.Batch, error) {
 	var responseMsg message.Batch
 
 	if h.asMultipart || msg.Len() == 1 {
 		// Easy, just do a single request.
-		resultMsg, err := h.client.Send(context.Background(), msg)
+		resultMsg, err := h.send(ctx, msg)
 		if err != nil {
 			var code int
 			var hErr component.ErrUnexpectedHTTPRes
@@ -162,7 +315,7 @@ func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 		_ = msg.Iter(func(i int, p *message.Part) error {
 			tmpMsg := message.QuickBatch(nil)
 			tmpMsg = append(tmpMsg, p)
-			result, err := h.client.Send(context.Background(), tmpMsg)
+			result, err := h.send(ctx, tmpMsg)
 			if err != nil {
 				h.log.Errorf("HTTP request to '%v' failed: %v", h.rawURL, err)
 
@@ -201,7 +354,7 @@ func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 			go func() {
 				for index := range reqChan {
 					tmpMsg := message.Batch{msg.Get(index)}
-					result, err := h.client.Send(context.Background(), tmpMsg)
+					result, err := h.send(ctx, tmpMsg)
 					if err == nil && result.Len() != 1 {
 						err = fmt.Errorf("unexpected response size: %v", result.Len())
 					}
@@ -244,5 +397,13 @@ func (h *httpProc) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg
 }
 
 func (h *httpProc) Close(ctx context.Context) error {
+	if h.deregister != nil {
+		h.deregister()
+	}
+	for _, p := range h.fallback {
+		if err := p.Close(ctx); err != nil {
+			return err
+		}
+	}
 	return h.client.Close(ctx)
 }
@@ -8,11 +8,13 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -410,3 +412,146 @@ func TestHTTPClientParallelError(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPClientCircuitBreaker(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "test error", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	conf := processor.NewConfig()
+	conf.Type = "http"
+	conf.HTTP.OldConfig.URL = ts.URL + "/testpost"
+	conf.HTTP.OldConfig.NumRetries = 0
+	conf.HTTP.CircuitBreaker.Enabled = true
+	conf.HTTP.CircuitBreaker.MinimumRequests = 2
+	conf.HTTP.CircuitBreaker.OpenDuration = "1h"
+
+	h, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		msgs, res := h.ProcessBatch(context.Background(), message.QuickBatch([][]byte{[]byte("test")}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+		require.Equal(t, 1, msgs[0].Len())
+
+		err := msgs[0].Get(0).ErrorGet()
+		require.Error(t, err)
+		if i >= 2 {
+			assert.EqualError(t, err, "circuit breaker is open")
+		}
+	}
+
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&reqCount))
+}
+
+func TestHTTPClientCircuitBreakerFallback(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "test error", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	fallbackConf := processor.NewConfig()
+	fallbackConf.Type = "bloblang"
+	fallbackConf.Bloblang = `root = "fallback: " + content()`
+
+	conf := processor.NewConfig()
+	conf.Type = "http"
+	conf.HTTP.OldConfig.URL = ts.URL + "/testpost"
+	conf.HTTP.OldConfig.NumRetries = 0
+	conf.HTTP.CircuitBreaker.Enabled = true
+	conf.HTTP.CircuitBreaker.MinimumRequests = 2
+	conf.HTTP.CircuitBreaker.OpenDuration = "1h"
+	conf.HTTP.CircuitBreaker.OpenAction = "fallback"
+	conf.HTTP.CircuitBreaker.Fallback = []processor.Config{fallbackConf}
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	h, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		msgs, res := h.ProcessBatch(context.Background(), message.QuickBatch([][]byte{[]byte("test")}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+		require.Equal(t, 1, msgs[0].Len())
+
+		if i < 2 {
+			require.Error(t, msgs[0].Get(0).ErrorGet())
+		} else {
+			require.NoError(t, msgs[0].Get(0).ErrorGet())
+			assert.Equal(t, "fallback: test", string(msgs[0].Get(0).AsBytes()))
+		}
+	}
+
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&reqCount))
+	assert.NoError(t, mgr.CheckReadiness())
+}
+
+func TestHTTPClientCircuitBreakerBlock(t *testing.T) {
+	var reqCount uint32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddUint32(&reqCount, 1)
+		http.Error(w, "test error", http.StatusBadGateway)
+	}))
+	defer ts.Close()
+
+	conf := processor.NewConfig()
+	conf.Type = "http"
+	conf.HTTP.OldConfig.URL = ts.URL + "/testpost"
+	conf.HTTP.OldConfig.NumRetries = 0
+	conf.HTTP.CircuitBreaker.Enabled = true
+	conf.HTTP.CircuitBreaker.MinimumRequests = 2
+	conf.HTTP.CircuitBreaker.OpenDuration = "1h"
+	conf.HTTP.CircuitBreaker.OpenAction = "block"
+
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	h, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.CheckReadiness())
+	for i := 0; i < 2; i++ {
+		msgs, res := h.ProcessBatch(context.Background(), message.QuickBatch([][]byte{[]byte("test")}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+		require.Error(t, msgs[0].Get(0).ErrorGet())
+	}
+	assert.EqualError(t, mgr.CheckReadiness(), "root.: circuit breaker is open")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer done()
+
+	msgs, res := h.ProcessBatch(ctx, message.QuickBatch([][]byte{[]byte("test")}))
+	require.NoError(t, res)
+	require.Len(t, msgs, 1)
+	assert.ErrorIs(t, msgs[0].Get(0).ErrorGet(), context.DeadlineExceeded)
+	assert.Equal(t, uint32(2), atomic.LoadUint32(&reqCount))
+
+	require.NoError(t, h.Close(context.Background()))
+	assert.NoError(t, mgr.CheckReadiness())
+}
+
+func TestHTTPClientCircuitBreakerBadOpenAction(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "http"
+	conf.HTTP.OldConfig.URL = "http://localhost:4195/testpost"
+	conf.HTTP.CircuitBreaker.Enabled = true
+	conf.HTTP.CircuitBreaker.OpenAction = "fallback"
+
+	_, err := mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at least one fallback processor is required")
+
+	conf.HTTP.CircuitBreaker.OpenAction = "nope"
+	_, err = mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "open_action 'nope' not recognised")
+}
@@ -0,0 +1,317 @@
+package javascript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dop251/goja"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	jsModeMessage = "message"
+	jsModeBatch   = "batch"
+)
+
+func javascriptProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Mapping").
+		Version("4.10.0").
+		Summary("Executes a JavaScript program for each message or batch of messages.").
+		Description(`
+Programs are executed with [goja](https://github.com/dop251/goja), an ECMAScript 5.1 engine (with many ES6 additions) written in pure Go, and therefore do not have access to Node.js or browser APIs. Each processing thread uses its own instance of the engine, and properties assigned to `+"`globalThis`"+` are kept between executions of that instance. Programs are executed as the body of a function, and can therefore end early with a `+"`return`"+` statement.
+
+An execution that exceeds `+"`timeout`"+`, or a program that throws an exception, results in the messages being processed failing with an error that can be handled using [error handling patterns](/docs/configuration/error_handling). Changes made to messages by a program are only applied once it completes successfully, and therefore failed messages are left unchanged.
+
+### The `+"`benthos`"+` Object
+
+Programs access messages through a global `+"`benthos`"+` object. When `+"`mode`"+` is `+"`message`"+` the program is executed once for each message and the message functions below are called directly on the object, e.g. `+"`benthos.setContent(\"hello\")`"+`. When `+"`mode`"+` is `+"`batch`"+` the program is executed once for each batch, and `+"`benthos.messages`"+` is an array of objects providing the message functions for each message of the batch.
+
+| Function | Description |
+|---|---|
+| `+"`content()`"+` | Returns the raw contents of the message as a string. |
+| `+"`setContent(value)`"+` | Replaces the raw contents of the message with a string. |
+| `+"`json()`"+` | Returns the contents of the message parsed as JSON, throwing an exception when it is not valid JSON. |
+| `+"`setJSON(value)`"+` | Replaces the contents of the message with a value serialised as JSON. |
+| `+"`meta(key)`"+` | Returns a metadata value of the message, or `+"`undefined`"+` if the key does not exist. |
+| `+"`metadata()`"+` | Returns an object containing all metadata key/value pairs of the message. |
+| `+"`setMeta(key, value)`"+` | Sets a metadata value of the message. |
+| `+"`deleteMeta(key)`"+` | Removes a metadata key from the message. |
+| `+"`error()`"+` | Returns the error of a message that has failed processing, or `+"`null`"+` if it has not failed. |
+| `+"`setError(message)`"+` | Fails the message with an error. |
+| `+"`drop()`"+` | Removes the message from the resulting batch. |
+
+The `+"`benthos`"+` object also provides the following functions regardless of `+"`mode`"+`:
+
+| Function | Description |
+|---|---|
+| `+"`cacheGet(resource, key)`"+` | Returns the value of a key from a [cache resource](/docs/components/caches/about) as a string, or `+"`undefined`"+` if the key does not exist. |
+| `+"`log(level, message)`"+` | Writes a log at the level `+"`error`"+`, `+"`warn`"+`, `+"`info`"+`, `+"`debug`"+` or `+"`trace`"+`. |`).
+		Field(service.NewStringField("code").
+			Description("An inline JavaScript program to execute. Either this field or `file` must be specified.").
+			Example(`let doc = benthos.json();
+doc.name = doc.name.toUpperCase();
+benthos.setJSON(doc);`).
+			Optional()).
+		Field(service.NewStringField("file").
+			Description("The path of a file containing a JavaScript program to execute. Either this field or `code` must be specified.").
+			Example("./transform.js").
+			Optional()).
+		Field(service.NewStringAnnotatedEnumField("mode", map[string]string{
+			jsModeMessage: "The program is executed once for each message.",
+			jsModeBatch:   "The program is executed once for each batch of messages.",
+		}).
+			Description("Whether the program is executed for each message or for each batch of messages.").
+			Default(jsModeMessage)).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time that a single execution of the program may take before it is aborted.").
+			Default("5s")).
+		LintRule(`root = match {
+  this.exists("code") == this.exists("file") => [ "exactly one of the fields code or file must be specified" ]
+}`).
+		Example("Structured Transformation",
+			"The contents of each message are parsed as JSON, modified and written back, with a metadata value added based on the result:",
+			`
+pipeline:
+  processors:
+    - javascript:
+        code: |
+          let doc = benthos.json();
+          doc.full_name = doc.first_name + " " + doc.last_name;
+          delete doc.first_name;
+          delete doc.last_name;
+          benthos.setJSON(doc);
+          benthos.setMeta("name_length", String(doc.full_name.length));
+`).
+		Example("Batch Deduplication",
+			"When executed for each batch a program is able to compare messages, here messages with an ID that has already been seen within the batch, or that exists within a cache resource, are dropped:",
+			`
+pipeline:
+  processors:
+    - javascript:
+        mode: batch
+        code: |
+          const seen = {};
+          for (const msg of benthos.messages) {
+            const id = msg.json().id;
+            if (seen[id] || benthos.cacheGet("known_ids", id) !== undefined) {
+              msg.drop();
+            }
+            seen[id] = true;
+          }
+
+cache_resources:
+  - label: known_ids
+    memory: {}
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor("javascript", javascriptProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newJavascriptProcessorFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type javascriptProcessor struct {
+	program   *goja.Program
+	batchMode bool
+	timeout   time.Duration
+
+	mgr *service.Resources
+	log *service.Logger
+
+	vms chan *jsVM
+}
+
+func newJavascriptProcessorFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*javascriptProcessor, error) {
+	var name, code string
+	if conf.Contains("file") {
+		var err error
+		if name, err = conf.FieldString("file"); err != nil {
+			return nil, err
+		}
+		codeBytes, err := os.ReadFile(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		code = string(codeBytes)
+	} else if conf.Contains("code") {
+		var err error
+		if code, err = conf.FieldString("code"); err != nil {
+			return nil, err
+		}
+		name = "code"
+	} else {
+		return nil, errors.New("either a code or file field must be specified")
+	}
+
+	mode, err := conf.FieldString("mode")
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+	return newJavascriptProcessor(name, code, mode == jsModeBatch, timeout, mgr)
+}
+
+func newJavascriptProcessor(name, code string, batchMode bool, timeout time.Duration, mgr *service.Resources) (*javascriptProcessor, error) {
+	// Programs are wrapped in a function so that top level let and const
+	// declarations do not clash across executions, and so that return can be
+	// used to end an execution early. The opening of the wrapper is kept on the
+	// first line so that line numbers within errors are correct.
+	program, err := goja.Compile(name, "(function(){"+code+"\n})()", false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile program: %w", err)
+	}
+	return &javascriptProcessor{
+		program:   program,
+		batchMode: batchMode,
+		timeout:   timeout,
+		mgr:       mgr,
+		log:       mgr.Logger(),
+		vms:       make(chan *jsVM, 128),
+	}, nil
+}
+
+// acquire returns an idle engine instance, or creates a new one when all
+// instances are in use, which results in an instance per processing thread.
+func (p *javascriptProcessor) acquire() *jsVM {
+	select {
+	case vm := <-p.vms:
+		return vm
+	default:
+	}
+	return newJSVM(p.mgr)
+}
+
+func (p *javascriptProcessor) release(vm *jsVM) {
+	select {
+	case p.vms <- vm:
+	default:
+	}
+}
+
+var errExecutionTimeout = errors.New("execution timed out")
+
+// run executes the program, returning interrupted as true when the execution
+// was aborted, in which case the engine instance should be discarded.
+func (p *javascriptProcessor) run(ctx context.Context, vm *jsVM) (interrupted bool, err error) {
+	vm.ctx = ctx
+	defer func() {
+		vm.ctx = nil
+	}()
+
+	done, watcherExited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(watcherExited)
+		timer := time.NewTimer(p.timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			vm.rt.Interrupt(errExecutionTimeout)
+		case <-ctx.Done():
+			vm.rt.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	_, err = vm.rt.RunProgram(p.program)
+	close(done)
+	<-watcherExited
+
+	var iErr *goja.InterruptedError
+	if errors.As(err, &iErr) {
+		if iErr.Value() == errExecutionTimeout {
+			return true, fmt.Errorf("execution of program exceeded timeout of %v", p.timeout)
+		}
+		return true, fmt.Errorf("execution of program was interrupted: %v", iErr.Value())
+	}
+
+	// An interrupt may have been requested after the program completed.
+	vm.rt.ClearInterrupt()
+
+	var ex *goja.Exception
+	if errors.As(err, &ex) {
+		return false, fmt.Errorf("program threw an exception: %v", ex.Value())
+	}
+	return false, err
+}
+
+// ProcessBatch executes the program against copies of messages, and therefore
+// changes made by a program are only applied when it completes successfully.
+func (p *javascriptProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	vm := p.acquire()
+
+	if p.batchMode {
+		msgs := make([]*jsMessage, len(batch))
+		objs := make([]any, len(batch))
+		for i, m := range batch {
+			jm := &jsMessage{msg: m.Copy()}
+			msgs[i] = jm
+			objs[i] = vm.newMessageObject(func() *jsMessage { return jm })
+		}
+		_ = vm.benthos.Set("messages", vm.rt.NewArray(objs...))
+		interrupted, err := p.run(ctx, vm)
+		if interrupted {
+			return nil, err
+		}
+		_ = vm.benthos.Delete("messages")
+		p.release(vm)
+		if err != nil {
+			return nil, err
+		}
+
+		var resBatch service.MessageBatch
+		for _, m := range msgs {
+			if !m.dropped {
+				resBatch = append(resBatch, m.msg)
+			}
+		}
+		if len(resBatch) == 0 {
+			return nil, nil
+		}
+		return []service.MessageBatch{resBatch}, nil
+	}
+
+	var resBatch service.MessageBatch
+	for _, m := range batch {
+		current := &jsMessage{msg: m.Copy()}
+		vm.current = current
+		interrupted, err := p.run(ctx, vm)
+		vm.current = nil
+		if interrupted {
+			vm = p.acquire()
+		}
+		if err != nil {
+			p.log.Debugf("Processing failed: %v", err)
+			m.SetError(err)
+			resBatch = append(resBatch, m)
+			continue
+		}
+		if !current.dropped {
+			resBatch = append(resBatch, current.msg)
+		}
+	}
+	p.release(vm)
+
+	if len(resBatch) == 0 {
+		return nil, nil
+	}
+	return []service.MessageBatch{resBatch}, nil
+}
+
+func (p *javascriptProcessor) Close(ctx context.Context) error {
+	return nil
+}
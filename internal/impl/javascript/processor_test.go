@@ -0,0 +1,355 @@
+package javascript
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testProcessor(t *testing.T, confStr string, res *service.Resources) *javascriptProcessor {
+	t.Helper()
+
+	conf, err := javascriptProcessorConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	if res == nil {
+		res = service.MockResources()
+	}
+	p, err := newJavascriptProcessorFromConfig(conf, res)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	return p
+}
+
+func testBatch(contents ...string) service.MessageBatch {
+	b := make(service.MessageBatch, len(contents))
+	for i, c := range contents {
+		b[i] = service.NewMessage([]byte(c))
+	}
+	return b
+}
+
+func batchContents(t *testing.T, b service.MessageBatch) []string {
+	t.Helper()
+
+	var contents []string
+	for _, m := range b {
+		mBytes, err := m.AsBytes()
+		require.NoError(t, err)
+		contents = append(contents, string(mBytes))
+	}
+	return contents
+}
+
+func TestJavascriptMessageMode(t *testing.T) {
+	p := testProcessor(t, `
+code: |
+  let doc = benthos.json();
+  doc.name = doc.name.toUpperCase();
+  doc.tags.push("processed");
+  benthos.setJSON(doc);
+  benthos.setMeta("original", benthos.meta("original") + " world");
+  benthos.deleteMeta("remove_me");
+`, nil)
+
+	msg := service.NewMessage([]byte(`{"name":"foo","tags":["a"]}`))
+	msg.MetaSet("original", "hello")
+	msg.MetaSet("remove_me", "yep")
+
+	res, err := p.ProcessBatch(context.Background(), service.MessageBatch{msg})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 1)
+
+	assert.Equal(t, []string{`{"name":"FOO","tags":["a","processed"]}`}, batchContents(t, res[0]))
+
+	v, _ := res[0][0].MetaGet("original")
+	assert.Equal(t, "hello world", v)
+	_, exists := res[0][0].MetaGet("remove_me")
+	assert.False(t, exists)
+}
+
+func TestJavascriptContentAndMetadata(t *testing.T) {
+	p := testProcessor(t, `
+code: |
+  if (benthos.meta("nope") !== undefined) {
+    throw new Error("expected undefined meta");
+  }
+  const meta = benthos.metadata();
+  const keys = Object.keys(meta).sort();
+  benthos.setContent(benthos.content() + ": " + keys.map(k => k + "=" + meta[k]).join(","));
+`, nil)
+
+	msg := service.NewMessage([]byte(`hello`))
+	msg.MetaSet("b", "2")
+	msg.MetaSet("a", "1")
+
+	res, err := p.ProcessBatch(context.Background(), service.MessageBatch{msg})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, []string{"hello: a=1,b=2"}, batchContents(t, res[0]))
+}
+
+func TestJavascriptErrorsAndDrops(t *testing.T) {
+	p := testProcessor(t, `
+code: |
+  const content = benthos.content();
+  if (content === "drop") {
+    benthos.drop();
+    return;
+  }
+  if (content === "throw") {
+    throw new Error("nope");
+  }
+  if (content === "fail") {
+    benthos.setError("failed on purpose");
+  }
+  if (content === "recover") {
+    benthos.setContent("recovered from: " + benthos.error());
+    return;
+  }
+  benthos.setContent(content + " " + String(benthos.error()));
+`, nil)
+
+	batch := testBatch("foo", "drop", "throw", "fail", "recover")
+	batch[4].SetError(assert.AnError)
+
+	res, err := p.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 4)
+
+	assert.Equal(t, []string{
+		"foo null",
+		"throw",
+		"fail failed on purpose",
+		"recovered from: " + assert.AnError.Error(),
+	}, batchContents(t, res[0]))
+
+	assert.NoError(t, res[0][0].GetError())
+	require.Error(t, res[0][1].GetError())
+	assert.Contains(t, res[0][1].GetError().Error(), "program threw an exception: Error: nope")
+	require.Error(t, res[0][2].GetError())
+	assert.Equal(t, "failed on purpose", res[0][2].GetError().Error())
+
+	res, err = p.ProcessBatch(context.Background(), testBatch("drop"))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestJavascriptChangesDiscardedOnFailure(t *testing.T) {
+	p := testProcessor(t, `
+code: |
+  benthos.setContent("changed");
+  benthos.setMeta("foo", "changed");
+  benthos.deleteMeta("bar");
+  throw new Error("nope");
+`, nil)
+
+	msg := service.NewMessage([]byte(`original`))
+	msg.MetaSet("foo", "original")
+	msg.MetaSet("bar", "original")
+
+	res, err := p.ProcessBatch(context.Background(), service.MessageBatch{msg})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 1)
+
+	assert.Equal(t, []string{"original"}, batchContents(t, res[0]))
+	require.Error(t, res[0][0].GetError())
+	v, _ := res[0][0].MetaGet("foo")
+	assert.Equal(t, "original", v)
+	v, _ = res[0][0].MetaGet("bar")
+	assert.Equal(t, "original", v)
+
+	p = testProcessor(t, `
+mode: batch
+code: |
+  benthos.messages[0].setContent("changed");
+  benthos.messages[0].setMeta("foo", "changed");
+  throw new Error("nope");
+`, nil)
+
+	batch := testBatch("original")
+	batch[0].MetaSet("foo", "original")
+
+	_, err = p.ProcessBatch(context.Background(), batch)
+	require.Error(t, err)
+	assert.Equal(t, []string{"original"}, batchContents(t, batch))
+	v, _ = batch[0].MetaGet("foo")
+	assert.Equal(t, "original", v)
+}
+
+func TestJavascriptBatchMode(t *testing.T) {
+	p := testProcessor(t, `
+mode: batch
+code: |
+  const seen = {};
+  for (const msg of benthos.messages) {
+    const id = msg.json().id;
+    if (seen[id]) {
+      msg.drop();
+      continue;
+    }
+    seen[id] = true;
+    msg.setMeta("count", String(benthos.messages.length));
+  }
+`, nil)
+
+	res, err := p.ProcessBatch(context.Background(), testBatch(
+		`{"id":"a"}`, `{"id":"b"}`, `{"id":"a"}`, `{"id":"c"}`,
+	))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, []string{`{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`}, batchContents(t, res[0]))
+
+	v, _ := res[0][2].MetaGet("count")
+	assert.Equal(t, "4", v)
+}
+
+func TestJavascriptBatchModeErrors(t *testing.T) {
+	p := testProcessor(t, `
+mode: batch
+code: |
+  benthos.content();
+`, nil)
+
+	_, err := p.ProcessBatch(context.Background(), testBatch("foo"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be called on an element of benthos.messages")
+}
+
+func TestJavascriptCacheGet(t *testing.T) {
+	res := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+	require.NoError(t, res.AccessCache(context.Background(), "foocache", func(c service.Cache) {
+		require.NoError(t, c.Set(context.Background(), "foo", []byte("bar"), nil))
+	}))
+
+	p := testProcessor(t, `
+code: |
+  const v = benthos.cacheGet("foocache", benthos.content());
+  benthos.setContent(v === undefined ? "not found" : v);
+`, res)
+
+	batch, err := p.ProcessBatch(context.Background(), testBatch("foo", "baz"))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.Equal(t, []string{"bar", "not found"}, batchContents(t, batch[0]))
+
+	p = testProcessor(t, `
+code: |
+  benthos.cacheGet("nope", "foo");
+`, res)
+
+	batch, err = p.ProcessBatch(context.Background(), testBatch("foo"))
+	require.NoError(t, err)
+	require.Error(t, batch[0][0].GetError())
+	assert.Contains(t, batch[0][0].GetError().Error(), "failed to access cache nope")
+}
+
+func TestJavascriptTimeout(t *testing.T) {
+	p := testProcessor(t, `
+timeout: 50ms
+code: |
+  if (benthos.content() === "spin") {
+    while (true) {}
+  }
+  benthos.setContent("ok");
+`, nil)
+
+	res, err := p.ProcessBatch(context.Background(), testBatch("spin", "foo"))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Error(t, res[0][0].GetError())
+	assert.Equal(t, "execution of program exceeded timeout of 50ms", res[0][0].GetError().Error())
+	assert.NoError(t, res[0][1].GetError())
+	assert.Equal(t, []string{"spin", "ok"}, batchContents(t, res[0]))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*20)
+	defer done()
+
+	p = testProcessor(t, `
+timeout: 10s
+code: |
+  while (true) {}
+`, nil)
+
+	res, err = p.ProcessBatch(ctx, testBatch("foo"))
+	require.NoError(t, err)
+	require.Error(t, res[0][0].GetError())
+	assert.Contains(t, res[0][0].GetError().Error(), "execution of program was interrupted")
+}
+
+func TestJavascriptGlobalState(t *testing.T) {
+	p := testProcessor(t, `
+code: |
+  globalThis.count = (globalThis.count || 0) + 1;
+  let count = globalThis.count;
+  benthos.setContent(String(count));
+`, nil)
+
+	for _, exp := range []string{"1", "2", "3"} {
+		res, err := p.ProcessBatch(context.Background(), testBatch("foo"))
+		require.NoError(t, err)
+		assert.Equal(t, []string{exp}, batchContents(t, res[0]))
+	}
+}
+
+func TestJavascriptParallel(t *testing.T) {
+	p := testProcessor(t, `
+code: |
+  benthos.setContent(benthos.content().toUpperCase());
+`, nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				res, err := p.ProcessBatch(context.Background(), testBatch("foo", "bar"))
+				require.NoError(t, err)
+				require.Len(t, res, 1)
+				assert.Equal(t, []string{"FOO", "BAR"}, batchContents(t, res[0]))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestJavascriptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "foo.js")
+	require.NoError(t, os.WriteFile(path, []byte(`benthos.setContent("from file");`), 0o644))
+
+	p := testProcessor(t, "file: "+path, nil)
+
+	res, err := p.ProcessBatch(context.Background(), testBatch("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"from file"}, batchContents(t, res[0]))
+}
+
+func TestJavascriptConfigErrors(t *testing.T) {
+	conf, err := javascriptProcessorConfig().ParseYAML(`code: 'benthos.setContent('`, nil)
+	require.NoError(t, err)
+
+	_, err = newJavascriptProcessorFromConfig(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile program")
+
+	conf, err = javascriptProcessorConfig().ParseYAML(`mode: batch`, nil)
+	require.NoError(t, err)
+
+	_, err = newJavascriptProcessorFromConfig(conf, service.MockResources())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "either a code or file field must be specified")
+}
@@ -0,0 +1,191 @@
+package javascript
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dop251/goja"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// jsMessage is a message being processed by an execution of a program.
+type jsMessage struct {
+	msg     *service.Message
+	dropped bool
+}
+
+// jsVM is an engine instance along with the host object exposed to programs.
+type jsVM struct {
+	rt        *goja.Runtime
+	benthos   *goja.Object
+	jsonParse goja.Callable
+
+	mgr *service.Resources
+	log *service.Logger
+
+	// The context and message of the current execution, only set while a
+	// program is being executed.
+	ctx     context.Context
+	current *jsMessage
+}
+
+func newJSVM(mgr *service.Resources) *jsVM {
+	vm := &jsVM{
+		rt:  goja.New(),
+		mgr: mgr,
+		log: mgr.Logger(),
+	}
+
+	// Documents are parsed with the engine itself rather than converted from
+	// Go values, as programs expect to be able to mutate them like any other
+	// object.
+	vm.jsonParse, _ = goja.AssertFunction(vm.rt.Get("JSON").ToObject(vm.rt).Get("parse"))
+
+	vm.benthos = vm.newMessageObject(func() *jsMessage {
+		if vm.current == nil {
+			panic(vm.rt.NewTypeError("message functions must be called on an element of benthos.messages in batch mode"))
+		}
+		return vm.current
+	})
+	vm.setFn(vm.benthos, "cacheGet", vm.cacheGet)
+	vm.setFn(vm.benthos, "log", vm.logFn)
+
+	_ = vm.rt.Set("benthos", vm.benthos)
+	return vm
+}
+
+func (vm *jsVM) setFn(obj *goja.Object, name string, fn func(goja.FunctionCall) goja.Value) {
+	_ = obj.Set(name, fn)
+}
+
+// throw aborts the current function call with a JavaScript exception.
+func (vm *jsVM) throw(err error) {
+	panic(vm.rt.NewGoError(err))
+}
+
+func (vm *jsVM) stringArg(call goja.FunctionCall, i int, name string) string {
+	arg := call.Argument(i)
+	if goja.IsUndefined(arg) || goja.IsNull(arg) {
+		panic(vm.rt.NewTypeError("missing argument: %v", name))
+	}
+	return arg.String()
+}
+
+// newMessageObject creates an object providing functions that access the
+// message returned by get.
+func (vm *jsVM) newMessageObject(get func() *jsMessage) *goja.Object {
+	obj := vm.rt.NewObject()
+
+	vm.setFn(obj, "content", func(call goja.FunctionCall) goja.Value {
+		b, err := get().msg.AsBytes()
+		if err != nil {
+			vm.throw(err)
+		}
+		return vm.rt.ToValue(string(b))
+	})
+	vm.setFn(obj, "setContent", func(call goja.FunctionCall) goja.Value {
+		get().msg.SetBytes([]byte(vm.stringArg(call, 0, "value")))
+		return goja.Undefined()
+	})
+	vm.setFn(obj, "json", func(call goja.FunctionCall) goja.Value {
+		b, err := get().msg.AsBytes()
+		if err != nil {
+			vm.throw(err)
+		}
+		v, err := vm.jsonParse(goja.Undefined(), vm.rt.ToValue(string(b)))
+		if err != nil {
+			var ex *goja.Exception
+			if errors.As(err, &ex) {
+				panic(ex.Value())
+			}
+			vm.throw(err)
+		}
+		return v
+	})
+	vm.setFn(obj, "setJSON", func(call goja.FunctionCall) goja.Value {
+		get().msg.SetStructuredMut(call.Argument(0).Export())
+		return goja.Undefined()
+	})
+	vm.setFn(obj, "meta", func(call goja.FunctionCall) goja.Value {
+		v, exists := get().msg.MetaGet(vm.stringArg(call, 0, "key"))
+		if !exists {
+			return goja.Undefined()
+		}
+		return vm.rt.ToValue(v)
+	})
+	vm.setFn(obj, "metadata", func(call goja.FunctionCall) goja.Value {
+		meta := vm.rt.NewObject()
+		_ = get().msg.MetaWalk(func(k, v string) error {
+			return meta.Set(k, v)
+		})
+		return meta
+	})
+	vm.setFn(obj, "setMeta", func(call goja.FunctionCall) goja.Value {
+		get().msg.MetaSet(vm.stringArg(call, 0, "key"), vm.stringArg(call, 1, "value"))
+		return goja.Undefined()
+	})
+	vm.setFn(obj, "deleteMeta", func(call goja.FunctionCall) goja.Value {
+		get().msg.MetaDelete(vm.stringArg(call, 0, "key"))
+		return goja.Undefined()
+	})
+	vm.setFn(obj, "error", func(call goja.FunctionCall) goja.Value {
+		if err := get().msg.GetError(); err != nil {
+			return vm.rt.ToValue(err.Error())
+		}
+		return goja.Null()
+	})
+	vm.setFn(obj, "setError", func(call goja.FunctionCall) goja.Value {
+		get().msg.SetError(errors.New(vm.stringArg(call, 0, "message")))
+		return goja.Undefined()
+	})
+	vm.setFn(obj, "drop", func(call goja.FunctionCall) goja.Value {
+		get().dropped = true
+		return goja.Undefined()
+	})
+	return obj
+}
+
+func (vm *jsVM) cacheGet(call goja.FunctionCall) goja.Value {
+	resource, key := vm.stringArg(call, 0, "resource"), vm.stringArg(call, 1, "key")
+
+	ctx := vm.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	var value []byte
+	var cacheErr error
+	if err := vm.mgr.AccessCache(ctx, resource, func(c service.Cache) {
+		value, cacheErr = c.Get(ctx, key)
+	}); err != nil {
+		vm.throw(fmt.Errorf("failed to access cache %v: %w", resource, err))
+	}
+	if errors.Is(cacheErr, service.ErrKeyNotFound) {
+		return goja.Undefined()
+	}
+	if cacheErr != nil {
+		vm.throw(cacheErr)
+	}
+	return vm.rt.ToValue(string(value))
+}
+
+func (vm *jsVM) logFn(call goja.FunctionCall) goja.Value {
+	level, msg := vm.stringArg(call, 0, "level"), vm.stringArg(call, 1, "message")
+	switch level {
+	case "error":
+		vm.log.Error(msg)
+	case "warn":
+		vm.log.Warn(msg)
+	case "info":
+		vm.log.Info(msg)
+	case "debug":
+		vm.log.Debug(msg)
+	case "trace":
+		vm.log.Trace(msg)
+	default:
+		panic(vm.rt.NewTypeError("unrecognised log level: %v", level))
+	}
+	return goja.Undefined()
+}
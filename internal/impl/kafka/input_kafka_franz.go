@@ -4,7 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"errors"
-	"strconv"
+	"fmt"
+	"path"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -44,8 +45,30 @@ This input adds the following metadata fields to each message:
 - kafka_partition
 - kafka_offset
 - kafka_timestamp_unix
+- kafka_timestamp
 - All record headers
 ` + "```" + `
+
+### Ordering
+
+By default messages of the same partition can be processed in parallel, up to the limit determined by the field ` + "`checkpoint_limit`" + `, which means that when the pipeline has multiple processing threads messages are not guaranteed to be processed in order. Setting the field ` + "[`ordering`](#ordering)" + ` to ` + "`partition`" + ` instead gives each partition its own lane, where a message is only delivered once the prior message of the same lane has been acknowledged, and therefore messages of a partition are processed in order whilst messages of different partitions are processed in parallel.
+
+When ordering is only required for messages that share a key then ` + "`ordering`" + ` can be set to ` + "`key`" + `, where the messages of each partition are divided into ` + "[`key_lanes`](#key_lanes)" + ` lanes by a hash of their key. This allows messages of the same partition to be processed in parallel whilst messages with the same key are processed in order.
+
+In both cases offsets are committed for each partition only once all messages of prior offsets have been acknowledged, and the consumption of a partition is paused whilst it has ` + "`checkpoint_limit`" + ` messages queued or in flight, which prevents a busy lane from holding up the lanes of other partitions. When partitions are revoked during a rebalance the messages queued for them are dropped so that they can be consumed by their new owner.
+
+### Metrics
+
+This input emits the gauges ` + "`kafka_lag`" + ` and ` + "`kafka_high_watermark`" + ` labelled by ` + "`topic`" + ` and ` + "`partition`" + `, which report the high water mark offset of each partition and the lag of the consumer group from its last committed offset. These are refreshed every ` + "`commit_period`" + `, and the gauges of partitions revoked from the consumer are reset to zero.
+
+### Admin Endpoints
+
+When the input has a [label](/docs/components/inputs/about#labels) the following HTTP endpoints are registered with the service wide HTTP server, which are prefixed with the stream identifier when running in streams mode:
+
+- ` + "`GET /kafka_franz/<label>/lag`" + ` returns the high water mark, last committed offset and lag of each partition currently assigned to the consumer, as of the latest refresh.
+- ` + "`POST /kafka_franz/<label>/seek?to=<position>`" + ` moves the consumer group offsets of the partitions currently assigned to the consumer, where the position is one of ` + "`earliest`" + `, ` + "`latest`" + `, ` + "`offset`" + ` (with the query parameter ` + "`offset`" + `) or ` + "`timestamp`" + ` (with the query parameter ` + "`timestamp`" + ` as an RFC 3339 string or unix milliseconds). The query parameter ` + "`topic`" + ` can be used to limit the seek to a single topic. The input is paused during the seek, messages that are in flight at the time are not committed once acknowledged, and the new offsets are committed before consumption resumes. The resulting offsets are returned as a JSON object. When the input has no label these endpoints are not registered, and a warning is logged instead.
+
+Partitions assigned to other members of the consumer group are not affected by a seek.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -63,6 +86,20 @@ This input adds the following metadata fields to each message:
 			Description("Determines how many messages of the same partition can be processed in parallel before applying back pressure. When a message of a given offset is delivered to the output the offset is only allowed to be committed when all messages of prior offsets have also been delivered, this ensures at-least-once delivery guarantees. However, this mechanism also increases the likelihood of duplicates in the event of crashes or server faults, reducing the checkpoint limit will mitigate this.").
 			Default(1024).
 			Advanced()).
+		Field(service.NewStringAnnotatedEnumField("ordering", map[string]string{
+			"none":      "Messages are delivered as they are consumed, and messages of the same partition can be processed in parallel up to the `checkpoint_limit`.",
+			"partition": "Each partition is given its own lane, where messages of a partition are delivered one at a time and in order.",
+			"key":       "The messages of each partition are divided into lanes by a hash of their key, where messages of a lane are delivered one at a time and in order.",
+		}).
+			Description("Determines whether messages are delivered in ordered lanes, which allows messages of different lanes to be processed in parallel whilst messages of the same lane are processed in order. Messages of a lane are only delivered once the prior message of the lane has been acknowledged.").
+			Default("none").
+			Advanced().
+			Version("4.10.0")).
+		Field(service.NewIntField("key_lanes").
+			Description("When `ordering` is set to `key`, the number of lanes that the messages of each partition are divided into.").
+			Default(16).
+			Advanced().
+			Version("4.10.0")).
 		Field(service.NewDurationField("commit_period").
 			Description("The period of time between each commit of the current partition offsets. Offsets are always committed during shutdown.").
 			Default("5s").
@@ -78,10 +115,24 @@ This input adds the following metadata fields to each message:
 func init() {
 	err := service.RegisterInput("kafka_franz", franzKafkaInputConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
-			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr.Logger())
+			rdr, err := newFranzKafkaReaderFromConfig(conf, mgr)
 			if err != nil {
 				return nil, err
 			}
+			if label := mgr.Label(); label != "" {
+				mgr.RegisterEndpoint(
+					path.Join("/kafka_franz", label, "lag"),
+					"Returns the lag of each partition assigned to the kafka_franz input.",
+					rdr.handleLag,
+				)
+				mgr.RegisterEndpoint(
+					path.Join("/kafka_franz", label, "seek"),
+					"Moves the consumer group offsets of the partitions assigned to the kafka_franz input.",
+					rdr.handleSeek,
+				)
+			} else {
+				mgr.Logger().Warn("The kafka_franz input has no label, and therefore the HTTP endpoints for inspecting partition lag and seeking offsets are not registered")
+			}
 			return service.AutoRetryNacks(rdr), nil
 		})
 	if err != nil {
@@ -106,8 +157,13 @@ type franzKafkaReader struct {
 	startFromOldest bool
 	commitPeriod    time.Duration
 	regexPattern    bool
+	ordering        string
+	keyLanes        int
+
+	msgChan        atomic.Value
+	seekChan       chan *kafkaSeekRequest
+	partitionState *franzPartitionState
 
-	msgChan atomic.Value
 	log     *service.Logger
 	shutSig *shutdown.Signaller
 }
@@ -121,9 +177,14 @@ func (f *franzKafkaReader) storeMsgChan(c chan msgWithAckFn) {
 	f.msgChan.Store(c)
 }
 
-func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaReader, error) {
+func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*franzKafkaReader, error) {
 	f := franzKafkaReader{
-		log:     log,
+		seekChan: make(chan *kafkaSeekRequest),
+		partitionState: newFranzPartitionState(
+			mgr.Metrics().NewGauge("kafka_lag", "topic", "partition"),
+			mgr.Metrics().NewGauge("kafka_high_watermark", "topic", "partition"),
+		),
+		log:     mgr.Logger(),
 		shutSig: shutdown.NewSignaller(),
 	}
 
@@ -155,6 +216,22 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	if f.ordering, err = conf.FieldString("ordering"); err != nil {
+		return nil, err
+	}
+	switch f.ordering {
+	case "none", "partition":
+	case "key":
+		if f.keyLanes, err = conf.FieldInt("key_lanes"); err != nil {
+			return nil, err
+		}
+		if f.keyLanes < 1 {
+			return nil, errors.New("key_lanes must be greater than zero")
+		}
+	default:
+		return nil, fmt.Errorf("ordering not recognised: %v", f.ordering)
+	}
+
 	if f.startFromOldest, err = conf.FieldBool("start_from_oldest"); err != nil {
 		return nil, err
 	}
@@ -212,6 +289,12 @@ func (c *checkpointTracker) addRecord(r *kgo.Record) (removeFn func() *kgo.Recor
 		defer c.mut.Unlock()
 
 		highestRec, _ := releaseFn().(*kgo.Record)
+
+		// If the partition has since been removed, due to a rebalance or a
+		// seek, then the record must no longer be committed.
+		if c.topics[r.Topic][r.Partition] != partCheckpoint {
+			return nil
+		}
 		return highestRec
 	}, int(partCheckpoint.Pending())
 }
@@ -280,6 +363,11 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 
 	checkpoints := newCheckpointTracker()
 
+	var lanes *partitionLanes
+	if f.ordering != "none" {
+		lanes = newPartitionLanes(f.keyLanes)
+	}
+
 	var initialOffset kgo.Offset
 	if f.startFromOldest {
 		initialOffset = kgo.NewOffset().AtStart()
@@ -293,6 +381,9 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		kgo.ConsumeTopics(f.topics...),
 		kgo.ConsumeResetOffset(initialOffset),
 		kgo.SASL(f.saslConfs...),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
+			f.partitionState.assign(m)
+		}),
 		kgo.OnPartitionsRevoked(func(rctx context.Context, c *kgo.Client, m map[string][]int32) {
 			// Note: this is a best attempt, there's a chance of duplicates if
 			// the checkpoint limit is borked with slow moving pending messages,
@@ -318,10 +409,18 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				f.log.Errorf("Commit error on partition revoke: %v", commitErr)
 			})
 			checkpoints.removeTopicPartitions(m)
+			if lanes != nil {
+				lanes.removeTopicPartitions(m)
+			}
+			f.partitionState.remove(m)
 		}),
 		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, m map[string][]int32) {
 			// No point trying to commit our offsets, just clean up our topic map
 			checkpoints.removeTopicPartitions(m)
+			if lanes != nil {
+				lanes.removeTopicPartitions(m)
+			}
+			f.partitionState.remove(m)
 		}),
 		kgo.AutoCommitMarks(),
 		kgo.AutoCommitInterval(f.commitPeriod),
@@ -343,7 +442,9 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 
 	msgChan := make(chan msgWithAckFn)
 	go func() {
+		var bgWG sync.WaitGroup
 		defer func() {
+			bgWG.Wait()
 			cl.Close()
 			f.storeMsgChan(nil)
 			close(msgChan)
@@ -355,6 +456,53 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		closeCtx, done := f.shutSig.CloseAtLeisureCtx(context.Background())
 		defer done()
 
+		if lanes != nil {
+			// Messages are pulled from the lanes as they become ready and fed
+			// into the same channel as non-ordered messages.
+			lanesCtx, lanesDone := context.WithCancel(closeCtx)
+			defer lanesDone()
+
+			bgWG.Add(1)
+			go func() {
+				defer bgWG.Done()
+				for {
+					m, err := lanes.next(lanesCtx)
+					if err != nil {
+						return
+					}
+					select {
+					case msgChan <- m:
+					case <-lanesCtx.Done():
+						return
+					}
+				}
+			}()
+		}
+
+		// The lag of assigned partitions is refreshed periodically rather than
+		// from fetches in order for it to reflect committed offsets, and to keep
+		// updating whilst partitions are paused or idle.
+		lagCtx, lagDone := context.WithCancel(closeCtx)
+		defer lagDone()
+
+		bgWG.Add(1)
+		go func() {
+			defer bgWG.Done()
+
+			ticker := time.NewTicker(f.commitPeriod)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+				case <-lagCtx.Done():
+					return
+				}
+				if err := f.updateLag(lagCtx, cl); err != nil && lagCtx.Err() == nil {
+					f.log.Debugf("Failed to update partition lag: %v", err)
+				}
+			}
+		}()
+
 		for {
 			// Using a stall prevention context here because I've realised we
 			// might end up disabling literally all the partitions and topics
@@ -391,12 +539,24 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				return
 			}
 
+			// Messages of partitions revoked after this point are rejected by
+			// the lanes.
+			var lanesGen uint64
+			if lanes != nil {
+				lanesGen = lanes.generation()
+			}
+
 			pauseTopicPartitions := map[string][]int32{}
 			iter := fetches.RecordIter()
 			for !iter.Done() {
 				record := iter.Next()
 				msg := recordToMessage(record)
 
+				var lane laneID
+				if lanes != nil {
+					lane = lanes.laneIDFor(record)
+				}
+
 				// The record lives on for checkpointing, but we don't need the
 				// contents going forward so discard these. This looked fine to
 				// me but could potentially be a source of problems so treat
@@ -417,15 +577,33 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 					pauseTopicPartitions[record.Topic] = append(pauseTopicPartitions[record.Topic], record.Partition)
 				}
 
-				select {
-				case msgChan <- msgWithAckFn{
+				m := msgWithAckFn{
 					msg: msg,
 					onAck: func() {
 						if maxRec := releaseFn(); maxRec != nil {
 							cl.MarkCommitRecords(maxRec)
 						}
 					},
-				}:
+				}
+
+				if lanes != nil {
+					// Pushing to a lane never blocks, and therefore a busy
+					// lane does not prevent the delivery of messages from
+					// other partitions. Instead, partitions are paused above
+					// once they reach the checkpoint limit.
+					if err := lanes.push(lane, lanesGen, m); err != nil {
+						// The partition was revoked whilst its records were
+						// being handled, and therefore the checkpoint created
+						// for it is dropped along with the message.
+						checkpoints.removeTopicPartitions(map[string][]int32{
+							record.Topic: {record.Partition},
+						})
+					}
+					continue
+				}
+
+				select {
+				case msgChan <- m:
 				case <-closeCtx.Done():
 					return
 				}
@@ -446,6 +624,15 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 			if len(resumeTopicPartitions) > 0 {
 				cl.ResumeFetchPartitions(resumeTopicPartitions)
 			}
+
+			// Seek requests are handled in between polls, which means the
+			// input is paused whilst they're performed.
+			select {
+			case req := <-f.seekChan:
+				offsets, err := f.seek(req, cl, checkpoints, lanes)
+				req.resChan <- kafkaSeekResult{offsets: offsets, err: err}
+			default:
+			}
 		}
 	}()
 
@@ -458,11 +645,12 @@ func recordToMessage(record *kgo.Record) *service.Message {
 	msg := service.NewMessage(record.Value)
 	msg.MetaSet("kafka_key", string(record.Key))
 	msg.MetaSet("kafka_topic", record.Topic)
-	msg.MetaSet("kafka_partition", strconv.Itoa(int(record.Partition)))
-	msg.MetaSet("kafka_offset", strconv.Itoa(int(record.Offset)))
-	msg.MetaSet("kafka_timestamp_unix", strconv.FormatInt(record.Timestamp.Unix(), 10))
+	msg.MetaSetMut("kafka_partition", int64(record.Partition))
+	msg.MetaSetMut("kafka_offset", record.Offset)
+	msg.MetaSetMut("kafka_timestamp_unix", record.Timestamp.Unix())
+	msg.MetaSetMut("kafka_timestamp", record.Timestamp)
 	for _, hdr := range record.Headers {
-		msg.MetaSet(hdr.Key, string(hdr.Value))
+		msg.MetaSetMut(hdr.Key, hdr.Value)
 	}
 	return msg
 }
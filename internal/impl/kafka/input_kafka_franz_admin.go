@@ -0,0 +1,365 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// franzPartitionState tracks the partitions currently assigned to a consumer
+// along with the latest lag observed for each of them, which is also reported
+// by the lag and high water mark gauges of each partition.
+type franzPartitionState struct {
+	mut        sync.Mutex
+	partitions map[string]map[int32]*franzPartitionStats
+
+	mLag           *service.MetricGauge
+	mHighWatermark *service.MetricGauge
+}
+
+type franzPartitionStats struct {
+	HighWatermark   int64 `json:"high_watermark"`
+	CommittedOffset int64 `json:"committed_offset"`
+	Lag             int64 `json:"lag"`
+}
+
+func newFranzPartitionState(mLag, mHighWatermark *service.MetricGauge) *franzPartitionState {
+	return &franzPartitionState{
+		partitions:     map[string]map[int32]*franzPartitionStats{},
+		mLag:           mLag,
+		mHighWatermark: mHighWatermark,
+	}
+}
+
+func (s *franzPartitionState) assign(m map[string][]int32) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for topic, partitions := range m {
+		topicStats := s.partitions[topic]
+		if topicStats == nil {
+			topicStats = map[int32]*franzPartitionStats{}
+			s.partitions[topic] = topicStats
+		}
+		for _, partition := range partitions {
+			if _, exists := topicStats[partition]; !exists {
+				topicStats[partition] = &franzPartitionStats{HighWatermark: -1, CommittedOffset: -1}
+			}
+		}
+	}
+}
+
+// remove drops the state of partitions that are no longer assigned to the
+// consumer, and zeroes their gauges in order for them not to report stale
+// values once the partitions are consumed elsewhere.
+func (s *franzPartitionState) remove(m map[string][]int32) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	for topic, partitions := range m {
+		topicStats := s.partitions[topic]
+		for _, partition := range partitions {
+			partStr := strconv.Itoa(int(partition))
+			s.mLag.Set(0, topic, partStr)
+			s.mHighWatermark.Set(0, topic, partStr)
+			delete(topicStats, partition)
+		}
+		if topicStats != nil && len(topicStats) == 0 {
+			delete(s.partitions, topic)
+		}
+	}
+}
+
+// update sets the high water mark and committed offset of a partition, where
+// a committed offset of -1 means no offset has been committed yet. Partitions
+// that are not assigned to the consumer are ignored.
+func (s *franzPartitionState) update(topic string, partition int32, highWatermark, committedOffset int64) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	stats := s.partitions[topic][partition]
+	if stats == nil {
+		return
+	}
+
+	partStr := strconv.Itoa(int(partition))
+	stats.HighWatermark = highWatermark
+	s.mHighWatermark.Set(highWatermark, topic, partStr)
+
+	stats.CommittedOffset = committedOffset
+	if committedOffset >= 0 {
+		stats.Lag = committedLag(highWatermark, committedOffset)
+		s.mLag.Set(stats.Lag, topic, partStr)
+	}
+}
+
+// committedLag returns the number of messages of a partition from a committed
+// offset, which is the offset of the next message to consume, up to the high
+// water mark.
+func committedLag(highWatermark, committedOffset int64) int64 {
+	lag := highWatermark - committedOffset
+	if lag < 0 {
+		lag = 0
+	}
+	return lag
+}
+
+// assigned returns the currently assigned partitions, optionally limited to a
+// single topic.
+func (s *franzPartitionState) assigned(topic string) map[string][]int32 {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	m := map[string][]int32{}
+	for t, topicStats := range s.partitions {
+		if topic != "" && t != topic {
+			continue
+		}
+		for partition := range topicStats {
+			m[t] = append(m[t], partition)
+		}
+	}
+	return m
+}
+
+func (s *franzPartitionState) snapshot() map[string]map[string]franzPartitionStats {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	m := make(map[string]map[string]franzPartitionStats, len(s.partitions))
+	for topic, topicStats := range s.partitions {
+		tm := make(map[string]franzPartitionStats, len(topicStats))
+		for partition, stats := range topicStats {
+			tm[strconv.Itoa(int(partition))] = *stats
+		}
+		m[topic] = tm
+	}
+	return m
+}
+
+// updateLag refreshes the lag of the partitions assigned to the consumer from
+// their high water marks and the offsets last committed by the consumer group,
+// which means the lag reflects messages that are consumed but not yet
+// acknowledged.
+func (f *franzKafkaReader) updateLag(ctx context.Context, cl *kgo.Client) error {
+	partitions := f.partitionState.assigned("")
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	highWatermarks, err := franzListOffsets(ctx, cl, partitions, -1)
+	if err != nil {
+		return err
+	}
+
+	committed := cl.CommittedOffsets()
+	for topic, parts := range highWatermarks {
+		for p, highWatermark := range parts {
+			committedOffset := int64(-1)
+			if eo, exists := committed[topic][p]; exists {
+				committedOffset = eo.Offset
+			}
+			f.partitionState.update(topic, p, highWatermark, committedOffset)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// seek resolves the target offsets of a seek request, resets the consumption
+// of the assigned partitions to those offsets and commits them. Messages that
+// are in flight for those partitions at the time of the seek are no longer
+// committed when acknowledged.
+func (f *franzKafkaReader) seek(
+	req *kafkaSeekRequest,
+	cl *kgo.Client,
+	checkpoints *checkpointTracker,
+	lanes *partitionLanes,
+) (map[string]map[int32]int64, error) {
+	partitions := f.partitionState.assigned(req.topic)
+	if len(partitions) == 0 {
+		return nil, errors.New("no partitions are currently assigned to this consumer")
+	}
+
+	var offsets map[string]map[int32]int64
+	var err error
+	switch req.to {
+	case kafkaSeekOffset:
+		offsets = map[string]map[int32]int64{}
+		for topic, parts := range partitions {
+			offsets[topic] = map[int32]int64{}
+			for _, p := range parts {
+				offsets[topic][p] = req.offset
+			}
+		}
+	case kafkaSeekEarliest:
+		offsets, err = franzListOffsets(req.ctx, cl, partitions, -2)
+	case kafkaSeekLatest:
+		offsets, err = franzListOffsets(req.ctx, cl, partitions, -1)
+	case kafkaSeekTimestamp:
+		if offsets, err = franzListOffsets(req.ctx, cl, partitions, req.timestamp.UnixMilli()); err != nil {
+			break
+		}
+
+		// Partitions without any records at or after the timestamp are moved
+		// to the end of the partition.
+		noRecords := map[string][]int32{}
+		for topic, parts := range offsets {
+			for p, o := range parts {
+				if o < 0 {
+					noRecords[topic] = append(noRecords[topic], p)
+				}
+			}
+		}
+		if len(noRecords) > 0 {
+			var latest map[string]map[int32]int64
+			if latest, err = franzListOffsets(req.ctx, cl, noRecords, -1); err != nil {
+				break
+			}
+			for topic, parts := range latest {
+				for p, o := range parts {
+					offsets[topic][p] = o
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	epochOffsets := map[string]map[int32]kgo.EpochOffset{}
+	for topic, parts := range offsets {
+		epochOffsets[topic] = map[int32]kgo.EpochOffset{}
+		for p, o := range parts {
+			epochOffsets[topic][p] = kgo.EpochOffset{Epoch: -1, Offset: o}
+		}
+	}
+
+	checkpoints.removeTopicPartitions(partitions)
+	if lanes != nil {
+		lanes.removeTopicPartitions(partitions)
+	}
+	cl.SetOffsets(epochOffsets)
+
+	var commitErr error
+	cl.CommitOffsetsSync(req.ctx, epochOffsets, func(_ *kgo.Client, _ *kmsg.OffsetCommitRequest, resp *kmsg.OffsetCommitResponse, err error) {
+		if err != nil {
+			commitErr = err
+			return
+		}
+		for _, t := range resp.Topics {
+			for _, p := range t.Partitions {
+				if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+					commitErr = fmt.Errorf("topic %v partition %v: %w", t.Topic, p.Partition, err)
+					return
+				}
+			}
+		}
+	})
+	if commitErr != nil {
+		return nil, fmt.Errorf("failed to commit offsets: %w", commitErr)
+	}
+	return offsets, nil
+}
+
+// franzListOffsets obtains the offsets of partitions for a given timestamp in
+// unix milliseconds, where -2 and -1 are the earliest and latest offsets
+// respectively.
+func franzListOffsets(ctx context.Context, cl *kgo.Client, partitions map[string][]int32, timestamp int64) (map[string]map[int32]int64, error) {
+	req := kmsg.NewPtrListOffsetsRequest()
+	req.ReplicaID = -1
+	for topic, parts := range partitions {
+		rt := kmsg.NewListOffsetsRequestTopic()
+		rt.Topic = topic
+		for _, p := range parts {
+			rp := kmsg.NewListOffsetsRequestTopicPartition()
+			rp.Partition = p
+			rp.CurrentLeaderEpoch = -1
+			rp.Timestamp = timestamp
+			rt.Partitions = append(rt.Partitions, rp)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+
+	resp, err := req.RequestWith(ctx, cl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list offsets: %w", err)
+	}
+
+	offsets := map[string]map[int32]int64{}
+	for _, t := range resp.Topics {
+		for _, p := range t.Partitions {
+			if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+				return nil, fmt.Errorf("failed to list offsets of topic %v partition %v: %w", t.Topic, p.Partition, err)
+			}
+			if offsets[t.Topic] == nil {
+				offsets[t.Topic] = map[int32]int64{}
+			}
+			offsets[t.Topic][p.Partition] = p.Offset
+		}
+	}
+	return offsets, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (f *franzKafkaReader) handleLag(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(f.partitionState.snapshot())
+}
+
+func (f *franzKafkaReader) handleSeek(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := parseKafkaSeekRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case f.seekChan <- req:
+	case <-r.Context().Done():
+		http.Error(w, "Timed out waiting for the consumer", http.StatusServiceUnavailable)
+		return
+	case <-f.shutSig.CloseAtLeisureChan():
+		http.Error(w, "Input is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+
+	var res kafkaSeekResult
+	select {
+	case res = <-req.resChan:
+	case <-r.Context().Done():
+		http.Error(w, "Timed out waiting for the seek to complete", http.StatusServiceUnavailable)
+		return
+	}
+	if res.err != nil {
+		f.log.Errorf("Failed to seek consumer group: %v", res.err)
+		http.Error(w, res.err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	f.log.Infof("Consumer group seeked to %v", req.to)
+
+	writeSeekOffsets(w, res.offsets)
+}
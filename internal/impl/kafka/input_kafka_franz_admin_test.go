@@ -0,0 +1,113 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzPartitionState(t *testing.T) {
+	s := newFranzPartitionState(nil, nil)
+	s.assign(map[string][]int32{"foo": {0, 1}, "bar": {0}})
+	s.update("foo", 0, 100, 90)
+	s.update("foo", 1, 50, -1)
+	s.update("baz", 0, 100, 90)
+
+	assert.Equal(t, map[string]map[string]franzPartitionStats{
+		"foo": {
+			"0": {HighWatermark: 100, CommittedOffset: 90, Lag: 10},
+			"1": {HighWatermark: 50, CommittedOffset: -1},
+		},
+		"bar": {
+			"0": {HighWatermark: -1, CommittedOffset: -1},
+		},
+	}, s.snapshot())
+
+	assert.Equal(t, map[string][]int32{"bar": {0}}, s.assigned("bar"))
+
+	s.remove(map[string][]int32{"foo": {0, 1}})
+	assert.Equal(t, map[string][]int32{"bar": {0}}, s.assigned(""))
+}
+
+func TestFranzPartitionStateGauges(t *testing.T) {
+	stats := metrics.NewLocal()
+	mgr := service.MockResources(func(m *mock.Manager) {
+		m.M = stats
+	})
+
+	s := newFranzPartitionState(
+		mgr.Metrics().NewGauge("kafka_lag", "topic", "partition"),
+		mgr.Metrics().NewGauge("kafka_high_watermark", "topic", "partition"),
+	)
+	s.assign(map[string][]int32{"foo": {0, 1}})
+	s.update("foo", 0, 100, 90)
+	s.update("foo", 1, 50, 50)
+	s.update("bar", 0, 100, 90)
+
+	assert.Equal(t, map[string]int64{
+		`kafka_lag{partition="0",topic="foo"}`:            10,
+		`kafka_high_watermark{partition="0",topic="foo"}`: 100,
+		`kafka_lag{partition="1",topic="foo"}`:            0,
+		`kafka_high_watermark{partition="1",topic="foo"}`: 50,
+	}, stats.GetCounters())
+
+	// Revoked partitions must not keep reporting their last values.
+	s.remove(map[string][]int32{"foo": {0}})
+	s.update("foo", 0, 200, 90)
+
+	assert.Equal(t, map[string]int64{
+		`kafka_lag{partition="0",topic="foo"}`:            0,
+		`kafka_high_watermark{partition="0",topic="foo"}`: 0,
+		`kafka_lag{partition="1",topic="foo"}`:            0,
+		`kafka_high_watermark{partition="1",topic="foo"}`: 50,
+	}, stats.GetCounters())
+}
+
+func TestFranzAdminHandlers(t *testing.T) {
+	f := &franzKafkaReader{partitionState: newFranzPartitionState(nil, nil)}
+	f.partitionState.assign(map[string][]int32{"foo": {0}})
+	f.partitionState.update("foo", 0, 10, 5)
+
+	w := httptest.NewRecorder()
+	f.handleLag(w, httptest.NewRequest(http.MethodGet, "/lag", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"foo":{"0":{"high_watermark":10,"committed_offset":5,"lag":5}}}`, w.Body.String())
+
+	w = httptest.NewRecorder()
+	f.handleSeek(w, httptest.NewRequest(http.MethodGet, "/seek?to=earliest", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	f.handleSeek(w, httptest.NewRequest(http.MethodPost, "/seek?to=nope", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFranzCheckpointTrackerRemoved(t *testing.T) {
+	c := newCheckpointTracker()
+
+	recA := &kgo.Record{Topic: "foo", Partition: 0, Offset: 10}
+	releaseA, _ := c.addRecord(recA)
+
+	recB := &kgo.Record{Topic: "foo", Partition: 0, Offset: 11}
+	releaseB, pending := c.addRecord(recB)
+	assert.Equal(t, 2, pending)
+
+	assert.Equal(t, recA, releaseA())
+
+	// Once a partition is removed acknowledgements of its prior records must
+	// not result in commits.
+	c.removeTopicPartitions(map[string][]int32{"foo": {0}})
+	assert.Nil(t, releaseB())
+
+	recC := &kgo.Record{Topic: "foo", Partition: 0, Offset: 5}
+	releaseC, pending := c.addRecord(recC)
+	assert.Equal(t, 1, pending)
+	assert.Equal(t, recC, releaseC())
+}
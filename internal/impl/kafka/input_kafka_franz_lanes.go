@@ -0,0 +1,221 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// errLaneRevoked is returned when pushing a message to the lane of a topic
+// partition that has been revoked since the message was consumed.
+var errLaneRevoked = errors.New("topic partition has been revoked")
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type laneID struct {
+	topic     string
+	partition int32
+	group     int
+}
+
+type partitionLane struct {
+	id       laneID
+	queue    []msgWithAckFn
+	inFlight bool
+	isReady  bool
+	removed  bool
+}
+
+// partitionLanes distributes consumed records across ordered lanes, where only
+// one message of each lane is in flight at any given time. Lanes are formed
+// from topic partitions and, optionally, hashed groups of record keys within a
+// partition, which allows messages of different lanes to be processed in
+// parallel whilst messages of the same lane are processed in order.
+//
+// Lanes do not limit the number of messages queued within them, and it is
+// therefore up to the caller to pause the consumption of partitions that have
+// too many pending messages.
+type partitionLanes struct {
+	keyGroups int
+
+	mut     sync.Mutex
+	lanes   map[laneID]*partitionLane
+	ready   []*partitionLane
+	changed chan struct{}
+
+	// Each removal of topic partitions increments the generation, and the
+	// generation of the last removal of each topic partition is recorded so
+	// that pushes of messages consumed prior to it can be rejected.
+	gen     uint64
+	revoked map[topicPartition]uint64
+}
+
+// newPartitionLanes creates lanes for consumed records. When keyGroups is
+// greater than zero the records of each partition are spread across that many
+// lanes by their key.
+func newPartitionLanes(keyGroups int) *partitionLanes {
+	return &partitionLanes{
+		keyGroups: keyGroups,
+		lanes:     map[laneID]*partitionLane{},
+		changed:   make(chan struct{}),
+		revoked:   map[topicPartition]uint64{},
+	}
+}
+
+// broadcast wakes all goroutines waiting on a change in state, must be called
+// with the mutex held.
+func (p *partitionLanes) broadcast() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+func (p *partitionLanes) laneIDFor(record *kgo.Record) laneID {
+	id := laneID{topic: record.Topic, partition: record.Partition}
+	if p.keyGroups > 0 {
+		id.group = keyLane(record.Key, p.keyGroups)
+	}
+	return id
+}
+
+// keyLane returns the lane out of n lanes that messages with a given key are
+// delivered through.
+func keyLane(key []byte, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	return int(h.Sum32() % uint32(n))
+}
+
+// generation returns the current generation of the lanes, which should be
+// obtained after records are consumed and before they are pushed.
+func (p *partitionLanes) generation() uint64 {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return p.gen
+}
+
+// push adds a message to a lane, where gen is the generation obtained after
+// the message was consumed. If the topic partition of the lane has since been
+// removed then errLaneRevoked is returned and the message is not added.
+func (p *partitionLanes) push(id laneID, gen uint64, m msgWithAckFn) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if p.revoked[topicPartition{topic: id.topic, partition: id.partition}] > gen {
+		return errLaneRevoked
+	}
+
+	lane := p.lanes[id]
+	if lane == nil {
+		lane = &partitionLane{id: id}
+		p.lanes[id] = lane
+	}
+	lane.queue = append(lane.queue, m)
+	if !lane.inFlight && !lane.isReady {
+		lane.isReady = true
+		p.ready = append(p.ready, lane)
+		p.broadcast()
+	}
+	return nil
+}
+
+// next blocks until a lane has a message that can be delivered, and returns
+// it. The lane is blocked until the message is acknowledged.
+func (p *partitionLanes) next(ctx context.Context) (msgWithAckFn, error) {
+	p.mut.Lock()
+	for len(p.ready) == 0 {
+		changed := p.changed
+		p.mut.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return msgWithAckFn{}, ctx.Err()
+		}
+		p.mut.Lock()
+	}
+
+	lane := p.ready[0]
+	p.ready[0] = nil
+	p.ready = p.ready[1:]
+
+	m := lane.queue[0]
+	lane.queue[0] = msgWithAckFn{}
+	lane.queue = lane.queue[1:]
+	lane.isReady = false
+	lane.inFlight = true
+
+	p.mut.Unlock()
+
+	onAck := m.onAck
+	m.onAck = func() {
+		onAck()
+		p.release(lane)
+	}
+	return m, nil
+}
+
+func (p *partitionLanes) release(lane *partitionLane) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	lane.inFlight = false
+	if lane.removed {
+		return
+	}
+	if len(lane.queue) > 0 {
+		lane.isReady = true
+		p.ready = append(p.ready, lane)
+	} else {
+		delete(p.lanes, lane.id)
+	}
+	p.broadcast()
+}
+
+// removeTopicPartitions drops the lanes of topic partitions that are no longer
+// assigned to this consumer along with all of their queued messages, which
+// will be consumed again by the new owner of the partition. Messages of these
+// topic partitions consumed prior to the removal are rejected by push.
+func (p *partitionLanes) removeTopicPartitions(m map[string][]int32) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	p.gen++
+	for topic, partitions := range m {
+		for _, partition := range partitions {
+			p.revoked[topicPartition{topic: topic, partition: partition}] = p.gen
+		}
+	}
+
+	removed := false
+	for id, lane := range p.lanes {
+		for _, partition := range m[id.topic] {
+			if partition == id.partition {
+				lane.removed = true
+				lane.queue = nil
+				delete(p.lanes, id)
+				removed = true
+				break
+			}
+		}
+	}
+	if !removed {
+		return
+	}
+
+	ready := p.ready[:0]
+	for _, lane := range p.ready {
+		if !lane.removed {
+			ready = append(ready, lane)
+		}
+	}
+	for i := len(ready); i < len(p.ready); i++ {
+		p.ready[i] = nil
+	}
+	p.ready = ready
+	p.broadcast()
+}
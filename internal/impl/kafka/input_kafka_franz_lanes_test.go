@@ -0,0 +1,162 @@
+package kafka
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testLaneMsg(content string, acked *[]string) msgWithAckFn {
+	return msgWithAckFn{
+		msg: service.NewMessage([]byte(content)),
+		onAck: func() {
+			*acked = append(*acked, content)
+		},
+	}
+}
+
+func testLaneNext(t *testing.T, lanes *partitionLanes) msgWithAckFn {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+
+	m, err := lanes.next(ctx)
+	require.NoError(t, err)
+	return m
+}
+
+func testLaneContent(t *testing.T, m msgWithAckFn) string {
+	t.Helper()
+
+	b, err := m.msg.AsBytes()
+	require.NoError(t, err)
+	return string(b)
+}
+
+func testLaneNextBlocks(t *testing.T, lanes *partitionLanes) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer done()
+
+	_, err := lanes.next(ctx)
+	require.Error(t, err)
+}
+
+func TestPartitionLanesOrdering(t *testing.T) {
+	lanes := newPartitionLanes(0)
+	var acked []string
+	for i := 0; i < 3; i++ {
+		for _, partition := range []int32{0, 1} {
+			id := lanes.laneIDFor(&kgo.Record{Topic: "foo", Partition: partition})
+			content := "p" + strconv.Itoa(int(partition)) + "-" + strconv.Itoa(i)
+			require.NoError(t, lanes.push(id, 0, testLaneMsg(content, &acked)))
+		}
+	}
+
+	// Only the head of each lane can be in flight.
+	a := testLaneNext(t, lanes)
+	b := testLaneNext(t, lanes)
+	assert.Equal(t, "p0-0", testLaneContent(t, a))
+	assert.Equal(t, "p1-0", testLaneContent(t, b))
+	testLaneNextBlocks(t, lanes)
+
+	b.onAck()
+	c := testLaneNext(t, lanes)
+	assert.Equal(t, "p1-1", testLaneContent(t, c))
+	testLaneNextBlocks(t, lanes)
+
+	a.onAck()
+	c.onAck()
+	assert.Equal(t, "p0-1", testLaneContent(t, testLaneNext(t, lanes)))
+	assert.Equal(t, "p1-2", testLaneContent(t, testLaneNext(t, lanes)))
+	assert.Equal(t, []string{"p1-0", "p0-0", "p1-1"}, acked)
+}
+
+func TestPartitionLanesKeyGroups(t *testing.T) {
+	lanes := newPartitionLanes(16)
+
+	fooID := lanes.laneIDFor(&kgo.Record{Topic: "foo", Partition: 0, Key: []byte("foo")})
+	assert.Equal(t, fooID, lanes.laneIDFor(&kgo.Record{Topic: "foo", Partition: 0, Key: []byte("foo")}))
+	assert.NotEqual(t, fooID, lanes.laneIDFor(&kgo.Record{Topic: "foo", Partition: 1, Key: []byte("foo")}))
+
+	var found bool
+	for i := 0; i < 100; i++ {
+		id := lanes.laneIDFor(&kgo.Record{Topic: "foo", Partition: 0, Key: []byte(strconv.Itoa(i))})
+		assert.Less(t, id.group, 16)
+		if id != fooID {
+			found = true
+		}
+	}
+	assert.True(t, found)
+}
+
+func TestPartitionLanesPushDoesNotBlock(t *testing.T) {
+	lanes := newPartitionLanes(0)
+	fooID := laneID{topic: "foo", partition: 0}
+	barID := laneID{topic: "foo", partition: 1}
+
+	var acked []string
+	for i := 0; i < 100; i++ {
+		require.NoError(t, lanes.push(fooID, 0, testLaneMsg("a"+strconv.Itoa(i), &acked)))
+	}
+	require.NoError(t, lanes.push(barID, 0, testLaneMsg("b0", &acked)))
+
+	// A lane with an unacknowledged message and a long queue does not hold up
+	// the messages of other lanes.
+	assert.Equal(t, "a0", testLaneContent(t, testLaneNext(t, lanes)))
+	assert.Equal(t, "b0", testLaneContent(t, testLaneNext(t, lanes)))
+	testLaneNextBlocks(t, lanes)
+}
+
+func TestPartitionLanesRemove(t *testing.T) {
+	lanes := newPartitionLanes(0)
+	var acked []string
+	require.NoError(t, lanes.push(laneID{topic: "foo", partition: 0}, 0, testLaneMsg("a0", &acked)))
+	require.NoError(t, lanes.push(laneID{topic: "foo", partition: 0}, 0, testLaneMsg("a1", &acked)))
+	require.NoError(t, lanes.push(laneID{topic: "foo", partition: 1}, 0, testLaneMsg("b0", &acked)))
+	require.NoError(t, lanes.push(laneID{topic: "bar", partition: 0}, 0, testLaneMsg("c0", &acked)))
+
+	a := testLaneNext(t, lanes)
+	assert.Equal(t, "a0", testLaneContent(t, a))
+
+	lanes.removeTopicPartitions(map[string][]int32{"foo": {0, 1}})
+
+	// The in flight message can still be acknowledged, but queued messages of
+	// the removed partitions are dropped.
+	a.onAck()
+	assert.Equal(t, "c0", testLaneContent(t, testLaneNext(t, lanes)))
+	testLaneNextBlocks(t, lanes)
+	assert.Equal(t, []string{"a0"}, acked)
+}
+
+func TestPartitionLanesRejectRevoked(t *testing.T) {
+	lanes := newPartitionLanes(0)
+	fooID := laneID{topic: "foo", partition: 0}
+	barID := laneID{topic: "foo", partition: 1}
+
+	var acked []string
+	gen := lanes.generation()
+	require.NoError(t, lanes.push(fooID, gen, testLaneMsg("a0", &acked)))
+
+	lanes.removeTopicPartitions(map[string][]int32{"foo": {0}})
+
+	// Messages of the revoked partition consumed before the removal are
+	// rejected, but other partitions are unaffected.
+	assert.ErrorIs(t, lanes.push(fooID, gen, testLaneMsg("a1", &acked)), errLaneRevoked)
+	require.NoError(t, lanes.push(barID, gen, testLaneMsg("b0", &acked)))
+	assert.Equal(t, "b0", testLaneContent(t, testLaneNext(t, lanes)))
+	testLaneNextBlocks(t, lanes)
+
+	// Messages consumed after the partition is assigned again are accepted.
+	require.NoError(t, lanes.push(fooID, lanes.generation(), testLaneMsg("a2", &acked)))
+	assert.Equal(t, "a2", testLaneContent(t, testLaneNext(t, lanes)))
+}
@@ -0,0 +1,57 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func TestFranzRecordToMessage(t *testing.T) {
+	msg := recordToMessage(&kgo.Record{
+		Key:       []byte("foo"),
+		Value:     []byte("hello world"),
+		Topic:     "bar",
+		Partition: 3,
+		Offset:    1234,
+		Timestamp: time.Unix(1668938400, 0),
+		Headers: []kgo.RecordHeader{
+			{Key: "baz", Value: []byte("buz")},
+			{Key: "bin", Value: []byte{0x00, 0xff}},
+		},
+	})
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+
+	meta := map[string]any{}
+	require.NoError(t, msg.MetaWalkMut(func(k string, v any) error {
+		meta[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]any{
+		"kafka_key":            "foo",
+		"kafka_topic":          "bar",
+		"kafka_partition":      int64(3),
+		"kafka_offset":         int64(1234),
+		"kafka_timestamp_unix": int64(1668938400),
+		"kafka_timestamp":      time.Unix(1668938400, 0),
+		"baz":                  []byte("buz"),
+		"bin":                  []byte{0x00, 0xff},
+	}, meta)
+
+	v, exists := msg.MetaGet("baz")
+	require.True(t, exists)
+	assert.Equal(t, "buz", v)
+}
+
+func TestMetaToHeaderValue(t *testing.T) {
+	assert.Equal(t, []byte("foo"), metaToHeaderValue("foo"))
+	assert.Equal(t, []byte{0x00, 0xff}, metaToHeaderValue([]byte{0x00, 0xff}))
+	assert.Equal(t, []byte("1234"), metaToHeaderValue(int64(1234)))
+	assert.Equal(t, []byte("true"), metaToHeaderValue(true))
+	assert.Equal(t, []byte("2022-11-20T10:00:00Z"), metaToHeaderValue(time.Unix(1668938400, 0).UTC()))
+}
@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	kafkaSeekEarliest  = "earliest"
+	kafkaSeekLatest    = "latest"
+	kafkaSeekOffset    = "offset"
+	kafkaSeekTimestamp = "timestamp"
+)
+
+// kafkaSeekRequest describes a request to move the consumer group offsets of
+// the partitions assigned to a consumer.
+type kafkaSeekRequest struct {
+	ctx       context.Context
+	topic     string
+	to        string
+	offset    int64
+	timestamp time.Time
+
+	// The kafka_franz input performs seeks within its consumer loop, which
+	// sends the result on this channel.
+	resChan chan kafkaSeekResult
+}
+
+type kafkaSeekResult struct {
+	offsets map[string]map[int32]int64
+	err     error
+}
+
+func parseKafkaSeekRequest(r *http.Request) (*kafkaSeekRequest, error) {
+	q := r.URL.Query()
+	req := &kafkaSeekRequest{
+		ctx:     r.Context(),
+		topic:   q.Get("topic"),
+		to:      q.Get("to"),
+		resChan: make(chan kafkaSeekResult, 1),
+	}
+
+	var err error
+	switch req.to {
+	case kafkaSeekEarliest, kafkaSeekLatest:
+	case kafkaSeekOffset:
+		if req.offset, err = strconv.ParseInt(q.Get("offset"), 10, 64); err != nil {
+			return nil, fmt.Errorf("failed to parse offset: %w", err)
+		}
+		if req.offset < 0 {
+			return nil, errors.New("offset must not be negative")
+		}
+	case kafkaSeekTimestamp:
+		tsStr := q.Get("timestamp")
+		if req.timestamp, err = time.Parse(time.RFC3339Nano, tsStr); err != nil {
+			ms, perr := strconv.ParseInt(tsStr, 10, 64)
+			if perr != nil {
+				return nil, fmt.Errorf("failed to parse timestamp, expected RFC 3339 or unix milliseconds: %w", err)
+			}
+			req.timestamp = time.UnixMilli(ms)
+		}
+	default:
+		return nil, fmt.Errorf("seek position '%v' not recognised, expected one of: earliest, latest, offset, timestamp", req.to)
+	}
+	return req, nil
+}
+
+// writeSeekOffsets writes the resulting offsets of a seek as a JSON object of
+// topics to partitions to offsets.
+func writeSeekOffsets(w http.ResponseWriter, offsets map[string]map[int32]int64) {
+	body := make(map[string]map[string]int64, len(offsets))
+	for topic, parts := range offsets {
+		tm := make(map[string]int64, len(parts))
+		for p, o := range parts {
+			tm[strconv.Itoa(int(p))] = o
+		}
+		body[topic] = tm
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,48 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKafkaSeekRequest(t *testing.T) {
+	tests := []struct {
+		query       string
+		to          string
+		offset      int64
+		timestamp   time.Time
+		errContains string
+	}{
+		{query: "to=earliest", to: "earliest"},
+		{query: "to=latest&topic=foo", to: "latest"},
+		{query: "to=offset&offset=10", to: "offset", offset: 10},
+		{query: "to=timestamp&timestamp=2022-11-20T10:00:00Z", to: "timestamp", timestamp: time.Unix(1668938400, 0)},
+		{query: "to=timestamp&timestamp=1668938400500", to: "timestamp", timestamp: time.UnixMilli(1668938400500)},
+		{query: "to=offset&offset=nope", errContains: "failed to parse offset"},
+		{query: "to=offset&offset=-1", errContains: "offset must not be negative"},
+		{query: "to=timestamp&timestamp=nope", errContains: "failed to parse timestamp"},
+		{query: "to=nope", errContains: "seek position 'nope' not recognised"},
+		{query: "", errContains: "seek position '' not recognised"},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.query, func(t *testing.T) {
+			req, err := parseKafkaSeekRequest(httptest.NewRequest(http.MethodPost, "/seek?"+test.query, nil))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.to, req.to)
+			assert.Equal(t, test.offset, req.offset)
+			assert.True(t, test.timestamp.Equal(req.timestamp))
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"path"
 	"strconv"
 	"strings"
 	"sync"
@@ -58,10 +59,20 @@ The field ` + "`kafka_lag`" + ` is the calculated difference between the high wa
 
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).
 
+### Metrics
+
+This input emits the gauges ` + "`kafka_lag`" + ` and ` + "`kafka_high_watermark`" + ` labelled by ` + "`topic`" + ` and ` + "`partition`" + `, which report the lag of the consumer and the high water mark offset of each partition as of the latest message consumed.
+
+### Admin Endpoints
+
+When the input has a [label](/docs/components/inputs/about#labels) the endpoint ` + "`POST /kafka/<label>/seek?to=<position>`" + ` is registered with the service wide HTTP server, which is prefixed with the stream identifier when running in streams mode. It moves the consumer group offsets of the partitions currently consumed by the input, where the position is one of ` + "`earliest`" + `, ` + "`latest`" + `, ` + "`offset`" + ` (with the query parameter ` + "`offset`" + `) or ` + "`timestamp`" + ` (with the query parameter ` + "`timestamp`" + ` as an RFC 3339 string or unix milliseconds). The query parameter ` + "`topic`" + ` can be used to limit the seek to a single topic. The new offsets are committed and consumption is then restarted from them, messages that are in flight at the time are not committed once acknowledged, and the resulting offsets are returned as a JSON object. Seeking requires a consumer group, and partitions assigned to other members of the consumer group are not affected.
+
 ### Ordering
 
 By default messages of a topic partition can be processed in parallel, up to a limit determined by the field ` + "`checkpoint_limit`" + `. However, if strict ordered processing is required then this value must be set to 1 in order to process shard messages in lock-step. When doing so it is recommended that you perform batching at this component for performance as it will not be possible to batch lock-stepped messages at the output level.
 
+Alternatively, setting the field ` + "[`ordering`](#ordering)" + ` to ` + "`partition`" + ` gives each partition its own lane, where a message batch is only delivered once the prior batch of the same lane has been acknowledged, and therefore messages of a partition are processed in order whilst messages of different partitions are processed in parallel. When ordering is only required for messages that share a key then ` + "`ordering`" + ` can be set to ` + "`key`" + `, where each batch of a partition is divided into ` + "[`key_lanes`](#key_lanes)" + ` lanes by a hash of the message keys. In both cases offsets are committed for each partition only once all messages of prior offsets have been acknowledged, and up to ` + "`checkpoint_limit`" + ` messages of a partition are queued or in flight at a given time. When a partition is revoked during a rebalance the messages queued for it are dropped so that they can be consumed by its new owner.
+
 ### Troubleshooting
 
 If you're seeing issues writing to or reading from Kafka with this component then it's worth trying out the newer ` + "[`kafka_franz` input](/docs/components/inputs/kafka_franz)" + `.
@@ -93,6 +104,12 @@ Unfortunately this error message will appear for a wide range of connection prob
 			docs.FieldInt(
 				"checkpoint_limit", "The maximum number of messages of the same topic and partition that can be processed at a given time. Increasing this limit enables parallel processing and batching at the output level to work on individual partitions. Any given offset will not be committed unless all messages under that offset are delivered in order to preserve at least once delivery guarantees.",
 			).AtVersion("3.33.0"),
+			docs.FieldString("ordering", "Determines whether messages are delivered in ordered lanes, which allows messages of different lanes to be processed in parallel whilst messages of the same lane are processed in order. Message batches of a lane are only delivered once the prior batch of the lane has been acknowledged.").HasAnnotatedOptions(
+				"none", "Messages are delivered as they are consumed, and messages of the same partition can be processed in parallel up to the `checkpoint_limit`.",
+				"partition", "Each partition is given its own lane, where message batches of a partition are delivered one at a time and in order.",
+				"key", "The messages of each partition are divided into lanes by a hash of their key, where message batches of a lane are delivered one at a time and in order.",
+			).Advanced().AtVersion("4.10.0"),
+			docs.FieldInt("key_lanes", "When `ordering` is set to `key`, the number of lanes that the messages of each partition are divided into.").Advanced().AtVersion("4.10.0"),
 			docs.FieldString("commit_period", "The period of time between each commit of the current partition offsets. Offsets are always committed during shutdown.").Advanced(),
 			docs.FieldString("max_processing_period", "A maximum estimate for the time taken to process a message, this is used for tuning consumer group synchronization.").Advanced(),
 			span.ExtractTracingSpanMappingDocs,
@@ -118,11 +135,21 @@ Unfortunately this error message will appear for a wide range of connection prob
 }
 
 func newKafkaInput(conf input.Config, mgr bundle.NewManagement, log log.Modular, stats metrics.Type) (input.Streamed, error) {
-	var rdr input.Async
-	var err error
-	if rdr, err = newKafkaReader(conf.Kafka, mgr, log); err != nil {
+	kRdr, err := newKafkaReader(conf.Kafka, mgr, log)
+	if err != nil {
 		return nil, err
 	}
+	if label := mgr.Label(); label != "" {
+		mgr.RegisterEndpoint(
+			path.Join("/kafka", label, "seek"),
+			"Moves the consumer group offsets of the partitions consumed by the kafka input.",
+			kRdr.handleSeek,
+		)
+	} else {
+		log.Warnln("The kafka input has no label, and therefore the HTTP endpoint for seeking offsets is not registered")
+	}
+
+	var rdr input.Async = kRdr
 	if conf.Kafka.ExtractTracingMap != "" {
 		if rdr, err = span.NewReader("kafka", conf.Kafka.ExtractTracingMap, rdr, mgr); err != nil {
 			return nil, err
@@ -163,10 +190,20 @@ type kafkaReader struct {
 	msgChan         chan asyncMessage
 	session         offsetMarker
 
+	// Seeks are performed by a func provided by the current connection, and
+	// each seek increments the generation in order for the offsets of messages
+	// consumed prior to it to no longer be marked once acknowledged.
+	seekMut sync.Mutex
+	seekFn  func(req *kafkaSeekRequest) (map[string]map[int32]int64, error)
+	seekGen uint64
+
 	conf input.KafkaConfig
 	log  log.Modular
 	mgr  bundle.NewManagement
 
+	mLag           metrics.StatGaugeVec
+	mHighWatermark metrics.StatGaugeVec
+
 	closeOnce  sync.Once
 	closedChan chan struct{}
 }
@@ -214,6 +251,8 @@ func newKafkaReader(conf input.KafkaConfig, mgr bundle.NewManagement, log log.Mo
 		mgr:             mgr,
 		closedChan:      make(chan struct{}),
 		topicPartitions: map[string][]int32{},
+		mLag:            mgr.Metrics().GetGaugeVec("kafka_lag", "topic", "partition"),
+		mHighWatermark:  mgr.Metrics().GetGaugeVec("kafka_high_watermark", "topic", "partition"),
 	}
 	if conf.TLS.Enabled {
 		var err error
@@ -287,6 +326,15 @@ func newKafkaReader(conf input.KafkaConfig, mgr bundle.NewManagement, log log.Mo
 			return nil, fmt.Errorf("failed to parse max processing period string: %v", err)
 		}
 	}
+	switch conf.Ordering {
+	case "none", "partition":
+	case "key":
+		if conf.KeyLanes < 1 {
+			return nil, errors.New("key_lanes must be greater than zero")
+		}
+	default:
+		return nil, fmt.Errorf("ordering '%v' is not recognised", conf.Ordering)
+	}
 	if conf.ConsumerGroup == "" && len(k.balancedTopics) > 0 {
 		return nil, errors.New("a consumer group must be specified when consuming balanced topics")
 	}
@@ -300,7 +348,7 @@ func newKafkaReader(conf input.KafkaConfig, mgr bundle.NewManagement, log log.Mo
 
 //------------------------------------------------------------------------------
 
-func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(context.Context, chan<- asyncMessage, message.Batch, int64) bool {
+func (k *kafkaReader) asyncCheckpointer(gen uint64, topic string, partition int32) func(context.Context, chan<- asyncMessage, message.Batch, int64) bool {
 	cp := checkpoint.NewCapped(int64(k.conf.CheckpointLimit))
 	return func(ctx context.Context, c chan<- asyncMessage, msg message.Batch, offset int64) bool {
 		if msg == nil {
@@ -317,18 +365,9 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 		case c <- asyncMessage{
 			msg: msg,
 			ackFn: func(ctx context.Context, res error) error {
-				maxOffset := resolveFn()
-				if maxOffset == nil {
-					return nil
-				}
-				k.cMut.Lock()
-				if k.session != nil {
-					k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
-					k.session.MarkOffset(topic, partition, maxOffset.(int64), "")
-				} else {
-					k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
+				if maxOffset := resolveFn(); maxOffset != nil {
+					k.markOffset(gen, topic, partition, maxOffset.(int64))
 				}
-				k.cMut.Unlock()
 				return nil
 			},
 		}:
@@ -339,7 +378,23 @@ func (k *kafkaReader) asyncCheckpointer(topic string, partition int32) func(cont
 	}
 }
 
-func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(context.Context, chan<- asyncMessage, message.Batch, int64) bool {
+// markOffset marks the offset of a partition to be committed with the current
+// session, if there is one, unless the offsets have been seeked since the
+// message was consumed.
+func (k *kafkaReader) markOffset(gen uint64, topic string, partition int32, offset int64) {
+	k.cMut.Lock()
+	defer k.cMut.Unlock()
+	if gen != k.seekGen {
+		k.log.Debugf("Not marking offset for topic '%v' partition '%v' as it was consumed before a seek.\n", topic, partition)
+	} else if k.session != nil {
+		k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
+		k.session.MarkOffset(topic, partition, offset, "")
+	} else {
+		k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
+	}
+}
+
+func (k *kafkaReader) syncCheckpointer(gen uint64, topic string, partition int32) func(context.Context, chan<- asyncMessage, message.Batch, int64) bool {
 	ackedChan := make(chan error)
 	return func(ctx context.Context, c chan<- asyncMessage, msg message.Batch, offset int64) bool {
 		if msg == nil {
@@ -351,14 +406,7 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 			ackFn: func(ctx context.Context, res error) error {
 				resErr := res
 				if resErr == nil {
-					k.cMut.Lock()
-					if k.session != nil {
-						k.log.Debugf("Marking offset for topic '%v' partition '%v'.\n", topic, partition)
-						k.session.MarkOffset(topic, partition, offset, "")
-					} else {
-						k.log.Debugf("Unable to mark offset for topic '%v' partition '%v'.\n", topic, partition)
-					}
-					k.cMut.Unlock()
+					k.markOffset(gen, topic, partition, offset)
 				}
 				select {
 				case ackedChan <- resErr:
@@ -383,6 +431,16 @@ func (k *kafkaReader) syncCheckpointer(topic string, partition int32) func(conte
 	}
 }
 
+// consumerLag returns the number of messages of a partition that follow a
+// given offset.
+func consumerLag(highWatermark, offset int64) int64 {
+	lag := highWatermark - offset - 1
+	if lag < 0 {
+		lag = 0
+	}
+	return lag
+}
+
 func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part {
 	part := message.NewPart(data.Value)
 
@@ -390,15 +448,12 @@ func dataToPart(highestOffset int64, data *sarama.ConsumerMessage) *message.Part
 		part.MetaSetMut(string(hdr.Key), string(hdr.Value))
 	}
 
-	lag := highestOffset - data.Offset - 1
-	if lag < 0 {
-		lag = 0
-	}
+	lag := consumerLag(highestOffset, data.Offset)
 
 	part.MetaSetMut("kafka_key", string(data.Key))
 	part.MetaSetMut("kafka_partition", int(data.Partition))
 	part.MetaSetMut("kafka_topic", data.Topic)
-	part.MetaSetMut("kafka_offset", int(data.Offset))
+	part.MetaSetMut("kafka_offset", data.Offset)
 	part.MetaSetMut("kafka_lag", lag)
 	part.MetaSetMut("kafka_timestamp_unix", data.Timestamp.Unix())
 
@@ -0,0 +1,94 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Shopify/sarama"
+)
+
+// saramaSeekPartitions returns the partitions of a seek request from those
+// consumed by the input, optionally limited to a single topic.
+func saramaSeekPartitions(consumed map[string][]int32, topic string) (map[string][]int32, error) {
+	partitions := map[string][]int32{}
+	for t, parts := range consumed {
+		if topic != "" && t != topic {
+			continue
+		}
+		if len(parts) > 0 {
+			partitions[t] = append([]int32(nil), parts...)
+		}
+	}
+	if len(partitions) == 0 {
+		return nil, errors.New("no partitions are currently consumed by this input")
+	}
+	return partitions, nil
+}
+
+// saramaSeekOffsets resolves the target offsets of a seek request for each of
+// the provided partitions.
+func saramaSeekOffsets(client sarama.Client, partitions map[string][]int32, req *kafkaSeekRequest) (map[string]map[int32]int64, error) {
+	offsets := map[string]map[int32]int64{}
+	for topic, parts := range partitions {
+		offsets[topic] = map[int32]int64{}
+		for _, p := range parts {
+			var offset int64
+			var err error
+			switch req.to {
+			case kafkaSeekOffset:
+				offset = req.offset
+			case kafkaSeekEarliest:
+				offset, err = client.GetOffset(topic, p, sarama.OffsetOldest)
+			case kafkaSeekLatest:
+				offset, err = client.GetOffset(topic, p, sarama.OffsetNewest)
+			case kafkaSeekTimestamp:
+				// Partitions without any records at or after the timestamp are
+				// moved to the end of the partition.
+				if offset, err = client.GetOffset(topic, p, req.timestamp.UnixMilli()); err == nil && offset < 0 {
+					offset, err = client.GetOffset(topic, p, sarama.OffsetNewest)
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to get offset of topic %v partition %v: %w", topic, p, err)
+			}
+			offsets[topic][p] = offset
+		}
+	}
+	return offsets, nil
+}
+
+func (k *kafkaReader) handleSeek(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	req, err := parseKafkaSeekRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Seeks are performed one at a time, as each one restarts consumption.
+	k.seekMut.Lock()
+	defer k.seekMut.Unlock()
+
+	k.cMut.Lock()
+	seekFn := k.seekFn
+	k.cMut.Unlock()
+	if seekFn == nil {
+		http.Error(w, "Input is not connected", http.StatusServiceUnavailable)
+		return
+	}
+
+	offsets, err := seekFn(req)
+	if err != nil {
+		k.log.Errorf("Failed to seek consumer group: %v\n", err)
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	k.log.Infof("Consumer group seeked to %v\n", req.to)
+	writeSeekOffsets(w, offsets)
+}
@@ -0,0 +1,159 @@
+package kafka
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestSaramaSeekPartitions(t *testing.T) {
+	consumed := map[string][]int32{"foo": {0, 1}, "bar": {2}, "baz": {}}
+
+	partitions, err := saramaSeekPartitions(consumed, "")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int32{"foo": {0, 1}, "bar": {2}}, partitions)
+
+	partitions, err = saramaSeekPartitions(consumed, "bar")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int32{"bar": {2}}, partitions)
+
+	_, err = saramaSeekPartitions(consumed, "baz")
+	require.Error(t, err)
+
+	_, err = saramaSeekPartitions(nil, "")
+	require.Error(t, err)
+}
+
+func TestSaramaSeekOffsets(t *testing.T) {
+	broker := sarama.NewMockBroker(t, 1)
+	defer broker.Close()
+
+	tsWithRecords := time.UnixMilli(1668938400000)
+	tsWithoutRecords := time.UnixMilli(1668938500000)
+
+	broker.SetHandlerByMap(map[string]sarama.MockResponse{
+		"MetadataRequest": sarama.NewMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("foo", 0, broker.BrokerID()).
+			SetLeader("foo", 1, broker.BrokerID()),
+		"OffsetRequest": sarama.NewMockOffsetResponse(t).
+			SetVersion(1).
+			SetOffset("foo", 0, sarama.OffsetOldest, 10).
+			SetOffset("foo", 0, sarama.OffsetNewest, 100).
+			SetOffset("foo", 0, tsWithRecords.UnixMilli(), 50).
+			SetOffset("foo", 0, tsWithoutRecords.UnixMilli(), -1).
+			SetOffset("foo", 1, sarama.OffsetOldest, 20).
+			SetOffset("foo", 1, sarama.OffsetNewest, 200).
+			SetOffset("foo", 1, tsWithRecords.UnixMilli(), -1),
+	})
+
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_0_0_0
+	client, err := sarama.NewClient([]string{broker.Addr()}, config)
+	require.NoError(t, err)
+	defer client.Close()
+
+	partitions := map[string][]int32{"foo": {0, 1}}
+	tests := []struct {
+		name     string
+		req      kafkaSeekRequest
+		expected map[string]map[int32]int64
+	}{
+		{
+			name:     "offset",
+			req:      kafkaSeekRequest{to: kafkaSeekOffset, offset: 5},
+			expected: map[string]map[int32]int64{"foo": {0: 5, 1: 5}},
+		},
+		{
+			name:     "earliest",
+			req:      kafkaSeekRequest{to: kafkaSeekEarliest},
+			expected: map[string]map[int32]int64{"foo": {0: 10, 1: 20}},
+		},
+		{
+			name:     "latest",
+			req:      kafkaSeekRequest{to: kafkaSeekLatest},
+			expected: map[string]map[int32]int64{"foo": {0: 100, 1: 200}},
+		},
+		{
+			name:     "timestamp",
+			req:      kafkaSeekRequest{to: kafkaSeekTimestamp, timestamp: tsWithRecords},
+			expected: map[string]map[int32]int64{"foo": {0: 50, 1: 200}},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			offsets, err := saramaSeekOffsets(client, partitions, &test.req)
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, offsets)
+		})
+	}
+
+	offsets, err := saramaSeekOffsets(client, map[string][]int32{"foo": {0}}, &kafkaSeekRequest{
+		to: kafkaSeekTimestamp, timestamp: tsWithoutRecords,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[int32]int64{"foo": {0: 100}}, offsets)
+}
+
+func TestSaramaSeekHandler(t *testing.T) {
+	k := &kafkaReader{log: log.Noop()}
+
+	w := httptest.NewRecorder()
+	k.handleSeek(w, httptest.NewRequest(http.MethodGet, "/seek?to=earliest", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	w = httptest.NewRecorder()
+	k.handleSeek(w, httptest.NewRequest(http.MethodPost, "/seek?to=nope", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	k.handleSeek(w, httptest.NewRequest(http.MethodPost, "/seek?to=earliest", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var seekReq *kafkaSeekRequest
+	k.seekFn = func(req *kafkaSeekRequest) (map[string]map[int32]int64, error) {
+		seekReq = req
+		return map[string]map[int32]int64{"foo": {0: 5}}, nil
+	}
+
+	w = httptest.NewRecorder()
+	k.handleSeek(w, httptest.NewRequest(http.MethodPost, "/seek?to=offset&offset=5&topic=foo", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"foo":{"0":5}}`, w.Body.String())
+	require.NotNil(t, seekReq)
+	assert.Equal(t, "foo", seekReq.topic)
+	assert.Equal(t, int64(5), seekReq.offset)
+
+	k.seekFn = func(req *kafkaSeekRequest) (map[string]map[int32]int64, error) {
+		return nil, errors.New("nope")
+	}
+
+	w = httptest.NewRecorder()
+	k.handleSeek(w, httptest.NewRequest(http.MethodPost, "/seek?to=latest", nil))
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), "nope")
+}
+
+func TestSaramaMarkOffsetAfterSeek(t *testing.T) {
+	marker := &testOffsetMarker{}
+	k := &kafkaReader{log: log.Noop(), session: marker}
+
+	k.markOffset(0, "foo", 0, 10)
+
+	// Messages consumed before a seek must not move the offsets once acked.
+	k.seekGen++
+	k.markOffset(0, "foo", 0, 11)
+	k.markOffset(1, "foo", 0, 5)
+
+	assert.Equal(t, []int64{10, 5}, marker.get())
+}
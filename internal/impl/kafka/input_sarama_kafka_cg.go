@@ -2,13 +2,14 @@ package kafka
 
 import (
 	"context"
+	"errors"
 	"io"
+	"strconv"
 	"time"
 
 	"github.com/Shopify/sarama"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
-	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 // Setup is run at the beginning of a new session, before ConsumeClaim.
@@ -47,13 +48,17 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 	}
 	defer batchPolicy.Close(context.Background())
 
+	// Lanes of the claim are stopped once the claim is no longer consumed,
+	// dropping any messages queued within them.
+	claimCtx, claimDone := context.WithCancel(sess.Context())
+	defer claimDone()
+
 	var nextTimedBatchChan <-chan time.Time
-	var flushBatch func(context.Context, chan<- asyncMessage, message.Batch, int64) bool
-	if k.conf.CheckpointLimit > 1 {
-		flushBatch = k.asyncCheckpointer(claim.Topic(), claim.Partition())
-	} else {
-		flushBatch = k.syncCheckpointer(claim.Topic(), claim.Partition())
-	}
+	flushBatch := k.newCheckpointer(claimCtx, k.msgChan, topic, partition)
+
+	partStr := strconv.Itoa(int(partition))
+	mLag := k.mLag.With(topic, partStr)
+	mHighWatermark := k.mHighWatermark.With(topic, partStr)
 
 	for {
 		if nextTimedBatchChan == nil {
@@ -73,7 +78,11 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 			}
 
 			latestOffset = data.Offset
-			part := dataToPart(claim.HighWaterMarkOffset(), data)
+			highWatermark := claim.HighWaterMarkOffset()
+			part := dataToPart(highWatermark, data)
+
+			mHighWatermark.Set(highWatermark)
+			mLag.Set(consumerLag(highWatermark, data.Offset))
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -90,9 +99,16 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 //------------------------------------------------------------------------------
 
 func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.Config) error {
+	// The client is also used for resolving the offsets of seeks.
+	client, err := sarama.NewClient(k.addresses, config)
+	if err != nil {
+		return err
+	}
+
 	// Start a new consumer group
-	group, err := sarama.NewConsumerGroup(k.addresses, k.conf.ConsumerGroup, config)
+	group, err := sarama.NewConsumerGroupFromClient(k.conf.ConsumerGroup, client)
 	if err != nil {
+		client.Close()
 		return err
 	}
 
@@ -115,25 +131,30 @@ func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.
 		}
 	}()
 
+	// Sessions are restarted after a seek in order for the claims of the new
+	// session to consume from the committed offsets.
+	var restartSessionFn context.CancelFunc
+
 	consumerDoneCtx, finishedFn := context.WithCancel(context.Background())
+	groupCtx, groupDoneFn := context.WithCancel(context.Background())
 	go func() {
 		defer finishedFn()
 	groupLoop:
 		for {
-			ctx, doneFn := context.WithCancel(context.Background())
+			sessCtx, sessDoneFn := context.WithCancel(groupCtx)
 
 			k.cMut.Lock()
-			k.consumerCloseFn = doneFn
+			restartSessionFn = sessDoneFn
 			k.cMut.Unlock()
 
 			k.log.Debugln("Starting consumer group")
-			gerr := group.Consume(ctx, k.balancedTopics, k)
+			gerr := group.Consume(sessCtx, k.balancedTopics, k)
 			select {
-			case <-ctx.Done():
+			case <-groupCtx.Done():
 				break groupLoop
 			default:
 			}
-			doneFn()
+			sessDoneFn()
 			if gerr != nil {
 				if gerr != io.EOF {
 					k.log.Errorf("Kafka group session error: %v\n", gerr)
@@ -144,17 +165,54 @@ func (k *kafkaReader) connectBalancedTopics(ctx context.Context, config *sarama.
 		k.log.Debugln("Closing consumer group")
 
 		group.Close()
+		client.Close()
 
 		k.cMut.Lock()
 		if k.msgChan != nil {
 			close(k.msgChan)
 			k.msgChan = nil
 		}
+		k.seekFn = nil
 		k.cMut.Unlock()
 	}()
 
 	k.msgChan = make(chan asyncMessage)
+	k.consumerCloseFn = groupDoneFn
 	k.consumerDoneCtx = consumerDoneCtx
+	k.seekFn = func(req *kafkaSeekRequest) (map[string]map[int32]int64, error) {
+		k.cMut.Lock()
+		sess, _ := k.session.(sarama.ConsumerGroupSession)
+		k.cMut.Unlock()
+		if sess == nil {
+			return nil, errors.New("the consumer group does not have an active session")
+		}
+
+		partitions, err := saramaSeekPartitions(sess.Claims(), req.topic)
+		if err != nil {
+			return nil, err
+		}
+		offsets, err := saramaSeekOffsets(client, partitions, req)
+		if err != nil {
+			return nil, err
+		}
+
+		k.cMut.Lock()
+		k.seekGen++
+		for topic, parts := range offsets {
+			for p, o := range parts {
+				// Resetting only moves offsets backwards and marking only
+				// moves them forwards, and therefore both are needed.
+				sess.ResetOffset(topic, p, o, "")
+				sess.MarkOffset(topic, p, o, "")
+			}
+		}
+		restartFn := restartSessionFn
+		k.cMut.Unlock()
+
+		sess.Commit()
+		restartFn()
+		return offsets, nil
+	}
 	k.log.Infof("Consuming kafka topics %v from brokers %s as group '%v'\n", k.balancedTopics, k.addresses, k.conf.ConsumerGroup)
 	return nil
 }
@@ -0,0 +1,135 @@
+package kafka
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// saramaLane delivers the batches of an ordered lane of a partition, where a
+// batch is only delivered once the prior batch of the lane has been
+// acknowledged.
+type saramaLane struct {
+	batches chan asyncMessage
+	acked   chan struct{}
+}
+
+func (l *saramaLane) run(ctx context.Context, c chan<- asyncMessage) {
+	for {
+		var m asyncMessage
+		select {
+		case m = <-l.batches:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case c <- m:
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-l.acked:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// newCheckpointer returns a function for flushing the batches of a partition
+// according to the configured ordering and checkpoint limit. Ordered lanes
+// are stopped when the provided context is cancelled, which must happen once
+// the partition is no longer consumed.
+func (k *kafkaReader) newCheckpointer(ctx context.Context, c chan<- asyncMessage, topic string, partition int32) func(context.Context, chan<- asyncMessage, message.Batch, int64) bool {
+	k.cMut.Lock()
+	gen := k.seekGen
+	k.cMut.Unlock()
+
+	switch {
+	case k.conf.Ordering != "none":
+		return k.laneCheckpointer(ctx, c, gen, topic, partition)
+	case k.conf.CheckpointLimit > 1:
+		return k.asyncCheckpointer(gen, topic, partition)
+	}
+	return k.syncCheckpointer(gen, topic, partition)
+}
+
+// laneCheckpointer returns a function for flushing the batches of a partition
+// through ordered lanes. When ordering by key each flushed batch is divided
+// into a batch for each lane by the keys of its messages, otherwise the
+// partition has a single lane. Offsets are committed once the messages of a
+// flushed batch and all prior batches have been acknowledged.
+func (k *kafkaReader) laneCheckpointer(ctx context.Context, c chan<- asyncMessage, gen uint64, topic string, partition int32) func(context.Context, chan<- asyncMessage, message.Batch, int64) bool {
+	numLanes := 1
+	if k.conf.Ordering == "key" {
+		numLanes = k.conf.KeyLanes
+	}
+
+	lanes := make([]*saramaLane, numLanes)
+	for i := range lanes {
+		lanes[i] = &saramaLane{
+			batches: make(chan asyncMessage, k.conf.CheckpointLimit),
+			acked:   make(chan struct{}, 1),
+		}
+		go lanes[i].run(ctx, c)
+	}
+
+	cp := checkpoint.NewCapped(int64(k.conf.CheckpointLimit))
+	return func(ctx context.Context, _ chan<- asyncMessage, msg message.Batch, offset int64) bool {
+		if msg == nil {
+			return true
+		}
+		resolveFn, err := cp.Track(ctx, offset, int64(msg.Len()))
+		if err != nil {
+			if err != component.ErrTimeout {
+				k.log.Errorf("Failed to checkpoint offset: %v\n", err)
+			}
+			return false
+		}
+
+		laneBatches := make([]message.Batch, numLanes)
+		for _, part := range msg {
+			i := 0
+			if numLanes > 1 {
+				i = keyLane([]byte(part.MetaGetStr("kafka_key")), numLanes)
+			}
+			laneBatches[i] = append(laneBatches[i], part)
+		}
+
+		var remaining int64
+		for _, b := range laneBatches {
+			if len(b) > 0 {
+				remaining++
+			}
+		}
+
+		for i, b := range laneBatches {
+			if len(b) == 0 {
+				continue
+			}
+			lane := lanes[i]
+			select {
+			case lane.batches <- asyncMessage{
+				msg: b,
+				ackFn: func(ctx context.Context, res error) error {
+					if atomic.AddInt64(&remaining, -1) == 0 {
+						if maxOffset := resolveFn(); maxOffset != nil {
+							k.markOffset(gen, topic, partition, maxOffset.(int64))
+						}
+					}
+					select {
+					case lane.acked <- struct{}{}:
+					default:
+					}
+					return nil
+				},
+			}:
+			case <-ctx.Done():
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,165 @@
+package kafka
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type testOffsetMarker struct {
+	mut     sync.Mutex
+	offsets []int64
+}
+
+func (t *testOffsetMarker) MarkOffset(topic string, partition int32, offset int64, metadata string) {
+	t.mut.Lock()
+	t.offsets = append(t.offsets, offset)
+	t.mut.Unlock()
+}
+
+func (t *testOffsetMarker) get() []int64 {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return append([]int64(nil), t.offsets...)
+}
+
+func testSaramaLaneBatch(keys ...string) message.Batch {
+	var b message.Batch
+	for _, k := range keys {
+		p := message.NewPart([]byte(k))
+		p.MetaSetMut("kafka_key", k)
+		b = append(b, p)
+	}
+	return b
+}
+
+func testSaramaLaneRecv(t *testing.T, c <-chan asyncMessage) asyncMessage {
+	t.Helper()
+	select {
+	case m := <-c:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+	return asyncMessage{}
+}
+
+func testSaramaLaneNoRecv(t *testing.T, c <-chan asyncMessage) {
+	t.Helper()
+	select {
+	case m := <-c:
+		t.Fatalf("unexpected message: %s", m.msg.Get(0).AsBytes())
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func testSaramaLaneReader(ordering string) (*kafkaReader, *testOffsetMarker) {
+	conf := input.NewKafkaConfig()
+	conf.Ordering = ordering
+	conf.KeyLanes = 2
+
+	marker := &testOffsetMarker{}
+	return &kafkaReader{
+		conf:    conf,
+		log:     log.Noop(),
+		session: marker,
+	}, marker
+}
+
+func TestSaramaLanesPartitionOrdering(t *testing.T) {
+	k, marker := testSaramaLaneReader("partition")
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	c := make(chan asyncMessage)
+	flush := k.newCheckpointer(ctx, c, "foo", 0)
+
+	require.True(t, flush(ctx, c, testSaramaLaneBatch("a", "b"), 2))
+	require.True(t, flush(ctx, c, testSaramaLaneBatch("c"), 3))
+
+	// The second batch is only delivered once the first is acknowledged.
+	first := testSaramaLaneRecv(t, c)
+	assert.Equal(t, 2, first.msg.Len())
+	testSaramaLaneNoRecv(t, c)
+
+	require.NoError(t, first.ackFn(ctx, nil))
+	second := testSaramaLaneRecv(t, c)
+	assert.Equal(t, "c", string(second.msg.Get(0).AsBytes()))
+
+	require.NoError(t, second.ackFn(ctx, nil))
+	assert.Equal(t, []int64{2, 3}, marker.get())
+}
+
+func TestSaramaLanesKeyOrdering(t *testing.T) {
+	k, marker := testSaramaLaneReader("key")
+
+	// Find keys that belong to different lanes.
+	keyA, keyB := "a", ""
+	for _, k := range []string{"b", "c", "d", "e", "f", "g"} {
+		if keyLane([]byte(k), 2) != keyLane([]byte(keyA), 2) {
+			keyB = k
+			break
+		}
+	}
+	require.NotEmpty(t, keyB)
+
+	ctx, done := context.WithCancel(context.Background())
+	defer done()
+
+	c := make(chan asyncMessage)
+	flush := k.newCheckpointer(ctx, c, "foo", 0)
+
+	require.True(t, flush(ctx, c, testSaramaLaneBatch(keyA, keyB, keyA), 3))
+	require.True(t, flush(ctx, c, testSaramaLaneBatch(keyB), 4))
+
+	// Each lane delivers its part of the first batch in parallel.
+	received := map[string]asyncMessage{}
+	for i := 0; i < 2; i++ {
+		m := testSaramaLaneRecv(t, c)
+		received[string(m.msg.Get(0).AsBytes())] = m
+	}
+	require.Contains(t, received, keyA)
+	require.Contains(t, received, keyB)
+	assert.Equal(t, 2, received[keyA].msg.Len())
+	testSaramaLaneNoRecv(t, c)
+
+	// Acknowledging lane B delivers its next batch, but the offset is not
+	// committed until lane A has also acknowledged the first batch.
+	require.NoError(t, received[keyB].ackFn(ctx, nil))
+	next := testSaramaLaneRecv(t, c)
+	assert.Equal(t, keyB, string(next.msg.Get(0).AsBytes()))
+	assert.Empty(t, marker.get())
+
+	require.NoError(t, received[keyA].ackFn(ctx, nil))
+	assert.Equal(t, []int64{3}, marker.get())
+
+	require.NoError(t, next.ackFn(ctx, nil))
+	assert.Equal(t, []int64{3, 4}, marker.get())
+}
+
+func TestSaramaLanesStopped(t *testing.T) {
+	k, _ := testSaramaLaneReader("partition")
+
+	ctx, done := context.WithCancel(context.Background())
+
+	c := make(chan asyncMessage)
+	flush := k.newCheckpointer(ctx, c, "foo", 0)
+
+	require.True(t, flush(ctx, c, testSaramaLaneBatch("a"), 1))
+	require.True(t, flush(ctx, c, testSaramaLaneBatch("b"), 2))
+	first := testSaramaLaneRecv(t, c)
+
+	// Once the partition is no longer consumed queued batches are dropped.
+	done()
+	require.NoError(t, first.ackFn(context.Background(), nil))
+	testSaramaLaneNoRecv(t, c)
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -13,7 +14,6 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
-	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 type closureOffsetTracker struct {
@@ -46,16 +46,18 @@ func (k *kafkaReader) runPartitionConsumer(
 	}
 	defer batchPolicy.Close(context.Background())
 
+	partCtx, partDone := context.WithCancel(ctx)
+	defer partDone()
+
 	var nextTimedBatchChan <-chan time.Time
-	var flushBatch func(context.Context, chan<- asyncMessage, message.Batch, int64) bool
-	if k.conf.CheckpointLimit > 1 {
-		flushBatch = k.asyncCheckpointer(topic, partition)
-	} else {
-		flushBatch = k.syncCheckpointer(topic, partition)
-	}
+	flushBatch := k.newCheckpointer(partCtx, k.msgChan, topic, partition)
 
 	var latestOffset int64
 
+	partStr := strconv.Itoa(int(partition))
+	mLag := k.mLag.With(topic, partStr)
+	mHighWatermark := k.mHighWatermark.With(topic, partStr)
+
 partMsgLoop:
 	for {
 		if nextTimedBatchChan == nil {
@@ -76,7 +78,11 @@ partMsgLoop:
 			k.log.Tracef("Received message from topic %v partition %v\n", topic, partition)
 
 			latestOffset = data.Offset
-			part := dataToPart(consumer.HighWaterMarkOffset(), data)
+			highWatermark := consumer.HighWaterMarkOffset()
+			part := dataToPart(highWatermark, data)
+
+			mHighWatermark.Set(highWatermark)
+			mLag.Set(consumerLag(highWatermark, data.Offset))
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
@@ -195,7 +201,7 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 	partConsumers := []sarama.PartitionConsumer{}
 	consumerWG := sync.WaitGroup{}
 	msgChan := make(chan asyncMessage)
-	ctx, doneFn := context.WithCancel(context.Background())
+	ctx, stopConsumersFn := context.WithCancel(context.Background())
 
 	for topic, partitions := range k.topicPartitions {
 		for _, partition := range partitions {
@@ -229,7 +235,7 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 					k.log.Warnf("Failed to read from stored offset, restarting from newest offset: %v\n", err)
 				}
 				if partConsumer, err = consumer.ConsumePartition(topic, partition, offset); err != nil {
-					doneFn()
+					stopConsumersFn()
 					return fmt.Errorf("failed to consume topic %v partition %v: %v", topic, partition, err)
 				}
 			}
@@ -272,6 +278,7 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 			close(k.msgChan)
 			k.msgChan = nil
 		}
+		k.seekFn = nil
 		k.cMut.Unlock()
 
 		if coordinator != nil {
@@ -284,5 +291,48 @@ func (k *kafkaReader) connectExplicitTopics(ctx context.Context, config *sarama.
 	k.consumerDoneCtx = doneCtx
 	k.session = offsetTracker
 	k.msgChan = msgChan
+	k.seekFn = func(req *kafkaSeekRequest) (map[string]map[int32]int64, error) {
+		if coordinator == nil {
+			return nil, errors.New("a consumer group is required in order to seek offsets")
+		}
+
+		partitions, err := saramaSeekPartitions(k.topicPartitions, req.topic)
+		if err != nil {
+			return nil, err
+		}
+		offsets, err := saramaSeekOffsets(client, partitions, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Offsets marked prior to the seek are dropped rather than committed.
+		k.cMut.Lock()
+		k.seekGen++
+		offsetPutReq = k.offsetPartitionPutRequest(k.conf.ConsumerGroup)
+		k.cMut.Unlock()
+
+		seekReq := k.offsetPartitionPutRequest(k.conf.ConsumerGroup)
+		for topic, parts := range offsets {
+			for p, o := range parts {
+				seekReq.AddBlock(topic, p, o, time.Now().Unix(), "")
+			}
+		}
+		res, err := coordinator.CommitOffset(seekReq)
+		if err != nil {
+			return nil, fmt.Errorf("failed to commit offsets: %w", err)
+		}
+		for topic, parts := range res.Errors {
+			for p, kerr := range parts {
+				if kerr != sarama.ErrNoError {
+					return nil, fmt.Errorf("failed to commit offset of topic %v partition %v: %w", topic, p, kerr)
+				}
+			}
+		}
+
+		// The partition consumers are closed, and as the input reconnects
+		// they're restarted from the committed offsets.
+		stopConsumersFn()
+		return offsets, nil
+	}
 	return nil
 }
@@ -12,9 +12,10 @@ import (
 
 func TestKafkaBadParams(t *testing.T) {
 	testCases := []struct {
-		name   string
-		topics []string
-		errStr string
+		name     string
+		topics   []string
+		ordering string
+		errStr   string
 	}{
 		{
 			name:   "mixing consumer types",
@@ -31,6 +32,12 @@ func TestKafkaBadParams(t *testing.T) {
 			topics: []string{"foo:1-2-3"},
 			errStr: "partition '1-2-3' is invalid, only one range can be specified",
 		},
+		{
+			name:     "bad ordering",
+			topics:   []string{"foo"},
+			ordering: "nope",
+			errStr:   "ordering 'nope' is not recognised",
+		},
 	}
 
 	for _, test := range testCases {
@@ -40,6 +47,9 @@ func TestKafkaBadParams(t *testing.T) {
 			conf.Type = "kafka"
 			conf.Kafka.Addresses = []string{"example.com:1234"}
 			conf.Kafka.Topics = test.topics
+			if test.ordering != "" {
+				conf.Kafka.Ordering = test.ordering
+			}
 
 			_, err := mock.NewManager().NewInput(conf)
 			require.Error(t, err)
@@ -3,8 +3,10 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +31,14 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 - You like shiny new stuff
 - You are experiencing issues with the existing ` + "`kafka`" + ` output
 - Someone told you to
+
+### Topic Creation
+
+By default topics are created by the brokers when they are first written to if the brokers allow automatic topic creation, in which case they are given the default settings of the brokers. Alternatively, the field ` + "[`create_topics.enabled`](#create_topicsenabled)" + ` can be set in order for this output to create topics that do not yet exist before writing to them, with an explicit number of partitions, replication factor and topic configs, which is useful when topics are derived dynamically from messages.
+
+### Metrics
+
+This output emits the counters ` + "`kafka_produce_sent`" + ` and ` + "`kafka_produce_error`" + ` along with the timer ` + "`kafka_produce_latency_ns`" + `, all of which are labelled by ` + "`topic`" + `.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -43,9 +53,17 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 			"murmur2_hash": "Kafka's default hash algorithm that uses a 32-bit murmur2 hash of the key to compute which partition the record will be on.",
 			"round_robin":  "Round-robin's messages through all available partitions. This algorithm has lower throughput and causes higher CPU load on brokers, but can be useful if you want to ensure an even distribution of records to partitions.",
 			"least_backup": "Chooses the least backed up partition (the partition with the fewest amount of buffered records). Partitions are selected per batch.",
+			"manual":       "Manually select a partition for each message, requires the field `partition` to be specified.",
 		}).
 			Description("Override the default murmur2 hashing partitioner.").
 			Advanced().Optional()).
+		Field(service.NewInterpolatedStringField("partition").
+			Description("An optional explicit partition to set for each message. This field is only relevant when the `partitioner` is set to `manual`. The provided interpolation string must be a valid integer.").
+			Example(`${! meta("partition") }`).
+			Example(`${! json("tenant_id") % 10 }`).
+			Optional().
+			Advanced().
+			Version("4.10.0")).
 		Field(service.NewMetadataFilterField("metadata").
 			Description("Determine which (if any) metadata values should be added to messages as headers.").
 			Optional()).
@@ -67,6 +85,24 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
 			Advanced()).
+		Field(service.NewObjectField("create_topics",
+			service.NewBoolField("enabled").
+				Description("Whether topics that do not exist should be created before messages are written to them.").
+				Default(false),
+			service.NewIntField("partitions").
+				Description("The number of partitions of created topics, or -1 to use the default of the brokers.").
+				Default(-1),
+			service.NewIntField("replication_factor").
+				Description("The replication factor of created topics, or -1 to use the default of the brokers.").
+				Default(-1),
+			service.NewStringMapField("configs").
+				Description("A map of topic configs to set for created topics.").
+				Example(map[string]any{"retention.ms": "86400000", "cleanup.policy": "compact"}).
+				Default(map[string]any{}),
+		).
+			Description("Determines whether and how topics are created by this output before messages are written to them. Topics are created at most once for the lifetime of a connection, and topics that already exist are left unchanged.").
+			Advanced().
+			Version("4.10.0")).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField())
 }
@@ -85,7 +121,7 @@ func init() {
 			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
 				return
 			}
-			output, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger())
+			output, err = newFranzKafkaWriterFromConfig(conf, mgr)
 			return
 		})
 	if err != nil {
@@ -100,6 +136,7 @@ type franzKafkaWriter struct {
 	topicStr         string
 	topic            *service.InterpolatedString
 	key              *service.InterpolatedString
+	partition        *service.InterpolatedString
 	tlsConf          *tls.Config
 	saslConfs        []sasl.Mechanism
 	metaFilter       *service.MetadataFilter
@@ -107,15 +144,23 @@ type franzKafkaWriter struct {
 	timeout          time.Duration
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
+	createTopics     *franzTopicCreator
 
 	client *kgo.Client
 
+	mSent    *service.MetricCounter
+	mError   *service.MetricCounter
+	mLatency *service.MetricTimer
+
 	log *service.Logger
 }
 
-func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*franzKafkaWriter, error) {
+func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*franzKafkaWriter, error) {
 	f := franzKafkaWriter{
-		log: log,
+		mSent:    mgr.Metrics().NewCounter("kafka_produce_sent", "topic"),
+		mError:   mgr.Metrics().NewCounter("kafka_produce_error", "topic"),
+		mLatency: mgr.Metrics().NewTimer("kafka_produce_latency_ns", "topic"),
+		log:      mgr.Logger(),
 	}
 
 	brokerList, err := conf.FieldStringList("seed_brokers")
@@ -191,11 +236,32 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 			f.partitioner = kgo.RoundRobinPartitioner()
 		case "least_backup":
 			f.partitioner = kgo.LeastBackupPartitioner()
+		case "manual":
+			f.partitioner = kgo.ManualPartitioner()
 		default:
 			return nil, fmt.Errorf("unknown partitioner: %v", partStr)
 		}
 	}
 
+	if conf.Contains("partition") {
+		if rawStr, _ := conf.FieldString("partition"); rawStr != "" {
+			if f.partition, err = conf.FieldInterpolatedString("partition"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	partStr, _ := conf.FieldString("partitioner")
+	if partStr == "manual" && f.partition == nil {
+		return nil, errors.New("partition field required for 'manual' partitioner")
+	}
+	if partStr != "manual" && f.partition != nil {
+		return nil, errors.New("partition field can only be specified for 'manual' partitioner")
+	}
+
+	if f.createTopics, err = franzTopicCreatorFromConfig(conf.Namespace("create_topics")); err != nil {
+		return nil, err
+	}
+
 	if conf.Contains("metadata") {
 		if f.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
 			return nil, err
@@ -265,19 +331,57 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		if f.key != nil {
 			record.Key = b.InterpolatedBytes(i, f.key)
 		}
-		_ = f.metaFilter.Walk(msg, func(key, value string) error {
+		if f.partition != nil {
+			partStr := b.InterpolatedString(i, f.partition)
+			if partStr == "" {
+				return errors.New("partition expression failed to produce a value")
+			}
+			partInt, perr := strconv.ParseInt(partStr, 10, 32)
+			if perr != nil {
+				return fmt.Errorf("failed to parse valid integer from partition expression: %w", perr)
+			}
+			if partInt < 0 {
+				return fmt.Errorf("invalid partition parsed from expression, must be >= 0, got %v", partInt)
+			}
+			record.Partition = int32(partInt)
+		}
+		_ = f.metaFilter.WalkMut(msg, func(key string, value any) error {
 			record.Headers = append(record.Headers, kgo.RecordHeader{
 				Key:   key,
-				Value: []byte(value),
+				Value: metaToHeaderValue(value),
 			})
 			return nil
 		})
 		records = append(records, record)
 	}
 
+	if f.createTopics != nil {
+		if err = f.createTopics.ensure(ctx, f.client, records); err != nil {
+			return
+		}
+	}
+
 	// TODO: This is very cool and allows us to easily return granular errors,
 	// so we should honor travis by doing it.
-	err = f.client.ProduceSync(ctx, records...).FirstErr()
+	startedAt := time.Now()
+	results := f.client.ProduceSync(ctx, records...)
+
+	latency := time.Since(startedAt).Nanoseconds()
+	latencyTopics := map[string]struct{}{}
+	for _, res := range results {
+		topic := res.Record.Topic
+		if res.Err != nil {
+			f.mError.Incr(1, topic)
+			continue
+		}
+		f.mSent.Incr(1, topic)
+		if _, exists := latencyTopics[topic]; !exists {
+			latencyTopics[topic] = struct{}{}
+			f.mLatency.Timing(latency, topic)
+		}
+	}
+
+	err = results.FirstErr()
 	return
 }
 
@@ -287,6 +391,9 @@ func (f *franzKafkaWriter) disconnect() {
 	}
 	f.client.Close()
 	f.client = nil
+	if f.createTopics != nil {
+		f.createTopics.reset()
+	}
 }
 
 func (f *franzKafkaWriter) Close(ctx context.Context) error {
@@ -0,0 +1,140 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzOutputConfigPartitioner(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        string
+		errContains string
+	}{
+		{
+			name: "manual with partition",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+partitioner: manual
+partition: ${! meta("partition") }
+`,
+		},
+		{
+			name: "manual without partition",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+partitioner: manual
+`,
+			errContains: "partition field required for 'manual' partitioner",
+		},
+		{
+			name: "partition without manual",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+partitioner: round_robin
+partition: "1"
+`,
+			errContains: "partition field can only be specified for 'manual' partitioner",
+		},
+		{
+			name: "partition without partitioner",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+partition: "1"
+`,
+			errContains: "partition field can only be specified for 'manual' partitioner",
+		},
+		{
+			name: "invalid topic partitions",
+			conf: `
+seed_brokers: [ localhost:9092 ]
+topic: foo
+create_topics:
+  enabled: true
+  partitions: 0
+`,
+			errContains: "create_topics.partitions must be greater than zero or -1",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := franzKafkaOutputConfig().ParseYAML(test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = newFranzKafkaWriterFromConfig(conf, service.MockResources())
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestFranzOutputTopicCreator(t *testing.T) {
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topic: foo
+create_topics:
+  enabled: true
+  partitions: 6
+  replication_factor: 3
+  configs:
+    retention.ms: "86400000"
+    cleanup.policy: compact
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newFranzKafkaWriterFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	require.NotNil(t, w.createTopics)
+
+	records := []*kgo.Record{
+		{Topic: "foo"}, {Topic: "bar"}, {Topic: "foo"}, {Topic: "baz"},
+	}
+	w.createTopics.known["baz"] = struct{}{}
+
+	topics := w.createTopics.missing(records)
+	assert.Equal(t, []string{"bar", "foo"}, topics)
+
+	req := w.createTopics.request(topics)
+	require.Len(t, req.Topics, 2)
+	for i, topic := range topics {
+		rt := req.Topics[i]
+		assert.Equal(t, topic, rt.Topic)
+		assert.Equal(t, int32(6), rt.NumPartitions)
+		assert.Equal(t, int16(3), rt.ReplicationFactor)
+		require.Len(t, rt.Configs, 2)
+		assert.Equal(t, "cleanup.policy", rt.Configs[0].Name)
+		assert.Equal(t, "compact", *rt.Configs[0].Value)
+		assert.Equal(t, "retention.ms", rt.Configs[1].Name)
+		assert.Equal(t, "86400000", *rt.Configs[1].Value)
+	}
+
+	w.createTopics.reset()
+	assert.Equal(t, []string{"bar", "baz", "foo"}, w.createTopics.missing(records))
+}
+
+func TestFranzOutputTopicCreatorDisabled(t *testing.T) {
+	conf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topic: foo
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newFranzKafkaWriterFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	assert.Nil(t, w.createTopics)
+}
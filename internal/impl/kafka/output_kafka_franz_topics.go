@@ -0,0 +1,141 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/twmb/franz-go/pkg/kerr"
+	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// franzTopicCreator creates topics that are written to by an output before
+// they are produced to, which allows topics derived from messages to be given
+// explicit settings rather than the defaults of the brokers.
+type franzTopicCreator struct {
+	partitions        int32
+	replicationFactor int16
+	configs           map[string]string
+
+	mut   sync.Mutex
+	known map[string]struct{}
+}
+
+// franzTopicCreatorFromConfig returns nil when topic creation is disabled.
+func franzTopicCreatorFromConfig(conf *service.ParsedConfig) (*franzTopicCreator, error) {
+	enabled, err := conf.FieldBool("enabled")
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	t := &franzTopicCreator{
+		known: map[string]struct{}{},
+	}
+
+	partitions, err := conf.FieldInt("partitions")
+	if err != nil {
+		return nil, err
+	}
+	if partitions == 0 || partitions < -1 {
+		return nil, fmt.Errorf("create_topics.partitions must be greater than zero or -1, got %v", partitions)
+	}
+	t.partitions = int32(partitions)
+
+	replicationFactor, err := conf.FieldInt("replication_factor")
+	if err != nil {
+		return nil, err
+	}
+	if replicationFactor == 0 || replicationFactor < -1 {
+		return nil, fmt.Errorf("create_topics.replication_factor must be greater than zero or -1, got %v", replicationFactor)
+	}
+	t.replicationFactor = int16(replicationFactor)
+
+	if t.configs, err = conf.FieldStringMap("configs"); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// missing returns the sorted and deduplicated topics of a batch of records
+// that have not yet been created.
+func (t *franzTopicCreator) missing(records []*kgo.Record) []string {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	seen := map[string]struct{}{}
+	var topics []string
+	for _, r := range records {
+		if _, exists := t.known[r.Topic]; exists {
+			continue
+		}
+		if _, exists := seen[r.Topic]; exists {
+			continue
+		}
+		seen[r.Topic] = struct{}{}
+		topics = append(topics, r.Topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+func (t *franzTopicCreator) request(topics []string) *kmsg.CreateTopicsRequest {
+	req := kmsg.NewPtrCreateTopicsRequest()
+	for _, topic := range topics {
+		rt := kmsg.NewCreateTopicsRequestTopic()
+		rt.Topic = topic
+		rt.NumPartitions = t.partitions
+		rt.ReplicationFactor = t.replicationFactor
+
+		keys := make([]string, 0, len(t.configs))
+		for k := range t.configs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := t.configs[k]
+			rc := kmsg.NewCreateTopicsRequestTopicConfig()
+			rc.Name = k
+			rc.Value = &v
+			rt.Configs = append(rt.Configs, rc)
+		}
+		req.Topics = append(req.Topics, rt)
+	}
+	return req
+}
+
+// ensure creates the topics of a batch of records that have not yet been
+// created, topics that already exist are left unchanged.
+func (t *franzTopicCreator) ensure(ctx context.Context, cl *kgo.Client, records []*kgo.Record) error {
+	topics := t.missing(records)
+	if len(topics) == 0 {
+		return nil
+	}
+
+	resp, err := t.request(topics).RequestWith(ctx, cl)
+	if err != nil {
+		return fmt.Errorf("failed to create topics: %w", err)
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	for _, rt := range resp.Topics {
+		if err := kerr.ErrorForCode(rt.ErrorCode); err != nil && !errors.Is(err, kerr.TopicAlreadyExists) {
+			return fmt.Errorf("failed to create topic %v: %w", rt.Topic, err)
+		}
+		t.known[rt.Topic] = struct{}{}
+	}
+	return nil
+}
+
+// reset clears the topics known to exist, which is called when the client is
+// closed as topics may have been deleted before the next connection.
+func (t *franzTopicCreator) reset() {
+	t.mut.Lock()
+	t.known = map[string]struct{}{}
+	t.mut.Unlock()
+}
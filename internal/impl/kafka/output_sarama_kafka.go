@@ -263,7 +263,7 @@ func (k *kafkaWriter) buildSystemHeaders(part *message.Part) []sarama.RecordHead
 		_ = k.metaFilter.Iter(part, func(k string, v any) error {
 			out = append(out, sarama.RecordHeader{
 				Key:   []byte(k),
-				Value: []byte(query.IToString(v)),
+				Value: metaToHeaderValue(v),
 			})
 			return nil
 		})
@@ -274,6 +274,16 @@ func (k *kafkaWriter) buildSystemHeaders(part *message.Part) []sarama.RecordHead
 	return nil
 }
 
+// metaToHeaderValue serialises a metadata value as a header value, where byte
+// slices are written as they are and other types are written in the same form
+// as their string representation.
+func metaToHeaderValue(v any) []byte {
+	if b, ok := v.([]byte); ok {
+		return b
+	}
+	return []byte(query.IToString(v))
+}
+
 //------------------------------------------------------------------------------
 
 func (k *kafkaWriter) buildUserDefinedHeaders(staticHeaders map[string]string) []sarama.RecordHeader {
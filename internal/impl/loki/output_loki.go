@@ -0,0 +1,316 @@
+package loki
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func lokiOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Services").
+		Version("4.10.0").
+		Summary("Pushes log lines to [Grafana Loki](https://grafana.com/oss/loki/).").
+		Description(`
+Each message is pushed as a log line to the stream identified by its interpolated labels. Messages of a batch are grouped into streams by their labels, and are sent within a single [snappy compressed protobuf push request](https://grafana.com/docs/loki/latest/api/#push-log-entries-to-loki).
+
+The timestamp of each line is the current time unless the ` + "`timestamp`" + ` field is set, in which case it must resolve to either an RFC 3339 formatted string or a unix timestamp in nanoseconds.
+
+### Performance
+
+This output benefits from sending multiple messages in flight in parallel for improved performance. You can tune the max number of in flight messages (or message batches) with the field ` + "`max_in_flight`" + `.
+
+This output benefits from sending messages as a batch for improved performance. Batches can be formed at both the input and output level. You can find out more [in this doc](/docs/configuration/batching).`).
+		Field(service.NewStringField("url").
+			Description("The URL of a Loki server. When a path is not specified `/loki/api/v1/push` is used.").
+			Example("http://localhost:3100")).
+		Field(service.NewInterpolatedStringMapField("labels").
+			Description("A map of labels that identify the stream of each message. At least one label must be specified. Labels should have a low cardinality as each distinct set of values creates a new stream.").
+			Example(map[string]any{
+				"app":   "benthos",
+				"level": `${! json("level") }`,
+			})).
+		Field(service.NewInterpolatedStringField("timestamp").
+			Description("An optional timestamp of each line, which must resolve to either an RFC 3339 formatted string or a unix timestamp in nanoseconds. When empty the current time is used.").
+			Example(`${! json("ts") }`).
+			Example(`${! meta("kafka_timestamp_unix").number() * 1000000000 }`).
+			Default("").
+			Advanced()).
+		Field(service.NewStringField("tenant_id").
+			Description("An optional tenant ID to send with requests as the `X-Scope-OrgID` header, which is required when Loki is running in multi-tenant mode.").
+			Default("")).
+		Field(service.NewStringMapField("headers").
+			Description("A map of headers to add to each request, which can be used for authentication.").
+			Example(map[string]any{"Authorization": "Basic dXNlcjpwYXNz"}).
+			Default(map[string]any{}).
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for a push request to complete.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of parallel message batches to have in flight at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching"))
+}
+
+func init() {
+	err := service.RegisterBatchOutput("loki", lokiOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newLokiWriterFromConfig(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type lokiWriter struct {
+	log *service.Logger
+
+	url       string
+	labels    map[string]*service.InterpolatedString
+	timestamp *service.InterpolatedString
+	headers   map[string]string
+
+	client *http.Client
+}
+
+func newLokiWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*lokiWriter, error) {
+	l := &lokiWriter{
+		log:    log,
+		client: &http.Client{},
+	}
+
+	rawURL, err := conf.FieldString("url")
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/loki/api/v1/push"
+	}
+	l.url = u.String()
+
+	if l.labels, err = conf.FieldInterpolatedStringMap("labels"); err != nil {
+		return nil, err
+	}
+	if len(l.labels) == 0 {
+		return nil, errors.New("at least one label must be specified")
+	}
+
+	if tsStr, _ := conf.FieldString("timestamp"); tsStr != "" {
+		if l.timestamp, err = conf.FieldInterpolatedString("timestamp"); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.headers, err = conf.FieldStringMap("headers"); err != nil {
+		return nil, err
+	}
+	tenantID, err := conf.FieldString("tenant_id")
+	if err != nil {
+		return nil, err
+	}
+	if tenantID != "" {
+		l.headers["X-Scope-OrgID"] = tenantID
+	}
+
+	if l.client.Timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		l.client.Transport = &http.Transport{TLSClientConfig: tlsConf}
+	}
+	return l, nil
+}
+
+func (l *lokiWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+type lokiEntry struct {
+	ts   time.Time
+	line string
+}
+
+type lokiStream struct {
+	labels  string
+	entries []lokiEntry
+}
+
+func (l *lokiWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	streams := map[string]*lokiStream{}
+	var streamKeys []string
+
+	for i, msg := range b {
+		labels := l.labelsString(b, i)
+
+		ts := time.Now()
+		if l.timestamp != nil {
+			var err error
+			if ts, err = parseLokiTimestamp(b.InterpolatedString(i, l.timestamp)); err != nil {
+				return err
+			}
+		}
+
+		line, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		s, exists := streams[labels]
+		if !exists {
+			s = &lokiStream{labels: labels}
+			streams[labels] = s
+			streamKeys = append(streamKeys, labels)
+		}
+		s.entries = append(s.entries, lokiEntry{ts: ts, line: string(line)})
+	}
+
+	ordered := make([]*lokiStream, 0, len(streamKeys))
+	for _, k := range streamKeys {
+		s := streams[k]
+		// Older versions of Loki reject entries of a stream that are out of
+		// order.
+		sort.SliceStable(s.entries, func(i, j int) bool {
+			return s.entries[i].ts.Before(s.entries[j].ts)
+		})
+		ordered = append(ordered, s)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", l.url, bytes.NewReader(snappy.Encode(nil, encodePushRequest(ordered))))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range l.headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := l.client.Do(req)
+	if err != nil {
+		return err
+	}
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	_ = res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("push request returned status code %v: %s", res.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// labelsString returns the labels of a message in the Prometheus format
+// expected by Loki, with keys sorted in order to identify the stream.
+func (l *lokiWriter) labelsString(b service.MessageBatch, i int) string {
+	keys := make([]string, 0, len(l.labels))
+	for k := range l.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for j, k := range keys {
+		if j > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(b.InterpolatedString(i, l.labels[k])))
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func parseLokiTimestamp(s string) (time.Time, error) {
+	if nanos, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, nanos), nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Unix(0, int64(f)), nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse timestamp '%v': expected an RFC 3339 string or unix nanoseconds", s)
+	}
+	return ts, nil
+}
+
+// encodePushRequest serialises streams as a logproto.PushRequest:
+//
+//	message PushRequest { repeated StreamAdapter streams = 1; }
+//	message StreamAdapter { string labels = 1; repeated EntryAdapter entries = 2; }
+//	message EntryAdapter { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func encodePushRequest(streams []*lokiStream) []byte {
+	var req []byte
+	for _, s := range streams {
+		var stream []byte
+		stream = protowire.AppendTag(stream, 1, protowire.BytesType)
+		stream = protowire.AppendString(stream, s.labels)
+
+		for _, e := range s.entries {
+			var ts []byte
+			if secs := e.ts.Unix(); secs != 0 {
+				ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+				ts = protowire.AppendVarint(ts, uint64(secs))
+			}
+			if nanos := e.ts.Nanosecond(); nanos != 0 {
+				ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+				ts = protowire.AppendVarint(ts, uint64(nanos))
+			}
+
+			var entry []byte
+			entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+			entry = protowire.AppendBytes(entry, ts)
+			entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+			entry = protowire.AppendString(entry, e.line)
+
+			stream = protowire.AppendTag(stream, 2, protowire.BytesType)
+			stream = protowire.AppendBytes(stream, entry)
+		}
+
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, stream)
+	}
+	return req
+}
+
+func (l *lokiWriter) Close(ctx context.Context) error {
+	l.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,169 @@
+package loki
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type decodedEntry struct {
+	ts   time.Time
+	line string
+}
+
+// decodePushRequest is a minimal decoder of logproto.PushRequest used to
+// verify the encoding of requests.
+func decodePushRequest(t *testing.T, b []byte) map[string][]decodedEntry {
+	t.Helper()
+
+	fields := func(b []byte, fn func(num protowire.Number, typ protowire.Type, v []byte, n uint64)) {
+		for len(b) > 0 {
+			num, typ, l := protowire.ConsumeTag(b)
+			require.GreaterOrEqual(t, l, 0)
+			b = b[l:]
+			switch typ {
+			case protowire.BytesType:
+				v, l := protowire.ConsumeBytes(b)
+				require.GreaterOrEqual(t, l, 0)
+				fn(num, typ, v, 0)
+				b = b[l:]
+			case protowire.VarintType:
+				v, l := protowire.ConsumeVarint(b)
+				require.GreaterOrEqual(t, l, 0)
+				fn(num, typ, nil, v)
+				b = b[l:]
+			default:
+				t.Fatalf("unexpected wire type: %v", typ)
+			}
+		}
+	}
+
+	streams := map[string][]decodedEntry{}
+	fields(b, func(_ protowire.Number, _ protowire.Type, stream []byte, _ uint64) {
+		var labels string
+		var entries []decodedEntry
+		fields(stream, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+			switch num {
+			case 1:
+				labels = string(v)
+			case 2:
+				var e decodedEntry
+				fields(v, func(num protowire.Number, _ protowire.Type, v []byte, _ uint64) {
+					switch num {
+					case 1:
+						var secs, nanos uint64
+						fields(v, func(num protowire.Number, _ protowire.Type, _ []byte, n uint64) {
+							if num == 1 {
+								secs = n
+							} else {
+								nanos = n
+							}
+						})
+						e.ts = time.Unix(int64(secs), int64(nanos))
+					case 2:
+						e.line = string(v)
+					}
+				})
+				entries = append(entries, e)
+			}
+		})
+		streams[labels] = entries
+	})
+	return streams
+}
+
+func TestLokiOutput(t *testing.T) {
+	reqs := make(chan map[string][]decodedEntry, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "tenant-a", r.Header.Get("X-Scope-OrgID"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		decoded, err := snappy.Decode(nil, body)
+		require.NoError(t, err)
+
+		reqs <- decodePushRequest(t, decoded)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	conf, err := lokiOutputConfig().ParseYAML(`
+url: `+server.URL+`
+tenant_id: tenant-a
+labels:
+  app: benthos
+  level: ${! json("level") }
+timestamp: ${! json("ts") }
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newLokiWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Connect(context.Background()))
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"level":"info","ts":"2022-10-10T10:00:02Z","msg":"c"}`)),
+		service.NewMessage([]byte(`{"level":"error","ts":"1665396000000000000","msg":"b"}`)),
+		service.NewMessage([]byte(`{"level":"info","ts":"2022-10-10T10:00:01.5Z","msg":"a"}`)),
+	}))
+
+	var streams map[string][]decodedEntry
+	select {
+	case streams = <-reqs:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	require.Len(t, streams, 2)
+	info := streams[`{app="benthos", level="info"}`]
+	require.Len(t, info, 2)
+	assert.Equal(t, `{"level":"info","ts":"2022-10-10T10:00:01.5Z","msg":"a"}`, info[0].line)
+	assert.True(t, time.Date(2022, 10, 10, 10, 0, 1, 500000000, time.UTC).Equal(info[0].ts))
+	assert.Equal(t, `{"level":"info","ts":"2022-10-10T10:00:02Z","msg":"c"}`, info[1].line)
+
+	errs := streams[`{app="benthos", level="error"}`]
+	require.Len(t, errs, 1)
+	assert.True(t, time.Unix(0, 1665396000000000000).Equal(errs[0].ts))
+
+	require.NoError(t, w.Close(context.Background()))
+}
+
+func TestLokiOutputErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "entry out of order", http.StatusBadRequest)
+	}))
+	t.Cleanup(server.Close)
+
+	conf, err := lokiOutputConfig().ParseYAML(`
+url: `+server.URL+`
+labels:
+  app: benthos
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newLokiWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	err = w.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte("hello"))})
+	require.EqualError(t, err, "push request returned status code 400: entry out of order")
+
+	conf, err = lokiOutputConfig().ParseYAML(`
+url: `+server.URL+`
+labels: {}
+`, nil)
+	require.NoError(t, err)
+	_, err = newLokiWriterFromConfig(conf, nil)
+	require.EqualError(t, err, "at least one label must be specified")
+}
@@ -5,7 +5,6 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -382,12 +381,12 @@ func convertMessage(m *nats.Msg) (*service.Message, service.AckFunc, error) {
 
 	metadata, err := m.Metadata()
 	if err == nil {
-		msg.MetaSet("nats_sequence_stream", strconv.Itoa(int(metadata.Sequence.Stream)))
-		msg.MetaSet("nats_sequence_consumer", strconv.Itoa(int(metadata.Sequence.Consumer)))
-		msg.MetaSet("nats_num_delivered", strconv.Itoa(int(metadata.NumDelivered)))
-		msg.MetaSet("nats_num_pending", strconv.Itoa(int(metadata.NumPending)))
+		msg.MetaSetMut("nats_sequence_stream", metadata.Sequence.Stream)
+		msg.MetaSetMut("nats_sequence_consumer", metadata.Sequence.Consumer)
+		msg.MetaSetMut("nats_num_delivered", metadata.NumDelivered)
+		msg.MetaSetMut("nats_num_pending", metadata.NumPending)
 		msg.MetaSet("nats_domain", metadata.Domain)
-		msg.MetaSet("nats_timestamp_unix_nano", strconv.Itoa(int(metadata.Timestamp.UnixNano())))
+		msg.MetaSetMut("nats_timestamp_unix_nano", metadata.Timestamp.UnixNano())
 	}
 
 	for k := range m.Header {
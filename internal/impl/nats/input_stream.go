@@ -4,7 +4,6 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -279,7 +278,7 @@ func (n *natsStreamReader) ReadBatch(ctx context.Context) (message.Batch, input.
 	bmsg := message.QuickBatch([][]byte{msg.Data})
 	part := bmsg.Get(0)
 	part.MetaSetMut("nats_stream_subject", msg.Subject)
-	part.MetaSetMut("nats_stream_sequence", strconv.FormatUint(msg.Sequence, 10))
+	part.MetaSetMut("nats_stream_sequence", msg.Sequence)
 
 	return bmsg, func(rctx context.Context, res error) error {
 		if res == nil {
@@ -0,0 +1,709 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+func init() {
+	_ = bundle.AllMetrics.Add(func(conf metrics.Config, nm bundle.NewManagement) (metrics.Type, error) {
+		return newOTLPMetrics(conf.OTLP, nm.Logger())
+	}, docs.ComponentSpec{
+		Name:    "open_telemetry_collector",
+		Type:    docs.TypeMetrics,
+		Status:  docs.StatusExperimental,
+		Version: "4.10.0",
+		Summary: `Push metrics to [Open Telemetry collectors](https://opentelemetry.io/docs/collector/) using the OTLP protocol over gRPC or HTTP.`,
+		Description: `
+Counters are exported as monotonic sums, gauges as gauges and timing metrics as histograms with values converted from nanoseconds into seconds.
+
+The ` + "`temporality`" + ` field determines whether counters and histograms are exported as totals accumulated since the metric was created (` + "`cumulative`" + `) or as the change since the previous push (` + "`delta`" + `). Most backends, including Prometheus, expect cumulative values, whereas some hosted services prefer delta values.
+
+Metrics are pushed to all configured collectors on the interval specified by ` + "`push_interval`" + `, and a final push is made during shutdown.`,
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldObject("http", "A list of http collectors.").Array().WithChildren(
+				docs.FieldString("url", "The URL of a collector to send metrics to. When a path is not specified `/v1/metrics` is used.").HasDefault("localhost:4318"),
+			).HasDefault([]any{}),
+			docs.FieldObject("grpc", "A list of grpc collectors.").Array().WithChildren(
+				docs.FieldString("url", "The URL of a collector to send metrics to.").HasDefault("localhost:4317"),
+			).HasDefault([]any{}),
+			docs.FieldString("resource_attributes", "A map of attributes to add to the resource of all metrics. When `service.name` is not specified it defaults to `benthos`.", map[string]string{
+				"service.name":                "my-pipeline",
+				"deployment.environment.name": "production",
+			}).Map().HasDefault(map[string]any{}),
+			docs.FieldString("temporality", "The aggregation temporality of exported counters and histograms.").HasAnnotatedOptions(
+				"cumulative", "Export the totals since each metric was created.",
+				"delta", "Export the change since the last successful push to each collector, values that fail to be pushed are included in the next push.",
+			).HasDefault("cumulative"),
+			docs.FieldString("push_interval", "The period of time between each push of metrics to collectors.").HasDefault("10s"),
+			docs.FieldString("timeout", "The maximum period of time to wait for a push to a collector to complete.").HasDefault("5s").Advanced(),
+			docs.FieldFloat("histogram_buckets", "The explicit bucket boundaries (in seconds) of timing metric histograms. If left empty defaults to `[0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10]`.").Array().HasDefault([]any{}).Advanced(),
+		),
+	})
+}
+
+//------------------------------------------------------------------------------
+
+var otlpDefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type otlpMetrics struct {
+	log         log.Modular
+	resource    *resourcepb.Resource
+	temporality metricspb.AggregationTemporality
+	buckets     []float64
+	timeout     time.Duration
+	collectors  []*otlpCollector
+
+	mut      sync.Mutex
+	counters map[string]*otlpFamily
+	gauges   map[string]*otlpFamily
+	timers   map[string]*otlpFamily
+
+	shutSig *shutdown.Signaller
+}
+
+func newOTLPMetrics(config metrics.OTLPConfig, log log.Modular) (*otlpMetrics, error) {
+	o := &otlpMetrics{
+		log:      log,
+		buckets:  config.HistogramBuckets,
+		counters: map[string]*otlpFamily{},
+		gauges:   map[string]*otlpFamily{},
+		timers:   map[string]*otlpFamily{},
+		shutSig:  shutdown.NewSignaller(),
+	}
+
+	switch config.Temporality {
+	case "cumulative":
+		o.temporality = metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	case "delta":
+		o.temporality = metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+	default:
+		return nil, fmt.Errorf("unrecognised temporality: %v", config.Temporality)
+	}
+
+	if len(o.buckets) == 0 {
+		o.buckets = otlpDefaultBuckets
+	}
+	if !sort.Float64sAreSorted(o.buckets) {
+		return nil, errors.New("histogram_buckets must be in ascending order")
+	}
+
+	interval, err := time.ParseDuration(config.PushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse push interval: %v", err)
+	}
+	if interval <= 0 {
+		return nil, errors.New("push interval must be greater than zero")
+	}
+	if o.timeout, err = time.ParseDuration(config.Timeout); err != nil {
+		return nil, fmt.Errorf("failed to parse timeout: %v", err)
+	}
+
+	attrs := map[string]string{"service.name": "benthos"}
+	for k, v := range config.ResourceAttributes {
+		attrs[k] = v
+	}
+	o.resource = &resourcepb.Resource{Attributes: otlpAttributes(attrs)}
+
+	for _, c := range config.HTTP {
+		e, err := newOTLPHTTPExporter(c.URL)
+		if err != nil {
+			return nil, fmt.Errorf("http collector '%v': %w", c.URL, err)
+		}
+		o.collectors = append(o.collectors, newOTLPCollector(e))
+	}
+	for _, c := range config.GRPC {
+		e, err := newOTLPGRPCExporter(c.URL)
+		if err != nil {
+			return nil, fmt.Errorf("grpc collector '%v': %w", c.URL, err)
+		}
+		o.collectors = append(o.collectors, newOTLPCollector(e))
+	}
+	if len(o.collectors) == 0 {
+		return nil, errors.New("at least one http or grpc collector must be specified")
+	}
+
+	go func() {
+		defer o.shutSig.ShutdownComplete()
+		for {
+			select {
+			case <-o.shutSig.CloseAtLeisureChan():
+				return
+			case <-time.After(interval):
+				o.push()
+			}
+		}
+	}()
+	return o, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (o *otlpMetrics) family(families map[string]*otlpFamily, path string, labelNames []string, ctor func() any) *otlpFamily {
+	o.mut.Lock()
+	defer o.mut.Unlock()
+
+	f, exists := families[path]
+	if !exists {
+		f = &otlpFamily{
+			name:       path,
+			labelNames: labelNames,
+			ctor:       ctor,
+			series:     map[string]*otlpSeries{},
+		}
+		families[path] = f
+	}
+	return f
+}
+
+func (o *otlpMetrics) GetCounter(path string) metrics.StatCounter {
+	return o.GetCounterVec(path).With()
+}
+
+func (o *otlpMetrics) GetCounterVec(path string, n ...string) metrics.StatCounterVec {
+	f := o.family(o.counters, path, n, func() any { return &otlpCounter{} })
+	return metrics.FakeCounterVec(func(labelValues ...string) metrics.StatCounter {
+		return f.with(labelValues).stat.(*otlpCounter)
+	})
+}
+
+func (o *otlpMetrics) GetTimer(path string) metrics.StatTimer {
+	return o.GetTimerVec(path).With()
+}
+
+func (o *otlpMetrics) GetTimerVec(path string, n ...string) metrics.StatTimerVec {
+	f := o.family(o.timers, path, n, func() any {
+		return &otlpTimer{bounds: o.buckets, hist: newOTLPHistogram(o.buckets)}
+	})
+	return metrics.FakeTimerVec(func(labelValues ...string) metrics.StatTimer {
+		return f.with(labelValues).stat.(*otlpTimer)
+	})
+}
+
+func (o *otlpMetrics) GetGauge(path string) metrics.StatGauge {
+	return o.GetGaugeVec(path).With()
+}
+
+func (o *otlpMetrics) GetGaugeVec(path string, n ...string) metrics.StatGaugeVec {
+	f := o.family(o.gauges, path, n, func() any { return &otlpGauge{} })
+	return metrics.FakeGaugeVec(func(labelValues ...string) metrics.StatGauge {
+		return f.with(labelValues).stat.(*otlpGauge)
+	})
+}
+
+func (o *otlpMetrics) HandlerFunc() http.HandlerFunc {
+	return nil
+}
+
+func (o *otlpMetrics) Close() error {
+	o.shutSig.CloseAtLeisure()
+	<-o.shutSig.HasClosedChan()
+
+	o.push()
+	for _, c := range o.collectors {
+		if err := c.exporter.close(); err != nil {
+			o.log.Errorf("Failed to close metrics collector connection: %v\n", err)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// otlpCollector is an exporter of a collector along with, when the temporality
+// is delta, the values that have not yet been successfully exported to it.
+// Values are only discarded once an export succeeds, and therefore a failed
+// push is included within the next push.
+type otlpCollector struct {
+	exporter otlpExporter
+
+	// The start of the delta interval that has not yet been exported.
+	start    time.Time
+	counters map[*otlpSeries]int64
+	timers   map[*otlpSeries]*otlpHistogram
+}
+
+func newOTLPCollector(e otlpExporter) *otlpCollector {
+	return &otlpCollector{
+		exporter: e,
+		start:    time.Now(),
+		counters: map[*otlpSeries]int64{},
+		timers:   map[*otlpSeries]*otlpHistogram{},
+	}
+}
+
+func (o *otlpMetrics) isDelta() bool {
+	return o.temporality == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+}
+
+// push collects the current state of all metrics and sends it to each
+// collector.
+func (o *otlpMetrics) push() {
+	now := time.Now()
+	if o.isDelta() {
+		o.drain()
+	}
+
+	var cumulativeReq *colmetricspb.ExportMetricsServiceRequest
+	for _, c := range o.collectors {
+		var req *colmetricspb.ExportMetricsServiceRequest
+		if o.isDelta() {
+			req = o.request(o.collect(now, c))
+		} else {
+			if cumulativeReq == nil {
+				cumulativeReq = o.request(o.collect(now, nil))
+			}
+			req = cumulativeReq
+		}
+
+		ctx, done := context.WithTimeout(context.Background(), o.timeout)
+		err := c.exporter.export(ctx, req)
+		done()
+		if err != nil {
+			if o.isDelta() {
+				o.log.Errorf("Failed to push metrics, values will be retained for the next push: %v\n", err)
+			} else {
+				o.log.Errorf("Failed to push metrics: %v\n", err)
+			}
+			continue
+		}
+
+		c.start = now
+		c.counters = map[*otlpSeries]int64{}
+		c.timers = map[*otlpSeries]*otlpHistogram{}
+	}
+}
+
+func (o *otlpMetrics) request(ms []*metricspb.Metric) *colmetricspb.ExportMetricsServiceRequest {
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: o.resource,
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope:   &commonpb.InstrumentationScope{Name: "benthos"},
+				Metrics: ms,
+			}},
+		}},
+	}
+}
+
+// drain resets counters and timers, adding their values to those not yet
+// exported to each collector.
+func (o *otlpMetrics) drain() {
+	o.mut.Lock()
+	counters := sortedFamilies(o.counters)
+	timers := sortedFamilies(o.timers)
+	o.mut.Unlock()
+
+	for _, f := range counters {
+		for _, s := range f.sortedSeries() {
+			v := atomic.SwapInt64(&s.stat.(*otlpCounter).value, 0)
+			for _, c := range o.collectors {
+				c.counters[s] += v
+			}
+		}
+	}
+	for _, f := range timers {
+		for _, s := range f.sortedSeries() {
+			h := s.stat.(*otlpTimer).snapshot(true)
+			for _, c := range o.collectors {
+				if existing, exists := c.timers[s]; exists {
+					existing.merge(&h)
+				} else {
+					hCopy := h.copy()
+					c.timers[s] = &hCopy
+				}
+			}
+		}
+	}
+}
+
+// collect returns the current state of all metrics. When the temporality is
+// delta the values not yet exported to the provided collector are used.
+func (o *otlpMetrics) collect(now time.Time, c *otlpCollector) []*metricspb.Metric {
+	o.mut.Lock()
+	counters := sortedFamilies(o.counters)
+	gauges := sortedFamilies(o.gauges)
+	timers := sortedFamilies(o.timers)
+	o.mut.Unlock()
+
+	startTime := func(s *otlpSeries) uint64 {
+		if c != nil {
+			return uint64(c.start.UnixNano())
+		}
+		return uint64(s.created.UnixNano())
+	}
+	nowNano := uint64(now.UnixNano())
+
+	var ms []*metricspb.Metric
+	for _, f := range counters {
+		sum := &metricspb.Sum{
+			AggregationTemporality: o.temporality,
+			IsMonotonic:            true,
+		}
+		for _, s := range f.sortedSeries() {
+			var v int64
+			if c != nil {
+				v = c.counters[s]
+			} else {
+				v = atomic.LoadInt64(&s.stat.(*otlpCounter).value)
+			}
+			sum.DataPoints = append(sum.DataPoints, &metricspb.NumberDataPoint{
+				Attributes:        s.attrs,
+				StartTimeUnixNano: startTime(s),
+				TimeUnixNano:      nowNano,
+				Value:             &metricspb.NumberDataPoint_AsInt{AsInt: v},
+			})
+		}
+		ms = append(ms, &metricspb.Metric{Name: f.name, Data: &metricspb.Metric_Sum{Sum: sum}})
+	}
+
+	for _, f := range gauges {
+		gauge := &metricspb.Gauge{}
+		for _, s := range f.sortedSeries() {
+			gauge.DataPoints = append(gauge.DataPoints, &metricspb.NumberDataPoint{
+				Attributes:   s.attrs,
+				TimeUnixNano: nowNano,
+				Value:        &metricspb.NumberDataPoint_AsInt{AsInt: atomic.LoadInt64(&s.stat.(*otlpGauge).value)},
+			})
+		}
+		ms = append(ms, &metricspb.Metric{Name: f.name, Data: &metricspb.Metric_Gauge{Gauge: gauge}})
+	}
+
+	for _, f := range timers {
+		hist := &metricspb.Histogram{AggregationTemporality: o.temporality}
+		for _, s := range f.sortedSeries() {
+			var h otlpHistogram
+			if c != nil {
+				if unsent, exists := c.timers[s]; exists {
+					h = *unsent
+				} else {
+					h = newOTLPHistogram(o.buckets)
+				}
+			} else {
+				h = s.stat.(*otlpTimer).snapshot(false)
+			}
+			dp := h.dataPoint(o.buckets)
+			dp.Attributes = s.attrs
+			dp.StartTimeUnixNano = startTime(s)
+			dp.TimeUnixNano = nowNano
+			hist.DataPoints = append(hist.DataPoints, dp)
+		}
+		ms = append(ms, &metricspb.Metric{Name: f.name, Unit: "s", Data: &metricspb.Metric_Histogram{Histogram: hist}})
+	}
+	return ms
+}
+
+func sortedFamilies(families map[string]*otlpFamily) []*otlpFamily {
+	fs := make([]*otlpFamily, 0, len(families))
+	for _, f := range families {
+		fs = append(fs, f)
+	}
+	sort.Slice(fs, func(i, j int) bool {
+		return fs[i].name < fs[j].name
+	})
+	return fs
+}
+
+func otlpAttributes(kvs map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(kvs))
+	for k := range kvs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		attrs = append(attrs, &commonpb.KeyValue{
+			Key:   k,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: kvs[k]}},
+		})
+	}
+	return attrs
+}
+
+//------------------------------------------------------------------------------
+
+// otlpFamily is a metric of a given name and set of label names, with a series
+// for each distinct set of label values.
+type otlpFamily struct {
+	name       string
+	labelNames []string
+	ctor       func() any
+
+	mut    sync.RWMutex
+	series map[string]*otlpSeries
+}
+
+type otlpSeries struct {
+	key     string
+	attrs   []*commonpb.KeyValue
+	created time.Time
+	stat    any
+}
+
+func (f *otlpFamily) with(labelValues []string) *otlpSeries {
+	key := strings.Join(labelValues, "\x00")
+
+	f.mut.RLock()
+	s, exists := f.series[key]
+	f.mut.RUnlock()
+	if exists {
+		return s
+	}
+
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	if s, exists = f.series[key]; exists {
+		return s
+	}
+
+	kvs := make(map[string]string, len(f.labelNames))
+	for i, n := range f.labelNames {
+		if i < len(labelValues) {
+			kvs[n] = labelValues[i]
+		}
+	}
+	s = &otlpSeries{
+		key:     key,
+		attrs:   otlpAttributes(kvs),
+		created: time.Now(),
+		stat:    f.ctor(),
+	}
+	f.series[key] = s
+	return s
+}
+
+func (f *otlpFamily) sortedSeries() []*otlpSeries {
+	f.mut.RLock()
+	ss := make([]*otlpSeries, 0, len(f.series))
+	for _, s := range f.series {
+		ss = append(ss, s)
+	}
+	f.mut.RUnlock()
+
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].key < ss[j].key
+	})
+	return ss
+}
+
+type otlpCounter struct {
+	value int64
+}
+
+func (c *otlpCounter) Incr(count int64) {
+	atomic.AddInt64(&c.value, count)
+}
+
+type otlpGauge struct {
+	value int64
+}
+
+func (g *otlpGauge) Set(value int64) {
+	atomic.StoreInt64(&g.value, value)
+}
+
+func (g *otlpGauge) Incr(count int64) {
+	atomic.AddInt64(&g.value, count)
+}
+
+func (g *otlpGauge) Decr(count int64) {
+	atomic.AddInt64(&g.value, -count)
+}
+
+// otlpHistogram is the state of a timer histogram.
+type otlpHistogram struct {
+	counts   []uint64
+	count    uint64
+	sum      float64
+	min, max float64
+}
+
+func newOTLPHistogram(bounds []float64) otlpHistogram {
+	return otlpHistogram{counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *otlpHistogram) copy() otlpHistogram {
+	c := *h
+	c.counts = make([]uint64, len(h.counts))
+	copy(c.counts, h.counts)
+	return c
+}
+
+func (h *otlpHistogram) merge(o *otlpHistogram) {
+	if o.count == 0 {
+		return
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	if h.count == 0 || o.min < h.min {
+		h.min = o.min
+	}
+	if h.count == 0 || o.max > h.max {
+		h.max = o.max
+	}
+	h.count += o.count
+	h.sum += o.sum
+}
+
+func (h *otlpHistogram) dataPoint(bounds []float64) *metricspb.HistogramDataPoint {
+	sum := h.sum
+	dp := &metricspb.HistogramDataPoint{
+		Count:          h.count,
+		Sum:            &sum,
+		BucketCounts:   make([]uint64, len(h.counts)),
+		ExplicitBounds: bounds,
+	}
+	copy(dp.BucketCounts, h.counts)
+	if h.count > 0 {
+		min, max := h.min, h.max
+		dp.Min, dp.Max = &min, &max
+	}
+	return dp
+}
+
+type otlpTimer struct {
+	bounds []float64
+
+	mut  sync.Mutex
+	hist otlpHistogram
+}
+
+func (t *otlpTimer) Timing(delta int64) {
+	v := float64(delta) / 1e9
+
+	t.mut.Lock()
+	h := &t.hist
+	h.counts[sort.SearchFloat64s(t.bounds, v)]++
+	if h.count == 0 || v < h.min {
+		h.min = v
+	}
+	if h.count == 0 || v > h.max {
+		h.max = v
+	}
+	h.count++
+	h.sum += v
+	t.mut.Unlock()
+}
+
+// snapshot returns a copy of the timings recorded, and when reset is true the
+// timer is cleared for the next delta interval.
+func (t *otlpTimer) snapshot(reset bool) otlpHistogram {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	h := t.hist.copy()
+	if reset {
+		t.hist = newOTLPHistogram(t.bounds)
+	}
+	return h
+}
+
+//------------------------------------------------------------------------------
+
+type otlpExporter interface {
+	export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error
+	close() error
+}
+
+type otlpGRPCExporter struct {
+	conn   *grpc.ClientConn
+	client colmetricspb.MetricsServiceClient
+}
+
+func newOTLPGRPCExporter(target string) (*otlpGRPCExporter, error) {
+	conn, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &otlpGRPCExporter{
+		conn:   conn,
+		client: colmetricspb.NewMetricsServiceClient(conn),
+	}, nil
+}
+
+func (g *otlpGRPCExporter) export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	_, err := g.client.Export(ctx, req)
+	return err
+}
+
+func (g *otlpGRPCExporter) close() error {
+	return g.conn.Close()
+}
+
+type otlpHTTPExporter struct {
+	url    string
+	client *http.Client
+}
+
+func newOTLPHTTPExporter(target string) (*otlpHTTPExporter, error) {
+	if !strings.Contains(target, "://") {
+		target = "http://" + target
+	}
+	u, err := url.Parse(target)
+	if err != nil {
+		return nil, err
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/metrics"
+	}
+	return &otlpHTTPExporter{
+		url:    u.String(),
+		client: &http.Client{},
+	}, nil
+}
+
+func (h *otlpHTTPExporter) export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	hReq, err := http.NewRequestWithContext(ctx, "POST", h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	hReq.Header.Set("Content-Type", "application/x-protobuf")
+
+	res, err := h.client.Do(hReq)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code from collector: %v", res.StatusCode)
+	}
+	return nil
+}
+
+func (h *otlpHTTPExporter) close() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,277 @@
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+type testMetricsCollector struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	reqs chan *colmetricspb.ExportMetricsServiceRequest
+}
+
+func (c *testMetricsCollector) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	c.reqs <- req
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func testGRPCCollector(t *testing.T) (string, <-chan *colmetricspb.ExportMetricsServiceRequest) {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	c := &testMetricsCollector{reqs: make(chan *colmetricspb.ExportMetricsServiceRequest, 10)}
+	s := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(s, c)
+	go func() {
+		_ = s.Serve(lis)
+	}()
+	t.Cleanup(s.Stop)
+
+	return lis.Addr().String(), c.reqs
+}
+
+func testHTTPCollector(t *testing.T) (string, <-chan *colmetricspb.ExportMetricsServiceRequest) {
+	t.Helper()
+
+	reqs := make(chan *colmetricspb.ExportMetricsServiceRequest, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req colmetricspb.ExportMetricsServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &req))
+		reqs <- &req
+	}))
+	t.Cleanup(s.Close)
+
+	return s.Listener.Addr().String(), reqs
+}
+
+func readExport(t *testing.T, reqs <-chan *colmetricspb.ExportMetricsServiceRequest) *colmetricspb.ExportMetricsServiceRequest {
+	t.Helper()
+
+	select {
+	case req := <-reqs:
+		return req
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for export")
+	}
+	return nil
+}
+
+func exportedMetrics(req *colmetricspb.ExportMetricsServiceRequest) map[string]*metricspb.Metric {
+	ms := map[string]*metricspb.Metric{}
+	for _, m := range req.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		ms[m.Name] = m
+	}
+	return ms
+}
+
+func exportedAttributes(dp interface {
+	GetAttributes() []*commonpb.KeyValue
+}) map[string]string {
+	attrs := map[string]string{}
+	for _, kv := range dp.GetAttributes() {
+		attrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	return attrs
+}
+
+func TestOTLPMetricsCumulative(t *testing.T) {
+	grpcAddr, grpcReqs := testGRPCCollector(t)
+	httpAddr, httpReqs := testHTTPCollector(t)
+
+	conf := metrics.NewOTLPConfig()
+	conf.GRPC = []metrics.OTLPCollectorConfig{{URL: grpcAddr}}
+	conf.HTTP = []metrics.OTLPCollectorConfig{{URL: httpAddr}}
+	conf.ResourceAttributes = map[string]string{"deployment.environment.name": "test"}
+	conf.PushInterval = "1h"
+	conf.HistogramBuckets = []float64{0.1, 1}
+
+	m, err := newOTLPMetrics(conf, log.Noop())
+	require.NoError(t, err)
+
+	m.GetCounter("counter_foo").Incr(2)
+	m.GetCounterVec("counter_bar", "topic").With("a").Incr(3)
+	m.GetCounterVec("counter_bar", "topic").With("b").Incr(4)
+	m.GetGauge("gauge_foo").Set(5)
+	tVec := m.GetTimerVec("timer_foo", "topic")
+	tVec.With("a").Timing(int64(time.Millisecond * 50))
+	tVec.With("a").Timing(int64(time.Millisecond * 500))
+	tVec.With("a").Timing(int64(time.Second * 5))
+
+	m.push()
+	m.GetCounter("counter_foo").Incr(1)
+	require.NoError(t, m.Close())
+
+	for _, reqs := range []<-chan *colmetricspb.ExportMetricsServiceRequest{grpcReqs, httpReqs} {
+		req := readExport(t, reqs)
+
+		resAttrs := exportedAttributes(req.ResourceMetrics[0].Resource)
+		assert.Equal(t, map[string]string{
+			"deployment.environment.name": "test",
+			"service.name":                "benthos",
+		}, resAttrs)
+
+		ms := exportedMetrics(req)
+		require.Len(t, ms, 4)
+
+		sum := ms["counter_foo"].GetSum()
+		require.NotNil(t, sum)
+		assert.True(t, sum.IsMonotonic)
+		assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+		assert.Equal(t, int64(2), sum.DataPoints[0].GetAsInt())
+
+		sum = ms["counter_bar"].GetSum()
+		require.Len(t, sum.DataPoints, 2)
+		assert.Equal(t, map[string]string{"topic": "a"}, exportedAttributes(sum.DataPoints[0]))
+		assert.Equal(t, int64(3), sum.DataPoints[0].GetAsInt())
+		assert.Equal(t, map[string]string{"topic": "b"}, exportedAttributes(sum.DataPoints[1]))
+		assert.Equal(t, int64(4), sum.DataPoints[1].GetAsInt())
+
+		assert.Equal(t, int64(5), ms["gauge_foo"].GetGauge().DataPoints[0].GetAsInt())
+
+		assert.Equal(t, "s", ms["timer_foo"].Unit)
+		hist := ms["timer_foo"].GetHistogram()
+		require.Len(t, hist.DataPoints, 1)
+		dp := hist.DataPoints[0]
+		assert.Equal(t, map[string]string{"topic": "a"}, exportedAttributes(dp))
+		assert.Equal(t, uint64(3), dp.Count)
+		assert.InDelta(t, 5.55, dp.GetSum(), 0.0001)
+		assert.Equal(t, []float64{0.1, 1}, dp.ExplicitBounds)
+		assert.Equal(t, []uint64{1, 1, 1}, dp.BucketCounts)
+		assert.InDelta(t, 0.05, dp.GetMin(), 0.0001)
+		assert.InDelta(t, 5, dp.GetMax(), 0.0001)
+	}
+
+	// The final push during close should include the totals since creation.
+	req := readExport(t, grpcReqs)
+	sum := exportedMetrics(req)["counter_foo"].GetSum()
+	assert.Equal(t, int64(3), sum.DataPoints[0].GetAsInt())
+	assert.Equal(t, uint64(3), exportedMetrics(req)["timer_foo"].GetHistogram().DataPoints[0].Count)
+}
+
+func TestOTLPMetricsDelta(t *testing.T) {
+	grpcAddr, grpcReqs := testGRPCCollector(t)
+
+	conf := metrics.NewOTLPConfig()
+	conf.GRPC = []metrics.OTLPCollectorConfig{{URL: grpcAddr}}
+	conf.Temporality = "delta"
+	conf.PushInterval = "1h"
+
+	m, err := newOTLPMetrics(conf, log.Noop())
+	require.NoError(t, err)
+
+	m.GetCounter("counter_foo").Incr(2)
+	m.GetTimer("timer_foo").Timing(int64(time.Millisecond))
+	m.push()
+
+	m.GetCounter("counter_foo").Incr(5)
+	require.NoError(t, m.Close())
+
+	req := readExport(t, grpcReqs)
+	sum := exportedMetrics(req)["counter_foo"].GetSum()
+	assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, sum.AggregationTemporality)
+	assert.Equal(t, int64(2), sum.DataPoints[0].GetAsInt())
+	firstEnd := sum.DataPoints[0].TimeUnixNano
+	assert.Equal(t, uint64(1), exportedMetrics(req)["timer_foo"].GetHistogram().DataPoints[0].Count)
+
+	req = readExport(t, grpcReqs)
+	sum = exportedMetrics(req)["counter_foo"].GetSum()
+	assert.Equal(t, int64(5), sum.DataPoints[0].GetAsInt())
+	assert.Equal(t, firstEnd, sum.DataPoints[0].StartTimeUnixNano)
+
+	hist := exportedMetrics(req)["timer_foo"].GetHistogram()
+	assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA, hist.AggregationTemporality)
+	assert.Equal(t, uint64(0), hist.DataPoints[0].Count)
+	assert.Len(t, hist.DataPoints[0].BucketCounts, len(otlpDefaultBuckets)+1)
+}
+
+func TestOTLPMetricsDeltaFailedPush(t *testing.T) {
+	var failing int32 = 1
+	reqs := make(chan *colmetricspb.ExportMetricsServiceRequest, 10)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "nope", http.StatusServiceUnavailable)
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req colmetricspb.ExportMetricsServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &req))
+		reqs <- &req
+	}))
+	t.Cleanup(s.Close)
+
+	conf := metrics.NewOTLPConfig()
+	conf.HTTP = []metrics.OTLPCollectorConfig{{URL: s.Listener.Addr().String()}}
+	conf.Temporality = "delta"
+	conf.PushInterval = "1h"
+
+	m, err := newOTLPMetrics(conf, log.Noop())
+	require.NoError(t, err)
+
+	m.GetCounter("counter_foo").Incr(2)
+	m.GetTimer("timer_foo").Timing(int64(time.Millisecond))
+	m.push()
+
+	atomic.StoreInt32(&failing, 0)
+	m.GetCounter("counter_foo").Incr(5)
+	m.GetTimer("timer_foo").Timing(int64(time.Second))
+	m.push()
+
+	req := readExport(t, reqs)
+	assert.Equal(t, int64(7), exportedMetrics(req)["counter_foo"].GetSum().DataPoints[0].GetAsInt())
+
+	dp := exportedMetrics(req)["timer_foo"].GetHistogram().DataPoints[0]
+	assert.Equal(t, uint64(2), dp.Count)
+	assert.Equal(t, 0.001, dp.GetMin())
+	assert.Equal(t, 1.0, dp.GetMax())
+	firstEnd := dp.TimeUnixNano
+
+	require.NoError(t, m.Close())
+
+	req = readExport(t, reqs)
+	sum := exportedMetrics(req)["counter_foo"].GetSum()
+	assert.Equal(t, int64(0), sum.DataPoints[0].GetAsInt())
+	assert.Equal(t, firstEnd, sum.DataPoints[0].StartTimeUnixNano)
+}
+
+func TestOTLPMetricsBadConfig(t *testing.T) {
+	conf := metrics.NewOTLPConfig()
+	_, err := newOTLPMetrics(conf, log.Noop())
+	require.EqualError(t, err, "at least one http or grpc collector must be specified")
+
+	conf.HTTP = []metrics.OTLPCollectorConfig{{URL: "localhost:4318"}}
+	conf.Temporality = "nope"
+	_, err = newOTLPMetrics(conf, log.Noop())
+	require.EqualError(t, err, "unrecognised temporality: nope")
+
+	conf.Temporality = "delta"
+	conf.HistogramBuckets = []float64{1, 0.5}
+	_, err = newOTLPMetrics(conf, log.Noop())
+	require.EqualError(t, err, "histogram_buckets must be in ascending order")
+}
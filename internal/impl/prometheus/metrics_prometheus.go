@@ -1,6 +1,7 @@
 package prometheus
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -38,10 +39,22 @@ The Push Gateway is useful for when Benthos instances are short lived. Do not
 include the "/metrics/jobs/..." path in the push URL.
 
 If the Push Gateway requires HTTP Basic Authentication it can be configured with
-` + "`push_basic_auth`.",
+` + "`push_basic_auth`." + `
+
+## Exemplars
+
+When ` + "`add_exemplars`" + ` is set to ` + "`true`" + ` and timings are exported as
+histograms the trace ID of messages is attached to latency observations as an
+[exemplar](https://grafana.com/docs/grafana/latest/fundamentals/exemplars/) with
+the label ` + "`trace_id`" + `. Exemplars are only exposed via the OpenMetrics
+format, which Prometheus requests when the ` + "`exemplar-storage`" + ` feature is
+enabled.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldBool("use_histogram_timing", "Whether to export timing metrics as a histogram, if `false` a summary is used instead. When exporting histogram timings the delta values are converted from nanoseconds into seconds in order to better fit within bucket definitions. For more information on histograms and summaries refer to: https://prometheus.io/docs/practices/histograms/.").HasDefault(false).Advanced().AtVersion("3.63.0"),
 			docs.FieldFloat("histogram_buckets", "Timing metrics histogram buckets (in seconds). If left empty defaults to DefBuckets (https://pkg.go.dev/github.com/prometheus/client_golang/prometheus#pkg-variables)").Array().HasDefault([]any{}).Advanced().AtVersion("3.63.0"),
+			docs.FieldBool("use_native_histograms", "Whether to export timing metrics as [native histograms](https://prometheus.io/docs/concepts/metric_types/#histogram), which offer a high resolution without the need to configure buckets. Native histograms are exposed alongside classic buckets and can only be scraped via the protobuf exposition format, which requires the `native-histograms` feature of Prometheus. Delta values are converted from nanoseconds into seconds as with `use_histogram_timing`.").HasDefault(false).Advanced().AtVersion("4.10.0"),
+			docs.FieldFloat("native_histogram_bucket_factor", "The maximum growth factor between the boundaries of adjacent native histogram buckets, lower values result in a higher resolution at the cost of more buckets.").HasDefault(1.1).Advanced().AtVersion("4.10.0"),
+			docs.FieldBool("add_exemplars", "Whether to attach the trace ID of messages as an exemplar to timing observations such as `output_latency_ns`, allowing you to jump from a latency spike to an offending trace. Exemplars require timings to be exported as histograms with either `use_histogram_timing` or `use_native_histograms`, and enabling them switches the `/metrics` endpoint to the OpenMetrics exposition format when requested by scrapers.").HasDefault(false).Advanced().AtVersion("4.10.0"),
 			docs.FieldBool("add_process_metrics", "Whether to export process metrics such as CPU and memory usage in addition to Benthos metrics.").Advanced().HasDefault(false),
 			docs.FieldBool("add_go_metrics", "Whether to export Go runtime metrics such as GC pauses in addition to Benthos metrics.").Advanced().HasDefault(false),
 			docs.FieldString("push_url", "An optional [Push Gateway URL](#push-gateway) to push metrics to.").Advanced().HasDefault(""),
@@ -85,14 +98,27 @@ func (p *promCounter) Incr(count int64) {
 type promTiming struct {
 	sum       prometheus.Observer
 	asSeconds bool
+	exemplars bool
 }
 
-func (p *promTiming) Timing(val int64) {
+func (p *promTiming) value(val int64) float64 {
 	vFloat := float64(val)
 	if p.asSeconds {
 		vFloat /= 1_000_000_000
 	}
-	p.sum.Observe(vFloat)
+	return vFloat
+}
+
+func (p *promTiming) Timing(val int64) {
+	p.sum.Observe(p.value(val))
+}
+
+func (p *promTiming) TimingWithTraceID(val int64, traceID string) {
+	if eo, ok := p.sum.(prometheus.ExemplarObserver); ok && p.exemplars {
+		eo.ObserveWithExemplar(p.value(val), prometheus.Labels{"trace_id": traceID})
+		return
+	}
+	p.sum.Observe(p.value(val))
 }
 
 //------------------------------------------------------------------------------
@@ -120,13 +146,15 @@ func (p *promTimingVec) With(labelValues ...string) metrics.StatTimer {
 }
 
 type promTimingHistVec struct {
-	sum   *prometheus.HistogramVec
-	count int
+	sum       *prometheus.HistogramVec
+	count     int
+	exemplars bool
 }
 
 func (p *promTimingHistVec) With(labelValues ...string) metrics.StatTimer {
 	return &promTiming{
 		asSeconds: true,
+		exemplars: p.exemplars,
 		sum:       p.sum.WithLabelValues(labelValues...),
 	}
 }
@@ -151,8 +179,10 @@ type prometheusMetrics struct {
 
 	fileOutputPath string
 
-	useHistogramTiming bool
-	histogramBuckets   []float64
+	useHistogramTiming          bool
+	histogramBuckets            []float64
+	nativeHistogramBucketFactor float64
+	addExemplars                bool
 
 	pusher *push.Pusher
 	reg    *prometheus.Registry
@@ -173,6 +203,7 @@ func newPrometheus(config metrics.Config, nm bundle.NewManagement) (metrics.Type
 		closedChan:         make(chan struct{}),
 		useHistogramTiming: promConf.UseHistogramTiming,
 		histogramBuckets:   promConf.HistogramBuckets,
+		addExemplars:       promConf.AddExemplars,
 		reg:                prometheus.NewRegistry(),
 		counters:           map[string]*promCounterVec{},
 		gauges:             map[string]*promGaugeVec{},
@@ -184,6 +215,17 @@ func newPrometheus(config metrics.Config, nm bundle.NewManagement) (metrics.Type
 		p.histogramBuckets = prometheus.DefBuckets
 	}
 
+	if promConf.UseNativeHistograms {
+		if promConf.NativeHistogramBucketFactor <= 1 {
+			return nil, errors.New("native_histogram_bucket_factor must be greater than 1")
+		}
+		p.useHistogramTiming = true
+		p.nativeHistogramBucketFactor = promConf.NativeHistogramBucketFactor
+	}
+	if p.addExemplars && !p.useHistogramTiming {
+		return nil, errors.New("add_exemplars requires either use_histogram_timing or use_native_histograms")
+	}
+
 	if promConf.AddProcessMetrics {
 		if err := p.reg.Register(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{})); err != nil {
 			return nil, err
@@ -233,7 +275,9 @@ func newPrometheus(config metrics.Config, nm bundle.NewManagement) (metrics.Type
 
 func (p *prometheusMetrics) HandlerFunc() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+		promhttp.HandlerFor(p.reg, promhttp.HandlerOpts{
+			EnableOpenMetrics: p.addExemplars,
+		}).ServeHTTP(w, r)
 	}
 }
 
@@ -325,15 +369,17 @@ func (p *prometheusMetrics) getTimerHistVec(path string, labelNames ...string) m
 	var exists bool
 	if pv, exists = p.timersHist[path]; !exists {
 		tmr := prometheus.NewHistogramVec(prometheus.HistogramOpts{
-			Name:    path,
-			Help:    "Benthos Timing metric",
-			Buckets: p.histogramBuckets,
+			Name:                        path,
+			Help:                        "Benthos Timing metric",
+			Buckets:                     p.histogramBuckets,
+			NativeHistogramBucketFactor: p.nativeHistogramBucketFactor,
 		}, labelNames)
 		p.reg.MustRegister(tmr)
 
 		pv = &promTimingHistVec{
-			sum:   tmr,
-			count: len(labelNames),
+			sum:       tmr,
+			count:     len(labelNames),
+			exemplars: p.addExemplars,
 		}
 		p.timersHist[path] = pv
 	}
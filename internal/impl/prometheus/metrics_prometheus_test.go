@@ -10,9 +10,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
 func TestPrometheusNoPushGateway(t *testing.T) {
@@ -215,3 +218,63 @@ func assertContainsTestMetrics(t *testing.T, body string) {
 	assert.Contains(t, body, "\ncountertwo{label1=\"value2\"} 11")
 	assert.Contains(t, body, "\ngaugetwo{label2=\"value3\"} 12")
 }
+
+func TestPrometheusExemplars(t *testing.T) {
+	config := metrics.NewConfig()
+	config.Prometheus.UseHistogramTiming = true
+	config.Prometheus.AddExemplars = true
+
+	nm, err := newPrometheus(config, mock.NewManager())
+	require.NoError(t, err)
+
+	batch := message.QuickBatch([][]byte{[]byte("hello world")})
+	tracing.InitSpans(sdktrace.NewTracerProvider(), "test", batch)
+	traceID := tracing.GetTraceID(batch.Get(0))
+
+	metrics.TimingWithTrace(nm.GetTimer("timerone"), 13, batch)
+	metrics.TimingWithTrace(nm.GetTimer("timertwo"), 14, message.QuickBatch([][]byte{[]byte("no trace")}))
+
+	req := httptest.NewRequest("GET", "http://example.com/foo", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=0.0.1")
+	w := httptest.NewRecorder()
+	nm.HandlerFunc()(w, req)
+
+	body, err := io.ReadAll(w.Result().Body)
+	require.NoError(t, err)
+
+	assert.Contains(t, string(body), `timerone_bucket{le="0.005"} 1 # {trace_id="`+traceID+`"} 1.3e-08`)
+	assert.Contains(t, string(body), `timertwo_bucket{le="0.005"} 1`+"\n")
+}
+
+func TestPrometheusExemplarsRequireHistograms(t *testing.T) {
+	config := metrics.NewConfig()
+	config.Prometheus.AddExemplars = true
+
+	_, err := newPrometheus(config, mock.NewManager())
+	require.Error(t, err)
+}
+
+func TestPrometheusNativeHistograms(t *testing.T) {
+	config := metrics.NewConfig()
+	config.Prometheus.UseNativeHistograms = true
+
+	nm, err := newPrometheus(config, mock.NewManager())
+	require.NoError(t, err)
+
+	nm.GetTimer("timerone").Timing(13)
+	nm.GetTimer("timerone").Timing(1_000_000_000)
+
+	families, err := nm.(*prometheusMetrics).reg.Gather()
+	require.NoError(t, err)
+	require.Len(t, families, 1)
+
+	hist := families[0].GetMetric()[0].GetHistogram()
+	assert.Equal(t, uint64(2), hist.GetSampleCount())
+	assert.Equal(t, int32(3), hist.GetSchema())
+	assert.NotEmpty(t, hist.GetPositiveSpan())
+	assert.NotEmpty(t, hist.GetBucket())
+
+	config.Prometheus.NativeHistogramBucketFactor = 1
+	_, err = newPrometheus(config, mock.NewManager())
+	require.Error(t, err)
+}
@@ -0,0 +1,169 @@
+package pure
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/circuitbreaker"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func circuitBreakerOutputSpec() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Version("4.10.0").
+		Categories("Utility").
+		Summary("Writes messages to a child output, and stops sending to it for a period when the ratio of failed writes reaches a threshold.").
+		Description(`
+Outputs that fail to write messages are retried indefinitely, which against a struggling downstream service means it's continuously hammered with requests whilst a backlog of messages grows. A circuit breaker tracks the ratio of failed writes within a ` + "`window`" + `, and once the ` + "`failure_ratio`" + ` is reached it opens and stops sending messages to the child output for the ` + "`open_duration`" + `.
+
+Once the ` + "`open_duration`" + ` has passed the circuit breaker becomes half-open, where a limited number of probe writes (` + "`half_open_probes`" + `) are attempted. If all probes succeed the circuit breaker closes and messages flow as normal, otherwise it opens again.
+
+### Open Behaviour
+
+By default, whilst the circuit breaker is open messages are blocked until it becomes half-open, during which time the output reports itself as not connected, which is reflected by the ` + "`/ready`" + ` endpoint.
+
+When a ` + "`fallback`" + ` output is configured then messages are instead routed to it whilst the circuit breaker is open, which can be used in order to send messages to a dead letter queue or a secondary service.
+
+### Metrics
+
+The metric ` + "`circuit_breaker_state`" + ` is a gauge of the current state, where ` + "`0`" + ` means closed, ` + "`1`" + ` means half-open and ` + "`2`" + ` means open. The counter ` + "`circuit_breaker_transition`" + ` is incremented each time the state changes and is labelled with the new ` + "`state`" + `.`).
+		Field(service.NewOutputField("output").Description("The child output to write messages to."))
+
+	for _, f := range circuitbreaker.FieldSpecs() {
+		spec = spec.Field(service.NewInternalField(f))
+	}
+
+	return spec.
+		Field(service.NewOutputField("fallback").
+			Description("An optional output to route messages to whilst the circuit breaker is open, if omitted messages are blocked until the circuit breaker is half-open.").
+			Optional()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Example("Dead Letter Queue", `
+Here we write messages to an HTTP service, and when more than half of the requests within a 30 second window fail we route messages to a file instead for 5 minutes before attempting to write to the service again:`,
+			`
+output:
+  circuit_breaker:
+    failure_ratio: 0.5
+    minimum_requests: 20
+    window: 30s
+    open_duration: 5m
+    output:
+      http_client:
+        url: http://example.com/post
+        verb: POST
+    fallback:
+      file:
+        path: ./dlq.jsonl
+        codec: lines
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"circuit_breaker", circuitBreakerOutputSpec(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newCircuitBreakerOutputFromConfig(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// circuitBreakerConfigFromParsed extracts circuit breaker fields from a parsed
+// config.
+func circuitBreakerConfigFromParsed(conf *service.ParsedConfig) (bConf circuitbreaker.Config, err error) {
+	if bConf.FailureRatio, err = conf.FieldFloat("failure_ratio"); err != nil {
+		return
+	}
+	if bConf.MinimumRequests, err = conf.FieldInt("minimum_requests"); err != nil {
+		return
+	}
+	if bConf.Window, err = conf.FieldString("window"); err != nil {
+		return
+	}
+	if bConf.OpenDuration, err = conf.FieldString("open_duration"); err != nil {
+		return
+	}
+	bConf.HalfOpenProbes, err = conf.FieldInt("half_open_probes")
+	return
+}
+
+type circuitBreakerOutput struct {
+	log *service.Logger
+
+	breaker  *circuitbreaker.Breaker
+	output   *service.OwnedOutput
+	fallback *service.OwnedOutput
+}
+
+func newCircuitBreakerOutputFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*circuitBreakerOutput, error) {
+	bConf, err := circuitBreakerConfigFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &circuitBreakerOutput{log: mgr.Logger()}
+	if c.output, err = conf.FieldOutput("output"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("fallback") {
+		if c.fallback, err = conf.FieldOutput("fallback"); err != nil {
+			return nil, err
+		}
+	}
+
+	mState := mgr.Metrics().NewGauge("circuit_breaker_state")
+	mTransition := mgr.Metrics().NewCounter("circuit_breaker_transition", "state")
+	mState.Set(int64(circuitbreaker.StateClosed))
+
+	if c.breaker, err = circuitbreaker.New(bConf, func(from, to circuitbreaker.State) {
+		c.log.Warnf("Circuit breaker state changed from %v to %v", from, to)
+		mState.Set(int64(to))
+		mTransition.Incr(1, to.String())
+	}); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *circuitBreakerOutput) Connect(ctx context.Context) error {
+	// Without a fallback we're considered disconnected whilst open, which
+	// blocks messages until the breaker is ready for probes.
+	if c.fallback == nil && !c.breaker.Available() {
+		return circuitbreaker.ErrOpen
+	}
+	return nil
+}
+
+func (c *circuitBreakerOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	done, allowed := c.breaker.Allow()
+	if !allowed {
+		if c.fallback != nil {
+			return c.fallback.WriteBatch(ctx, batch)
+		}
+		return service.ErrNotConnected
+	}
+
+	err := c.output.WriteBatch(ctx, batch)
+	done(err)
+	return err
+}
+
+func (c *circuitBreakerOutput) Close(ctx context.Context) error {
+	if err := c.output.Close(ctx); err != nil {
+		return err
+	}
+	if c.fallback != nil {
+		return c.fallback.Close(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package pure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func circuitBreakerOutput(t *testing.T, confStr string) (output.Streamed, chan message.Transaction) {
+	t.Helper()
+
+	conf := output.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+
+	s, err := mock.NewManager().NewOutput(conf)
+	require.NoError(t, err)
+
+	sendChan := make(chan message.Transaction)
+	require.NoError(t, s.Consume(sendChan))
+
+	t.Cleanup(func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+		s.TriggerCloseNow()
+		require.NoError(t, s.WaitForClose(ctx))
+		done()
+	})
+	return s, sendChan
+}
+
+func circuitBreakerSend(t *testing.T, sendChan chan message.Transaction, content string) chan error {
+	t.Helper()
+
+	resChan := make(chan error, 1)
+	select {
+	case sendChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(content)}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+	return resChan
+}
+
+func TestCircuitBreakerOutputFallback(t *testing.T) {
+	_, sendChan := circuitBreakerOutput(t, `
+circuit_breaker:
+  minimum_requests: 2
+  failure_ratio: 0.5
+  open_duration: 1h
+  max_in_flight: 1
+  output:
+    reject: nope
+  fallback:
+    drop: {}
+`)
+
+	for i, exp := range []bool{true, true, false, false} {
+		resChan := circuitBreakerSend(t, sendChan, "hello world")
+		select {
+		case err := <-resChan:
+			if exp {
+				assert.Error(t, err, i)
+			} else {
+				assert.NoError(t, err, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	}
+}
+
+func TestCircuitBreakerOutputBlocks(t *testing.T) {
+	s, sendChan := circuitBreakerOutput(t, `
+circuit_breaker:
+  minimum_requests: 1
+  open_duration: 1h
+  max_in_flight: 1
+  output:
+    reject: nope
+`)
+
+	select {
+	case err := <-circuitBreakerSend(t, sendChan, "first"):
+		assert.Error(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+
+	resChan := circuitBreakerSend(t, sendChan, "second")
+	select {
+	case err := <-resChan:
+		t.Fatalf("expected message to be blocked, got: %v", err)
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	assert.Eventually(t, func() bool {
+		return !s.Connected()
+	}, time.Second, time.Millisecond*10)
+}
@@ -6,6 +6,8 @@ import (
 	"sort"
 	"strings"
 
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
@@ -134,8 +136,11 @@ func (l *logProcessor) levelToLogFn(level string) (func(logger log.Modular, msg
 }
 
 func (l *logProcessor) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg message.Batch) ([]message.Batch, error) {
-	_ = msg.Iter(func(i int, _ *message.Part) error {
+	_ = msg.Iter(func(i int, p *message.Part) error {
 		targetLog := l.logger
+		if traceID := tracing.GetTraceID(p); traceID != (trace.TraceID{}).String() {
+			targetLog = targetLog.With("trace_id", traceID)
+		}
 		if l.fieldsMapping != nil {
 			v, err := l.fieldsMapping.Exec(query.FunctionContext{
 				Maps:     map[string]query.Function{},
@@ -2,6 +2,7 @@ package pure
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -21,6 +22,8 @@ import (
 	"github.com/jhump/protoreflect/desc"
 	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/jhump/protoreflect/dynamic"
+	protov2 "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 func init() {
@@ -60,11 +63,37 @@ to manipulate the contents of the document within Benthos.
 
 ### ` + "`from_json`" + `
 
-Attempts to create a target protobuf message from a generic JSON structure.`,
+Attempts to create a target protobuf message from a generic JSON structure.
+
+### ` + "`to_structured`" + `
+
+Converts protobuf messages directly into a structured document, which avoids serialising the result as JSON when the message is to be processed further. Fields of the type ` + "`google.protobuf.Any`" + ` are resolved into the message they contain, where the resulting object contains the field ` + "`@type`" + ` along with the fields of the contained message. The representation of other well known types can be configured with the field ` + "[`well_known_types`](#well_known_types)" + `.
+
+### ` + "`from_structured`" + `
+
+Attempts to create a target protobuf message from the structured contents of a message, which are expected to follow the same format as documents consumed with ` + "`from_json`" + `.
+
+## Schemas
+
+Message definitions are obtained either by parsing .proto files found within ` + "[`import_paths`](#import_paths)" + `, or from compiled and binary encoded ` + "`FileDescriptorSet`" + ` files listed in ` + "[`descriptor_sets`](#descriptor_sets)" + `, which can be produced with ` + "`protoc --include_imports --descriptor_set_out=<file>`" + `. Images produced by [Buf](https://buf.build/) with ` + "`buf build -o <file>`" + ` are compatible with descriptor sets and can therefore also be used.
+
+Alternatively, the definitions of a module hosted on the [Buf Schema Registry](https://buf.build/docs/bsr/introduction) can be fetched when the processor is created by setting ` + "[`bsr.module`](#bsrmodule)" + `, in which case the module and all of its dependencies are obtained from the [reflection API](https://buf.build/docs/bsr/api-access) of the registry.`,
 		Config: docs.FieldComponent().WithChildren(
-			docs.FieldString("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json"),
+			docs.FieldString("operator", "The [operator](#operators) to execute").HasOptions("to_json", "from_json", "to_structured", "from_structured"),
 			docs.FieldString("message", "The fully qualified name of the protobuf message to convert to/from."),
-			docs.FieldString("import_paths", "A list of directories containing .proto files, including all definitions required for parsing the target message. If left empty the current directory is used. Each directory listed will be walked with all found .proto files imported.").Array(),
+			docs.FieldString("import_paths", "A list of directories containing .proto files, including all definitions required for parsing the target message. If left empty, and neither `descriptor_sets` nor a `bsr` module are specified, the current directory is used. Each directory listed will be walked with all found .proto files imported.").Array(),
+			docs.FieldString("descriptor_sets", "A list of paths to binary encoded `FileDescriptorSet` files, such as those produced by `protoc` or `buf build`, containing the definitions required for parsing the target message.", []string{"./schemas.binpb"}).Array().AtVersion("4.10.0"),
+			docs.FieldObject("bsr", "Fetch the definitions of a module from the Buf Schema Registry.").WithChildren(
+				docs.FieldString("module", "The name of a module to fetch the definitions of, including the remote that hosts it. When empty no module is fetched.", "buf.build/acme/weather"),
+				docs.FieldString("version", "An optional version of the module to fetch, which can be a commit, tag or label. When empty the latest version is fetched.", "main", "v1.2.0"),
+				docs.FieldString("api_key", "An API token used to authenticate with the registry, which is required for private modules."),
+				docs.FieldString("url", "An optional URL of the registry API, which by default is derived from the remote of the module.", "https://buf.example.com").Advanced(),
+			).AtVersion("4.10.0"),
+			docs.FieldString("well_known_types", "How well known types, such as `google.protobuf.Timestamp`, are represented when converted with the `to_structured` operator.").HasAnnotatedOptions(
+				"canonical", "Well known types are converted into the same representation as their JSON mapping, e.g. timestamps become RFC 3339 strings and wrapper types become the value they wrap.",
+				"raw", "Well known types are converted as regular messages, e.g. timestamps become objects with the fields `seconds` and `nanos`.",
+			).Advanced().AtVersion("4.10.0"),
+			docs.FieldString("field_mask", "An optional list of field paths, consisting of field names as defined in the schema and separated by dots, that are kept when converting with the `to_json` and `to_structured` operators. When empty all fields are kept.", []string{"id", "content.name"}).Array().Advanced().AtVersion("4.10.0"),
 		).ChildDefaultAndTypesFromStruct(processor.NewProtobufConfig()),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -162,30 +191,69 @@ pipeline:
 
 type protobufOperator func(part *message.Part) error
 
-func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
-	if msg == "" {
+// protobufSchema contains the descriptors loaded for a processor, along with
+// the target message type.
+type protobufSchema struct {
+	files    []*desc.FileDescriptor
+	msg      *desc.MessageDescriptor
+	resolver jsonpb.AnyResolver
+}
+
+func loadProtobufSchema(conf processor.ProtobufConfig) (*protobufSchema, error) {
+	if conf.Message == "" {
 		return nil, errors.New("message field must not be empty")
 	}
 
-	descriptors, err := loadDescriptors(importPaths)
-	if err != nil {
-		return nil, err
+	var files []*desc.FileDescriptor
+	if len(conf.DescriptorSets) > 0 {
+		setFiles, err := loadDescriptorSets(conf.DescriptorSets)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, setFiles...)
+	}
+	if conf.BSR.Module != "" {
+		bsrFiles, err := loadBSRDescriptors(context.Background(), conf.BSR)
+		if err != nil {
+			return nil, fmt.Errorf("bsr: %w", err)
+		}
+		files = append(files, bsrFiles...)
+	}
+	if len(conf.ImportPaths) > 0 || (len(conf.DescriptorSets) == 0 && conf.BSR.Module == "") {
+		pathFiles, err := loadDescriptors(conf.ImportPaths)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, pathFiles...)
 	}
 
-	m := getMessageFromDescriptors(msg, descriptors)
+	m := getMessageFromDescriptors(conf.Message, files)
 	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
+		sources := append(append([]string{}, conf.ImportPaths...), conf.DescriptorSets...)
+		if conf.BSR.Module != "" {
+			sources = append(sources, conf.BSR.Module)
+		}
+		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", conf.Message, sources)
 	}
 
+	return &protobufSchema{
+		files:    files,
+		msg:      m,
+		resolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), files...),
+	}, nil
+}
+
+func newProtobufToJSONOperator(schema *protobufSchema, mask protobufFieldMask) (protobufOperator, error) {
 	marshaller := &jsonpb.Marshaler{
-		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
+		AnyResolver: schema.resolver,
 	}
 
 	return func(part *message.Part) error {
-		msg := dynamic.NewMessage(m)
+		msg := dynamic.NewMessage(schema.msg)
 		if err := proto.Unmarshal(part.AsBytes(), msg); err != nil {
 			return fmt.Errorf("failed to unmarshal message: %w", err)
 		}
+		mask.apply(msg)
 
 		data, err := msg.MarshalJSONPB(marshaller)
 		if err != nil {
@@ -197,29 +265,71 @@ func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperat
 	}, nil
 }
 
-func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOperator, error) {
-	if msg == "" {
-		return nil, errors.New("message field must not be empty")
+func newProtobufFromJSONOperator(schema *protobufSchema) (protobufOperator, error) {
+	unmarshaler := &jsonpb.Unmarshaler{
+		AnyResolver: schema.resolver,
 	}
 
-	descriptors, err := loadDescriptors(importPaths)
-	if err != nil {
-		return nil, err
-	}
+	return func(part *message.Part) error {
+		msg := dynamic.NewMessage(schema.msg)
+		if err := msg.UnmarshalJSONPB(unmarshaler, part.AsBytes()); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
+		}
 
-	m := getMessageFromDescriptors(msg, descriptors)
-	if m == nil {
-		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", msg, importPaths)
+		data, err := msg.Marshal()
+		if err != nil {
+			return fmt.Errorf("failed to marshal protobuf message: %v", err)
+		}
+
+		part.SetBytes(data)
+		return nil
+	}, nil
+}
+
+func newProtobufToStructuredOperator(schema *protobufSchema, mask protobufFieldMask, rawWellKnownTypes bool) (protobufOperator, error) {
+	conv := &protobufStructuredConverter{
+		resolver:          schema.resolver,
+		rawWellKnownTypes: rawWellKnownTypes,
 	}
 
+	return func(part *message.Part) error {
+		msg := dynamic.NewMessage(schema.msg)
+		if err := proto.Unmarshal(part.AsBytes(), msg); err != nil {
+			return fmt.Errorf("failed to unmarshal message: %w", err)
+		}
+		mask.apply(msg)
+
+		v, err := conv.messageToStructured(msg)
+		if err != nil {
+			return fmt.Errorf("failed to convert protobuf message: %w", err)
+		}
+
+		part.SetStructuredMut(v)
+		return nil
+	}, nil
+}
+
+func newProtobufFromStructuredOperator(schema *protobufSchema) (protobufOperator, error) {
 	unmarshaler := &jsonpb.Unmarshaler{
-		AnyResolver: dynamic.AnyResolver(dynamic.NewMessageFactoryWithDefaults(), descriptors...),
+		AnyResolver: schema.resolver,
 	}
 
 	return func(part *message.Part) error {
-		msg := dynamic.NewMessage(m)
-		if err := msg.UnmarshalJSONPB(unmarshaler, part.AsBytes()); err != nil {
-			return fmt.Errorf("failed to unmarshal JSON message: %w", err)
+		v, err := part.AsStructured()
+		if err != nil {
+			return fmt.Errorf("failed to obtain structured message: %w", err)
+		}
+
+		// The structured contents are expected to match the JSON mapping of the
+		// message, and therefore we can rely on the same parser.
+		jBytes, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to marshal structured message: %w", err)
+		}
+
+		msg := dynamic.NewMessage(schema.msg)
+		if err := msg.UnmarshalJSONPB(unmarshaler, jBytes); err != nil {
+			return fmt.Errorf("failed to unmarshal structured message: %w", err)
 		}
 
 		data, err := msg.Marshal()
@@ -232,14 +342,41 @@ func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOper
 	}, nil
 }
 
-func strToProtobufOperator(opStr, message string, importPaths []string) (protobufOperator, error) {
-	switch opStr {
+func strToProtobufOperator(conf processor.ProtobufConfig) (protobufOperator, error) {
+	switch conf.Operator {
+	case "to_json", "from_json", "to_structured", "from_structured":
+	default:
+		return nil, fmt.Errorf("operator not recognised: %v", conf.Operator)
+	}
+
+	var rawWellKnownTypes bool
+	switch conf.WellKnownTypes {
+	case "", "canonical":
+	case "raw":
+		rawWellKnownTypes = true
+	default:
+		return nil, fmt.Errorf("well_known_types option not recognised: %v", conf.WellKnownTypes)
+	}
+
+	schema, err := loadProtobufSchema(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	mask, err := newProtobufFieldMask(schema.msg, conf.FieldMask)
+	if err != nil {
+		return nil, err
+	}
+
+	switch conf.Operator {
 	case "to_json":
-		return newProtobufToJSONOperator(message, importPaths)
+		return newProtobufToJSONOperator(schema, mask)
 	case "from_json":
-		return newProtobufFromJSONOperator(message, importPaths)
+		return newProtobufFromJSONOperator(schema)
+	case "to_structured":
+		return newProtobufToStructuredOperator(schema, mask, rawWellKnownTypes)
 	}
-	return nil, fmt.Errorf("operator not recognised: %v", opStr)
+	return newProtobufFromStructuredOperator(schema)
 }
 
 func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
@@ -280,6 +417,70 @@ func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
 	return fds, err
 }
 
+// loadDescriptorSets reads binary encoded FileDescriptorSet files, such as
+// those produced by protoc with --descriptor_set_out or by buf build. Imports
+// of the well known types that are missing from a set are added from those
+// compiled into Benthos.
+func loadDescriptorSets(paths []string) ([]*desc.FileDescriptor, error) {
+	var fds []*desc.FileDescriptor
+	for _, p := range paths {
+		setBytes, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+		}
+
+		var set descriptorpb.FileDescriptorSet
+		if err := protov2.Unmarshal(setBytes, &set); err != nil {
+			return nil, fmt.Errorf("failed to parse descriptor set '%v': %w", p, err)
+		}
+		setFds, err := descriptorsFromSet(&set)
+		if err != nil {
+			return nil, fmt.Errorf("descriptor set '%v': %w", p, err)
+		}
+		fds = append(fds, setFds...)
+	}
+	return fds, nil
+}
+
+// descriptorsFromSet creates descriptors for each file of a set, adding any
+// imports of the well known types that are missing from the set.
+func descriptorsFromSet(set *descriptorpb.FileDescriptorSet) ([]*desc.FileDescriptor, error) {
+	if err := addMissingDependencies(set); err != nil {
+		return nil, fmt.Errorf("failed to resolve imports: %w", err)
+	}
+
+	files, err := desc.CreateFileDescriptorsFromSet(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create descriptors: %w", err)
+	}
+	fds := make([]*desc.FileDescriptor, 0, len(set.GetFile()))
+	for _, f := range set.GetFile() {
+		fds = append(fds, files[f.GetName()])
+	}
+	return fds, nil
+}
+
+func addMissingDependencies(set *descriptorpb.FileDescriptorSet) error {
+	seen := map[string]struct{}{}
+	for _, f := range set.GetFile() {
+		seen[f.GetName()] = struct{}{}
+	}
+	for i := 0; i < len(set.File); i++ {
+		for _, dep := range set.File[i].GetDependency() {
+			if _, exists := seen[dep]; exists {
+				continue
+			}
+			fd, err := desc.LoadFileDescriptor(dep)
+			if err != nil {
+				return fmt.Errorf("import '%v' not found within set: %w", dep, err)
+			}
+			seen[dep] = struct{}{}
+			set.File = append(set.File, fd.AsFileDescriptorProto())
+		}
+	}
+	return nil
+}
+
 func getMessageFromDescriptors(message string, fds []*desc.FileDescriptor) *desc.MessageDescriptor {
 	var msg *desc.MessageDescriptor
 	for _, fd := range fds {
@@ -303,7 +504,7 @@ func newProtobuf(conf processor.ProtobufConfig, mgr bundle.NewManagement) (*prot
 		log: mgr.Logger(),
 	}
 	var err error
-	if p.operator, err = strToProtobufOperator(conf.Operator, conf.Message, conf.ImportPaths); err != nil {
+	if p.operator, err = strToProtobufOperator(conf); err != nil {
 		return nil, err
 	}
 	return p, nil
@@ -0,0 +1,111 @@
+package pure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+)
+
+const bsrFetchTimeout = 30 * time.Second
+
+// bsrDescriptorSetPath is the path of the GetFileDescriptorSet procedure of
+// the Buf reflection API, which is called with the Connect protocol.
+const bsrDescriptorSetPath = "/buf.reflect.v1beta1.FileDescriptorSetService/GetFileDescriptorSet"
+
+// bsrURL returns the base URL of the registry that hosts a module, which
+// unless overridden is the remote of the module name.
+func bsrURL(conf processor.ProtobufBSRConfig) (string, error) {
+	if conf.URL != "" {
+		return strings.TrimSuffix(conf.URL, "/"), nil
+	}
+	remote, _, found := strings.Cut(conf.Module, "/")
+	if !found || remote == "" {
+		return "", fmt.Errorf("module '%v' must be of the form <remote>/<owner>/<repository>", conf.Module)
+	}
+	return "https://" + remote, nil
+}
+
+// loadBSRDescriptors fetches the FileDescriptorSet of a module, including all
+// of its dependencies, from the Buf Schema Registry.
+func loadBSRDescriptors(ctx context.Context, conf processor.ProtobufBSRConfig) ([]*desc.FileDescriptor, error) {
+	baseURL, err := bsrURL(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	reqBody, err := json.Marshal(struct {
+		Module  string `json:"module"`
+		Version string `json:"version,omitempty"`
+	}{
+		Module:  conf.Module,
+		Version: conf.Version,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, done := context.WithTimeout(ctx, bsrFetchTimeout)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+bsrDescriptorSetPath, bytes.NewReader(reqBody))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connect-Protocol-Version", "1")
+	if conf.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+conf.APIKey)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch module '%v': %w", conf.Module, err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module '%v': %w", conf.Module, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		var connectErr struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		}
+		if jErr := json.Unmarshal(resBody, &connectErr); jErr == nil && connectErr.Code != "" {
+			return nil, fmt.Errorf("failed to fetch module '%v': %v: %v", conf.Module, connectErr.Code, connectErr.Message)
+		}
+		return nil, fmt.Errorf("failed to fetch module '%v': status code %v", conf.Module, res.StatusCode)
+	}
+
+	var resMsg struct {
+		FileDescriptorSet json.RawMessage `json:"fileDescriptorSet"`
+	}
+	if err := json.Unmarshal(resBody, &resMsg); err != nil {
+		return nil, fmt.Errorf("failed to parse response for module '%v': %w", conf.Module, err)
+	}
+	if len(resMsg.FileDescriptorSet) == 0 {
+		return nil, fmt.Errorf("response for module '%v' did not contain a descriptor set", conf.Module)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := protojson.Unmarshal(resMsg.FileDescriptorSet, &set); err != nil {
+		return nil, fmt.Errorf("failed to parse descriptor set of module '%v': %w", conf.Module, err)
+	}
+	fds, err := descriptorsFromSet(&set)
+	if err != nil {
+		return nil, fmt.Errorf("module '%v': %w", conf.Module, err)
+	}
+	return fds, nil
+}
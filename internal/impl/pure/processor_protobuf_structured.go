@@ -0,0 +1,371 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/dynamic"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// protobufStructuredConverter converts dynamic protobuf messages into generic
+// structured values, following the same conventions as the JSON mapping of
+// protobuf where possible.
+type protobufStructuredConverter struct {
+	resolver          jsonpb.AnyResolver
+	rawWellKnownTypes bool
+}
+
+func (c *protobufStructuredConverter) messageToStructured(msg *dynamic.Message) (any, error) {
+	md := msg.GetMessageDescriptor()
+	if md.GetFullyQualifiedName() == "google.protobuf.Any" {
+		return c.anyToStructured(msg)
+	}
+	if !c.rawWellKnownTypes {
+		if v, ok, err := c.wellKnownToStructured(msg); ok || err != nil {
+			return v, err
+		}
+	}
+
+	obj := map[string]any{}
+	for _, fd := range md.GetFields() {
+		if !msg.HasField(fd) {
+			continue
+		}
+		v, err := c.fieldToStructured(fd, msg.GetField(fd))
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %w", fd.GetName(), err)
+		}
+		obj[fd.GetJSONName()] = v
+	}
+	return obj, nil
+}
+
+func (c *protobufStructuredConverter) fieldToStructured(fd *desc.FieldDescriptor, v any) (any, error) {
+	if fd.IsMap() {
+		entries, _ := v.(map[any]any)
+		obj := make(map[string]any, len(entries))
+		for k, e := range entries {
+			ev, err := c.valueToStructured(fd.GetMapValueType(), e)
+			if err != nil {
+				return nil, err
+			}
+			obj[fmt.Sprintf("%v", k)] = ev
+		}
+		return obj, nil
+	}
+	if fd.IsRepeated() {
+		elements, _ := v.([]any)
+		arr := make([]any, len(elements))
+		for i, e := range elements {
+			ev, err := c.valueToStructured(fd, e)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = ev
+		}
+		return arr, nil
+	}
+	return c.valueToStructured(fd, v)
+}
+
+func (c *protobufStructuredConverter) valueToStructured(fd *desc.FieldDescriptor, v any) (any, error) {
+	switch fd.GetType() {
+	case descriptorpb.FieldDescriptorProto_TYPE_ENUM:
+		n, _ := v.(int32)
+		if ev := fd.GetEnumType().FindValueByNumber(n); ev != nil {
+			return ev.GetName(), nil
+		}
+		return int64(n), nil
+	case descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, descriptorpb.FieldDescriptorProto_TYPE_GROUP:
+		pm, ok := v.(proto.Message)
+		if !ok {
+			return nil, fmt.Errorf("unexpected message value type: %T", v)
+		}
+		dm, err := dynamic.AsDynamicMessage(pm)
+		if err != nil {
+			return nil, err
+		}
+		return c.messageToStructured(dm)
+	}
+
+	switch t := v.(type) {
+	case int32:
+		return int64(t), nil
+	case uint32:
+		return uint64(t), nil
+	case float32:
+		return float64(t), nil
+	}
+	return v, nil
+}
+
+// anyToStructured resolves the message contained within an Any, which results
+// in an object containing the type URL along with the fields of the message.
+// Well known types that are not represented by objects are placed within the
+// field value.
+func (c *protobufStructuredConverter) anyToStructured(msg *dynamic.Message) (any, error) {
+	typeURL, _ := msg.GetFieldByName("type_url").(string)
+	value, _ := msg.GetFieldByName("value").([]byte)
+	if typeURL == "" {
+		return map[string]any{}, nil
+	}
+
+	inner, err := c.resolver.Resolve(typeURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve any type '%v': %w", typeURL, err)
+	}
+	if err := proto.Unmarshal(value, inner); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal any type '%v': %w", typeURL, err)
+	}
+	dm, err := dynamic.AsDynamicMessage(inner)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := c.messageToStructured(dm)
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := v.(map[string]any); ok && (c.rawWellKnownTypes || !isProtobufWellKnownType(dm.GetMessageDescriptor())) {
+		obj["@type"] = typeURL
+		return obj, nil
+	}
+	return map[string]any{
+		"@type": typeURL,
+		"value": v,
+	}, nil
+}
+
+func isProtobufWellKnownType(md *desc.MessageDescriptor) bool {
+	switch md.GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp",
+		"google.protobuf.Duration",
+		"google.protobuf.Struct",
+		"google.protobuf.Value",
+		"google.protobuf.ListValue",
+		"google.protobuf.Empty",
+		"google.protobuf.FieldMask",
+		"google.protobuf.DoubleValue",
+		"google.protobuf.FloatValue",
+		"google.protobuf.Int64Value",
+		"google.protobuf.UInt64Value",
+		"google.protobuf.Int32Value",
+		"google.protobuf.UInt32Value",
+		"google.protobuf.BoolValue",
+		"google.protobuf.StringValue",
+		"google.protobuf.BytesValue":
+		return true
+	}
+	return false
+}
+
+// wellKnownToStructured converts well known types into the same values as
+// their canonical JSON representations. Returns false if the message is not a
+// well known type.
+func (c *protobufStructuredConverter) wellKnownToStructured(msg *dynamic.Message) (any, bool, error) {
+	md := msg.GetMessageDescriptor()
+	if !isProtobufWellKnownType(md) {
+		return nil, false, nil
+	}
+
+	switch md.GetFullyQualifiedName() {
+	case "google.protobuf.Timestamp":
+		seconds, _ := msg.GetFieldByName("seconds").(int64)
+		nanos, _ := msg.GetFieldByName("nanos").(int32)
+		return time.Unix(seconds, int64(nanos)).UTC().Format(time.RFC3339Nano), true, nil
+	case "google.protobuf.Duration":
+		seconds, _ := msg.GetFieldByName("seconds").(int64)
+		nanos, _ := msg.GetFieldByName("nanos").(int32)
+		return formatProtobufDuration(seconds, nanos), true, nil
+	case "google.protobuf.Empty":
+		return map[string]any{}, true, nil
+	case "google.protobuf.FieldMask":
+		paths, _ := msg.GetFieldByName("paths").([]any)
+		strs := make([]string, 0, len(paths))
+		for _, p := range paths {
+			s, _ := p.(string)
+			strs = append(strs, protobufSnakeToCamel(s))
+		}
+		return strings.Join(strs, ","), true, nil
+	case "google.protobuf.Struct":
+		fields, _ := msg.GetFieldByName("fields").(map[any]any)
+		obj := make(map[string]any, len(fields))
+		for k, f := range fields {
+			v, err := c.structValueToStructured(f)
+			if err != nil {
+				return nil, true, err
+			}
+			obj[fmt.Sprintf("%v", k)] = v
+		}
+		return obj, true, nil
+	case "google.protobuf.ListValue":
+		values, _ := msg.GetFieldByName("values").([]any)
+		arr := make([]any, len(values))
+		for i, e := range values {
+			v, err := c.structValueToStructured(e)
+			if err != nil {
+				return nil, true, err
+			}
+			arr[i] = v
+		}
+		return arr, true, nil
+	case "google.protobuf.Value":
+		for _, fd := range md.GetFields() {
+			if !msg.HasField(fd) {
+				continue
+			}
+			if fd.GetName() == "null_value" {
+				return nil, true, nil
+			}
+			v, err := c.fieldToStructured(fd, msg.GetField(fd))
+			return v, true, err
+		}
+		return nil, true, nil
+	}
+
+	// The remaining well known types are wrappers of a single value.
+	fd := md.FindFieldByName("value")
+	if fd == nil {
+		return nil, true, errors.New("wrapper type is missing value field")
+	}
+	v, err := c.fieldToStructured(fd, msg.GetField(fd))
+	return v, true, err
+}
+
+func (c *protobufStructuredConverter) structValueToStructured(v any) (any, error) {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("unexpected struct value type: %T", v)
+	}
+	dm, err := dynamic.AsDynamicMessage(pm)
+	if err != nil {
+		return nil, err
+	}
+	return c.messageToStructured(dm)
+}
+
+func formatProtobufDuration(seconds int64, nanos int32) string {
+	var sign string
+	if seconds < 0 || nanos < 0 {
+		sign = "-"
+		if seconds < 0 {
+			seconds = -seconds
+		}
+		if nanos < 0 {
+			nanos = -nanos
+		}
+	}
+	str := sign + strconv.FormatInt(seconds, 10)
+	if nanos > 0 {
+		str += "." + strings.TrimRight(fmt.Sprintf("%09d", nanos), "0")
+	}
+	return str + "s"
+}
+
+func protobufSnakeToCamel(s string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range s {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			b.WriteString(strings.ToUpper(string(r)))
+			upper = false
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+//------------------------------------------------------------------------------
+
+// protobufFieldMask describes the fields of a message that should be kept, where
+// each key is a field name and the value describes the fields of that nested
+// message to keep. A nil mask keeps all fields.
+type protobufFieldMask map[string]protobufFieldMask
+
+func newProtobufFieldMask(md *desc.MessageDescriptor, paths []string) (protobufFieldMask, error) {
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	mask := protobufFieldMask{}
+	for _, path := range paths {
+		node, nodeMsg := mask, md
+		names := strings.Split(path, ".")
+		for i, name := range names {
+			fd := nodeMsg.FindFieldByName(name)
+			if fd == nil {
+				return nil, fmt.Errorf("field mask path '%v' not found in message %v", path, md.GetFullyQualifiedName())
+			}
+			if i == len(names)-1 {
+				node[name] = nil
+				break
+			}
+			if fd.GetMessageType() == nil || fd.IsMap() {
+				return nil, fmt.Errorf("field mask path '%v' cannot descend into field %v", path, name)
+			}
+			next, exists := node[name]
+			if exists && next == nil {
+				// The entire field is already kept.
+				break
+			}
+			if !exists {
+				next = protobufFieldMask{}
+				node[name] = next
+			}
+			node, nodeMsg = next, fd.GetMessageType()
+		}
+	}
+	return mask, nil
+}
+
+// apply clears all fields of a message that are not included within the mask.
+func (m protobufFieldMask) apply(msg *dynamic.Message) {
+	if m == nil {
+		return
+	}
+	for _, fd := range msg.GetMessageDescriptor().GetFields() {
+		sub, keep := m[fd.GetName()]
+		if !keep {
+			msg.ClearField(fd)
+			continue
+		}
+		if sub == nil || !msg.HasField(fd) {
+			continue
+		}
+		if fd.IsRepeated() {
+			elements, _ := msg.GetField(fd).([]any)
+			for i, e := range elements {
+				elements[i] = sub.applyValue(e)
+			}
+			msg.SetField(fd, elements)
+			continue
+		}
+		msg.SetField(fd, sub.applyValue(msg.GetField(fd)))
+	}
+}
+
+func (m protobufFieldMask) applyValue(v any) any {
+	pm, ok := v.(proto.Message)
+	if !ok {
+		return v
+	}
+	dm, err := dynamic.AsDynamicMessage(pm)
+	if err != nil {
+		return v
+	}
+	m.apply(dm)
+	return dm
+}
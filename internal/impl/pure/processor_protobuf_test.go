@@ -2,10 +2,21 @@ package pure_test
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
 
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
@@ -199,3 +210,274 @@ func TestProtobufErrors(t *testing.T) {
 		})
 	}
 }
+
+func newProtobufTestProc(t *testing.T, fn func(conf *processor.ProtobufConfig)) processor.V1 {
+	t.Helper()
+
+	conf := processor.NewConfig()
+	conf.Type = "protobuf"
+	conf.Protobuf.ImportPaths = []string{"../../../config/test/protobuf/schema"}
+	fn(&conf.Protobuf)
+
+	proc, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+	return proc
+}
+
+func protobufTestRun(t *testing.T, proc processor.V1, part *message.Part) *message.Part {
+	t.Helper()
+
+	msgs, res := proc.ProcessBatch(context.Background(), message.Batch{part})
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+	require.Equal(t, 1, msgs[0].Len())
+	require.NoError(t, msgs[0].Get(0).ErrorGet())
+	return msgs[0].Get(0)
+}
+
+func TestProtobufStructured(t *testing.T) {
+	tests := []struct {
+		name           string
+		message        string
+		wellKnownTypes string
+		input          string
+		output         any
+	}{
+		{
+			name:    "basic fields",
+			message: "testing.Person",
+			input:   `{"firstName":"john","lastName":"oates","age":10}`,
+			output: map[string]any{
+				"firstName": "john",
+				"lastName":  "oates",
+				"age":       int64(10),
+			},
+		},
+		{
+			name:    "canonical timestamp",
+			message: "testing.Person",
+			input:   `{"firstName":"john","lastUpdated":"2022-11-20T10:00:00.5Z"}`,
+			output: map[string]any{
+				"firstName":   "john",
+				"lastUpdated": "2022-11-20T10:00:00.5Z",
+			},
+		},
+		{
+			name:           "raw timestamp",
+			message:        "testing.Person",
+			wellKnownTypes: "raw",
+			input:          `{"firstName":"john","lastUpdated":"2022-11-20T10:00:00.5Z"}`,
+			output: map[string]any{
+				"firstName": "john",
+				"lastUpdated": map[string]any{
+					"seconds": int64(1668938400),
+					"nanos":   int64(500000000),
+				},
+			},
+		},
+		{
+			name:    "any field",
+			message: "testing.Envelope",
+			input:   `{"id":747,"content":{"@type":"type.googleapis.com/testing.Person","firstName":"bob"}}`,
+			output: map[string]any{
+				"id": int64(747),
+				"content": map[string]any{
+					"@type":     "type.googleapis.com/testing.Person",
+					"firstName": "bob",
+				},
+			},
+		},
+		{
+			name:    "repeated messages",
+			message: "testing.House",
+			input:   `{"address":"123","people":[{"firstName":"bob"},{"firstName":"jane"}]}`,
+			output: map[string]any{
+				"address": "123",
+				"people": []any{
+					map[string]any{"firstName": "bob"},
+					map[string]any{"firstName": "jane"},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fromJSON := newProtobufTestProc(t, func(conf *processor.ProtobufConfig) {
+				conf.Operator = "from_json"
+				conf.Message = test.message
+			})
+			toStructured := newProtobufTestProc(t, func(conf *processor.ProtobufConfig) {
+				conf.Operator = "to_structured"
+				conf.Message = test.message
+				conf.WellKnownTypes = test.wellKnownTypes
+			})
+			fromStructured := newProtobufTestProc(t, func(conf *processor.ProtobufConfig) {
+				conf.Operator = "from_structured"
+				conf.Message = test.message
+			})
+
+			encoded := protobufTestRun(t, fromJSON, message.NewPart([]byte(test.input))).AsBytes()
+
+			structured := protobufTestRun(t, toStructured, message.NewPart(encoded))
+			v, err := structured.AsStructured()
+			require.NoError(t, err)
+			assert.Equal(t, test.output, v)
+
+			if test.wellKnownTypes == "" {
+				part := message.NewPart(nil)
+				part.SetStructuredMut(v)
+				assert.Equal(t, encoded, protobufTestRun(t, fromStructured, part).AsBytes())
+			}
+		})
+	}
+}
+
+func TestProtobufFieldMask(t *testing.T) {
+	fromJSON := newProtobufTestProc(t, func(conf *processor.ProtobufConfig) {
+		conf.Operator = "from_json"
+		conf.Message = "testing.House"
+	})
+	toJSON := newProtobufTestProc(t, func(conf *processor.ProtobufConfig) {
+		conf.Operator = "to_json"
+		conf.Message = "testing.House"
+		conf.FieldMask = []string{"people.first_name", "people.age"}
+	})
+
+	encoded := protobufTestRun(t, fromJSON, message.NewPart([]byte(
+		`{"address":"123","people":[{"firstName":"bob","lastName":"smith","age":20},{"firstName":"jane","email":"jane@example.com"}]}`,
+	))).AsBytes()
+
+	out := protobufTestRun(t, toJSON, message.NewPart(encoded))
+	assert.Equal(t, `{"people":[{"firstName":"bob","age":20},{"firstName":"jane"}]}`, string(out.AsBytes()))
+
+	conf := processor.NewConfig()
+	conf.Type = "protobuf"
+	conf.Protobuf.Operator = "to_json"
+	conf.Protobuf.Message = "testing.House"
+	conf.Protobuf.ImportPaths = []string{"../../../config/test/protobuf/schema"}
+	conf.Protobuf.FieldMask = []string{"people.nope"}
+
+	_, err := mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "field mask path 'people.nope' not found in message testing.House")
+}
+
+func TestProtobufDescriptorSets(t *testing.T) {
+	parser := protoparse.Parser{
+		ImportPaths: []string{"../../../config/test/protobuf/schema"},
+	}
+	fds, err := parser.ParseFiles("house.proto")
+	require.NoError(t, err)
+
+	// Only include the files of the schema and not the well known types that
+	// it imports, which should be resolved by the processor.
+	set := desc.ToFileDescriptorSet(fds...)
+	files := set.File[:0]
+	for _, f := range set.File {
+		if !strings.HasPrefix(f.GetName(), "google/protobuf/") {
+			files = append(files, f)
+		}
+	}
+	set.File = files
+
+	setBytes, err := proto.Marshal(set)
+	require.NoError(t, err)
+
+	setPath := filepath.Join(t.TempDir(), "house.binpb")
+	require.NoError(t, os.WriteFile(setPath, setBytes, 0o644))
+
+	setProc := func(operator string) processor.V1 {
+		conf := processor.NewConfig()
+		conf.Type = "protobuf"
+		conf.Protobuf.Operator = operator
+		conf.Protobuf.Message = "testing.House"
+		conf.Protobuf.DescriptorSets = []string{setPath}
+
+		proc, err := mock.NewManager().NewProcessor(conf)
+		require.NoError(t, err)
+		return proc
+	}
+
+	input := `{"people":[{"firstName":"bob","lastUpdated":"2022-11-20T10:00:00Z"}],"address":"123"}`
+
+	encoded := protobufTestRun(t, setProc("from_json"), message.NewPart([]byte(input))).AsBytes()
+	out := protobufTestRun(t, setProc("to_json"), message.NewPart(encoded))
+	assert.Equal(t, input, string(out.AsBytes()))
+}
+
+func TestProtobufBSR(t *testing.T) {
+	parser := protoparse.Parser{
+		ImportPaths: []string{"../../../config/test/protobuf/schema"},
+	}
+	fds, err := parser.ParseFiles("house.proto")
+	require.NoError(t, err)
+
+	setJSON, err := protojson.Marshal(desc.ToFileDescriptorSet(fds...))
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/buf.reflect.v1beta1.FileDescriptorSetService/GetFileDescriptorSet", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var req struct {
+			Module  string `json:"module"`
+			Version string `json:"version"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		if r.Header.Get("Authorization") != "Bearer foo" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":"unauthenticated","message":"you must be authenticated"}`))
+			return
+		}
+		if req.Module != "buf.example.com/acme/house" || req.Version != "v1" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":"not_found","message":"module not found"}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"fileDescriptorSet":%s,"version":"abc123"}`, setJSON)
+	}))
+	t.Cleanup(server.Close)
+
+	bsrConf := func(operator, module, apiKey string) processor.Config {
+		conf := processor.NewConfig()
+		conf.Type = "protobuf"
+		conf.Protobuf.Operator = operator
+		conf.Protobuf.Message = "testing.House"
+		conf.Protobuf.BSR.Module = module
+		conf.Protobuf.BSR.Version = "v1"
+		conf.Protobuf.BSR.APIKey = apiKey
+		conf.Protobuf.BSR.URL = server.URL
+		return conf
+	}
+
+	fromJSON, err := mock.NewManager().NewProcessor(bsrConf("from_json", "buf.example.com/acme/house", "foo"))
+	require.NoError(t, err)
+	toJSON, err := mock.NewManager().NewProcessor(bsrConf("to_json", "buf.example.com/acme/house", "foo"))
+	require.NoError(t, err)
+
+	input := `{"people":[{"firstName":"bob","lastUpdated":"2022-11-20T10:00:00Z"}],"address":"123"}`
+
+	encoded := protobufTestRun(t, fromJSON, message.NewPart([]byte(input))).AsBytes()
+	out := protobufTestRun(t, toJSON, message.NewPart(encoded))
+	assert.Equal(t, input, string(out.AsBytes()))
+
+	_, err = mock.NewManager().NewProcessor(bsrConf("to_json", "buf.example.com/acme/house", "bar"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unauthenticated: you must be authenticated")
+
+	_, err = mock.NewManager().NewProcessor(bsrConf("to_json", "buf.example.com/acme/nope", "foo"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not_found: module not found")
+
+	conf := bsrConf("to_json", "nope", "foo")
+	conf.Protobuf.BSR.URL = ""
+	_, err = mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be of the form <remote>/<owner>/<repository>")
+}
@@ -119,7 +119,7 @@ type sftpReader struct {
 func newSFTPReader(conf input.SFTPConfig, mgr bundle.NewManagement) (*sftpReader, error) {
 	codecConf := codec.NewReaderConfig()
 	codecConf.MaxScanTokenSize = conf.MaxBuffer
-	ctor, err := codec.GetReader(conf.Codec, codecConf)
+	ctor, err := mgr.Environment().GetReaderCodec(conf.Codec, codecConf)
 	if err != nil {
 		return nil, err
 	}
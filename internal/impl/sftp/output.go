@@ -88,7 +88,7 @@ func newSFTPWriter(conf output.SFTPConfig, mgr bundle.NewManagement) (*sftpWrite
 	}
 
 	var err error
-	if s.codec, s.codecConf, err = codec.GetWriter(conf.Codec); err != nil {
+	if s.codec, s.codecConf, err = mgr.Environment().GetWriterCodec(conf.Codec); err != nil {
 		return nil, err
 	}
 	if s.path, err = mgr.BloblEnvironment().NewField(conf.Path); err != nil {
@@ -0,0 +1,442 @@
+package splunk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func splunkHECOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Services").
+		Version("4.10.0").
+		Summary("Sends events to a [Splunk HTTP Event Collector](https://docs.splunk.com/Documentation/Splunk/latest/Data/UsetheHTTPEventCollector) (HEC).").
+		Description(`
+Each message is sent as the event of a HEC event object, where messages containing valid JSON are sent as structured events and all others are sent as strings. Messages of a batch are sent within a single request.
+
+### Indexer Acknowledgement
+
+When ` + "`indexer_acknowledgement.enabled`" + ` is set a batch is only acknowledged once Splunk confirms that its events have been indexed, which requires indexer acknowledgement to be enabled for the HEC token. Each request is sent with a channel ID and the resulting ack IDs are polled in the background, allowing multiple batches to await acknowledgement in parallel up to ` + "`max_in_flight`" + `. Batches that are not acknowledged within ` + "`indexer_acknowledgement.timeout`" + ` are sent again.
+
+### Performance
+
+This output benefits from sending multiple messages in flight in parallel for improved performance. You can tune the max number of in flight messages (or message batches) with the field ` + "`max_in_flight`" + `.
+
+This output benefits from sending messages as a batch for improved performance. Batches can be formed at both the input and output level. You can find out more [in this doc](/docs/configuration/batching).`).
+		Field(service.NewStringField("url").
+			Description("The URL of a HEC endpoint. When a path is not specified `/services/collector/event` is used.").
+			Example("https://localhost:8088")).
+		Field(service.NewStringField("token").
+			Description("A HEC token used to authenticate requests.")).
+		Field(service.NewInterpolatedStringField("index").
+			Description("An optional index to write events to. When empty the default index of the token is used.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("source").
+			Description("An optional source value of events.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("sourcetype").
+			Description("An optional sourcetype value of events.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("host").
+			Description("An optional host value of events.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("time").
+			Description("An optional time of events in epoch seconds, which can include a fractional part. When empty the time at which events are received by Splunk is used.").
+			Example(`${! timestamp_unix() }`).
+			Default("").
+			Advanced()).
+		Field(service.NewObjectField("indexer_acknowledgement",
+			service.NewBoolField("enabled").
+				Description("Whether to wait for indexer acknowledgement of each batch.").
+				Default(false),
+			service.NewStringField("channel").
+				Description("The channel ID (a GUID) to send requests with. When empty a random channel is generated.").
+				Default(""),
+			service.NewDurationField("poll_interval").
+				Description("The period of time between each poll of pending acknowledgements.").
+				Default("1s"),
+			service.NewDurationField("timeout").
+				Description("The maximum period of time to wait for a batch to be acknowledged before it is sent again.").
+				Default("1m"),
+		).Description("Wait for Splunk to confirm that events have been indexed before acknowledging batches.")).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for a request to complete.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of parallel message batches to have in flight at any given time.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching"))
+}
+
+func init() {
+	err := service.RegisterBatchOutput("splunk_hec", splunkHECOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (
+			output service.BatchOutput,
+			batchPolicy service.BatchPolicy,
+			maxInFlight int,
+			err error,
+		) {
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			output, err = newHECWriterFromConfig(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type hecWriter struct {
+	log *service.Logger
+
+	eventURL string
+	ackURL   string
+	token    string
+
+	index      *service.InterpolatedString
+	source     *service.InterpolatedString
+	sourcetype *service.InterpolatedString
+	host       *service.InterpolatedString
+	timeField  *service.InterpolatedString
+
+	acksEnabled  bool
+	channel      string
+	pollInterval time.Duration
+	ackTimeout   time.Duration
+
+	client *http.Client
+
+	ackMut     sync.Mutex
+	pendingAck map[int64]chan struct{}
+	pollerWake chan struct{}
+	pollerOnce sync.Once
+	closeChan  chan struct{}
+	closeOnce  sync.Once
+}
+
+func newHECWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*hecWriter, error) {
+	h := &hecWriter{
+		log:        log,
+		client:     &http.Client{},
+		pendingAck: map[int64]chan struct{}{},
+		pollerWake: make(chan struct{}, 1),
+		closeChan:  make(chan struct{}),
+	}
+
+	rawURL, err := conf.FieldString("url")
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/services/collector/event"
+	}
+	h.eventURL = u.String()
+	u.Path = "/services/collector/ack"
+	h.ackURL = u.String()
+
+	if h.token, err = conf.FieldString("token"); err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct {
+		name string
+		dst  **service.InterpolatedString
+	}{
+		{"index", &h.index},
+		{"source", &h.source},
+		{"sourcetype", &h.sourcetype},
+		{"host", &h.host},
+		{"time", &h.timeField},
+	} {
+		if raw, _ := conf.FieldString(f.name); raw == "" {
+			continue
+		}
+		if *f.dst, err = conf.FieldInterpolatedString(f.name); err != nil {
+			return nil, err
+		}
+	}
+
+	ackConf := conf.Namespace("indexer_acknowledgement")
+	if h.acksEnabled, err = ackConf.FieldBool("enabled"); err != nil {
+		return nil, err
+	}
+	if h.channel, err = ackConf.FieldString("channel"); err != nil {
+		return nil, err
+	}
+	if h.channel == "" {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, err
+		}
+		h.channel = id.String()
+	}
+	if h.pollInterval, err = ackConf.FieldDuration("poll_interval"); err != nil {
+		return nil, err
+	}
+	if h.ackTimeout, err = ackConf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+
+	if h.client.Timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		h.client.Transport = &http.Transport{TLSClientConfig: tlsConf}
+	}
+	return h, nil
+}
+
+func (h *hecWriter) Connect(ctx context.Context) error {
+	if h.acksEnabled {
+		h.pollerOnce.Do(func() {
+			go h.pollAcks()
+		})
+	}
+	return nil
+}
+
+type hecResponse struct {
+	Text  string `json:"text"`
+	Code  int    `json:"code"`
+	AckID *int64 `json:"ackId"`
+}
+
+func (h *hecWriter) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for i, msg := range b {
+		event := map[string]any{}
+
+		raw, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+		if json.Valid(raw) {
+			event["event"] = json.RawMessage(raw)
+		} else {
+			event["event"] = string(raw)
+		}
+
+		for k, v := range map[string]*service.InterpolatedString{
+			"index":      h.index,
+			"source":     h.source,
+			"sourcetype": h.sourcetype,
+			"host":       h.host,
+		} {
+			if v != nil {
+				if s := b.InterpolatedString(i, v); s != "" {
+					event[k] = s
+				}
+			}
+		}
+		if h.timeField != nil {
+			if s := b.InterpolatedString(i, h.timeField); s != "" {
+				t, err := strconv.ParseFloat(s, 64)
+				if err != nil {
+					return fmt.Errorf("failed to parse time '%v': %w", s, err)
+				}
+				event["time"] = t
+			}
+		}
+
+		if err := enc.Encode(event); err != nil {
+			return err
+		}
+	}
+
+	var res hecResponse
+	if err := h.post(ctx, h.eventURL, buf.Bytes(), &res); err != nil {
+		return err
+	}
+	if !h.acksEnabled {
+		return nil
+	}
+	if res.AckID == nil {
+		return errors.New("indexer acknowledgement is enabled but no ack ID was returned, ensure it is enabled for the HEC token")
+	}
+	return h.awaitAck(ctx, *res.AckID)
+}
+
+func (h *hecWriter) post(ctx context.Context, target string, body []byte, res *hecResponse) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Splunk "+h.token)
+	req.Header.Set("Content-Type", "application/json")
+	if h.acksEnabled {
+		req.Header.Set("X-Splunk-Request-Channel", h.channel)
+	}
+
+	hRes, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resBody, _ := io.ReadAll(io.LimitReader(hRes.Body, 1<<20))
+	_ = hRes.Body.Close()
+
+	if hRes.StatusCode < 200 || hRes.StatusCode > 299 {
+		var errRes hecResponse
+		if json.Unmarshal(resBody, &errRes) == nil && errRes.Text != "" {
+			return fmt.Errorf("request returned status code %v: %v (code %v)", hRes.StatusCode, errRes.Text, errRes.Code)
+		}
+		return fmt.Errorf("request returned status code %v", hRes.StatusCode)
+	}
+	if res != nil {
+		if err := json.Unmarshal(resBody, res); err != nil {
+			return fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// awaitAck blocks until the ack ID has been confirmed by the background poller,
+// the ack timeout is reached, or the context is cancelled.
+func (h *hecWriter) awaitAck(ctx context.Context, ackID int64) error {
+	ackChan := make(chan struct{})
+	h.ackMut.Lock()
+	h.pendingAck[ackID] = ackChan
+	h.ackMut.Unlock()
+
+	select {
+	case h.pollerWake <- struct{}{}:
+	default:
+	}
+
+	defer func() {
+		h.ackMut.Lock()
+		delete(h.pendingAck, ackID)
+		h.ackMut.Unlock()
+	}()
+
+	select {
+	case <-ackChan:
+		return nil
+	case <-time.After(h.ackTimeout):
+		return fmt.Errorf("timed out waiting for indexer acknowledgement of ack ID %v", ackID)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *hecWriter) pollAcks() {
+	for {
+		select {
+		case <-h.pollerWake:
+		case <-h.closeChan:
+			return
+		}
+
+		for {
+			select {
+			case <-time.After(h.pollInterval):
+			case <-h.closeChan:
+				return
+			}
+			if remaining := h.checkAcks(); remaining == 0 {
+				break
+			}
+		}
+	}
+}
+
+// checkAcks queries the status of all pending ack IDs, releases those that have
+// been confirmed and returns the number still pending.
+func (h *hecWriter) checkAcks() int {
+	h.ackMut.Lock()
+	ids := make([]int64, 0, len(h.pendingAck))
+	for id := range h.pendingAck {
+		ids = append(ids, id)
+	}
+	h.ackMut.Unlock()
+	if len(ids) == 0 {
+		return 0
+	}
+
+	body, err := json.Marshal(map[string]any{"acks": ids})
+	if err != nil {
+		return len(ids)
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), h.client.Timeout)
+	defer done()
+
+	req, err := http.NewRequestWithContext(ctx, "POST", h.ackURL+"?channel="+url.QueryEscape(h.channel), bytes.NewReader(body))
+	if err != nil {
+		return len(ids)
+	}
+	req.Header.Set("Authorization", "Splunk "+h.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Splunk-Request-Channel", h.channel)
+
+	res, err := h.client.Do(req)
+	if err != nil {
+		h.log.Warnf("Failed to poll indexer acknowledgements: %v", err)
+		return len(ids)
+	}
+	resBody, _ := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	_ = res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		h.log.Warnf("Failed to poll indexer acknowledgements: status code %v", res.StatusCode)
+		return len(ids)
+	}
+
+	var ackRes struct {
+		Acks map[string]bool `json:"acks"`
+	}
+	if err := json.Unmarshal(resBody, &ackRes); err != nil {
+		h.log.Warnf("Failed to parse indexer acknowledgements: %v", err)
+		return len(ids)
+	}
+
+	h.ackMut.Lock()
+	defer h.ackMut.Unlock()
+	for idStr, acked := range ackRes.Acks {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil || !acked {
+			continue
+		}
+		if c, exists := h.pendingAck[id]; exists {
+			close(c)
+			delete(h.pendingAck, id)
+		}
+	}
+	return len(h.pendingAck)
+}
+
+func (h *hecWriter) Close(ctx context.Context) error {
+	h.closeOnce.Do(func() {
+		close(h.closeChan)
+	})
+	h.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,203 @@
+package splunk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSplunkHECOutput(t *testing.T) {
+	var eventsMut sync.Mutex
+	var events []map[string]any
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/services/collector/event", r.URL.Path)
+		assert.Equal(t, "Splunk foo", r.Header.Get("Authorization"))
+		assert.Empty(t, r.Header.Get("X-Splunk-Request-Channel"))
+
+		eventsMut.Lock()
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var e map[string]any
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+			events = append(events, e)
+		}
+		eventsMut.Unlock()
+		_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+	}))
+	t.Cleanup(server.Close)
+
+	conf, err := splunkHECOutputConfig().ParseYAML(`
+url: `+server.URL+`
+token: foo
+index: main
+sourcetype: ${! meta("type").or("") }
+time: ${! json("ts").catch("") }
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newHECWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Connect(context.Background()))
+
+	msgA := service.NewMessage([]byte(`{"ts":1665396000.5,"msg":"hello"}`))
+	msgA.MetaSet("type", "_json")
+	msgB := service.NewMessage([]byte(`not json`))
+
+	require.NoError(t, w.WriteBatch(context.Background(), service.MessageBatch{msgA, msgB}))
+	require.NoError(t, w.Close(context.Background()))
+
+	eventsMut.Lock()
+	defer eventsMut.Unlock()
+	assert.Equal(t, []map[string]any{
+		{
+			"event":      map[string]any{"ts": 1665396000.5, "msg": "hello"},
+			"index":      "main",
+			"sourcetype": "_json",
+			"time":       1665396000.5,
+		},
+		{
+			"event": "not json",
+			"index": "main",
+		},
+	}, events)
+}
+
+func TestSplunkHECOutputAcks(t *testing.T) {
+	var ackMut sync.Mutex
+	var nextAckID int64
+	polls := map[int64]int{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/services/collector/event", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-channel", r.Header.Get("X-Splunk-Request-Channel"))
+
+		ackMut.Lock()
+		id := nextAckID
+		nextAckID++
+		ackMut.Unlock()
+
+		_ = json.NewEncoder(w).Encode(map[string]any{"text": "Success", "code": 0, "ackId": id})
+	})
+	mux.HandleFunc("/services/collector/ack", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test-channel", r.URL.Query().Get("channel"))
+
+		var req struct {
+			Acks []int64 `json:"acks"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		// Each ack is only confirmed on the second poll.
+		acks := map[string]bool{}
+		ackMut.Lock()
+		for _, id := range req.Acks {
+			polls[id]++
+			acks[jsonInt(id)] = polls[id] > 1
+		}
+		ackMut.Unlock()
+		_ = json.NewEncoder(w).Encode(map[string]any{"acks": acks})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	conf, err := splunkHECOutputConfig().ParseYAML(`
+url: `+server.URL+`
+token: foo
+indexer_acknowledgement:
+  enabled: true
+  channel: test-channel
+  poll_interval: 10ms
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newHECWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+	require.NoError(t, w.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = w.Close(context.Background())
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+			defer done()
+			assert.NoError(t, w.WriteBatch(ctx, service.MessageBatch{service.NewMessage([]byte("hello"))}))
+		}()
+	}
+	wg.Wait()
+
+	ackMut.Lock()
+	defer ackMut.Unlock()
+	assert.Len(t, polls, 5)
+	for id, n := range polls {
+		assert.Equal(t, 2, n, id)
+	}
+}
+
+func jsonInt(i int64) string {
+	b, _ := json.Marshal(i)
+	return string(b)
+}
+
+func TestSplunkHECOutputErrors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/bad/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"text":"Invalid token","code":4}`))
+	})
+	mux.HandleFunc("/no/acks", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"text":"Success","code":0}`))
+	})
+	mux.HandleFunc("/services/collector/ack", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"acks":{}}`))
+	})
+	mux.HandleFunc("/never/acks", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"text":"Success","code":0,"ackId":0}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	newWriter := func(path string, acks bool) *hecWriter {
+		t.Helper()
+		conf, err := splunkHECOutputConfig().ParseYAML(`
+url: `+server.URL+path+`
+token: foo
+indexer_acknowledgement:
+  enabled: `+map[bool]string{true: "true", false: "false"}[acks]+`
+  poll_interval: 10ms
+  timeout: 100ms
+`, nil)
+		require.NoError(t, err)
+		w, err := newHECWriterFromConfig(conf, nil)
+		require.NoError(t, err)
+		require.NoError(t, w.Connect(context.Background()))
+		t.Cleanup(func() {
+			_ = w.Close(context.Background())
+		})
+		return w
+	}
+
+	batch := service.MessageBatch{service.NewMessage([]byte("hello"))}
+
+	err := newWriter("/bad/token", false).WriteBatch(context.Background(), batch)
+	require.EqualError(t, err, "request returned status code 403: Invalid token (code 4)")
+
+	err = newWriter("/no/acks", true).WriteBatch(context.Background(), batch)
+	require.EqualError(t, err, "indexer acknowledgement is enabled but no ack ID was returned, ensure it is enabled for the HEC token")
+
+	err = newWriter("/never/acks", true).WriteBatch(context.Background(), batch)
+	require.EqualError(t, err, "timed out waiting for indexer acknowledgement of ack ID 0")
+}
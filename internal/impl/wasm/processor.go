@@ -0,0 +1,378 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	wasmPageSize = 65536
+	wasmMaxPages = 65536
+
+	hostModuleName = "benthos_wasm"
+)
+
+func wasmProcessorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Categories("Utility").
+		Version("4.10.0").
+		Summary("Executes a function exported by a [WebAssembly](https://webassembly.org/) module for each message.").
+		Description(`
+This processor allows you to write message transformations in any language that compiles to WebAssembly, such as Rust or TinyGo, and load them at runtime without recompiling Benthos. Modules are executed with [wazero](https://wazero.io/), a runtime written in pure Go.
+
+Each processing thread uses its own instance of the module, and therefore modules do not need to be thread safe. Modules are able to import [WASI](https://wasi.dev/) functions, but do not have access to the filesystem or environment. Modules should be built as reactors (libraries) rather than commands, i.e. with ` + "`-buildmode=c-shared`" + ` for TinyGo or as a ` + "`cdylib`" + ` crate for Rust, and any exported ` + "`_initialize`" + ` function is called when an instance is created.
+
+An execution that exceeds ` + "`timeout`" + `, or a module that attempts to grow its memory beyond ` + "`max_memory`" + `, results in the message failing with an error that can be handled using [error handling patterns](/docs/configuration/error_handling). When an execution times out the instance is discarded and a new instance is created for subsequent messages.
+
+### ABI
+
+The function named by ` + "`function`" + ` must take no arguments and return no values. During its execution the guest accesses the message being processed by calling the following functions imported from the module ` + "`benthos_wasm`" + `. Pointers are offsets into the exported ` + "`memory`" + ` of the guest, and all lengths are in bytes:
+
+| Function | Signature | Description |
+|---|---|---|
+| ` + "`v0_msg_len`" + ` | ` + "`() -> i32`" + ` | Returns the length of the message contents. |
+| ` + "`v0_msg_read`" + ` | ` + "`(ptr i32)`" + ` | Copies the message contents into guest memory at ` + "`ptr`" + `, which must have room for ` + "`v0_msg_len`" + ` bytes. |
+| ` + "`v0_msg_set`" + ` | ` + "`(ptr i32, len i32)`" + ` | Replaces the message contents with a copy of guest memory. |
+| ` + "`v0_meta_len`" + ` | ` + "`(key_ptr i32, key_len i32) -> i32`" + ` | Returns the length of a metadata value, or -1 if the key does not exist. |
+| ` + "`v0_meta_read`" + ` | ` + "`(key_ptr i32, key_len i32, ptr i32)`" + ` | Copies a metadata value into guest memory at ` + "`ptr`" + `. |
+| ` + "`v0_meta_set`" + ` | ` + "`(key_ptr i32, key_len i32, val_ptr i32, val_len i32)`" + ` | Sets a metadata value. |
+| ` + "`v0_meta_delete`" + ` | ` + "`(key_ptr i32, key_len i32)`" + ` | Removes a metadata key. |
+| ` + "`v0_msg_set_error`" + ` | ` + "`(ptr i32, len i32)`" + ` | Fails the message with an error message read from guest memory. |
+| ` + "`v0_msg_drop`" + ` | ` + "`()`" + ` | Removes the message from the resulting batch. |
+
+Changes made to a message only take effect once the function returns successfully.`).
+		Field(service.NewStringField("module_path").
+			Description("The path of a `.wasm` module to load.").
+			Example("./transform.wasm")).
+		Field(service.NewStringField("function").
+			Description("The name of the exported function to call for each message.").
+			Default("process")).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time that a single execution of the function may take before it is aborted.").
+			Default("5s")).
+		Field(service.NewStringField("max_memory").
+			Description("The maximum amount of memory that each instance of the module may use, which is rounded up to a multiple of the WebAssembly page size of 64KiB.").
+			Default("64MiB").
+			Example("16MiB").
+			Advanced())
+}
+
+func init() {
+	err := service.RegisterProcessor("wasm", wasmProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newWasmProcessorFromConfig(conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type wasmProcessor struct {
+	function string
+	timeout  time.Duration
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	modConf  wazero.ModuleConfig
+
+	instances chan api.Module
+}
+
+func newWasmProcessorFromConfig(conf *service.ParsedConfig) (*wasmProcessor, error) {
+	modulePath, err := conf.FieldString("module_path")
+	if err != nil {
+		return nil, err
+	}
+	moduleBytes, err := os.ReadFile(modulePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read module: %w", err)
+	}
+
+	function, err := conf.FieldString("function")
+	if err != nil {
+		return nil, err
+	}
+	timeout, err := conf.FieldDuration("timeout")
+	if err != nil {
+		return nil, err
+	}
+
+	maxMemoryStr, err := conf.FieldString("max_memory")
+	if err != nil {
+		return nil, err
+	}
+	maxMemory, err := humanize.ParseBytes(maxMemoryStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse max_memory: %w", err)
+	}
+	maxPages := (maxMemory + wasmPageSize - 1) / wasmPageSize
+	if maxPages == 0 || maxPages > wasmMaxPages {
+		return nil, errors.New("invalid max_memory, must be between 64KiB and 4GiB")
+	}
+	return newWasmProcessor(moduleBytes, function, timeout, uint32(maxPages))
+}
+
+func newWasmProcessor(moduleBytes []byte, function string, timeout time.Duration, maxPages uint32) (*wasmProcessor, error) {
+	ctx := context.Background()
+
+	p := &wasmProcessor{
+		function:  function,
+		timeout:   timeout,
+		instances: make(chan api.Module, 128),
+		runtime: wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+			WithMemoryLimitPages(maxPages).
+			WithCloseOnContextDone(true)),
+		modConf: wazero.NewModuleConfig().
+			WithName("").
+			WithStartFunctions("_initialize"),
+	}
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, p.runtime); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate wasi: %w", err)
+	}
+	if err := instantiateHostModule(ctx, p.runtime); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to instantiate host module: %w", err)
+	}
+
+	var err error
+	if p.compiled, err = p.runtime.CompileModule(ctx, moduleBytes); err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to compile module: %w", err)
+	}
+
+	fn, exists := p.compiled.ExportedFunctions()[function]
+	if !exists {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("module does not export function '%v'", function)
+	}
+	if len(fn.ParamTypes()) > 0 || len(fn.ResultTypes()) > 0 {
+		_ = p.runtime.Close(ctx)
+		return nil, fmt.Errorf("exported function '%v' must take no arguments and return no values", function)
+	}
+	if _, exists := p.compiled.ExportedMemories()["memory"]; !exists {
+		_ = p.runtime.Close(ctx)
+		return nil, errors.New("module does not export memory")
+	}
+
+	// Instantiate once in order to fail early on modules with missing imports
+	// or failing initialisation.
+	inst, err := p.instantiate(ctx)
+	if err != nil {
+		_ = p.runtime.Close(ctx)
+		return nil, err
+	}
+	p.release(inst)
+	return p, nil
+}
+
+func (p *wasmProcessor) instantiate(ctx context.Context) (api.Module, error) {
+	inst, err := p.runtime.InstantiateModule(ctx, p.compiled, p.modConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate module: %w", err)
+	}
+	return inst, nil
+}
+
+// acquire returns an idle instance of the module, or creates a new one when
+// all instances are in use, which results in an instance per processing
+// thread.
+func (p *wasmProcessor) acquire(ctx context.Context) (api.Module, error) {
+	select {
+	case inst := <-p.instances:
+		return inst, nil
+	default:
+	}
+	return p.instantiate(ctx)
+}
+
+func (p *wasmProcessor) release(inst api.Module) {
+	select {
+	case p.instances <- inst:
+	default:
+		_ = inst.Close(context.Background())
+	}
+}
+
+func (p *wasmProcessor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	inst, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contents, err := msg.AsBytes()
+	if err != nil {
+		p.release(inst)
+		return nil, err
+	}
+
+	state := &callState{
+		msg:      msg,
+		contents: contents,
+		metaSets: map[string]*string{},
+	}
+
+	callCtx, done := context.WithTimeout(context.WithValue(ctx, callStateKey{}, state), p.timeout)
+	_, err = inst.ExportedFunction(p.function).Call(callCtx)
+	timedOut := callCtx.Err() != nil
+	done()
+
+	if err != nil {
+		// An instance that was aborted or trapped may be left in an
+		// inconsistent state and is therefore discarded.
+		_ = inst.Close(context.Background())
+		if timedOut && ctx.Err() == nil {
+			return nil, fmt.Errorf("execution of function '%v' exceeded timeout of %v", p.function, p.timeout)
+		}
+		return nil, fmt.Errorf("execution of function '%v' failed: %w", p.function, err)
+	}
+	p.release(inst)
+
+	if state.err != nil {
+		return nil, state.err
+	}
+	if state.dropped {
+		return nil, nil
+	}
+	if state.contentsSet {
+		msg.SetBytes(state.contents)
+	}
+	for k, v := range state.metaSets {
+		if v == nil {
+			msg.MetaDelete(k)
+		} else {
+			msg.MetaSet(k, *v)
+		}
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (p *wasmProcessor) Close(ctx context.Context) error {
+	return p.runtime.Close(ctx)
+}
+
+//------------------------------------------------------------------------------
+
+type callStateKey struct{}
+
+// callState holds the message being processed by a single execution of the
+// guest function, along with any changes made to it.
+type callState struct {
+	msg *service.Message
+
+	contents    []byte
+	contentsSet bool
+	metaSets    map[string]*string
+	dropped     bool
+	err         error
+}
+
+func (s *callState) metaGet(key string) (string, bool) {
+	if v, exists := s.metaSets[key]; exists {
+		if v == nil {
+			return "", false
+		}
+		return *v, true
+	}
+	return s.msg.MetaGet(key)
+}
+
+var errNoCallState = errors.New("host function called outside of message processing")
+
+func stateFromCtx(ctx context.Context) *callState {
+	state, ok := ctx.Value(callStateKey{}).(*callState)
+	if !ok {
+		panic(errNoCallState)
+	}
+	return state
+}
+
+// readGuest copies a region of guest memory, panicking when out of range,
+// which aborts the execution with an error.
+func readGuest(m api.Module, ptr, length uint32) []byte {
+	b, ok := m.Memory().Read(ptr, length)
+	if !ok {
+		panic(fmt.Errorf("out of range memory read at offset %v of length %v", ptr, length))
+	}
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
+
+func writeGuest(m api.Module, ptr uint32, b []byte) {
+	if !m.Memory().Write(ptr, b) {
+		panic(fmt.Errorf("out of range memory write at offset %v of length %v", ptr, len(b)))
+	}
+}
+
+func instantiateHostModule(ctx context.Context, r wazero.Runtime) error {
+	_, err := r.NewHostModuleBuilder(hostModuleName).
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context) uint32 {
+			return uint32(len(stateFromCtx(ctx).contents))
+		}).
+		Export("v0_msg_len").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr uint32) {
+			writeGuest(m, ptr, stateFromCtx(ctx).contents)
+		}).
+		Export("v0_msg_read").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, length uint32) {
+			state := stateFromCtx(ctx)
+			state.contents = readGuest(m, ptr, length)
+			state.contentsSet = true
+		}).
+		Export("v0_msg_set").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen uint32) int32 {
+			v, exists := stateFromCtx(ctx).metaGet(string(readGuest(m, keyPtr, keyLen)))
+			if !exists {
+				return -1
+			}
+			return int32(len(v))
+		}).
+		Export("v0_meta_len").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen, ptr uint32) {
+			v, _ := stateFromCtx(ctx).metaGet(string(readGuest(m, keyPtr, keyLen)))
+			writeGuest(m, ptr, []byte(v))
+		}).
+		Export("v0_meta_read").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen, valPtr, valLen uint32) {
+			v := string(readGuest(m, valPtr, valLen))
+			stateFromCtx(ctx).metaSets[string(readGuest(m, keyPtr, keyLen))] = &v
+		}).
+		Export("v0_meta_set").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, keyPtr, keyLen uint32) {
+			stateFromCtx(ctx).metaSets[string(readGuest(m, keyPtr, keyLen))] = nil
+		}).
+		Export("v0_meta_delete").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, length uint32) {
+			stateFromCtx(ctx).err = errors.New(string(readGuest(m, ptr, length)))
+		}).
+		Export("v0_msg_set_error").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context) {
+			stateFromCtx(ctx).dropped = true
+		}).
+		Export("v0_msg_drop").
+		Instantiate(ctx)
+	return err
+}
@@ -0,0 +1,172 @@
+package wasm
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// testdata/test.wasm is compiled from testdata/test.wat.
+func testProcessor(t *testing.T, confStr string) *wasmProcessor {
+	t.Helper()
+
+	conf, err := wasmProcessorConfig().ParseYAML(`module_path: ./testdata/test.wasm
+`+confStr, nil)
+	require.NoError(t, err)
+
+	p, err := newWasmProcessorFromConfig(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, p.Close(context.Background()))
+	})
+	return p
+}
+
+func TestWasmProcessor(t *testing.T) {
+	p := testProcessor(t, `function: process`)
+
+	msg := service.NewMessage([]byte("hello world 123"))
+	msg.MetaSet("foo", "bar")
+
+	res, err := p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "HELLO WORLD 123", string(b))
+
+	v, _ := res[0].MetaGet("processed")
+	assert.Equal(t, "true", v)
+	v, _ = res[0].MetaGet("foo")
+	assert.Equal(t, "bar", v)
+}
+
+func TestWasmProcessorParallel(t *testing.T) {
+	p := testProcessor(t, `function: process`)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				res, err := p.Process(context.Background(), service.NewMessage([]byte("foo bar")))
+				require.NoError(t, err)
+				require.Len(t, res, 1)
+
+				b, err := res[0].AsBytes()
+				require.NoError(t, err)
+				assert.Equal(t, "FOO BAR", string(b))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestWasmProcessorMetadata(t *testing.T) {
+	p := testProcessor(t, `function: copy_meta`)
+
+	msg := service.NewMessage([]byte("hello world"))
+	msg.MetaSet("foo", "from metadata")
+
+	res, err := p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "from metadata", string(b))
+
+	_, exists := res[0].MetaGet("foo")
+	assert.False(t, exists)
+
+	_, err = p.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.EqualError(t, err, "foo not set")
+}
+
+func TestWasmProcessorDrop(t *testing.T) {
+	p := testProcessor(t, `function: drop`)
+
+	res, err := p.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.NoError(t, err)
+	assert.Empty(t, res)
+}
+
+func TestWasmProcessorTimeout(t *testing.T) {
+	p := testProcessor(t, `
+function: spin
+timeout: 50ms
+`)
+
+	for i := 0; i < 2; i++ {
+		_, err := p.Process(context.Background(), service.NewMessage([]byte("hello world")))
+		require.EqualError(t, err, "execution of function 'spin' exceeded timeout of 50ms")
+	}
+}
+
+func TestWasmProcessorTrap(t *testing.T) {
+	p := testProcessor(t, `function: trap`)
+
+	for i := 0; i < 2; i++ {
+		_, err := p.Process(context.Background(), service.NewMessage([]byte("hello world")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "execution of function 'trap' failed: wasm error: unreachable")
+	}
+}
+
+func TestWasmProcessorMemoryLimit(t *testing.T) {
+	p := testProcessor(t, `
+function: grow
+max_memory: 128KiB
+`)
+
+	_, err := p.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.NoError(t, err)
+
+	// The same instance is reused and therefore reaches the limit.
+	_, err = p.Process(context.Background(), service.NewMessage([]byte("hello world")))
+	require.EqualError(t, err, "out of memory")
+}
+
+func TestWasmProcessorBadConfig(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		conf   string
+		errStr string
+	}{
+		{
+			name:   "missing function",
+			conf:   `function: nope`,
+			errStr: "module does not export function 'nope'",
+		},
+		{
+			name:   "bad signature",
+			conf:   `function: bad_args`,
+			errStr: "exported function 'bad_args' must take no arguments and return no values",
+		},
+		{
+			name: "bad memory",
+			conf: `
+function: process
+max_memory: 5GiB
+`,
+			errStr: "invalid max_memory, must be between 64KiB and 4GiB",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := wasmProcessorConfig().ParseYAML(`module_path: ./testdata/test.wasm
+`+test.conf, nil)
+			require.NoError(t, err)
+
+			_, err = newWasmProcessorFromConfig(conf)
+			require.EqualError(t, err, test.errStr)
+		})
+	}
+}
@@ -0,0 +1,74 @@
+;; The source of test.wasm, which exercises the benthos_wasm ABI. Compile with:
+;;
+;;   wat2wasm test.wat -o test.wasm
+(module
+  (import "benthos_wasm" "v0_msg_len" (func $msg_len (result i32)))
+  (import "benthos_wasm" "v0_msg_read" (func $msg_read (param i32)))
+  (import "benthos_wasm" "v0_msg_set" (func $msg_set (param i32 i32)))
+  (import "benthos_wasm" "v0_meta_len" (func $meta_len (param i32 i32) (result i32)))
+  (import "benthos_wasm" "v0_meta_read" (func $meta_read (param i32 i32 i32)))
+  (import "benthos_wasm" "v0_meta_set" (func $meta_set (param i32 i32 i32 i32)))
+  (import "benthos_wasm" "v0_meta_delete" (func $meta_delete (param i32 i32)))
+  (import "benthos_wasm" "v0_msg_set_error" (func $msg_set_error (param i32 i32)))
+  (import "benthos_wasm" "v0_msg_drop" (func $msg_drop))
+
+  (memory (export "memory") 1)
+
+  (data (i32.const 0) "processed")
+  (data (i32.const 16) "true")
+  (data (i32.const 32) "foo")
+  (data (i32.const 48) "foo not set")
+  (data (i32.const 64) "out of memory")
+
+  ;; Uppercases ASCII characters of the message and sets the metadata key
+  ;; processed to true.
+  (func (export "process")
+    (local $len i32) (local $i i32) (local $b i32)
+    (local.set $len (call $msg_len))
+    (call $msg_read (i32.const 1024))
+    (local.set $i (i32.const 0))
+    (block $done
+      (loop $next
+        (br_if $done (i32.ge_u (local.get $i) (local.get $len)))
+        (local.set $b (i32.load8_u (i32.add (i32.const 1024) (local.get $i))))
+        (if (i32.ge_u (local.get $b) (i32.const 97))
+          (then
+            (if (i32.le_u (local.get $b) (i32.const 122))
+              (then
+                (i32.store8
+                  (i32.add (i32.const 1024) (local.get $i))
+                  (i32.sub (local.get $b) (i32.const 32)))))))
+        (local.set $i (i32.add (local.get $i) (i32.const 1)))
+        (br $next)))
+    (call $msg_set (i32.const 1024) (local.get $len))
+    (call $meta_set (i32.const 0) (i32.const 9) (i32.const 16) (i32.const 4)))
+
+  ;; Replaces the message with the value of the metadata key foo, which is then
+  ;; deleted, or fails the message when foo is not set.
+  (func (export "copy_meta")
+    (local $len i32)
+    (if (i32.lt_s (local.tee $len (call $meta_len (i32.const 32) (i32.const 3))) (i32.const 0))
+      (then
+        (call $msg_set_error (i32.const 48) (i32.const 11)))
+      (else
+        (call $meta_read (i32.const 32) (i32.const 3) (i32.const 1024))
+        (call $msg_set (i32.const 1024) (local.get $len))
+        (call $meta_delete (i32.const 32) (i32.const 3)))))
+
+  (func (export "drop")
+    (call $msg_drop))
+
+  (func (export "spin")
+    (loop $forever
+      (br $forever)))
+
+  (func (export "trap")
+    unreachable)
+
+  ;; Grows memory by a page, failing the message when the limit is reached.
+  (func (export "grow")
+    (if (i32.eq (memory.grow (i32.const 1)) (i32.const -1))
+      (then
+        (call $msg_set_error (i32.const 64) (i32.const 13)))))
+
+  (func (export "bad_args") (param i32)))
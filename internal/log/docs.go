@@ -27,6 +27,20 @@ func Spec() docs.FieldSpecs {
 			docs.FieldBool("rotate", "Whether to rotate log files automatically.").HasDefault(false),
 			docs.FieldInt("rotate_max_age_days", "The maximum number of days to retain old log files based on the timestamp encoded in their filename, after which they are deleted. Setting to zero disables this mechanism.").HasDefault(0),
 		),
+		docs.FieldObject("otlp", "Specify fields for optionally shipping logs to an [OTLP](https://opentelemetry.io/docs/specs/otlp/) logs endpoint in addition to the standard destination. Logs are buffered and sent in batches from the background, and are dropped rather than blocking the service when the buffer is full. Static fields are added as resource attributes, with `@service` becoming `service.name`, and trace IDs are attached to logs that have them.").WithChildren(
+			docs.FieldString("url", "The URL of an OTLP endpoint to ship logs to. When the protocol is `http` and a path is not specified `/v1/logs` is used. Leave this field empty to disable shipping logs over OTLP.", "localhost:4318", "http://otel-collector:4318/v1/logs").HasDefault(""),
+			docs.FieldString("protocol", "The protocol to ship logs with.").HasOptions("http", "grpc").HasDefault("http"),
+			docs.FieldString("headers", "A map of headers (or gRPC metadata) to add to each request.").Map().HasDefault(map[string]string{}),
+			docs.FieldString("flush_interval", "The period of time between each batch of logs being shipped.").HasDefault("1s"),
+			docs.FieldInt("max_buffer", "The maximum number of logs to buffer, after which further logs are dropped until the buffer is flushed. Dropped logs and failures to ship logs are reported to the standard destination at most once a minute.").HasDefault(10000),
+		).AtVersion("4.10.0"),
+		docs.FieldObject("loki", "Specify fields for optionally shipping logs to a [Grafana Loki](https://grafana.com/oss/loki/) push endpoint in addition to the standard destination. Logs are buffered and sent in batches from the background, and are dropped rather than blocking the service when the buffer is full. Each log is shipped in the configured `format` with a `level` label.").WithChildren(
+			docs.FieldString("url", "The URL of a Loki server to ship logs to. When a path is not specified `/loki/api/v1/push` is used. Leave this field empty to disable shipping logs to Loki.", "http://loki:3100").HasDefault(""),
+			docs.FieldString("labels", "A map of labels to add to each stream of logs.", map[string]string{"app": "benthos", "env": "prod"}).Map().HasDefault(map[string]string{}),
+			docs.FieldString("headers", "A map of headers to add to each request, which can be used for authentication or setting a tenant with `X-Scope-OrgID`.").Map().HasDefault(map[string]string{}),
+			docs.FieldString("flush_interval", "The period of time between each batch of logs being shipped.").HasDefault("1s"),
+			docs.FieldInt("max_buffer", "The maximum number of logs to buffer, after which further logs are dropped until the buffer is flushed. Dropped logs and failures to ship logs are reported to the standard destination at most once a minute.").HasDefault(10000),
+		).AtVersion("4.10.0"),
 	}
 }
 
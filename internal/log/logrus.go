@@ -1,6 +1,7 @@
 package log
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,6 +19,8 @@ type Config struct {
 	TimestampName string            `json:"timestamp_name" yaml:"timestamp_name"`
 	StaticFields  map[string]string `json:"static_fields" yaml:"static_fields"`
 	File          File              `json:"file" yaml:"file"`
+	OTLP          OTLP              `json:"otlp" yaml:"otlp"`
+	Loki          Loki              `json:"loki" yaml:"loki"`
 }
 
 // File contains configuration for file based logging.
@@ -38,6 +41,8 @@ func NewConfig() Config {
 		StaticFields: map[string]string{
 			"@service": "benthos",
 		},
+		OTLP: NewOTLP(),
+		Loki: NewLoki(),
 	}
 }
 
@@ -88,7 +93,8 @@ func (conf *Config) UnmarshalYAML(unmarshal func(any) error) error {
 
 // Logger is an object with support for levelled logging and modular components.
 type Logger struct {
-	entry *logrus.Entry
+	entry    *logrus.Entry
+	shippers []*shipper
 }
 
 // NewV2 returns a new logger from a config, or returns an error if the config
@@ -138,13 +144,45 @@ func NewV2(stream io.Writer, config Config) (Modular, error) {
 		logger.Level = logrus.TraceLevel
 	}
 
+	shippers, err := newShippers(config)
+	if err != nil {
+		return nil, err
+	}
+
 	sFields := logrus.Fields{}
 	for k, v := range config.StaticFields {
 		sFields[k] = v
 	}
 	logEntry := logger.WithFields(sFields)
 
-	return &Logger{entry: logEntry}, nil
+	for _, s := range shippers {
+		logger.AddHook(s)
+		s.start(logEntry)
+	}
+
+	return &Logger{entry: logEntry, shippers: shippers}, nil
+}
+
+// Flush blocks until all logs buffered for shipping have been sent, or the
+// context is cancelled.
+func (l *Logger) Flush(ctx context.Context) error {
+	for _, s := range l.shippers {
+		if err := s.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown sends all logs buffered for shipping and stops shipping any further
+// logs.
+func (l *Logger) Shutdown(ctx context.Context) error {
+	for _, s := range l.shippers {
+		if err := s.shutdown(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -0,0 +1,304 @@
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// shippedEntry is a snapshot of a log entry queued for shipping.
+type shippedEntry struct {
+	time    time.Time
+	level   logrus.Level
+	message string
+	line    string
+	fields  map[string]any
+	traceID string
+	spanID  string
+}
+
+type shipFn func(ctx context.Context, entries []shippedEntry) error
+
+// shippingNoticeInterval is the minimum period between notices of dropped
+// entries or failed shipments being logged by a shipper.
+const shippingNoticeInterval = time.Minute
+
+// shippingNoticeKey marks the context of log entries that are notices from a
+// shipper, which are written locally but not shipped in order to avoid a
+// feedback loop.
+type shippingNoticeKey struct{}
+
+// shipper is a logrus hook that buffers log entries and ships them in batches
+// from a background goroutine. Entries are dropped rather than blocking the
+// caller when the buffer is full.
+type shipper struct {
+	name      string
+	ship      shipFn
+	timeout   time.Duration
+	interval  time.Duration
+	maxBuffer int
+
+	entries  chan shippedEntry
+	flushReq chan chan struct{}
+	dropped  int64
+
+	// Notices of dropped entries and failed shipments are aggregated and
+	// logged at most once per noticeInterval. These fields are only accessed
+	// by the background goroutine once started.
+	notices        *logrus.Entry
+	noticeInterval time.Duration
+	lastNotice     time.Time
+	pendingDropped int64
+	pendingFailed  int
+	pendingErr     error
+
+	closeOnce sync.Once
+	closeChan chan struct{}
+	doneChan  chan struct{}
+}
+
+func newShipper(name string, ship shipFn, flushInterval string, maxBuffer int) (*shipper, error) {
+	interval, err := time.ParseDuration(flushInterval)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flush interval: %w", err)
+	}
+	if interval <= 0 {
+		return nil, errors.New("flush interval must be greater than zero")
+	}
+	if maxBuffer <= 0 {
+		return nil, errors.New("max buffer must be greater than zero")
+	}
+
+	s := &shipper{
+		name:      name,
+		ship:      ship,
+		timeout:   interval * 5,
+		interval:  interval,
+		maxBuffer: maxBuffer,
+		entries:   make(chan shippedEntry, maxBuffer),
+		flushReq:  make(chan chan struct{}),
+		closeChan: make(chan struct{}),
+		doneChan:  make(chan struct{}),
+
+		noticeInterval: shippingNoticeInterval,
+	}
+	return s, nil
+}
+
+// start begins shipping entries in the background, where notices of dropped
+// entries and failed shipments are logged to the provided entry.
+func (s *shipper) start(notices *logrus.Entry) {
+	s.notices = notices.WithContext(context.WithValue(context.Background(), shippingNoticeKey{}, struct{}{}))
+	go s.loop()
+}
+
+// Levels returns all levels as filtering is performed by the logger.
+func (s *shipper) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire queues a log entry for shipping.
+func (s *shipper) Fire(entry *logrus.Entry) error {
+	if entry.Context != nil && entry.Context.Value(shippingNoticeKey{}) != nil {
+		return nil
+	}
+
+	e := shippedEntry{
+		time:    entry.Time,
+		level:   entry.Level,
+		message: entry.Message,
+		fields:  make(map[string]any, len(entry.Data)),
+	}
+	for k, v := range entry.Data {
+		e.fields[k] = v
+	}
+	if line, err := entry.Logger.Formatter.Format(entry); err == nil {
+		e.line = string(trimNewline(line))
+	} else {
+		e.line = entry.Message
+	}
+
+	if entry.Context != nil {
+		if sc := trace.SpanContextFromContext(entry.Context); sc.IsValid() {
+			e.traceID, e.spanID = sc.TraceID().String(), sc.SpanID().String()
+		}
+	}
+	if e.traceID == "" {
+		if id, ok := e.fields["trace_id"].(string); ok {
+			e.traceID = id
+		}
+	}
+
+	select {
+	case s.entries <- e:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+	return nil
+}
+
+func trimNewline(b []byte) []byte {
+	for len(b) > 0 && (b[len(b)-1] == '\n' || b[len(b)-1] == '\r') {
+		b = b[:len(b)-1]
+	}
+	return b
+}
+
+func (s *shipper) loop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	var batch []shippedEntry
+	drain := func() {
+		for {
+			select {
+			case e := <-s.entries:
+				batch = append(batch, e)
+				if len(batch) >= s.maxBuffer {
+					return
+				}
+			default:
+				return
+			}
+		}
+	}
+	send := func() {
+		drain()
+		s.pendingDropped += atomic.SwapInt64(&s.dropped, 0)
+		if len(batch) == 0 {
+			return
+		}
+		ctx, done := context.WithTimeout(context.Background(), s.timeout)
+		if err := s.ship(ctx, batch); err != nil {
+			s.pendingFailed += len(batch)
+			s.pendingErr = err
+		}
+		done()
+		batch = nil
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			send()
+			s.notify(false)
+		case e := <-s.entries:
+			if batch = append(batch, e); len(batch) >= s.maxBuffer {
+				send()
+				s.notify(false)
+			}
+		case resChan := <-s.flushReq:
+			send()
+			s.notify(false)
+			close(resChan)
+		case <-s.closeChan:
+			send()
+			s.notify(true)
+			return
+		}
+	}
+}
+
+// notify logs the entries dropped and failed to be shipped since the last
+// notice, unless a notice was logged within the notice interval and force is
+// false.
+func (s *shipper) notify(force bool) {
+	if s.pendingDropped == 0 && s.pendingFailed == 0 {
+		return
+	}
+	if !force && time.Since(s.lastNotice) < s.noticeInterval {
+		return
+	}
+	s.lastNotice = time.Now()
+
+	if s.pendingDropped > 0 {
+		s.notices.Warnf("Dropped %v log entries due to a full %v shipping buffer", s.pendingDropped, s.name)
+	}
+	if s.pendingFailed > 0 {
+		s.notices.Errorf("Failed to ship %v log entries to %v: %v", s.pendingFailed, s.name, s.pendingErr)
+	}
+	s.pendingDropped, s.pendingFailed, s.pendingErr = 0, 0, nil
+}
+
+// flush ships all buffered entries and blocks until complete or the context is
+// cancelled.
+func (s *shipper) flush(ctx context.Context) error {
+	resChan := make(chan struct{})
+	select {
+	case s.flushReq <- resChan:
+	case <-s.doneChan:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-resChan:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// shutdown ships all buffered entries and stops the background goroutine.
+func (s *shipper) shutdown(ctx context.Context) error {
+	s.closeOnce.Do(func() {
+		close(s.closeChan)
+	})
+	select {
+	case <-s.doneChan:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func newShippers(config Config) ([]*shipper, error) {
+	var shippers []*shipper
+	if config.OTLP.URL != "" {
+		ship, err := newOTLPShipFn(config.OTLP, config.StaticFields)
+		if err != nil {
+			return nil, fmt.Errorf("otlp: %w", err)
+		}
+		s, err := newShipper("otlp", ship, config.OTLP.FlushInterval, config.OTLP.MaxBuffer)
+		if err != nil {
+			return nil, fmt.Errorf("otlp: %w", err)
+		}
+		shippers = append(shippers, s)
+	}
+	if config.Loki.URL != "" {
+		ship, err := newLokiShipFn(config.Loki)
+		if err != nil {
+			return nil, fmt.Errorf("loki: %w", err)
+		}
+		s, err := newShipper("loki", ship, config.Loki.FlushInterval, config.Loki.MaxBuffer)
+		if err != nil {
+			return nil, fmt.Errorf("loki: %w", err)
+		}
+		shippers = append(shippers, s)
+	}
+	return shippers, nil
+}
+
+func decodeHexID(id string, size int) []byte {
+	b, err := hex.DecodeString(id)
+	if err != nil || len(b) != size {
+		return nil
+	}
+	for _, c := range b {
+		if c != 0 {
+			return b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+)
+
+// Loki contains configuration for shipping logs to a Loki push endpoint.
+type Loki struct {
+	URL           string            `json:"url" yaml:"url"`
+	Labels        map[string]string `json:"labels" yaml:"labels"`
+	Headers       map[string]string `json:"headers" yaml:"headers"`
+	FlushInterval string            `json:"flush_interval" yaml:"flush_interval"`
+	MaxBuffer     int               `json:"max_buffer" yaml:"max_buffer"`
+}
+
+// NewLoki returns a Loki config with default values.
+func NewLoki() Loki {
+	return Loki{
+		URL:           "",
+		Labels:        map[string]string{},
+		Headers:       map[string]string{},
+		FlushInterval: "1s",
+		MaxBuffer:     10000,
+	}
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][]string        `json:"values"`
+}
+
+func newLokiShipFn(config Loki) (shipFn, error) {
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/loki/api/v1/push"
+	}
+	target := u.String()
+
+	client := &http.Client{}
+	return func(ctx context.Context, entries []shippedEntry) error {
+		// Entries are grouped into a stream per level, with the level added
+		// as a label.
+		streams := map[string]*lokiStream{}
+		for _, e := range entries {
+			level := e.level.String()
+			s, exists := streams[level]
+			if !exists {
+				labels := make(map[string]string, len(config.Labels)+1)
+				for k, v := range config.Labels {
+					labels[k] = v
+				}
+				labels["level"] = level
+				s = &lokiStream{Stream: labels}
+				streams[level] = s
+			}
+			s.Values = append(s.Values, []string{strconv.FormatInt(e.time.UnixNano(), 10), e.line})
+		}
+
+		levels := make([]string, 0, len(streams))
+		for k := range streams {
+			levels = append(levels, k)
+		}
+		sort.Strings(levels)
+
+		var payload struct {
+			Streams []*lokiStream `json:"streams"`
+		}
+		for _, l := range levels {
+			payload.Streams = append(payload.Streams, streams[l])
+		}
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range config.Headers {
+			req.Header.Set(k, v)
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		_, _ = io.Copy(io.Discard, res.Body)
+		_ = res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("unexpected status code: %v", res.StatusCode)
+		}
+		return nil
+	}, nil
+}
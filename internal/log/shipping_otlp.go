@@ -0,0 +1,181 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// OTLP contains configuration for shipping logs to an OTLP logs endpoint.
+type OTLP struct {
+	URL           string            `json:"url" yaml:"url"`
+	Protocol      string            `json:"protocol" yaml:"protocol"`
+	Headers       map[string]string `json:"headers" yaml:"headers"`
+	FlushInterval string            `json:"flush_interval" yaml:"flush_interval"`
+	MaxBuffer     int               `json:"max_buffer" yaml:"max_buffer"`
+}
+
+// NewOTLP returns an OTLP config with default values.
+func NewOTLP() OTLP {
+	return OTLP{
+		URL:           "",
+		Protocol:      "http",
+		Headers:       map[string]string{},
+		FlushInterval: "1s",
+		MaxBuffer:     10000,
+	}
+}
+
+var otlpSeverities = map[logrus.Level]logspb.SeverityNumber{
+	logrus.TraceLevel: logspb.SeverityNumber_SEVERITY_NUMBER_TRACE,
+	logrus.DebugLevel: logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG,
+	logrus.InfoLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_INFO,
+	logrus.WarnLevel:  logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+	logrus.ErrorLevel: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+	logrus.FatalLevel: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL,
+	logrus.PanicLevel: logspb.SeverityNumber_SEVERITY_NUMBER_FATAL4,
+}
+
+func newOTLPShipFn(config OTLP, staticFields map[string]string) (shipFn, error) {
+	var export func(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error
+
+	switch config.Protocol {
+	case "http":
+		target := config.URL
+		if !strings.Contains(target, "://") {
+			target = "http://" + target
+		}
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/v1/logs"
+		}
+		target = u.String()
+
+		client := &http.Client{}
+		export = func(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+			body, err := proto.Marshal(req)
+			if err != nil {
+				return err
+			}
+			hReq, err := http.NewRequestWithContext(ctx, "POST", target, bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			hReq.Header.Set("Content-Type", "application/x-protobuf")
+			for k, v := range config.Headers {
+				hReq.Header.Set(k, v)
+			}
+			res, err := client.Do(hReq)
+			if err != nil {
+				return err
+			}
+			_, _ = io.Copy(io.Discard, res.Body)
+			_ = res.Body.Close()
+			if res.StatusCode < 200 || res.StatusCode > 299 {
+				return fmt.Errorf("unexpected status code: %v", res.StatusCode)
+			}
+			return nil
+		}
+	case "grpc":
+		conn, err := grpc.Dial(config.URL, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		client := collogspb.NewLogsServiceClient(conn)
+		md := metadata.New(config.Headers)
+		export = func(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+			_, err := client.Export(metadata.NewOutgoingContext(ctx, md), req)
+			return err
+		}
+	default:
+		return nil, fmt.Errorf("protocol '%v' not recognised", config.Protocol)
+	}
+
+	resAttrs := map[string]any{"service.name": "benthos"}
+	for k, v := range staticFields {
+		if k == "@service" {
+			k = "service.name"
+		}
+		resAttrs[k] = v
+	}
+	resource := &resourcepb.Resource{Attributes: otlpLogAttributes(resAttrs)}
+
+	return func(ctx context.Context, entries []shippedEntry) error {
+		records := make([]*logspb.LogRecord, 0, len(entries))
+		for _, e := range entries {
+			attrs := make(map[string]any, len(e.fields))
+			for k, v := range e.fields {
+				if sv, isStatic := staticFields[k]; (isStatic && sv == v) || k == "trace_id" {
+					continue
+				}
+				attrs[k] = v
+			}
+			records = append(records, &logspb.LogRecord{
+				TimeUnixNano:         uint64(e.time.UnixNano()),
+				ObservedTimeUnixNano: uint64(e.time.UnixNano()),
+				SeverityNumber:       otlpSeverities[e.level],
+				SeverityText:         strings.ToUpper(e.level.String()),
+				Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: e.message}},
+				Attributes:           otlpLogAttributes(attrs),
+				TraceId:              decodeHexID(e.traceID, 16),
+				SpanId:               decodeHexID(e.spanID, 8),
+			})
+		}
+		return export(ctx, &collogspb.ExportLogsServiceRequest{
+			ResourceLogs: []*logspb.ResourceLogs{{
+				Resource: resource,
+				ScopeLogs: []*logspb.ScopeLogs{{
+					Scope:      &commonpb.InstrumentationScope{Name: "benthos"},
+					LogRecords: records,
+				}},
+			}},
+		})
+	}, nil
+}
+
+func otlpLogAttributes(fields map[string]any) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		var v *commonpb.AnyValue
+		switch t := fields[k].(type) {
+		case string:
+			v = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: t}}
+		case bool:
+			v = &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: t}}
+		case int:
+			v = &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(t)}}
+		case int64:
+			v = &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: t}}
+		case float64:
+			v = &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: t}}
+		default:
+			v = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprintf("%v", t)}}
+		}
+		attrs = append(attrs, &commonpb.KeyValue{Key: k, Value: v})
+	}
+	return attrs
+}
@@ -0,0 +1,229 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestShippingLoki(t *testing.T) {
+	var mut sync.Mutex
+	var reqs []map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/push", r.URL.Path)
+		assert.Equal(t, "tenant-a", r.Header.Get("X-Scope-OrgID"))
+
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mut.Lock()
+		reqs = append(reqs, body)
+		mut.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	conf := NewConfig()
+	conf.Format = "logfmt"
+	conf.LogLevel = "INFO"
+	conf.Loki.URL = server.URL
+	conf.Loki.Labels = map[string]string{"app": "foo"}
+	conf.Loki.Headers = map[string]string{"X-Scope-OrgID": "tenant-a"}
+	conf.Loki.FlushInterval = "1h"
+
+	l, err := NewV2(io.Discard, conf)
+	require.NoError(t, err)
+
+	l.Infoln("hello world")
+	l.With("foo", "bar").Warnln("uh oh")
+	l.Debugln("not shipped")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, l.(*Logger).Flush(ctx))
+
+	mut.Lock()
+	require.Len(t, reqs, 1)
+	streams := reqs[0]["streams"].([]any)
+	mut.Unlock()
+	require.Len(t, streams, 2)
+
+	info := streams[0].(map[string]any)
+	assert.Equal(t, map[string]any{"app": "foo", "level": "info"}, info["stream"])
+	infoValues := info["values"].([]any)
+	require.Len(t, infoValues, 1)
+	assert.Equal(t, `level=info msg="hello world" @service=benthos`, infoValues[0].([]any)[1])
+
+	warn := streams[1].(map[string]any)
+	assert.Equal(t, map[string]any{"app": "foo", "level": "warning"}, warn["stream"])
+	assert.Equal(t, `level=warning msg="uh oh" @service=benthos foo=bar`, warn["values"].([]any)[0].([]any)[1])
+
+	require.NoError(t, l.(*Logger).Shutdown(ctx))
+}
+
+func TestShippingOTLP(t *testing.T) {
+	reqChan := make(chan *collogspb.ExportLogsServiceRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/logs", r.URL.Path)
+		assert.Equal(t, "Bearer foo", r.Header.Get("Authorization"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req collogspb.ExportLogsServiceRequest
+		require.NoError(t, proto.Unmarshal(body, &req))
+		reqChan <- &req
+	}))
+	t.Cleanup(server.Close)
+
+	conf := NewConfig()
+	conf.StaticFields = map[string]string{"@service": "foo", "region": "eu"}
+	conf.OTLP.URL = server.URL
+	conf.OTLP.Headers = map[string]string{"Authorization": "Bearer foo"}
+	conf.OTLP.FlushInterval = "1h"
+
+	l, err := NewV2(io.Discard, conf)
+	require.NoError(t, err)
+
+	l.With("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736", "count", 5).Errorf("failed: %v", "nope")
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, l.(*Logger).Shutdown(ctx))
+
+	var req *collogspb.ExportLogsServiceRequest
+	select {
+	case req = <-reqChan:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	rl := req.ResourceLogs[0]
+	resAttrs := map[string]string{}
+	for _, kv := range rl.Resource.Attributes {
+		resAttrs[kv.Key] = kv.Value.GetStringValue()
+	}
+	assert.Equal(t, map[string]string{"service.name": "foo", "region": "eu"}, resAttrs)
+
+	records := rl.ScopeLogs[0].LogRecords
+	require.Len(t, records, 1)
+	assert.Equal(t, "failed: nope", records[0].Body.GetStringValue())
+	assert.Equal(t, logspb.SeverityNumber_SEVERITY_NUMBER_ERROR, records[0].SeverityNumber)
+	assert.Equal(t, "ERROR", records[0].SeverityText)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", hex.EncodeToString(records[0].TraceId))
+	require.Len(t, records[0].Attributes, 1)
+	assert.Equal(t, "count", records[0].Attributes[0].Key)
+	assert.Equal(t, int64(5), records[0].Attributes[0].Value.GetIntValue())
+}
+
+func TestShippingNonBlocking(t *testing.T) {
+	unblock := make(chan struct{})
+	var shipped []shippedEntry
+	s, err := newShipper("test", func(ctx context.Context, entries []shippedEntry) error {
+		<-unblock
+		shipped = append(shipped, entries...)
+		return nil
+	}, "1h", 2)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+	logger.AddHook(s)
+	s.start(logger.WithFields(logrus.Fields{}))
+
+	// The shipper is blocked once its buffer fills, at which point further
+	// logs must be dropped rather than block the caller.
+	logDone := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			logger.Info("hello")
+		}
+		close(logDone)
+	}()
+
+	select {
+	case <-logDone:
+	case <-time.After(time.Second * 5):
+		t.Fatal("logging blocked")
+	}
+
+	close(unblock)
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+	require.NoError(t, s.shutdown(ctx))
+
+	assert.Less(t, len(shipped), 100)
+	assert.Greater(t, len(shipped), 0)
+	for _, e := range shipped {
+		assert.Equal(t, "hello", e.message)
+	}
+	assert.Contains(t, out.String(), "log entries due to a full test shipping buffer")
+}
+
+func TestShippingFailureNotices(t *testing.T) {
+	var attempts int
+	s, err := newShipper("test", func(ctx context.Context, entries []shippedEntry) error {
+		attempts++
+		return errors.New("nope")
+	}, "1h", 10)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.Out = &out
+	logger.AddHook(s)
+	s.start(logger.WithFields(logrus.Fields{}))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	logger.Info("first")
+	require.NoError(t, s.flush(ctx))
+	assert.Equal(t, 1, strings.Count(out.String(), "Failed to ship"))
+	assert.Contains(t, out.String(), "Failed to ship 1 log entries to test: nope")
+
+	// Notices are rate limited, and therefore subsequent failures are
+	// aggregated until the next notice.
+	logger.Info("second")
+	require.NoError(t, s.flush(ctx))
+	logger.Info("third")
+	require.NoError(t, s.flush(ctx))
+	assert.Equal(t, 1, strings.Count(out.String(), "Failed to ship"))
+
+	require.NoError(t, s.shutdown(ctx))
+	assert.Equal(t, 2, strings.Count(out.String(), "Failed to ship"))
+	assert.Contains(t, out.String(), "Failed to ship 2 log entries to test: nope")
+
+	// Notices themselves are never shipped.
+	assert.Equal(t, 3, attempts)
+}
+
+func TestShippingBadConfig(t *testing.T) {
+	conf := NewConfig()
+	conf.OTLP.URL = "localhost:4318"
+	conf.OTLP.Protocol = "nope"
+	_, err := NewV2(io.Discard, conf)
+	require.EqualError(t, err, "otlp: protocol 'nope' not recognised")
+
+	conf = NewConfig()
+	conf.Loki.URL = "http://localhost:3100"
+	conf.Loki.FlushInterval = "nope"
+	_, err = NewV2(io.Discard, conf)
+	require.Error(t, err)
+}
@@ -145,6 +145,11 @@ func (m *Manager) BloblEnvironment() *bloblang.Environment {
 	return bloblang.GlobalEnvironment()
 }
 
+// Environment always returns the global environment.
+func (m *Manager) Environment() *bundle.Environment {
+	return bundle.GlobalEnvironment
+}
+
 // ProbeCache returns true if a cache resource exists under the provided name.
 func (m *Manager) ProbeCache(name string) bool {
 	_, exists := m.Caches[name]
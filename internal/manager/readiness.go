@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+)
+
+// readinessChecks is a collection of checks registered by components that are
+// neither inputs nor outputs, but are able to determine whether a stream is
+// ready. The collection is shared by all variants of a manager derived from
+// the same stream.
+type readinessChecks struct {
+	mut    sync.Mutex
+	nextID int
+	checks map[int]readinessCheck
+}
+
+type readinessCheck struct {
+	path string
+	fn   func() error
+}
+
+func newReadinessChecks() *readinessChecks {
+	return &readinessChecks{checks: map[int]readinessCheck{}}
+}
+
+// RegisterReadinessCheck adds a closure that is called each time the readiness
+// of the stream is checked, where a non-nil error indicates that the component
+// is not ready. The returned closure removes the check and should be called
+// when the component is closed.
+func (t *Type) RegisterReadinessCheck(fn func() error) (deregister func()) {
+	r := t.readiness
+
+	r.mut.Lock()
+	id := r.nextID
+	r.nextID++
+	r.checks[id] = readinessCheck{
+		path: "root." + query.SliceToDotPath(t.componentPath...),
+		fn:   fn,
+	}
+	r.mut.Unlock()
+
+	return func() {
+		r.mut.Lock()
+		delete(r.checks, id)
+		r.mut.Unlock()
+	}
+}
+
+// CheckReadiness calls all registered readiness checks and returns an error
+// describing each component that is not ready.
+func (t *Type) CheckReadiness() error {
+	r := t.readiness
+
+	r.mut.Lock()
+	checks := make([]readinessCheck, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, c)
+	}
+	r.mut.Unlock()
+
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].path < checks[j].path
+	})
+
+	var errs []string
+	for _, c := range checks {
+		if err := c.fn(); err != nil {
+			errs = append(errs, fmt.Sprintf("%v: %v", c.path, err))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(errs, ", "))
+}
@@ -0,0 +1,33 @@
+package manager_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager"
+)
+
+func TestManagerReadinessChecks(t *testing.T) {
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	fooMgr := mgr.ForStream("foo").IntoPath("pipeline", "processors", "0").(*manager.Type)
+	barMgr := mgr.ForStream("bar").(*manager.Type)
+
+	deregisterA := fooMgr.RegisterReadinessCheck(func() error {
+		return errors.New("not ready a")
+	})
+	_ = fooMgr.IntoPath("1").(*manager.Type).RegisterReadinessCheck(func() error {
+		return errors.New("not ready b")
+	})
+
+	assert.EqualError(t, fooMgr.CheckReadiness(), "root.pipeline.processors.0: not ready a, root.pipeline.processors.0.1: not ready b")
+	assert.NoError(t, barMgr.CheckReadiness())
+	assert.NoError(t, mgr.CheckReadiness())
+
+	deregisterA()
+	assert.EqualError(t, fooMgr.CheckReadiness(), "root.pipeline.processors.0.1: not ready b")
+}
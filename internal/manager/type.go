@@ -85,6 +85,8 @@ type Type struct {
 
 	pipes    map[string]<-chan message.Transaction
 	pipeLock *sync.RWMutex
+
+	readiness *readinessChecks
 }
 
 // OptFunc is an opt setting for a manager type.
@@ -180,6 +182,8 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 
 		pipes:    map[string]<-chan message.Transaction{},
 		pipeLock: &sync.RWMutex{},
+
+		readiness: newReadinessChecks(),
 	}
 
 	for _, opt := range opts {
@@ -285,6 +289,7 @@ func (t *Type) forStream(id string) *Type {
 		"stream": id,
 	})
 	newT.stats = t.stats.WithLabels("stream", id)
+	newT.readiness = newReadinessChecks()
 	return &newT
 }
 
@@ -0,0 +1,128 @@
+package rpcplugin
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+)
+
+// startTimeout is the maximum period of time to wait for a launched plugin to
+// complete its handshake.
+var startTimeout = time.Second * 10
+
+// client manages a launched plugin executable and the connection to it.
+type client struct {
+	path string
+
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	conn  *grpc.ClientConn
+	rpc   pluginv1.PluginClient
+
+	exited chan struct{}
+}
+
+// startClient launches a plugin executable, waits for its handshake and
+// establishes a gRPC connection to it.
+func startClient(path string) (*client, error) {
+	c := &client{
+		path:   path,
+		exited: make(chan struct{}),
+	}
+
+	c.cmd = exec.Command(path)
+	c.cmd.Env = append(os.Environ(), pluginv1.MagicCookieKey+"="+pluginv1.MagicCookieValue)
+	c.cmd.Stderr = os.Stderr
+
+	var err error
+	if c.stdin, err = c.cmd.StdinPipe(); err != nil {
+		return nil, err
+	}
+	stdout, err := c.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := c.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to launch plugin: %w", err)
+	}
+	go func() {
+		_ = c.cmd.Wait()
+		close(c.exited)
+	}()
+
+	lineChan := make(chan string, 1)
+	go func() {
+		rdr := bufio.NewReader(stdout)
+		line, _ := rdr.ReadString('\n')
+		lineChan <- line
+
+		// Anything written to stdout after the handshake is forwarded in order
+		// to prevent the plugin from blocking.
+		_, _ = io.Copy(os.Stderr, rdr)
+	}()
+
+	var line string
+	select {
+	case line = <-lineChan:
+	case <-c.exited:
+		return nil, errors.New("plugin exited before completing handshake")
+	case <-time.After(startTimeout):
+		c.kill()
+		return nil, errors.New("timed out waiting for plugin handshake")
+	}
+
+	handshake, err := pluginv1.ParseHandshake(line)
+	if err != nil {
+		c.kill()
+		return nil, err
+	}
+
+	target := handshake.Address
+	if handshake.Network == "unix" {
+		target = "unix://" + target
+	}
+	if c.conn, err = grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials())); err != nil {
+		c.kill()
+		return nil, fmt.Errorf("failed to connect to plugin: %w", err)
+	}
+	c.rpc = pluginv1.NewPluginClient(c.conn)
+	return c, nil
+}
+
+// describe returns the components provided by the plugin.
+func (c *client) describe(ctx context.Context) ([]*pluginv1.Component, error) {
+	res, err := c.rpc.Describe(ctx, &pluginv1.DescribeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Components, nil
+}
+
+// close asks the plugin to exit by closing its stdin, and kills it if it does
+// not exit within the context deadline.
+func (c *client) close(ctx context.Context) {
+	if c.conn != nil {
+		_ = c.conn.Close()
+	}
+	_ = c.stdin.Close()
+	select {
+	case <-c.exited:
+	case <-ctx.Done():
+		c.kill()
+	}
+}
+
+func (c *client) kill() {
+	_ = c.cmd.Process.Kill()
+	<-c.exited
+}
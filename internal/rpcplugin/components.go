@@ -0,0 +1,138 @@
+package rpcplugin
+
+import (
+	"context"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
+)
+
+// instance is a component instance that lives within a plugin process.
+type instance struct {
+	c  *client
+	id uint64
+}
+
+func newInstance(c *client, typ pluginv1.ComponentType, name, label string, pluginConf any) (*instance, *pluginv1.InitResponse, error) {
+	var confBytes []byte
+	if node, ok := pluginConf.(*yaml.Node); ok && node != nil {
+		var err error
+		if confBytes, err = yaml.Marshal(node); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	res, err := c.rpc.Init(context.Background(), &pluginv1.InitRequest{
+		Type:   typ,
+		Name:   name,
+		Config: confBytes,
+		Label:  label,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("plugin %v: %w", c.path, err)
+	}
+	if err := errFromProto(res.Error); err != nil {
+		return nil, nil, err
+	}
+	return &instance{c: c, id: res.InstanceId}, res, nil
+}
+
+func (i *instance) Connect(ctx context.Context) error {
+	res, err := i.c.rpc.Connect(ctx, &pluginv1.ConnectRequest{InstanceId: i.id})
+	if err != nil {
+		return err
+	}
+	return errFromProto(res.Error)
+}
+
+func (i *instance) Close(ctx context.Context) error {
+	res, err := i.c.rpc.Close(ctx, &pluginv1.CloseRequest{InstanceId: i.id})
+	if err != nil {
+		return err
+	}
+	return errFromProto(res.Error)
+}
+
+//------------------------------------------------------------------------------
+
+type pluginInput struct {
+	*instance
+}
+
+var _ input.Async = &pluginInput{}
+
+func (p *pluginInput) ReadBatch(ctx context.Context) (message.Batch, input.AsyncAckFn, error) {
+	res, err := p.c.rpc.ReadBatch(ctx, &pluginv1.ReadBatchRequest{InstanceId: p.id})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, nil, component.ErrTimeout
+		}
+		return nil, nil, err
+	}
+	if err := errFromProto(res.Error); err != nil {
+		return nil, nil, err
+	}
+
+	ackID := res.AckId
+	return batchFromProto(res.Batch), func(ctx context.Context, err error) error {
+		req := &pluginv1.AckRequest{InstanceId: p.id, AckId: ackID}
+		if err != nil {
+			req.Error = err.Error()
+		}
+		res, rerr := p.c.rpc.Ack(ctx, req)
+		if rerr != nil {
+			return rerr
+		}
+		return errFromProto(res.Error)
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+type pluginProcessor struct {
+	*instance
+}
+
+func (p *pluginProcessor) ProcessBatch(ctx context.Context, spans []*tracing.Span, b message.Batch) ([]message.Batch, error) {
+	res, err := p.c.rpc.ProcessBatch(ctx, &pluginv1.ProcessBatchRequest{
+		InstanceId: p.id,
+		Batch:      batchToProto(b),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := errFromProto(res.Error); err != nil {
+		return nil, err
+	}
+
+	batches := make([]message.Batch, 0, len(res.Batches))
+	for _, pb := range res.Batches {
+		if rb := batchFromProto(pb); len(rb) > 0 {
+			batches = append(batches, rb)
+		}
+	}
+	return batches, nil
+}
+
+//------------------------------------------------------------------------------
+
+type pluginOutput struct {
+	*instance
+}
+
+func (p *pluginOutput) WriteBatch(ctx context.Context, b message.Batch) error {
+	res, err := p.c.rpc.WriteBatch(ctx, &pluginv1.WriteBatchRequest{
+		InstanceId: p.id,
+		Batch:      batchToProto(b),
+	})
+	if err != nil {
+		return err
+	}
+	return errFromProto(res.Error)
+}
@@ -0,0 +1,63 @@
+package rpcplugin
+
+import (
+	"errors"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+)
+
+func batchToProto(b message.Batch) *pluginv1.Batch {
+	pb := &pluginv1.Batch{
+		Messages: make([]*pluginv1.Message, len(b)),
+	}
+	for i, p := range b {
+		m := &pluginv1.Message{
+			Contents: p.AsBytes(),
+			Metadata: map[string]string{},
+		}
+		_ = p.MetaIterStr(func(k, v string) error {
+			m.Metadata[k] = v
+			return nil
+		})
+		if err := p.ErrorGet(); err != nil {
+			m.Error = err.Error()
+		}
+		pb.Messages[i] = m
+	}
+	return pb
+}
+
+func batchFromProto(pb *pluginv1.Batch) message.Batch {
+	if pb == nil {
+		return nil
+	}
+	b := make(message.Batch, len(pb.Messages))
+	for i, m := range pb.Messages {
+		p := message.NewPart(m.Contents)
+		for k, v := range m.Metadata {
+			p.MetaSetMut(k, v)
+		}
+		if m.Error != "" {
+			p.ErrorSet(errors.New(m.Error))
+		}
+		b[i] = p
+	}
+	return b
+}
+
+// errFromProto converts an error returned by a plugin into the equivalent
+// internal error.
+func errFromProto(pe *pluginv1.Error) error {
+	if pe == nil {
+		return nil
+	}
+	switch pe.Kind {
+	case pluginv1.Error_KIND_NOT_CONNECTED:
+		return component.ErrNotConnected
+	case pluginv1.Error_KIND_END_OF_INPUT:
+		return component.ErrTypeClosed
+	}
+	return errors.New(pe.Message)
+}
@@ -0,0 +1,66 @@
+// Package pluginv1 contains the gRPC protocol spoken between Benthos and
+// out-of-process plugins, along with the handshake used to establish it.
+package pluginv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative plugin.proto
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MagicCookieKey is the environment variable set when launching a plugin,
+	// which allows plugin executables to detect that they have been launched
+	// by Benthos.
+	MagicCookieKey = "BENTHOS_PLUGIN_MAGIC_COOKIE"
+
+	// MagicCookieValue is the value of MagicCookieKey.
+	MagicCookieValue = "d7a4b9c6e0f1432e8c5a6b3f9e2d1c07"
+
+	// CoreProtocolVersion is the version of the handshake.
+	CoreProtocolVersion = 1
+
+	// ProtocolVersion is the version of the Plugin service.
+	ProtocolVersion = 1
+)
+
+// Handshake describes where a launched plugin is serving the Plugin service.
+type Handshake struct {
+	Network string
+	Address string
+}
+
+// String formats the handshake as the line written to stdout by plugins.
+func (h Handshake) String() string {
+	return fmt.Sprintf("%v|%v|%v|%v|grpc", CoreProtocolVersion, ProtocolVersion, h.Network, h.Address)
+}
+
+// ParseHandshake parses the handshake line written to stdout by a plugin.
+func ParseHandshake(line string) (h Handshake, err error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 5 {
+		return h, fmt.Errorf("unrecognised handshake: %q", line)
+	}
+
+	if v, err := strconv.Atoi(parts[0]); err != nil || v != CoreProtocolVersion {
+		return h, fmt.Errorf("unsupported core protocol version: %v", parts[0])
+	}
+	if v, err := strconv.Atoi(parts[1]); err != nil || v != ProtocolVersion {
+		return h, fmt.Errorf("unsupported plugin protocol version: %v", parts[1])
+	}
+
+	h.Network, h.Address = parts[2], parts[3]
+	if h.Network != "tcp" && h.Network != "unix" {
+		return h, fmt.Errorf("unsupported network type: %v", h.Network)
+	}
+	if h.Address == "" {
+		return h, errors.New("handshake address must not be empty")
+	}
+	if parts[4] != "grpc" {
+		return h, fmt.Errorf("unsupported protocol: %v", parts[4])
+	}
+	return h, nil
+}
@@ -0,0 +1,37 @@
+package pluginv1_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+)
+
+func TestHandshakeRoundTrip(t *testing.T) {
+	h := pluginv1.Handshake{Network: "tcp", Address: "127.0.0.1:4321"}
+	assert.Equal(t, "1|1|tcp|127.0.0.1:4321|grpc", h.String())
+
+	parsed, err := pluginv1.ParseHandshake(h.String() + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, h, parsed)
+}
+
+func TestHandshakeErrors(t *testing.T) {
+	for _, test := range []struct {
+		line string
+		err  string
+	}{
+		{line: "hello world", err: "unrecognised handshake"},
+		{line: "2|1|tcp|127.0.0.1:4321|grpc", err: "unsupported core protocol version: 2"},
+		{line: "1|9|tcp|127.0.0.1:4321|grpc", err: "unsupported plugin protocol version: 9"},
+		{line: "1|1|udp|127.0.0.1:4321|grpc", err: "unsupported network type: udp"},
+		{line: "1|1|unix||grpc", err: "handshake address must not be empty"},
+		{line: "1|1|tcp|127.0.0.1:4321|netrpc", err: "unsupported protocol: netrpc"},
+	} {
+		_, err := pluginv1.ParseHandshake(test.line)
+		require.Error(t, err, test.line)
+		assert.Contains(t, err.Error(), test.err, test.line)
+	}
+}
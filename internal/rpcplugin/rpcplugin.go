@@ -0,0 +1,152 @@
+// Package rpcplugin launches out-of-process plugin executables and registers
+// the components that they provide, proxying calls to them over gRPC.
+package rpcplugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	iprocessors "github.com/benthosdev/benthos/v4/internal/component/input/processors"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/output/batcher"
+	oprocessors "github.com/benthosdev/benthos/v4/internal/component/output/processors"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+)
+
+var (
+	clientsMut sync.Mutex
+	clients    []*client
+)
+
+// InitPlugins launches the plugin executables at the paths provided and
+// registers their components with the global environment.
+func InitPlugins(paths ...string) error {
+	for _, path := range paths {
+		if err := initPlugin(path, bundle.GlobalEnvironment); err != nil {
+			return fmt.Errorf("plugin %v: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func initPlugin(path string, env *bundle.Environment) error {
+	c, err := startClient(path)
+	if err != nil {
+		return err
+	}
+
+	clientsMut.Lock()
+	clients = append(clients, c)
+	clientsMut.Unlock()
+
+	ctx, done := context.WithTimeout(context.Background(), startTimeout)
+	defer done()
+
+	components, err := c.describe(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe components: %w", err)
+	}
+	for _, comp := range components {
+		var spec docs.ComponentSpec
+		if err := json.Unmarshal(comp.Spec, &spec); err != nil {
+			return fmt.Errorf("failed to parse spec of component %v: %w", comp.Name, err)
+		}
+		spec.Name = comp.Name
+
+		switch comp.Type {
+		case pluginv1.ComponentType_COMPONENT_TYPE_INPUT:
+			spec.Type = docs.TypeInput
+			err = registerInput(c, spec, env)
+		case pluginv1.ComponentType_COMPONENT_TYPE_PROCESSOR:
+			spec.Type = docs.TypeProcessor
+			err = registerProcessor(c, spec, env)
+		case pluginv1.ComponentType_COMPONENT_TYPE_OUTPUT:
+			spec.Type = docs.TypeOutput
+			err = registerOutput(c, spec, env)
+		default:
+			err = fmt.Errorf("unsupported component type: %v", comp.Type)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to register component %v: %w", comp.Name, err)
+		}
+	}
+	return nil
+}
+
+// Shutdown stops all launched plugins, killing any that do not exit within the
+// timeout.
+func Shutdown(timeout time.Duration) {
+	clientsMut.Lock()
+	defer clientsMut.Unlock()
+
+	ctx, done := context.WithTimeout(context.Background(), timeout)
+	defer done()
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c *client) {
+			defer wg.Done()
+			c.close(ctx)
+		}(c)
+	}
+	wg.Wait()
+	clients = nil
+}
+
+//------------------------------------------------------------------------------
+
+func registerInput(c *client, spec docs.ComponentSpec, env *bundle.Environment) error {
+	return env.InputAdd(iprocessors.WrapConstructor(func(conf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		inst, _, err := newInstance(c, pluginv1.ComponentType_COMPONENT_TYPE_INPUT, spec.Name, conf.Label, conf.Plugin)
+		if err != nil {
+			return nil, err
+		}
+		return input.NewAsyncReader(conf.Type, false, &pluginInput{instance: inst}, nm)
+	}), spec)
+}
+
+func registerProcessor(c *client, spec docs.ComponentSpec, env *bundle.Environment) error {
+	return env.ProcessorAdd(func(conf processor.Config, nm bundle.NewManagement) (processor.V1, error) {
+		inst, _, err := newInstance(c, pluginv1.ComponentType_COMPONENT_TYPE_PROCESSOR, spec.Name, conf.Label, conf.Plugin)
+		if err != nil {
+			return nil, err
+		}
+		return processor.NewV2BatchedToV1Processor(conf.Type, &pluginProcessor{instance: inst}, nm), nil
+	}, spec)
+}
+
+func registerOutput(c *client, spec docs.ComponentSpec, env *bundle.Environment) error {
+	return env.OutputAdd(oprocessors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
+		inst, res, err := newInstance(c, pluginv1.ComponentType_COMPONENT_TYPE_OUTPUT, spec.Name, conf.Label, conf.Plugin)
+		if err != nil {
+			return nil, err
+		}
+
+		maxInFlight := int(res.MaxInFlight)
+		if maxInFlight < 1 {
+			maxInFlight = 1
+		}
+		o, err := output.NewAsyncWriter(conf.Type, maxInFlight, &pluginOutput{instance: inst}, nm)
+		if err != nil {
+			return nil, err
+		}
+
+		batchConf := batchconfig.NewConfig()
+		if p := res.BatchPolicy; p != nil {
+			batchConf.Count = int(p.Count)
+			batchConf.ByteSize = int(p.ByteSize)
+			batchConf.Period = p.Period
+			batchConf.Check = p.Check
+		}
+		return batcher.NewFromConfig(batchConf, o, nm)
+	}), spec)
+}
@@ -0,0 +1,321 @@
+package rpcplugin_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+	"github.com/benthosdev/benthos/v4/public/service"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
+)
+
+// When launched as a plugin the test binary serves the components below
+// instead of running the tests.
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginv1.MagicCookieKey) == pluginv1.MagicCookieValue {
+		if err := servePlugin(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if err := rpcplugin.InitPlugins(os.Args[0]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	code := m.Run()
+	rpcplugin.Shutdown(time.Second * 5)
+	os.Exit(code)
+}
+
+var fileMut sync.Mutex
+
+func appendLine(path, line string) error {
+	fileMut.Lock()
+	defer fileMut.Unlock()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintln(f, line)
+	return err
+}
+
+type countInput struct {
+	count   int
+	ackPath string
+
+	mut sync.Mutex
+	n   int
+}
+
+func (c *countInput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (c *countInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.n >= c.count {
+		return nil, nil, service.ErrEndOfInput
+	}
+	c.n++
+
+	id := c.n
+	msg := service.NewMessage([]byte(fmt.Sprintf("hello world %v", id)))
+	msg.MetaSet("id", fmt.Sprintf("%v", id))
+	return msg, func(ctx context.Context, err error) error {
+		return appendLine(c.ackPath, fmt.Sprintf("ack %v: %v", id, err))
+	}, nil
+}
+
+func (c *countInput) Close(ctx context.Context) error {
+	return nil
+}
+
+type upperProcessor struct {
+	prefix string
+}
+
+func (u *upperProcessor) ProcessBatch(ctx context.Context, b service.MessageBatch) ([]service.MessageBatch, error) {
+	for _, m := range b {
+		mBytes, err := m.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+		m.SetBytes(append([]byte(u.prefix), bytes.ToUpper(mBytes)...))
+		m.MetaSet("processed", "true")
+	}
+	return []service.MessageBatch{b}, nil
+}
+
+func (u *upperProcessor) Close(ctx context.Context) error {
+	return nil
+}
+
+type fileOutput struct {
+	path string
+}
+
+func (f *fileOutput) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (f *fileOutput) WriteBatch(ctx context.Context, b service.MessageBatch) error {
+	for _, m := range b {
+		mBytes, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+		if string(mBytes) == "REJECT ME" {
+			return fmt.Errorf("rejected message")
+		}
+		id, _ := m.MetaGet("id")
+		processed, _ := m.MetaGet("processed")
+		if err := appendLine(f.path, fmt.Sprintf("%s (%v, %v)", mBytes, id, processed)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fileOutput) Close(ctx context.Context) error {
+	return nil
+}
+
+func servePlugin() error {
+	p := service.NewRPCPlugin()
+
+	if err := p.RegisterInput("test_count",
+		service.NewConfigSpec().
+			Summary("Emits a number of messages.").
+			Field(service.NewIntField("count")).
+			Field(service.NewStringField("ack_path")),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			count, err := conf.FieldInt("count")
+			if err != nil {
+				return nil, err
+			}
+			ackPath, err := conf.FieldString("ack_path")
+			if err != nil {
+				return nil, err
+			}
+			return &countInput{count: count, ackPath: ackPath}, nil
+		}); err != nil {
+		return err
+	}
+
+	if err := p.RegisterBatchProcessor("test_upper",
+		service.NewConfigSpec().
+			Summary("Uppercases messages.").
+			Field(service.NewStringField("prefix").Default("")),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			prefix, err := conf.FieldString("prefix")
+			if err != nil {
+				return nil, err
+			}
+			return &upperProcessor{prefix: prefix}, nil
+		}); err != nil {
+		return err
+	}
+
+	if err := p.RegisterBatchOutput("test_file",
+		service.NewConfigSpec().
+			Summary("Writes messages to a file.").
+			Field(service.NewStringField("path")),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchOutput, service.BatchPolicy, int, error) {
+			path, err := conf.FieldString("path")
+			if err != nil {
+				return nil, service.BatchPolicy{}, 0, err
+			}
+			return &fileOutput{path: path}, service.BatchPolicy{Count: 2, Period: "50ms"}, 1, nil
+		}); err != nil {
+		return err
+	}
+
+	return p.Serve()
+}
+
+func readLines(t testing.TB, path string) []string {
+	t.Helper()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+	sort.Strings(lines)
+	return lines
+}
+
+func TestPluginComponentDocs(t *testing.T) {
+	spec, exists := bundle.GlobalEnvironment.GetDocs("test_count", docs.TypeInput)
+	require.True(t, exists)
+	assert.Equal(t, "Emits a number of messages.", spec.Summary)
+	assert.Equal(t, docs.TypeInput, spec.Type)
+
+	spec, exists = bundle.GlobalEnvironment.GetDocs("test_upper", docs.TypeProcessor)
+	require.True(t, exists)
+	assert.Equal(t, "Uppercases messages.", spec.Summary)
+
+	spec, exists = bundle.GlobalEnvironment.GetDocs("test_file", docs.TypeOutput)
+	require.True(t, exists)
+	assert.Equal(t, "Writes messages to a file.", spec.Summary)
+
+	builder := service.NewStreamBuilder()
+	err := builder.AddProcessorYAML(`
+test_upper:
+  prefix: foo
+  nope: bar
+`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nope")
+}
+
+func TestPluginStream(t *testing.T) {
+	tmpDir := t.TempDir()
+	outPath := filepath.Join(tmpDir, "out.txt")
+	ackPath := filepath.Join(tmpDir, "acks.txt")
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.SetYAML(fmt.Sprintf(`
+input:
+  test_count:
+    count: 3
+    ack_path: %v
+
+pipeline:
+  processors:
+    - test_upper:
+        prefix: "> "
+
+output:
+  test_file:
+    path: %v
+
+logger:
+  level: none
+`, ackPath, outPath)))
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+	require.NoError(t, strm.Run(ctx))
+
+	assert.Equal(t, []string{
+		"> HELLO WORLD 1 (1, true)",
+		"> HELLO WORLD 2 (2, true)",
+		"> HELLO WORLD 3 (3, true)",
+	}, readLines(t, outPath))
+
+	assert.Equal(t, []string{
+		"ack 1: <nil>",
+		"ack 2: <nil>",
+		"ack 3: <nil>",
+	}, readLines(t, ackPath))
+}
+
+func TestPluginProcessorAndOutputErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	outPath := filepath.Join(tmpDir, "out.txt")
+
+	builder := service.NewStreamBuilder()
+	require.NoError(t, builder.SetLoggerYAML(`level: none`))
+	require.NoError(t, builder.AddProcessorYAML(`test_upper: {}`))
+	require.NoError(t, builder.AddOutputYAML(fmt.Sprintf(`
+test_file:
+  path: %v
+`, outPath)))
+
+	sendFn, err := builder.AddBatchProducerFunc()
+	require.NoError(t, err)
+
+	strm, err := builder.Build()
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- strm.Run(ctx)
+	}()
+
+	require.NoError(t, sendFn(ctx, service.MessageBatch{
+		service.NewMessage([]byte("foo")),
+		service.NewMessage([]byte("bar")),
+	}))
+
+	err = sendFn(ctx, service.MessageBatch{
+		service.NewMessage([]byte("reject me")),
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected message")
+
+	require.NoError(t, strm.Stop(ctx))
+	<-runErr
+
+	assert.Equal(t, []string{
+		"BAR (, true)",
+		"FOO (, true)",
+	}, readLines(t, outPath))
+}
@@ -30,6 +30,7 @@ const ServerlessResponseType = "sync_response"
 // handler.
 type Handler struct {
 	transactionChan chan message.Transaction
+	flushLogs       func(ctx context.Context)
 	done            func(exitTimeout time.Duration) error
 }
 
@@ -42,6 +43,10 @@ func (h *Handler) Close(tout time.Duration) error {
 // Handle is a request/response func that injects a payload into the underlying
 // Benthos pipeline and returns a result.
 func (h *Handler) Handle(ctx context.Context, obj any) (any, error) {
+	// Serverless environments might freeze the process between invocations,
+	// and therefore shipped logs are flushed before returning.
+	defer h.flushLogs(ctx)
+
 	part := message.NewPart(nil)
 	part.SetStructuredMut(obj)
 	msg := message.Batch{part}
@@ -161,6 +166,13 @@ func NewHandler(conf config.Type) (*Handler, error) {
 
 	return &Handler{
 		transactionChan: transactionChan,
+		flushLogs: func(ctx context.Context) {
+			if flusher, ok := logger.(interface {
+				Flush(context.Context) error
+			}); ok {
+				_ = flusher.Flush(ctx)
+			}
+		},
 		done: func(exitTimeout time.Duration) error {
 			close(transactionChan)
 
@@ -191,6 +203,11 @@ func NewHandler(conf config.Type) (*Handler, error) {
 			if sCloseErr := stats.Close(); sCloseErr != nil {
 				logger.Errorf("Failed to cleanly close metrics aggregator: %v\n", sCloseErr)
 			}
+			if shutter, ok := logger.(interface {
+				Shutdown(context.Context) error
+			}); ok {
+				_ = shutter.Shutdown(ctx)
+			}
 			return nil
 		},
 	}, nil
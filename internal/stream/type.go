@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"runtime/pprof"
 	"sync/atomic"
@@ -51,13 +52,14 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 	healthCheck := func(w http.ResponseWriter, r *http.Request) {
 		inputConnected := t.inputLayer.Connected()
 		outputConnected := t.outputLayer.Connected()
+		componentsErr := t.checkComponentReadiness()
 
 		if atomic.LoadUint32(&t.closed) == 1 {
 			http.Error(w, "Stream terminated", http.StatusNotFound)
 			return
 		}
 
-		if inputConnected && outputConnected {
+		if inputConnected && outputConnected && componentsErr == nil {
 			_, _ = w.Write([]byte("OK"))
 			return
 		}
@@ -69,10 +71,13 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 		if !outputConnected {
 			_, _ = w.Write([]byte("output not connected\n"))
 		}
+		if componentsErr != nil {
+			_, _ = fmt.Fprintf(w, "components not ready: %v\n", componentsErr)
+		}
 	}
 	t.manager.RegisterEndpoint(
 		"/ready",
-		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
+		"Returns 200 OK if all inputs and outputs are connected and all other components are ready, otherwise a 503 is returned.",
 		healthCheck,
 	)
 	return t, nil
@@ -90,9 +95,21 @@ func OptOnClose(onClose func()) func(*Type) {
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
-// of the stream are connected.
+// of the stream are connected, and all other components that report readiness
+// are ready.
 func (t *Type) IsReady() bool {
-	return t.inputLayer.Connected() && t.outputLayer.Connected()
+	return t.inputLayer.Connected() && t.outputLayer.Connected() && t.checkComponentReadiness() == nil
+}
+
+// checkComponentReadiness returns an error when a component other than an input
+// or output, such as a processor with an open circuit breaker, is not ready.
+func (t *Type) checkComponentReadiness() error {
+	if r, ok := t.manager.(interface {
+		CheckReadiness() error
+	}); ok {
+		return r.CheckReadiness()
+	}
+	return nil
 }
 
 func (t *Type) start() (err error) {
@@ -1,10 +1,12 @@
 package template
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/nsf/jsondiff"
@@ -13,8 +15,11 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bloblang/parser"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
 )
 
 // FieldConfig describes a configuration field used in the template.
@@ -29,11 +34,19 @@ type FieldConfig struct {
 
 // TestConfig defines a unit test for the template.
 type TestConfig struct {
-	Name     string    `yaml:"name"`
-	Config   yaml.Node `yaml:"config"`
-	Expected yaml.Node `yaml:"expected,omitempty"`
+	Name          string    `yaml:"name"`
+	Config        yaml.Node `yaml:"config"`
+	Expected      yaml.Node `yaml:"expected,omitempty"`
+	InputBatch    yaml.Node `yaml:"input_batch,omitempty"`
+	OutputBatches yaml.Node `yaml:"output_batches,omitempty"`
 }
 
+// TestRunner executes the input batch of a template test through the
+// processors that result from applying the template, and returns a
+// description of each output batch condition that failed. Relative file paths
+// referenced by the test are resolved from dir.
+type TestRunner func(dir string, test TestConfig, procs []processor.V1) ([]string, error)
+
 // Config describes a Benthos component template.
 type Config struct {
 	Name           string        `yaml:"name"`
@@ -45,6 +58,7 @@ type Config struct {
 	Fields         []FieldConfig `yaml:"fields"`
 	Mapping        string        `yaml:"mapping"`
 	MetricsMapping string        `yaml:"metrics_mapping"`
+	Resources      yaml.Node     `yaml:"resources,omitempty"`
 	Tests          []TestConfig  `yaml:"tests"`
 }
 
@@ -150,8 +164,9 @@ func diffYAMLNodesAsJSON(expNode, actNode *yaml.Node) (string, error) {
 }
 
 // Test ensures that the template compiles, and executes any unit test
-// definitions within the config.
-func (c Config) Test() ([]string, error) {
+// definitions within the config. Tests with an input batch are executed with
+// the provided runner from the directory of the template file.
+func (c Config) Test(dir string, runner TestRunner) ([]string, error) {
 	compiled, err := c.compile()
 	if err != nil {
 		return nil, err
@@ -176,6 +191,82 @@ func (c Config) Test() ([]string, error) {
 				return nil, fmt.Errorf("test '%v': mismatch between expected and actual resulting config: %v", test.Name, diff)
 			}
 		}
+		if len(test.InputBatch.Content) > 0 {
+			if runner == nil {
+				return nil, fmt.Errorf("test '%v': input_batch cannot be executed without a test runner", test.Name)
+			}
+			execFailures, err := c.executeTest(dir, test, outConf, runner)
+			if err != nil {
+				return nil, fmt.Errorf("test '%v': %w", test.Name, err)
+			}
+			failures = append(failures, execFailures...)
+		}
+	}
+	return failures, nil
+}
+
+// executeTest runs the input batch of a test through the processors resulting
+// from applying the template, and checks the output batches against the
+// expected conditions. For processor templates this is the resulting processor
+// itself, and for output templates it is the chain of processors of the
+// resulting output.
+func (c Config) executeTest(dir string, test TestConfig, outConf *yaml.Node, runner TestRunner) ([]string, error) {
+	var procConfs []processor.Config
+	switch docs.Type(c.Type) {
+	case docs.TypeProcessor:
+		procConf := processor.NewConfig()
+		if err := outConf.Decode(&procConf); err != nil {
+			return nil, fmt.Errorf("failed to parse resulting config: %w", err)
+		}
+		procConfs = append(procConfs, procConf)
+	case docs.TypeOutput:
+		outputConf := output.NewConfig()
+		if err := outConf.Decode(&outputConf); err != nil {
+			return nil, fmt.Errorf("failed to parse resulting config: %w", err)
+		}
+		procConfs = outputConf.Processors
+	default:
+		return nil, fmt.Errorf("input_batch is only supported by processor and output templates, this template is of type %v", c.Type)
+	}
+
+	resConf := manager.NewResourceConfig()
+	if len(c.Resources.Content) > 0 {
+		if err := c.Resources.Decode(&resConf); err != nil {
+			return nil, fmt.Errorf("failed to parse resources: %w", err)
+		}
+	}
+
+	mgr, err := manager.New(resConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialise resources: %w", err)
+	}
+
+	var procs []processor.V1
+	defer func() {
+		ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+		defer done()
+
+		for _, p := range procs {
+			_ = p.Close(ctx)
+		}
+		mgr.TriggerStopConsuming()
+		_ = mgr.WaitForClose(ctx)
+	}()
+
+	for _, conf := range procConfs {
+		proc, err := mgr.NewProcessor(conf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialise processors: %w", err)
+		}
+		procs = append(procs, proc)
+	}
+
+	failures, err := runner(dir, test, procs)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range failures {
+		failures[i] = fmt.Sprintf("test '%v': %v", test.Name, f)
 	}
 	return failures, nil
 }
@@ -256,12 +347,26 @@ func ConfigSpec() docs.FieldSpecs {
 			"mapping", "A [Bloblang](/docs/guides/bloblang/about) mapping that translates the fields of the template into a valid Benthos configuration for the target component type.",
 		),
 		templateMetricsMappingDocs(),
+		docs.FieldObject("resources", "An optional set of resources, such as caches and rate limits, that are required by configs resulting from the template. These resources are created when executing the `input_batch` of tests, and are otherwise expected to be provided by the config the template is used within. The format matches that of a Benthos resources config file.").WithChildren(
+			docs.FieldInput("input_resources", "A list of input resources.").Array().Optional(),
+			docs.FieldProcessor("processor_resources", "A list of processor resources.").Array().Optional(),
+			docs.FieldOutput("output_resources", "A list of output resources.").Array().Optional(),
+			docs.FieldCache("cache_resources", "A list of cache resources.").Array().Optional(),
+			docs.FieldRateLimit("rate_limit_resources", "A list of rate limit resources.").Array().Optional(),
+		).Optional(),
 		docs.FieldObject(
 			"tests", "Optional unit test definitions for the template that verify certain configurations produce valid configs. These tests are executed with the command `benthos template lint`.",
 		).Array().WithChildren(
 			docs.FieldString("name", "A name to identify the test."),
 			docs.FieldObject("config", "A configuration to run this test with, the config resulting from applying the template with this config will be linted."),
 			docs.FieldObject("expected", "An optional configuration describing the expected result of applying the template, when specified the result will be diffed and any mismatching fields will be reported as a test error.").Optional(),
+			docs.FieldObject("input_batch", "An optional batch of messages to execute through the processors resulting from applying the template. For templates of the type `processor` this is the resulting processor, and for templates of the type `output` this is the `processors` field of the resulting output, the output itself is not executed. Not supported by other template types.").Array().WithChildren(
+				docs.FieldString("content", "The raw content of the input message.").Optional(),
+				docs.FieldAnything("json_content", "Sets the raw content of the message to a JSON document matching the structure of the value.").Optional(),
+				docs.FieldString("file_content", "Sets the raw content of the message by reading a file.").Optional(),
+				docs.FieldAnything("metadata", "A map of metadata key/values to add to the input message.").Map().Optional(),
+			).Optional(),
+			docs.FieldAnything("output_batches", "An optional list of batches of conditions, where each condition is checked against the corresponding message resulting from the `input_batch`. The conditions are the same as those supported by `benthos test` unit test definitions.").ArrayOfArrays().Optional(),
 		).HasDefault([]any{}),
 	}
 }
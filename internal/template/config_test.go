@@ -0,0 +1,174 @@
+package template_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	clitest "github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/template"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+func TestTemplateTestInputBatch(t *testing.T) {
+	confStr := `
+name: cache_upper
+type: processor
+fields:
+  - name: cache
+    type: string
+mapping: |
+  root.branch.request_map = "root = content().uppercase()"
+  root.branch.processors = [
+    { "cache": { "operator": "set", "resource": this.cache, "key": "${! content() }", "value": "${! content() }" } },
+    { "cache": { "operator": "get", "resource": this.cache, "key": "${! content() }" } },
+  ]
+  root.branch.result_map = "root.upper = content().string()"
+resources:
+  cache_resources:
+    - label: foocache
+      memory: {}
+tests:
+  - name: with resources
+    config:
+      cache: foocache
+    input_batch:
+      - content: '{"id":"foo"}'
+      - content: '{"id":"bar"}'
+    output_batches:
+      - - json_equals: { "id": "foo", "upper": "{\"ID\":\"FOO\"}" }
+        - json_equals: { "id": "bar", "upper": "nope" }
+`
+
+	var conf template.Config
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+
+	failures, err := conf.Test(".", clitest.RunTemplateTest)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.Contains(t, failures[0], "test 'with resources': batch 0 message 1: json_equals:")
+}
+
+func TestTemplateTestInputBatchMissingResource(t *testing.T) {
+	confStr := `
+name: cache_get
+type: processor
+fields:
+  - name: cache
+    type: string
+mapping: |
+  root.cache.operator = "get"
+  root.cache.resource = this.cache
+  root.cache.key = "${! content() }"
+tests:
+  - name: without resources
+    config:
+      cache: foocache
+    input_batch:
+      - content: foo
+`
+
+	var conf template.Config
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+
+	_, err := conf.Test(".", clitest.RunTemplateTest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "test 'without resources'")
+	assert.Contains(t, err.Error(), "foocache")
+}
+
+func TestTemplateTestInputBatchOutput(t *testing.T) {
+	confStr := `
+name: upper_drop
+type: output
+fields:
+  - name: prefix
+    type: string
+mapping: |
+  root.drop = {}
+  root.processors = [
+    { "mapping": "root = \"%s\" + content().uppercase()".format(this.prefix) },
+    { "cache": { "operator": "set", "resource": "foocache", "key": "${! content() }", "value": "${! content() }" } },
+  ]
+resources:
+  cache_resources:
+    - label: foocache
+      memory: {}
+tests:
+  - name: upper
+    config:
+      prefix: "foo: "
+    input_batch:
+      - content: bar
+      - content: baz
+    output_batches:
+      - - content_equals: "foo: BAR"
+        - content_equals: "foo: nope"
+`
+
+	var conf template.Config
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+
+	failures, err := conf.Test(".", clitest.RunTemplateTest)
+	require.NoError(t, err)
+	require.Len(t, failures, 1)
+	assert.Contains(t, failures[0], "test 'upper': batch 0 message 1: content_equals:")
+}
+
+func TestTemplateTestInputBatchWrongType(t *testing.T) {
+	confStr := `
+name: stdin_thing
+type: input
+mapping: |
+  root.stdin = {}
+tests:
+  - name: not a processor
+    config: {}
+    input_batch:
+      - content: foo
+`
+
+	var conf template.Config
+	require.NoError(t, yaml.Unmarshal([]byte(confStr), &conf))
+
+	_, err := conf.Test(".", clitest.RunTemplateTest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only supported by processor and output templates")
+}
+
+func TestTemplateTestInputBatchFromDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "input.txt"), []byte("foo"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "expected.txt"), []byte("FOO"), 0o644))
+
+	confStr := `
+name: upper
+type: processor
+mapping: |
+  root.mapping = "root = content().uppercase()"
+tests:
+  - name: from files
+    config: {}
+    input_batch:
+      - file_content: ./input.txt
+    output_batches:
+      - - file_equals: ./expected.txt
+`
+	templatePath := filepath.Join(dir, "upper.yaml")
+	require.NoError(t, os.WriteFile(templatePath, []byte(confStr), 0o644))
+
+	conf, lints, err := template.ReadConfig(templatePath)
+	require.NoError(t, err)
+	require.Empty(t, lints)
+
+	// Relative file paths are resolved from the directory of the template
+	// rather than the working directory.
+	failures, err := conf.Test(filepath.Dir(templatePath), clitest.RunTemplateTest)
+	require.NoError(t, err)
+	assert.Empty(t, failures)
+}
@@ -114,12 +114,18 @@ func InitSpans(prov trace.TracerProvider, operationName string, batch message.Ba
 }
 
 // InitSpan sets up an OpenTracing span on a message part if one does not
-// already exist.
+// already exist. When the tracer provider was configured to extract trace
+// context from metadata then the span will be a child of any trace context
+// found within the metadata of the message.
 func InitSpan(prov trace.TracerProvider, operationName string, part *message.Part) *message.Part {
 	if GetActiveSpan(part) != nil {
 		return part
 	}
-	ctx, _ := prov.Tracer(name).Start(context.Background(), operationName)
+	parentCtx := context.Background()
+	if extract, _ := metadataPropagation(prov); extract {
+		parentCtx = extractFromMetadata(part)
+	}
+	ctx, _ := prov.Tracer(name).Start(parentCtx, operationName)
 	return message.WithContext(ctx, part)
 }
 
@@ -0,0 +1,91 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// propagatingProvider wraps a tracer provider with settings for automatically
+// propagating trace context through message metadata.
+type propagatingProvider struct {
+	trace.TracerProvider
+
+	extract bool
+	inject  bool
+}
+
+// WithMetadataPropagation wraps a tracer provider so that spans initialised for
+// consumed messages are children of any trace context found within the message
+// metadata (extract), and the spans of messages being written by outputs are
+// added to the message metadata (inject). The format of the trace context
+// follows the global text map propagator, which is W3C trace context
+// (`traceparent` and `tracestate`).
+func WithMetadataPropagation(prov trace.TracerProvider, extract, inject bool) trace.TracerProvider {
+	if !extract && !inject {
+		return prov
+	}
+	return &propagatingProvider{
+		TracerProvider: prov,
+		extract:        extract,
+		inject:         inject,
+	}
+}
+
+// Shutdown the underlying tracer provider if it supports it.
+func (p *propagatingProvider) Shutdown(ctx context.Context) error {
+	if shutter, ok := p.TracerProvider.(interface {
+		Shutdown(context.Context) error
+	}); ok {
+		return shutter.Shutdown(ctx)
+	}
+	return nil
+}
+
+func metadataPropagation(prov trace.TracerProvider) (extract, inject bool) {
+	if p, ok := prov.(*propagatingProvider); ok {
+		return p.extract, p.inject
+	}
+	return false, false
+}
+
+// extractFromMetadata attempts to extract a parent trace context from the
+// metadata of a message, metadata keys are matched case insensitively as
+// protocols such as HTTP canonicalise header keys.
+func extractFromMetadata(part *message.Part) context.Context {
+	c := propagation.MapCarrier{}
+	_ = part.MetaIterStr(func(k, v string) error {
+		c[strings.ToLower(k)] = v
+		return nil
+	})
+	return otel.GetTextMapPropagator().Extract(context.Background(), c)
+}
+
+// InjectSpansIntoMetadata adds the trace context of each span to the metadata
+// of the corresponding message when the tracer provider was configured to
+// inject trace context. Messages are shallow copied before being modified.
+func InjectSpansIntoMetadata(prov trace.TracerProvider, batch message.Batch, spans []*Span) {
+	if _, inject := metadataPropagation(prov); !inject || len(spans) < len(batch) {
+		return
+	}
+	for i, p := range batch {
+		if p == nil || spans[i] == nil {
+			continue
+		}
+		c := propagation.MapCarrier{}
+		otel.GetTextMapPropagator().Inject(spans[i].ctx, c)
+		if len(c) == 0 {
+			continue
+		}
+		p = p.ShallowCopy()
+		for k, v := range c {
+			p.MetaSetMut(k, v)
+		}
+		batch[i] = p
+	}
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+const testTraceParent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestMetadataPropagationDisabled(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tp := trace.NewNoopTracerProvider()
+	assert.Equal(t, tp, WithMetadataPropagation(tp, false, false))
+
+	part := message.NewPart([]byte("hello"))
+	part.MetaSetMut("traceparent", testTraceParent)
+
+	part = InitSpan(tp, "test", part)
+	assert.False(t, trace.SpanFromContext(part.GetContext()).SpanContext().IsValid())
+}
+
+func TestMetadataPropagationExtract(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tp := WithMetadataPropagation(trace.NewNoopTracerProvider(), true, false)
+
+	partOne := message.NewPart([]byte("hello"))
+	partOne.MetaSetMut("Traceparent", testTraceParent)
+	partTwo := message.NewPart([]byte("world"))
+
+	batch := message.Batch{partOne, partTwo}
+	InitSpans(tp, "test", batch)
+
+	spanCtx := trace.SpanFromContext(batch[0].GetContext()).SpanContext()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanCtx.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spanCtx.SpanID().String())
+
+	assert.False(t, trace.SpanFromContext(batch[1].GetContext()).SpanContext().IsValid())
+}
+
+func TestMetadataPropagationInject(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tp := WithMetadataPropagation(sdktrace.NewTracerProvider(), true, true)
+
+	inPart := message.NewPart([]byte("hello"))
+	inPart.MetaSetMut("traceparent", testTraceParent)
+
+	batch := message.Batch{inPart}
+	InitSpans(tp, "input", batch)
+	batch[0].MetaDelete("traceparent")
+	original := batch[0]
+
+	_, spans := WithChildSpans(tp, "output", batch)
+	InjectSpansIntoMetadata(tp, batch, spans)
+
+	spanCtx := trace.SpanFromContext(spans[0].ctx).SpanContext()
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spanCtx.TraceID().String())
+	assert.Equal(t, fmt.Sprintf("00-%v-%v-01", spanCtx.TraceID(), spanCtx.SpanID()), batch[0].MetaGetStr("traceparent"))
+
+	// The original message must not be modified.
+	_, exists := original.MetaGetMut("traceparent")
+	require.False(t, exists)
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/codec"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ScannerCodec consumes a stream of bytes, such as a file or a connection, as
+// discrete batches of messages. A scanner codec is created for each stream
+// consumed by an input and is closed once the stream is finished with.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type ScannerCodec interface {
+	// NextBatch returns the next batch of messages from the stream, and must
+	// return io.EOF once the stream has been fully consumed.
+	NextBatch(ctx context.Context) (MessageBatch, error)
+
+	// Close the codec along with the underlying stream.
+	Close(ctx context.Context) error
+}
+
+// ScannerCodecCreator creates a scanner codec for a stream, where name
+// identifies the stream where possible, e.g. the path of a file.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type ScannerCodecCreator func(name string, r io.ReadCloser) (ScannerCodec, error)
+
+// ScannerCodecConstructor is called for each component configured with a
+// custom scanner codec and returns a func that creates a codec for each stream
+// consumed by the component. The args contain any parameters following the
+// codec name, e.g. for the codec `foo:bar` the args would be `bar`.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type ScannerCodecConstructor func(args string) (ScannerCodecCreator, error)
+
+// WriterCodec writes messages to a stream of bytes, such as a file or a
+// connection. A writer codec is created for each stream written to by an
+// output and is closed once the stream is finished with.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type WriterCodec interface {
+	// Write a message to the stream.
+	Write(ctx context.Context, msg *Message) error
+
+	// Close the codec along with the underlying stream.
+	Close(ctx context.Context) error
+}
+
+// WriterCodecCreator creates a writer codec for a stream.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type WriterCodecCreator func(w io.WriteCloser) (WriterCodec, error)
+
+// WriterCodecConfig describes how the streams written to by a writer codec
+// should be opened by outputs that write to files.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type WriterCodecConfig struct {
+	// Append messages to the end of an existing file rather than replacing it.
+	Append bool
+
+	// Truncate an existing file before writing to it.
+	Truncate bool
+
+	// CloseAfter closes the stream after each message is written, which
+	// results in each message being written to a new file.
+	CloseAfter bool
+}
+
+// WriterCodecConstructor is called for each component configured with a
+// custom writer codec and returns a func that creates a codec for each stream
+// written to by the component, along with how those streams should be opened.
+// The args contain any parameters following the codec name, e.g. for the
+// codec `foo:bar` the args would be `bar`.
+//
+// Experimental: This type signature is experimental and therefore subject to
+// change outside of major version releases.
+type WriterCodecConstructor func(args string) (WriterCodecCreator, WriterCodecConfig, error)
+
+//------------------------------------------------------------------------------
+
+// airGapScannerCodec wraps a scanner codec with the acknowledgement tracking
+// expected of internal reader codecs, where the source is acknowledged once it
+// has been fully consumed and all messages from it have been acknowledged.
+type airGapScannerCodec struct {
+	s         ScannerCodec
+	sourceAck codec.ReaderAckFn
+
+	mut      sync.Mutex
+	finished bool
+	pending  int32
+	acked    bool
+}
+
+func newAirGapScannerCodec(s ScannerCodec, ackFn codec.ReaderAckFn) *airGapScannerCodec {
+	return &airGapScannerCodec{s: s, sourceAck: ackFn}
+}
+
+func (a *airGapScannerCodec) ackSource(ctx context.Context, err error) error {
+	if a.acked {
+		return nil
+	}
+	a.acked = true
+	return a.sourceAck(ctx, err)
+}
+
+func (a *airGapScannerCodec) ack(ctx context.Context, err error) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	a.pending--
+	if err != nil {
+		return a.ackSource(ctx, err)
+	}
+	if a.pending == 0 && a.finished {
+		return a.ackSource(ctx, nil)
+	}
+	return nil
+}
+
+func (a *airGapScannerCodec) Next(ctx context.Context) ([]*message.Part, codec.ReaderAckFn, error) {
+	batch, err := a.s.NextBatch(ctx)
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			a.finished = true
+			if a.pending == 0 {
+				_ = a.ackSource(ctx, nil)
+			}
+		} else {
+			_ = a.ackSource(ctx, err)
+		}
+		return nil, nil, err
+	}
+
+	parts := make([]*message.Part, len(batch))
+	for i, m := range batch {
+		parts[i] = m.part
+	}
+	a.pending++
+	return parts, a.ack, nil
+}
+
+func (a *airGapScannerCodec) Close(ctx context.Context) error {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	if !a.finished {
+		_ = a.ackSource(ctx, errors.New("service shutting down"))
+	}
+	if a.pending == 0 {
+		_ = a.ackSource(ctx, nil)
+	}
+	return a.s.Close(ctx)
+}
+
+type airGapWriterCodec struct {
+	w WriterCodec
+}
+
+func (a *airGapWriterCodec) Write(ctx context.Context, p *message.Part) error {
+	return a.w.Write(ctx, newMessageFromPart(p))
+}
+
+func (a *airGapWriterCodec) Close(ctx context.Context) error {
+	return a.w.Close(ctx)
+}
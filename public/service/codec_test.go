@@ -0,0 +1,152 @@
+package service_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type lengthPrefixedScanner struct {
+	r  io.ReadCloser
+	br *bufio.Reader
+}
+
+func (l *lengthPrefixedScanner) NextBatch(ctx context.Context) (service.MessageBatch, error) {
+	var size uint32
+	if err := binary.Read(l.br, binary.BigEndian, &size); err != nil {
+		return nil, err
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(l.br, b); err != nil {
+		return nil, err
+	}
+	return service.MessageBatch{service.NewMessage(b)}, nil
+}
+
+func (l *lengthPrefixedScanner) Close(ctx context.Context) error {
+	return l.r.Close()
+}
+
+type lengthPrefixedWriter struct {
+	w io.WriteCloser
+}
+
+func (l *lengthPrefixedWriter) Write(ctx context.Context, msg *service.Message) error {
+	b, err := msg.AsBytes()
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(l.w, binary.BigEndian, uint32(len(b))); err != nil {
+		return err
+	}
+	_, err = l.w.Write(b)
+	return err
+}
+
+func (l *lengthPrefixedWriter) Close(ctx context.Context) error {
+	return l.w.Close()
+}
+
+func TestCustomCodecs(t *testing.T) {
+	env := service.NewEnvironment()
+
+	require.NoError(t, env.RegisterScannerCodec("length_prefixed", func(args string) (service.ScannerCodecCreator, error) {
+		return func(name string, r io.ReadCloser) (service.ScannerCodec, error) {
+			return &lengthPrefixedScanner{r: r, br: bufio.NewReader(r)}, nil
+		}, nil
+	}))
+	require.NoError(t, env.RegisterWriterCodec("length_prefixed", func(args string) (service.WriterCodecCreator, service.WriterCodecConfig, error) {
+		return func(w io.WriteCloser) (service.WriterCodec, error) {
+			return &lengthPrefixedWriter{w: w}, nil
+		}, service.WriterCodecConfig{Append: true}, nil
+	}))
+
+	require.Error(t, env.RegisterScannerCodec("lines", func(args string) (service.ScannerCodecCreator, error) {
+		return nil, errors.New("nope")
+	}))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	path := filepath.Join(t.TempDir(), "data.bin")
+	contents := []string{"foo", "bar\nbaz", "buz"}
+
+	// Write messages to a file with the custom codec.
+	wb := env.NewStreamBuilder()
+	require.NoError(t, wb.AddOutputYAML(`
+file:
+  path: `+path+`
+  codec: length_prefixed
+`))
+	pushFn, err := wb.AddProducerFunc()
+	require.NoError(t, err)
+
+	wStrm, err := wb.Build()
+	require.NoError(t, err)
+
+	wDone := make(chan error, 1)
+	go func() {
+		wDone <- wStrm.Run(ctx)
+	}()
+
+	for _, c := range contents {
+		require.NoError(t, pushFn(ctx, service.NewMessage([]byte(c))))
+	}
+	require.NoError(t, wStrm.Stop(ctx))
+	require.NoError(t, <-wDone)
+
+	// Read them back with the same codec.
+	rb := env.NewStreamBuilder()
+	require.NoError(t, rb.AddInputYAML(`
+file:
+  paths: [ `+path+` ]
+  codec: length_prefixed
+`))
+
+	var resMut sync.Mutex
+	var res []string
+	require.NoError(t, rb.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		b, err := m.AsBytes()
+		require.NoError(t, err)
+		resMut.Lock()
+		res = append(res, string(b))
+		resMut.Unlock()
+		return nil
+	}))
+
+	rStrm, err := rb.Build()
+	require.NoError(t, err)
+	require.NoError(t, rStrm.Run(ctx))
+
+	resMut.Lock()
+	assert.Equal(t, contents, res)
+	resMut.Unlock()
+
+	// The codec is isolated to the environment it was registered with.
+	gb := service.NewStreamBuilder()
+	require.NoError(t, gb.AddInputYAML(`
+file:
+  paths: [ `+path+` ]
+  codec: length_prefixed
+`))
+	require.NoError(t, gb.AddConsumerFunc(func(ctx context.Context, m *service.Message) error {
+		return nil
+	}))
+	gStrm, err := gb.Build()
+	require.NoError(t, err)
+
+	err = gStrm.Run(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "codec was not recognised: length_prefixed")
+}
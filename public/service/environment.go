@@ -3,11 +3,13 @@ package service
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"go.opentelemetry.io/otel/trace"
 
 	ibloblang "github.com/benthosdev/benthos/v4/internal/bloblang"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
@@ -457,6 +459,54 @@ func (e *Environment) WalkTracers(fn func(name string, config *ConfigView)) {
 	}
 }
 
+// RegisterScannerCodec attempts to register a new custom codec that inputs
+// can use to consume streams of bytes as messages, where the name of the
+// codec is the value configured within the codec field of those inputs.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func (e *Environment) RegisterScannerCodec(name string, ctor ScannerCodecConstructor) error {
+	return e.internal.ReaderCodecAdd(name, func(args string, _ codec.ReaderConfig) (codec.ReaderConstructor, error) {
+		creator, err := ctor(args)
+		if err != nil {
+			return nil, err
+		}
+		return func(path string, r io.ReadCloser, ackFn codec.ReaderAckFn) (codec.Reader, error) {
+			s, err := creator(path, r)
+			if err != nil {
+				return nil, err
+			}
+			return newAirGapScannerCodec(s, ackFn), nil
+		}, nil
+	})
+}
+
+// RegisterWriterCodec attempts to register a new custom codec that outputs
+// can use to write messages to streams of bytes, where the name of the codec
+// is the value configured within the codec field of those outputs.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func (e *Environment) RegisterWriterCodec(name string, ctor WriterCodecConstructor) error {
+	return e.internal.WriterCodecAdd(name, func(args string) (codec.WriterConstructor, codec.WriterConfig, error) {
+		creator, conf, err := ctor(args)
+		if err != nil {
+			return nil, codec.WriterConfig{}, err
+		}
+		return func(w io.WriteCloser) (codec.Writer, error) {
+			wc, err := creator(w)
+			if err != nil {
+				return nil, err
+			}
+			return &airGapWriterCodec{w: wc}, nil
+		}, codec.WriterConfig{
+			Append:     conf.Append,
+			Truncate:   conf.Truncate,
+			CloseAfter: conf.CloseAfter,
+		}, nil
+	})
+}
+
 // XFormatConfigJSON returns a byte slice of the Benthos configuration spec
 // formatted as a JSON object. The schema of this method is undocumented and is
 // not intended for general use.
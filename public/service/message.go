@@ -206,6 +206,26 @@ func (m *Message) MetaSet(key, value string) {
 	}
 }
 
+// MetaGetMut attempts to find a metadata key from the message and returns the
+// value if found, and a boolean indicating whether it was found. The value
+// returned is not guaranteed to be a string, and can be any type that was set
+// by the component that created it, such as an int64 for numeric values, a
+// time.Time for timestamps or a []byte for binary values.
+//
+// It is not safe to mutate the contents of the returned value.
+func (m *Message) MetaGetMut(key string) (any, bool) {
+	return m.part.MetaGetMut(key)
+}
+
+// MetaSetMut sets the value of a metadata key to any value. The value provided
+// is stored as is and must not be mutated after this call. Values should be of
+// a type that is supported by Bloblang, such as strings, []byte, int64,
+// uint64, float64, bool, time.Time or structured types composed of
+// map[string]any and []any.
+func (m *Message) MetaSetMut(key string, value any) {
+	m.part.MetaSetMut(key, value)
+}
+
 // MetaDelete removes a key from the message metadata.
 func (m *Message) MetaDelete(key string) {
 	m.part.MetaDelete(key)
@@ -218,6 +238,16 @@ func (m *Message) MetaWalk(fn func(string, string) error) error {
 	return m.part.MetaIterStr(fn)
 }
 
+// MetaWalkMut iterates each metadata key/value pair and executes a provided
+// closure on each iteration without serialising the values as strings. To stop
+// iterating, return an error from the closure. An error returned by the closure
+// will be returned by this function.
+//
+// It is not safe to mutate the values provided to the closure.
+func (m *Message) MetaWalkMut(fn func(key string, value any) error) error {
+	return m.part.MetaIterMut(fn)
+}
+
 //------------------------------------------------------------------------------
 
 // BloblangQuery executes a parsed Bloblang mapping on a message and returns a
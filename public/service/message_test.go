@@ -114,6 +114,55 @@ func TestMessageQuery(t *testing.T) {
 	}, seen)
 }
 
+func TestMessageMetaMut(t *testing.T) {
+	g1 := NewMessage([]byte(`hello`))
+	g1.MetaSetMut("foo", int64(10))
+	g1.MetaSetMut("bar", []byte("baz"))
+	g1.MetaSet("baz", "buz")
+
+	v, ok := g1.MetaGetMut("foo")
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), v)
+
+	_, ok = g1.MetaGetMut("nope")
+	assert.False(t, ok)
+
+	str, ok := g1.MetaGet("foo")
+	assert.True(t, ok)
+	assert.Equal(t, "10", str)
+
+	str, ok = g1.MetaGet("bar")
+	assert.True(t, ok)
+	assert.Equal(t, "baz", str)
+
+	g2 := g1.Copy()
+	g2.MetaSetMut("foo", int64(20))
+
+	v, _ = g1.MetaGetMut("foo")
+	assert.Equal(t, int64(10), v)
+
+	seen := map[string]any{}
+	require.NoError(t, g2.MetaWalkMut(func(k string, v any) error {
+		seen[k] = v
+		return nil
+	}))
+	assert.Equal(t, map[string]any{
+		"foo": int64(20),
+		"bar": []byte("baz"),
+		"baz": "buz",
+	}, seen)
+
+	blobl, err := bloblang.Parse(`root = meta("foo") + meta("baz")`)
+	require.NoError(t, err)
+
+	res, err := g2.BloblangQuery(blobl)
+	require.NoError(t, err)
+
+	resBytes, err := res.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "20buz", string(resBytes))
+}
+
 func TestMessageMutate(t *testing.T) {
 	p := message.NewPart([]byte(`not a json doc`))
 	p.MetaSetMut("foo", "bar")
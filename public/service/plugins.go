@@ -183,3 +183,23 @@ type OtelTracerProviderConstructor func(conf *ParsedConfig) (trace.TracerProvide
 func RegisterOtelTracerProvider(name string, spec *ConfigSpec, ctor OtelTracerProviderConstructor) error {
 	return globalEnvironment.RegisterOtelTracerProvider(name, spec, ctor)
 }
+
+// RegisterScannerCodec attempts to register a new custom codec that inputs
+// can use to consume streams of bytes as messages, where the name of the
+// codec is the value configured within the codec field of those inputs.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func RegisterScannerCodec(name string, ctor ScannerCodecConstructor) error {
+	return globalEnvironment.RegisterScannerCodec(name, ctor)
+}
+
+// RegisterWriterCodec attempts to register a new custom codec that outputs
+// can use to write messages to streams of bytes, where the name of the codec
+// is the value configured within the codec field of those outputs.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func RegisterWriterCodec(name string, ctor WriterCodecConstructor) error {
+	return globalEnvironment.RegisterWriterCodec(name, ctor)
+}
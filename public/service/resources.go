@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
@@ -73,6 +74,16 @@ func (r *Resources) Metrics() *Metrics {
 	return newReverseAirGapMetrics(r.mgr.Metrics())
 }
 
+// RegisterEndpoint registers a server wide HTTP endpoint, which is served by
+// the HTTP server of the service. When running in streams mode the endpoint is
+// prefixed with the identifier of the stream.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func (r *Resources) RegisterEndpoint(path, desc string, fn http.HandlerFunc) {
+	r.mgr.RegisterEndpoint(path, desc, fn)
+}
+
 // OtelTracer returns an open telemetry tracer provider that can be used to
 // create new tracers.
 //
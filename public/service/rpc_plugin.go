@@ -0,0 +1,495 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/rpcplugin/pluginv1"
+)
+
+// RPCPlugin is a collection of component plugins that are served to Benthos
+// from a separate executable, allowing components to be added to a Benthos
+// binary without recompiling it. Plugin executables are launched by Benthos
+// with the `--plugins` flag, and their components are available to configs in
+// the same way as those compiled into the binary.
+//
+// Components of an RPC plugin do not have access to the resources of the
+// Benthos process that launched them, such as caches or rate limits, and logs
+// written with the provided logger are forwarded to the stderr of Benthos.
+//
+// Experimental: This type is experimental and could have its signature and/or
+// behaviour changed outside of major version bumps.
+type RPCPlugin struct {
+	specs      []*pluginv1.Component
+	inputs     map[string]rpcPluginInput
+	processors map[string]rpcPluginProcessor
+	outputs    map[string]rpcPluginOutput
+}
+
+type rpcPluginInput struct {
+	spec *ConfigSpec
+	ctor InputConstructor
+}
+
+type rpcPluginProcessor struct {
+	spec *ConfigSpec
+	ctor BatchProcessorConstructor
+}
+
+type rpcPluginOutput struct {
+	spec *ConfigSpec
+	ctor BatchOutputConstructor
+}
+
+// NewRPCPlugin creates an empty collection of RPC plugin components.
+//
+// Experimental: This function is experimental and could have its signature
+// and/or behaviour changed outside of major version bumps.
+func NewRPCPlugin() *RPCPlugin {
+	return &RPCPlugin{
+		inputs:     map[string]rpcPluginInput{},
+		processors: map[string]rpcPluginProcessor{},
+		outputs:    map[string]rpcPluginOutput{},
+	}
+}
+
+func (p *RPCPlugin) addSpec(typ pluginv1.ComponentType, name string, spec *ConfigSpec) error {
+	for _, c := range p.specs {
+		if c.Type == typ && c.Name == name {
+			return fmt.Errorf("component %v has already been registered", name)
+		}
+	}
+	specBytes, err := json.Marshal(spec.component)
+	if err != nil {
+		return err
+	}
+	p.specs = append(p.specs, &pluginv1.Component{
+		Type: typ,
+		Name: name,
+		Spec: specBytes,
+	})
+	return nil
+}
+
+// RegisterInput adds an input to the plugin.
+func (p *RPCPlugin) RegisterInput(name string, spec *ConfigSpec, ctor InputConstructor) error {
+	if err := p.addSpec(pluginv1.ComponentType_COMPONENT_TYPE_INPUT, name, spec); err != nil {
+		return err
+	}
+	p.inputs[name] = rpcPluginInput{spec: spec, ctor: ctor}
+	return nil
+}
+
+// RegisterBatchProcessor adds a batch processor to the plugin.
+func (p *RPCPlugin) RegisterBatchProcessor(name string, spec *ConfigSpec, ctor BatchProcessorConstructor) error {
+	if err := p.addSpec(pluginv1.ComponentType_COMPONENT_TYPE_PROCESSOR, name, spec); err != nil {
+		return err
+	}
+	p.processors[name] = rpcPluginProcessor{spec: spec, ctor: ctor}
+	return nil
+}
+
+// RegisterBatchOutput adds a batch output to the plugin. The batch policy
+// returned by the constructor is applied by Benthos before messages are sent
+// to the plugin, although processors within the policy are not supported.
+func (p *RPCPlugin) RegisterBatchOutput(name string, spec *ConfigSpec, ctor BatchOutputConstructor) error {
+	if err := p.addSpec(pluginv1.ComponentType_COMPONENT_TYPE_OUTPUT, name, spec); err != nil {
+		return err
+	}
+	p.outputs[name] = rpcPluginOutput{spec: spec, ctor: ctor}
+	return nil
+}
+
+// Serve the plugin components to the Benthos process that launched this
+// executable. This call blocks until Benthos closes the plugin, at which point
+// all remaining component instances are closed.
+//
+// An error is returned if the executable was not launched by Benthos.
+func (p *RPCPlugin) Serve() error {
+	if os.Getenv(pluginv1.MagicCookieKey) != pluginv1.MagicCookieValue {
+		return errors.New("this executable is a Benthos plugin and must be launched by Benthos with the --plugins flag")
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return err
+	}
+
+	logConf := log.NewConfig()
+	logConf.StaticFields = map[string]string{
+		"@service": "benthos",
+		"@plugin":  os.Args[0],
+	}
+	logger, err := log.NewV2(os.Stderr, logConf)
+	if err != nil {
+		return err
+	}
+
+	srv := newRPCPluginServer(p, logger)
+	gSrv := grpc.NewServer()
+	pluginv1.RegisterPluginServer(gSrv, srv)
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- gSrv.Serve(lis)
+	}()
+
+	handshake := pluginv1.Handshake{Network: "tcp", Address: lis.Addr().String()}
+	fmt.Fprintln(os.Stdout, handshake.String())
+
+	// Benthos signals that the plugin should exit by closing stdin, which also
+	// happens when Benthos exits unexpectedly.
+	stdinClosed := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, os.Stdin)
+		close(stdinClosed)
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-stdinClosed:
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+	srv.closeAll(ctx)
+	gSrv.Stop()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type rpcPluginInstance struct {
+	input     Input
+	processor BatchProcessor
+	output    BatchOutput
+
+	ackMut sync.Mutex
+	ackID  uint64
+	acks   map[uint64]AckFunc
+}
+
+type rpcPluginServer struct {
+	pluginv1.UnimplementedPluginServer
+
+	plugin *RPCPlugin
+	log    log.Modular
+
+	instID    uint64
+	instMut   sync.Mutex
+	instances map[uint64]*rpcPluginInstance
+}
+
+func newRPCPluginServer(p *RPCPlugin, logger log.Modular) *rpcPluginServer {
+	return &rpcPluginServer{
+		plugin:    p,
+		log:       logger,
+		instances: map[uint64]*rpcPluginInstance{},
+	}
+}
+
+func rpcErrToProto(err error) *pluginv1.Error {
+	if err == nil {
+		return nil
+	}
+	pe := &pluginv1.Error{Message: err.Error()}
+	if errors.Is(err, ErrNotConnected) {
+		pe.Kind = pluginv1.Error_KIND_NOT_CONNECTED
+	} else if errors.Is(err, ErrEndOfInput) {
+		pe.Kind = pluginv1.Error_KIND_END_OF_INPUT
+	}
+	return pe
+}
+
+func rpcBatchToProto(b MessageBatch) *pluginv1.Batch {
+	pb := &pluginv1.Batch{
+		Messages: make([]*pluginv1.Message, len(b)),
+	}
+	for i, m := range b {
+		pm := &pluginv1.Message{
+			Contents: m.part.AsBytes(),
+			Metadata: map[string]string{},
+		}
+		_ = m.part.MetaIterStr(func(k, v string) error {
+			pm.Metadata[k] = v
+			return nil
+		})
+		if err := m.part.ErrorGet(); err != nil {
+			pm.Error = err.Error()
+		}
+		pb.Messages[i] = pm
+	}
+	return pb
+}
+
+func rpcBatchFromProto(pb *pluginv1.Batch) MessageBatch {
+	if pb == nil {
+		return nil
+	}
+	b := make(MessageBatch, len(pb.Messages))
+	for i, pm := range pb.Messages {
+		part := message.NewPart(pm.Contents)
+		for k, v := range pm.Metadata {
+			part.MetaSetMut(k, v)
+		}
+		if pm.Error != "" {
+			part.ErrorSet(errors.New(pm.Error))
+		}
+		b[i] = newMessageFromPart(part)
+	}
+	return b
+}
+
+func (s *rpcPluginServer) getInstance(id uint64) (*rpcPluginInstance, error) {
+	s.instMut.Lock()
+	defer s.instMut.Unlock()
+	inst, exists := s.instances[id]
+	if !exists {
+		return nil, fmt.Errorf("instance %v does not exist", id)
+	}
+	return inst, nil
+}
+
+func (s *rpcPluginServer) Describe(ctx context.Context, req *pluginv1.DescribeRequest) (*pluginv1.DescribeResponse, error) {
+	return &pluginv1.DescribeResponse{Components: s.plugin.specs}, nil
+}
+
+func (s *rpcPluginServer) Init(ctx context.Context, req *pluginv1.InitRequest) (*pluginv1.InitResponse, error) {
+	initRes := &pluginv1.InitResponse{}
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetLogger(s.log.With("label", req.Label, "plugin", req.Name)),
+		manager.OptSetEnvironment(globalEnvironment.internal),
+		manager.OptSetBloblangEnvironment(globalEnvironment.getBloblangParserEnv()),
+	)
+	if err != nil {
+		initRes.Error = rpcErrToProto(fmt.Errorf("failed to instantiate resources: %w", err))
+		return initRes, nil
+	}
+	res := newResourcesFromManager(mgr)
+
+	parse := func(spec *ConfigSpec) (*ParsedConfig, error) {
+		var n yaml.Node
+		if len(req.Config) > 0 {
+			if err := yaml.Unmarshal(req.Config, &n); err != nil {
+				return nil, err
+			}
+		}
+		if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+			n = *n.Content[0]
+		}
+		if n.Kind == 0 {
+			_ = n.Encode(nil)
+		}
+		return spec.configFromNode(mgr, &n)
+	}
+
+	inst := &rpcPluginInstance{acks: map[uint64]AckFunc{}}
+	err = func() error {
+		switch req.Type {
+		case pluginv1.ComponentType_COMPONENT_TYPE_INPUT:
+			c, exists := s.plugin.inputs[req.Name]
+			if !exists {
+				return fmt.Errorf("input %v does not exist", req.Name)
+			}
+			conf, err := parse(c.spec)
+			if err != nil {
+				return err
+			}
+			inst.input, err = c.ctor(conf, res)
+			return err
+		case pluginv1.ComponentType_COMPONENT_TYPE_PROCESSOR:
+			c, exists := s.plugin.processors[req.Name]
+			if !exists {
+				return fmt.Errorf("processor %v does not exist", req.Name)
+			}
+			conf, err := parse(c.spec)
+			if err != nil {
+				return err
+			}
+			inst.processor, err = c.ctor(conf, res)
+			return err
+		case pluginv1.ComponentType_COMPONENT_TYPE_OUTPUT:
+			c, exists := s.plugin.outputs[req.Name]
+			if !exists {
+				return fmt.Errorf("output %v does not exist", req.Name)
+			}
+			conf, err := parse(c.spec)
+			if err != nil {
+				return err
+			}
+			out, policy, maxInFlight, err := c.ctor(conf, res)
+			if err != nil {
+				return err
+			}
+			inst.output = out
+			initRes.MaxInFlight = int64(maxInFlight)
+			initRes.BatchPolicy = &pluginv1.BatchPolicy{
+				Count:    int64(policy.Count),
+				ByteSize: int64(policy.ByteSize),
+				Period:   policy.Period,
+				Check:    policy.Check,
+			}
+			return nil
+		}
+		return fmt.Errorf("unsupported component type: %v", req.Type)
+	}()
+	if err != nil {
+		initRes.Error = rpcErrToProto(err)
+		return initRes, nil
+	}
+
+	s.instMut.Lock()
+	s.instID++
+	initRes.InstanceId = s.instID
+	s.instances[initRes.InstanceId] = inst
+	s.instMut.Unlock()
+	return initRes, nil
+}
+
+func (s *rpcPluginServer) Connect(ctx context.Context, req *pluginv1.ConnectRequest) (*pluginv1.ConnectResponse, error) {
+	inst, err := s.getInstance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case inst.input != nil:
+		err = inst.input.Connect(ctx)
+	case inst.output != nil:
+		err = inst.output.Connect(ctx)
+	}
+	return &pluginv1.ConnectResponse{Error: rpcErrToProto(err)}, nil
+}
+
+func (s *rpcPluginServer) ReadBatch(ctx context.Context, req *pluginv1.ReadBatchRequest) (*pluginv1.ReadBatchResponse, error) {
+	inst, err := s.getInstance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	if inst.input == nil {
+		return nil, fmt.Errorf("instance %v is not an input", req.InstanceId)
+	}
+
+	msg, ackFn, err := inst.input.Read(ctx)
+	if err != nil {
+		return &pluginv1.ReadBatchResponse{Error: rpcErrToProto(err)}, nil
+	}
+
+	inst.ackMut.Lock()
+	inst.ackID++
+	ackID := inst.ackID
+	inst.acks[ackID] = ackFn
+	inst.ackMut.Unlock()
+
+	return &pluginv1.ReadBatchResponse{
+		Batch: rpcBatchToProto(MessageBatch{msg}),
+		AckId: ackID,
+	}, nil
+}
+
+func (s *rpcPluginServer) Ack(ctx context.Context, req *pluginv1.AckRequest) (*pluginv1.AckResponse, error) {
+	inst, err := s.getInstance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+
+	inst.ackMut.Lock()
+	ackFn, exists := inst.acks[req.AckId]
+	delete(inst.acks, req.AckId)
+	inst.ackMut.Unlock()
+	if !exists {
+		return nil, fmt.Errorf("ack %v does not exist", req.AckId)
+	}
+
+	var ackErr error
+	if req.Error != "" {
+		ackErr = errors.New(req.Error)
+	}
+	return &pluginv1.AckResponse{Error: rpcErrToProto(ackFn(ctx, ackErr))}, nil
+}
+
+func (s *rpcPluginServer) ProcessBatch(ctx context.Context, req *pluginv1.ProcessBatchRequest) (*pluginv1.ProcessBatchResponse, error) {
+	inst, err := s.getInstance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	if inst.processor == nil {
+		return nil, fmt.Errorf("instance %v is not a processor", req.InstanceId)
+	}
+
+	batches, err := inst.processor.ProcessBatch(ctx, rpcBatchFromProto(req.Batch))
+	if err != nil {
+		return &pluginv1.ProcessBatchResponse{Error: rpcErrToProto(err)}, nil
+	}
+
+	res := &pluginv1.ProcessBatchResponse{
+		Batches: make([]*pluginv1.Batch, len(batches)),
+	}
+	for i, b := range batches {
+		res.Batches[i] = rpcBatchToProto(b)
+	}
+	return res, nil
+}
+
+func (s *rpcPluginServer) WriteBatch(ctx context.Context, req *pluginv1.WriteBatchRequest) (*pluginv1.WriteBatchResponse, error) {
+	inst, err := s.getInstance(req.InstanceId)
+	if err != nil {
+		return nil, err
+	}
+	if inst.output == nil {
+		return nil, fmt.Errorf("instance %v is not an output", req.InstanceId)
+	}
+	err = inst.output.WriteBatch(ctx, rpcBatchFromProto(req.Batch))
+	return &pluginv1.WriteBatchResponse{Error: rpcErrToProto(err)}, nil
+}
+
+func (s *rpcPluginServer) Close(ctx context.Context, req *pluginv1.CloseRequest) (*pluginv1.CloseResponse, error) {
+	s.instMut.Lock()
+	inst, exists := s.instances[req.InstanceId]
+	delete(s.instances, req.InstanceId)
+	s.instMut.Unlock()
+	if !exists {
+		return &pluginv1.CloseResponse{}, nil
+	}
+	return &pluginv1.CloseResponse{Error: rpcErrToProto(inst.close(ctx))}, nil
+}
+
+func (i *rpcPluginInstance) close(ctx context.Context) error {
+	switch {
+	case i.input != nil:
+		return i.input.Close(ctx)
+	case i.processor != nil:
+		return i.processor.Close(ctx)
+	case i.output != nil:
+		return i.output.Close(ctx)
+	}
+	return nil
+}
+
+func (s *rpcPluginServer) closeAll(ctx context.Context) {
+	s.instMut.Lock()
+	instances := s.instances
+	s.instances = map[uint64]*rpcPluginInstance{}
+	s.instMut.Unlock()
+
+	for id, inst := range instances {
+		if err := inst.close(ctx); err != nil {
+			s.log.Errorf("Failed to close instance %v: %v", id, err)
+		}
+	}
+}
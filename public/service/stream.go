@@ -175,6 +175,16 @@ func (s *Stream) Stop(ctx context.Context) (err error) {
 		return
 	}
 
-	err = closeHTTP(ctx)
+	if err = closeHTTP(ctx); err != nil {
+		return
+	}
+
+	// Logs are shipped last in order to capture everything logged during
+	// shutdown.
+	if shutter, ok := s.logger.(interface {
+		Shutdown(context.Context) error
+	}); ok {
+		err = shutter.Shutdown(ctx)
+	}
 	return
 }
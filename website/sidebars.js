@@ -30,6 +30,7 @@ module.exports = {
         'configuration/processing_pipelines',
         'configuration/unit_testing',
         'configuration/templating',
+        'configuration/rpc_plugins',
         'configuration/dynamic_inputs_and_outputs',
         'configuration/using_cue',
       ],